@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/bornholm/corpus/internal/command"
+	"github.com/bornholm/corpus/internal/command/eval"
 	"github.com/bornholm/corpus/internal/command/index"
 	"github.com/bornholm/corpus/internal/command/watch"
 )
@@ -11,5 +12,6 @@ func main() {
 		"corpus-cli", "a corpus client tool",
 		watch.Command(),
 		index.Command(),
+		eval.Command(),
 	)
 }
@@ -0,0 +1,126 @@
+package eval
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/bornholm/corpus/internal/command/common"
+	"github.com/bornholm/corpus/pkg/eval"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	flagDataset    = "dataset"
+	flagOutput     = "output"
+	flagCollection = "collection"
+	flagMaxResults = "max-results"
+	flagSearchOnly = "search-only"
+)
+
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "eval",
+		Usage: "Evaluate the retrieval and the answers of the server against a dataset of questions",
+		Flags: common.WithCommonFlags(
+			&cli.StringFlag{
+				Name:     flagDataset,
+				Aliases:  []string{"d"},
+				Usage:    "Path to the dataset of questions (YAML or JSONL)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    flagOutput,
+				Aliases: []string{"o"},
+				Usage:   "Path to the JSON report file (use '-' for stdout)",
+				Value:   "-",
+			},
+			&cli.StringSliceFlag{
+				Name:    flagCollection,
+				Aliases: []string{"c"},
+				Usage:   "Collection ID(s) to restrict the retrieval to, overriding the collections of the dataset",
+			},
+			&cli.IntFlag{
+				Name:    flagMaxResults,
+				Aliases: []string{"k"},
+				Usage:   "Number of retrieved sources considered for the retrieval metrics",
+				Value:   5,
+			},
+			&cli.BoolFlag{
+				Name:  flagSearchOnly,
+				Usage: "Only evaluate the retrieval, without asking the questions",
+			},
+		),
+		Action: func(cCtx *cli.Context) error {
+			ctx := cCtx.Context
+
+			corpusClient, err := common.GetCorpusClient(cCtx)
+			if err != nil {
+				return errors.Wrap(err, "could not create corpus client")
+			}
+
+			dataset, err := eval.LoadDataset(cCtx.String(flagDataset))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			if collections := cCtx.StringSlice(flagCollection); len(collections) > 0 {
+				dataset.Collections = collections
+				for i := range dataset.Cases {
+					dataset.Cases[i].Collections = nil
+				}
+			}
+
+			slog.InfoContext(ctx, "starting evaluation", slog.Int("cases", len(dataset.Cases)))
+
+			report, err := eval.Run(ctx, eval.NewClientTarget(corpusClient), dataset,
+				eval.WithMaxResults(cCtx.Int(flagMaxResults)),
+				eval.WithSearchOnly(cCtx.Bool(flagSearchOnly)),
+			)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			slog.InfoContext(ctx, "evaluation completed",
+				slog.Int("cases", report.Summary.Cases),
+				slog.Int("failed", report.Summary.Failed),
+			)
+
+			if err := writeReport(cCtx.String(flagOutput), report); err != nil {
+				return errors.Wrap(err, "could not write report")
+			}
+
+			if report.Summary.Failed > 0 {
+				return errors.New("some cases could not be evaluated")
+			}
+
+			return nil
+		},
+	}
+}
+
+func writeReport(path string, report *eval.Report) error {
+	var w io.Writer = os.Stdout
+
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		defer file.Close()
+
+		w = file
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -1,6 +1,11 @@
 package config
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
 
 type HTTP struct {
 	BaseURL   string        `env:"BASE_URL,expand" envDefault:"/"`
@@ -45,6 +50,12 @@ type OIDCProvider struct {
 	DiscoveryURL string `env:"DISCOVERY_URL"`
 	Icon         string `env:"ICON"`
 	Label        string `env:"LABEL"`
+	// Name of the claim listing the groups of the user, empty to disable
+	// the synchronization of the groups
+	GroupsClaim string `env:"GROUPS_CLAIM"`
+	// Rules mapping the claims of the users to roles, activation and groups,
+	// evaluated on each login
+	ClaimRules ClaimRules `env:"CLAIM_RULES"`
 }
 
 type GiteaProvider struct {
@@ -62,3 +73,39 @@ type HTTPRateLimit struct {
 	CacheSize       int           `env:"CACHE_SIZE,expand" envDefault:"50"`
 	CacheTTL        time.Duration `env:"CACHE_TTL,expand" envDefault:"1h"`
 }
+
+// ClaimRule grants roles, groups or an activation state to the users whose
+// claim matches a value
+type ClaimRule struct {
+	// Name of the claim, nested claims can be referenced with a dotted path
+	// (ie "realm_access.roles")
+	Claim string `json:"claim"`
+	// Expected value of the claim, or "*" to match any value
+	Value string `json:"value"`
+
+	Roles  []string `json:"roles,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// ClaimRules is a list of claim rules, parsed from a JSON array
+type ClaimRules []ClaimRule
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *ClaimRules) UnmarshalText(text []byte) error {
+	var rules []ClaimRule
+
+	if err := json.Unmarshal(text, &rules); err != nil {
+		return errors.Wrap(err, "could not parse claim rules")
+	}
+
+	for i, rule := range rules {
+		if rule.Claim == "" || rule.Value == "" {
+			return errors.Errorf("claim rule #%d: claim and value are required", i)
+		}
+	}
+
+	*r = rules
+
+	return nil
+}
@@ -17,6 +17,21 @@ type LLMProvider struct {
 	RateLimit   LLMRateLimit  `envPrefix:"RATE_LIMIT_"`
 	MaxRetries  int           `env:"MAX_RETRIES,expand" envDefault:"3"`
 	BaseBackoff time.Duration `env:"BASE_BACKOFF" envDefault:"1s"`
+
+	Fake LLMFake `envPrefix:"FAKE_"`
+}
+
+// LLMFake configures the "fake" provider, a deterministic client requiring no
+// network for the development and the tests
+type LLMFake struct {
+	// Mode is the strategy of the chat completions not matched by a rule of
+	// the Script: "extractive" (sentences of the context sharing the most
+	// words with the question) or "echo" (the question)
+	Mode string `env:"MODE,expand" envDefault:"extractive"`
+	// Script is the path to a YAML file of rules ("match" regular expression
+	// and "response") matched against the prompts
+	Script     string `env:"SCRIPT,expand"`
+	Dimensions int    `env:"DIMENSIONS,expand" envDefault:"384"`
 }
 
 type LLMRateLimit struct {
@@ -37,6 +52,61 @@ type LLMIndex struct {
 	MaxWords      int `env:"MAX_WORDS,expand" envDefault:"2000"`
 	MaxTotalWords int `env:"MAX_TOTAL_WORDS,expand" envDefault:"50000"`
 
+	// Chunker is the strategy splitting the indexed files into sections:
+	// "heading" (markdown headings, sections limited to MaxWords), "sentence"
+	// (windows of ChunkSentences sentences overlapping by ChunkSentenceOverlap),
+	// "fixed" (MaxWords words overlapping by ChunkOverlap) or "semantic"
+	// (topic shifts detected with the sentences embeddings, beyond the
+	// ChunkSemanticPercentile of the distances between consecutive sentences).
+	// ChunkerExtensions and ChunkerCollections override the strategy per file
+	// extension or per collection identifier, e.g. ".pdf:fixed,.vtt:sentence".
+	Chunker                 string            `env:"CHUNKER,expand" envDefault:"heading"`
+	ChunkerExtensions       map[string]string `env:"CHUNKER_EXTENSIONS,expand"`
+	ChunkerCollections      map[string]string `env:"CHUNKER_COLLECTIONS,expand"`
+	ChunkOverlap            int               `env:"CHUNK_OVERLAP,expand" envDefault:"50"`
+	ChunkSentences          int               `env:"CHUNK_SENTENCES,expand" envDefault:"5"`
+	ChunkSentenceOverlap    int               `env:"CHUNK_SENTENCE_OVERLAP,expand" envDefault:"1"`
+	ChunkSemanticPercentile float64           `env:"CHUNK_SEMANTIC_PERCENTILE,expand" envDefault:"0.9"`
+
+	// Fusion selects how the results of the full-text and vector indexes are
+	// merged: "rrf" (Reciprocal Rank Fusion) or "weighted" (legacy fixed
+	// weighting, ignoring ranks). FusionRRFK is the RRF rank constant.
+	Fusion     string `env:"FUSION,expand" envDefault:"rrf"`
+	FusionRRFK int    `env:"FUSION_RRF_K,expand" envDefault:"60"`
+
+	// HyDE selects how the hypothetical answer generated for the search query
+	// is used: "replace" (the hypothetical answer is searched instead of the
+	// query), "expand" (it is searched alongside the query and the results are
+	// fused) or "disabled".
+	HyDE string `env:"HYDE,expand" envDefault:"replace"`
+
+	// MultiQuery is the number of LLM paraphrases of the search query searched
+	// alongside it, the results of every query being fused (RAG-fusion).
+	// Disabled when 0.
+	MultiQuery int `env:"MULTI_QUERY,expand" envDefault:"0"`
+
+	// Contextualize selects the context prefixed to the indexed text of each
+	// section, the stored section content being left untouched: "headings"
+	// (document title and headings breadcrumb), "summary" (same, followed by an
+	// LLM generated one-line summary of the document) or "disabled".
+	Contextualize string `env:"CONTEXTUALIZE,expand" envDefault:"disabled"`
+
+	// ContextExpansion selects the surroundings of the retrieved sections given
+	// to the LLM when answering: "none", "parent" (the parent section),
+	// "siblings" (ContextExpansionSiblings sections before and after) or
+	// "document" (the whole document). Expanded sections are only added while
+	// the context does not exceed MaxTotalWords. Overridable per request.
+	ContextExpansion         string `env:"CONTEXT_EXPANSION,expand" envDefault:"none"`
+	ContextExpansionSiblings int    `env:"CONTEXT_EXPANSION_SIBLINGS,expand" envDefault:"1"`
+
+	// MMR enables the Maximal Marginal Relevance reranking of the search
+	// results, trading relevance (MMRLambda, 1 ignoring novelty) against novelty
+	// using the sections embeddings. Sections whose similarity with an already
+	// selected one reaches MMRMaxSimilarity are discarded. Disabled by default.
+	MMR              bool    `env:"MMR,expand" envDefault:"false"`
+	MMRLambda        float64 `env:"MMR_LAMBDA,expand" envDefault:"0.7"`
+	MMRMaxSimilarity float64 `env:"MMR_MAX_SIMILARITY,expand" envDefault:"0.95"`
+
 	// GroundingCheck enables the grounding (γ) verifier: after retrieval, an LLM
 	// judges whether the evidence supports a reliable answer and the service
 	// abstains instead of generating when it does not. Disabled by default.
@@ -53,4 +123,16 @@ type LLMIndex struct {
 	// searching each and fusing their evidence before answering.
 	QueryDecomposition         bool `env:"QUERY_DECOMPOSITION,expand" envDefault:"false"`
 	DecompositionMaxSubQueries int  `env:"DECOMPOSITION_MAX_SUBQUERIES,expand" envDefault:"3"`
+
+	// QueryCondensation enables the rewriting of the follow-up questions of a
+	// conversation into standalone questions before retrieval, using at most
+	// the ConversationHistoryTurns previous turns.
+	QueryCondensation        bool `env:"QUERY_CONDENSATION,expand" envDefault:"true"`
+	ConversationHistoryTurns int  `env:"CONVERSATION_HISTORY_TURNS,expand" envDefault:"5"`
+
+	// AgentMaxSteps and AgentMaxTokens bound the tool calling steps and the
+	// tokens consumed by the agent of the "agent" ask mode, the answer being
+	// forced with the sections gathered so far once exhausted.
+	AgentMaxSteps  int `env:"AGENT_MAX_STEPS,expand" envDefault:"8"`
+	AgentMaxTokens int `env:"AGENT_MAX_TOKENS,expand" envDefault:"100000"`
 }
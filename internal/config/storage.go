@@ -3,9 +3,14 @@ package config
 import "time"
 
 type Storage struct {
-	Database  Database       `envPrefix:"DATABASE_"`
-	Bleve     BleveIndex     `envPrefix:"BLEVE_"`
-	SQLiteVec SQLiteVecIndex `envPrefix:"SQLITEVEC_"`
+	Database Database   `envPrefix:"DATABASE_"`
+	Bleve    BleveIndex `envPrefix:"BLEVE_"`
+	// VectorIndex selects the vector index: "sqlitevec" or "memvec" (pure Go,
+	// in-memory)
+	VectorIndex string         `env:"VECTOR_INDEX,expand" envDefault:"sqlitevec"`
+	SQLiteVec   SQLiteVecIndex `envPrefix:"SQLITEVEC_"`
+	MemVec      MemVecIndex    `envPrefix:"MEMVEC_"`
+	Trash       Trash          `envPrefix:"TRASH_"`
 }
 
 type Database struct {
@@ -18,12 +23,36 @@ type Database struct {
 
 type SQLiteVecIndex struct {
 	DSN string `env:"DSN,expand" envDefault:"index.sqlite"`
+	// Dimensions of the embeddings vectors, detected from the first embeddings
+	// response if zero
+	Dimensions int `env:"DIMENSIONS,expand" envDefault:"0"`
+	// CacheSize is the maximum number of cached section embeddings, 0 disables
+	// the cache
+	CacheSize int `env:"CACHE_SIZE,expand" envDefault:"100000"`
+}
+
+type MemVecIndex struct {
+	// Path of the file the index is persisted to, the index only lives in
+	// memory if empty
+	Path string `env:"PATH,expand" envDefault:"index.memvec"`
+	// Dimensions of the embeddings vectors, detected from the first embeddings
+	// response if zero
+	Dimensions int `env:"DIMENSIONS,expand" envDefault:"0"`
 }
 
 type BleveIndex struct {
 	DSN string `env:"DSN,expand" envDefault:"index.bleve"`
 }
 
+type Trash struct {
+	// Delay after which the documents and collections moved to the trash are
+	// permanently deleted
+	Delay time.Duration `env:"DELAY,expand" envDefault:"720h"`
+	// PurgeInterval is the interval between two purges of the trash, 0
+	// disabling the purge
+	PurgeInterval time.Duration `env:"PURGE_INTERVAL,expand" envDefault:"1h"`
+}
+
 type StoreCache struct {
 	Enabled bool          `env:"ENABLED,expand" envDefault:"true"`
 	Size    int           `env:"SIZE,expand" envDefault:"25"`
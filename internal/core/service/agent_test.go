@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// scriptedAgentLLM implements llm.Client, returning the scripted responses in
+// order (the last one repeats) and recording the number of tools offered by
+// each call.
+type scriptedAgentLLM struct {
+	llm.Client
+	responses []llm.ChatCompletionResponse
+	tools     []int
+}
+
+func (m *scriptedAgentLLM) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	idx := len(m.tools)
+	if idx >= len(m.responses) {
+		idx = len(m.responses) - 1
+	}
+
+	m.tools = append(m.tools, len(opts.Tools))
+
+	return m.responses[idx], nil
+}
+
+func agentToolCall(name string, params string) llm.ChatCompletionResponse {
+	return llm.NewChatCompletionResponse(
+		llm.NewMessage(llm.RoleAssistant, ""),
+		llm.NewChatCompletionUsage(10, 5, 15),
+		llm.NewToolCall("call-"+name, name, params),
+	)
+}
+
+func agentAnswer(answer string) llm.ChatCompletionResponse {
+	return llm.NewChatCompletionResponse(
+		llm.NewMessage(llm.RoleAssistant, answer),
+		llm.NewChatCompletionUsage(10, 5, 15),
+	)
+}
+
+// stubPersistedDocument implements model.PersistedDocument by embedding the
+// interface.
+type stubPersistedDocument struct {
+	model.PersistedDocument
+	id          model.DocumentID
+	collections []model.Collection
+}
+
+func (d *stubPersistedDocument) ID() model.DocumentID { return d.id }
+func (d *stubPersistedDocument) Source() *url.URL {
+	return &url.URL{Scheme: "test", Host: string(d.id)}
+}
+func (d *stubPersistedDocument) Collections() []model.Collection { return d.collections }
+func (d *stubPersistedDocument) Sections() []model.Section       { return nil }
+
+// agentStore adds the documents to the readableStore
+type agentStore struct {
+	*readableStore
+	documents map[model.DocumentID]model.PersistedDocument
+}
+
+func (s *agentStore) GetDocumentByID(ctx context.Context, id model.DocumentID) (model.PersistedDocument, error) {
+	document, exists := s.documents[id]
+	if !exists {
+		return nil, errors.WithStack(port.ErrNotFound)
+	}
+
+	return document, nil
+}
+
+// newTestAgentManager returns a manager whose sections belong to the "doc"
+// document, itself in the given collection, the user reading the "c1"
+// collection only
+func newTestAgentManager(client llm.Client, index *stubIndex, documentCollection model.CollectionID) *DocumentManager {
+	store := &agentStore{
+		readableStore: &readableStore{
+			stubStore: storeWithSections("sec-1", "sec-2"),
+			readable:  []model.PersistedCollection{&stubPersistedCollection{id: "c1"}},
+		},
+		documents: map[model.DocumentID]model.PersistedDocument{
+			"doc": &stubPersistedDocument{
+				id:          "doc",
+				collections: []model.Collection{model.NewCollection(documentCollection, "", "", "")},
+			},
+		},
+	}
+
+	return NewDocumentManager(store, index, nil, client, WithAgentBudget(4, 1000))
+}
+
+func TestAskAgent_TraceAndCitations(t *testing.T) {
+	client := &scriptedAgentLLM{responses: []llm.ChatCompletionResponse{
+		agentToolCall(AgentToolSearch, `{"query": "capital of France"}`),
+		agentToolCall(AgentToolReadSection, `{"section_id": "sec-2"}`),
+		agentAnswer("Paris is the capital [1]. It is large [2][5]."),
+	}}
+	index := &stubIndex{byQuery: map[string][]*port.IndexSearchResult{
+		"capital of France": {resultWith("sec-1")},
+	}}
+
+	manager := newTestAgentManager(client, index, "c1")
+	user := model.NewUser("test", "test", "test@example.com", "Test", true)
+
+	result, err := manager.AskAgent(context.Background(), "What is the capital of France?", nil, WithAgentUser(user))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(result.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(result.Steps))
+	}
+	if e, g := AgentToolSearch, result.Steps[0].Tool; e != g {
+		t.Errorf("steps[0].Tool: expected %q, got %q", e, g)
+	}
+	if e, g := "capital of France", result.Steps[0].Arguments["query"]; e != g {
+		t.Errorf("steps[0].Arguments[query]: expected %q, got %v", e, g)
+	}
+	if e, g := AgentToolReadSection, result.Steps[1].Tool; e != g {
+		t.Errorf("steps[1].Tool: expected %q, got %q", e, g)
+	}
+	if e, g := 2, result.Steps[1].Step; e != g {
+		t.Errorf("steps[1].Step: expected %d, got %d", e, g)
+	}
+
+	if result.Exhausted {
+		t.Errorf("expected the budget not to be exhausted")
+	}
+	if e, g := int64(45), result.Tokens; e != g {
+		t.Errorf("tokens: expected %d, got %d", e, g)
+	}
+
+	if len(result.Citations) != 2 || result.Citations[0].SectionID != "sec-1" || result.Citations[1].SectionID != "sec-2" {
+		t.Fatalf("expected citations of sec-1 and sec-2, got %+v", result.Citations)
+	}
+	if len(result.InvalidCitations) != 1 || result.InvalidCitations[0] != 5 {
+		t.Errorf("expected the invalid citation 5, got %v", result.InvalidCitations)
+	}
+	if _, exists := result.Contents["sec-2"]; !exists {
+		t.Errorf("expected the contents to hold the section read by the agent")
+	}
+}
+
+func TestAskAgent_BudgetExhausted(t *testing.T) {
+	client := &scriptedAgentLLM{responses: []llm.ChatCompletionResponse{
+		agentToolCall(AgentToolSearch, `{"query": "capital of France"}`),
+		agentToolCall(AgentToolSearch, `{"query": "capital of France"}`),
+		agentAnswer("Paris [1]."),
+	}}
+	index := &stubIndex{byQuery: map[string][]*port.IndexSearchResult{
+		"capital of France": {resultWith("sec-1")},
+	}}
+
+	manager := newTestAgentManager(client, index, "c1")
+
+	result, err := manager.AskAgent(context.Background(), "What is the capital of France?", nil, WithAgentMaxSteps(2))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if !result.Exhausted {
+		t.Fatalf("expected the budget to be exhausted")
+	}
+	if e, g := 2, len(result.Steps); e != g {
+		t.Fatalf("expected %d steps, got %d", e, g)
+	}
+	if e, g := 3, len(client.tools); e != g {
+		t.Fatalf("expected %d completions, got %d", e, g)
+	}
+	if g := client.tools[2]; g != 0 {
+		t.Errorf("expected the final completion not to offer tools, got %d", g)
+	}
+	if e, g := "Paris [1].", result.Answer; e != g {
+		t.Errorf("answer: expected %q, got %q", e, g)
+	}
+}
+
+func TestAskAgent_BudgetUpperBound(t *testing.T) {
+	client := &scriptedAgentLLM{responses: []llm.ChatCompletionResponse{
+		agentToolCall(AgentToolSearch, `{"query": "unknown"}`),
+	}}
+
+	manager := newTestAgentManager(client, &stubIndex{}, "c1")
+
+	// The requested budget can not exceed the one of the manager
+	result, err := manager.AskAgent(context.Background(), "What is the capital of France?", nil, WithAgentMaxSteps(100))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 4, len(result.Steps); e != g {
+		t.Fatalf("expected %d steps, got %d", e, g)
+	}
+}
+
+func TestAskAgent_Permissions(t *testing.T) {
+	user := model.NewUser("test", "test", "test@example.com", "Test", true)
+
+	t.Run("unreadable collection", func(t *testing.T) {
+		manager := newTestAgentManager(&scriptedAgentLLM{}, &stubIndex{}, "c1")
+
+		_, err := manager.AskAgent(context.Background(), "What is the capital of France?", []model.CollectionID{"c2"}, WithAgentUser(user))
+		if !errors.Is(err, ErrNoReadableCollection) {
+			t.Fatalf("expected ErrNoReadableCollection, got %v", err)
+		}
+	})
+
+	t.Run("section out of scope", func(t *testing.T) {
+		client := &scriptedAgentLLM{responses: []llm.ChatCompletionResponse{
+			agentToolCall(AgentToolReadSection, `{"section_id": "sec-1"}`),
+			agentAnswer("Paris [1]."),
+		}}
+
+		manager := newTestAgentManager(client, &stubIndex{}, "c2")
+
+		result, err := manager.AskAgent(context.Background(), "What is the capital of France?", nil, WithAgentUser(user))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		if e, g := "The section 'sec-1' does not exist.", result.Steps[0].Result; e != g {
+			t.Errorf("steps[0].Result: expected %q, got %q", e, g)
+		}
+		if len(result.Citations) != 0 || len(result.Contents) != 0 {
+			t.Errorf("expected no section to be disclosed, got %+v", result.Citations)
+		}
+	})
+}
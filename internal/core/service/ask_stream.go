@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// AskEventType is the type of the events emitted while streaming an answer
+type AskEventType string
+
+const (
+	// AskEventResults is emitted once the retrieval is done, before the
+	// generation of the answer
+	AskEventResults AskEventType = "results"
+	// AskEventDelta is emitted for each fragment of the generated answer
+	AskEventDelta AskEventType = "delta"
+	// AskEventDone is emitted once the answer is complete
+	AskEventDone AskEventType = "done"
+)
+
+// AskEvent is emitted while streaming an answer
+type AskEvent struct {
+	Type AskEventType
+	// Results are the retrieved results (AskEventResults)
+	Results []*port.IndexSearchResult
+	// Delta is the next fragment of the answer (AskEventDelta)
+	Delta string
+	// Result is the outcome of the request, with the complete answer, the
+	// grounding verdict and the cited sections (AskEventDone)
+	Result *AskResult
+}
+
+// AskStreamFunc receives the events of a streamed answer. Returning an error
+// interrupts the generation.
+type AskStreamFunc func(ctx context.Context, event AskEvent) error
+
+// AskStream is the streaming variant of Ask: the given results are emitted
+// first, then the fragments of the answer as they are generated and finally
+// the complete result.
+func (m *DocumentManager) AskStream(ctx context.Context, query string, results []*port.IndexSearchResult, fn AskStreamFunc, funcs ...DocumentManagerAskOptionFunc) (*AskResult, error) {
+	opts := NewDocumentManagerAskOptions(funcs...)
+	opts.stream = fn
+
+	result, err := m.ask(ctx, query, results, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := fn(ctx, AskEvent{Type: AskEventDone, Result: result}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return result, nil
+}
+
+// AskWithRetrievalStream is the streaming variant of AskWithRetrieval: the
+// retrieved results are emitted first, then the fragments of the answer as
+// they are generated and finally the complete result.
+func (m *DocumentManager) AskWithRetrievalStream(ctx context.Context, query string, collections []model.CollectionID, fn AskStreamFunc, funcs ...DocumentManagerAskOptionFunc) (*AskResult, error) {
+	funcs = append(slices.Clip(funcs), func(opts *DocumentManagerAskOptions) {
+		opts.stream = fn
+	})
+
+	result, err := m.AskWithRetrieval(ctx, query, collections, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := fn(ctx, AskEvent{Type: AskEventDone, Result: result}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return result, nil
+}
+
+// emit sends the event to the stream function, if any
+func (o *DocumentManagerAskOptions) emit(ctx context.Context, event AskEvent) error {
+	if o.stream == nil {
+		return nil
+	}
+
+	if err := o.stream(ctx, event); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// streamCompletion generates the completion with the streaming API of the
+// client, emitting each fragment of content, and returns the complete content
+func streamCompletion(ctx context.Context, client llm.Client, fn AskStreamFunc, funcs ...llm.ChatCompletionOptionFunc) (string, error) {
+	// Stops the generation if the stream is interrupted
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks, err := client.ChatCompletionStream(ctx, funcs...)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var sb strings.Builder
+
+	for chunk := range chunks {
+		if err := chunk.Error(); err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		if chunk.IsComplete() {
+			break
+		}
+
+		delta := chunk.Delta()
+		if delta == nil || delta.Content() == "" {
+			continue
+		}
+
+		sb.WriteString(delta.Content())
+
+		if err := fn(ctx, AskEvent{Type: AskEventDelta, Delta: delta.Content()}); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	return sb.String(), nil
+}
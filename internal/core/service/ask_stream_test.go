@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
+)
+
+// streamingLLM streams its canned response word by word
+type streamingLLM struct {
+	llm.Client
+	response string
+}
+
+func (m *streamingLLM) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	words := strings.SplitAfter(m.response, " ")
+
+	chunks := make(chan llm.StreamChunk, len(words)+1)
+	for _, w := range words {
+		chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, w))
+	}
+	chunks <- llm.NewCompleteStreamChunk(llm.NewChatCompletionUsage(0, 0, 0))
+	close(chunks)
+
+	return chunks, nil
+}
+
+func TestAskWithRetrievalStream(t *testing.T) {
+	index := &stubIndex{byQuery: map[string][]*port.IndexSearchResult{
+		"q": {resultWith("sec-1")},
+	}}
+
+	dm := NewDocumentManager(storeWithSections("sec-1"), index, nil, &streamingLLM{response: "Paris is the capital"},
+		WithGroundingChecker(&fakeChecker{result: &GroundingResult{Status: GroundingValid, Score: 0.9}}),
+	)
+
+	events := make([]AskEvent, 0)
+
+	result, err := dm.AskWithRetrievalStream(context.Background(), "q", nil, func(ctx context.Context, event AskEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events (results, 4 deltas, done), got %d", len(events))
+	}
+	if events[0].Type != AskEventResults || len(events[0].Results) != 1 {
+		t.Fatalf("expected the results event first, got %+v", events[0])
+	}
+
+	var sb strings.Builder
+	for _, e := range events[1:5] {
+		if e.Type != AskEventDelta {
+			t.Fatalf("expected a delta event, got %q", e.Type)
+		}
+		sb.WriteString(e.Delta)
+	}
+
+	if sb.String() != "Paris is the capital" {
+		t.Fatalf("expected the deltas to form the answer, got %q", sb.String())
+	}
+
+	done := events[5]
+	if done.Type != AskEventDone || done.Result != result {
+		t.Fatalf("expected the done event with the result last, got %+v", done)
+	}
+	if result.Answer != "Paris is the capital" {
+		t.Fatalf("expected the complete answer, got %q", result.Answer)
+	}
+	if result.Grounding == nil || result.Grounding.Status != GroundingValid {
+		t.Fatalf("expected the grounding verdict, got %+v", result.Grounding)
+	}
+	if _, exists := result.Contents["sec-1"]; !exists {
+		t.Fatalf("expected the cited section in contents, got %v", result.Contents)
+	}
+}
+
+func TestAskStream_Abstention(t *testing.T) {
+	dm := NewDocumentManager(newStubStore(), nil, nil, &streamingLLM{response: "should not be produced"},
+		WithGroundingChecker(&fakeChecker{result: &GroundingResult{Status: GroundingInvalid, Score: 0.1}}),
+	)
+
+	types := make([]AskEventType, 0)
+
+	result, err := dm.AskStream(context.Background(), "q", oneResult(), func(ctx context.Context, event AskEvent) error {
+		types = append(types, event.Type)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []AskEventType{AskEventResults, AskEventDelta, AskEventDone}
+	if !slices.Equal(expected, types) {
+		t.Fatalf("expected events %v, got %v", expected, types)
+	}
+	if result.Answer != abstentionAnswer(result.Grounding) {
+		t.Fatalf("expected the abstention answer, got %q", result.Answer)
+	}
+}
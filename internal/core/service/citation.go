@@ -0,0 +1,128 @@
+package service
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+// Citation is a verified reference of the answer to one of the sections
+// given as context to the LLM. Start and End are the offsets, in runes, of the
+// citation marker in the answer.
+type Citation struct {
+	Marker     int              `json:"marker"`
+	SectionID  model.SectionID  `json:"section_id"`
+	DocumentID model.DocumentID `json:"document_id"`
+	Source     string           `json:"source"`
+	Start      int              `json:"start"`
+	End        int              `json:"end"`
+}
+
+// citationMarkerRegExp matches the citation markers, i.e. "[1]" or "[1, 2]"
+var citationMarkerRegExp = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// extractCitations parses the citation markers of the answer and validates them
+// against the numbered context sections. The markers referencing an unknown
+// section are stripped from the answer and their numbers returned as invalid,
+// the valid markers being normalized to the "[1][2]" form.
+func extractCitations(answer string, sections []contextSection) (string, []Citation, []int) {
+	byNumber := make(map[int]contextSection, len(sections))
+	for _, s := range sections {
+		byNumber[s.Number] = s
+	}
+
+	var (
+		sb        strings.Builder
+		citations = make([]Citation, 0)
+		invalid   = make([]int, 0)
+		last      = 0
+		length    = 0
+	)
+
+	write := func(s string) {
+		sb.WriteString(s)
+		length += utf8.RuneCountInString(s)
+	}
+
+	for _, match := range citationMarkerRegExp.FindAllStringSubmatchIndex(answer, -1) {
+		start, end := match[0], match[1]
+
+		// Markdown links, i.e. "[1](https://...)", are not citations
+		if end < len(answer) && answer[end] == '(' {
+			continue
+		}
+
+		numbers := parseCitationNumbers(answer[match[2]:match[3]])
+
+		valid := make([]int, 0, len(numbers))
+		for _, n := range numbers {
+			if _, exists := byNumber[n]; exists {
+				valid = append(valid, n)
+			}
+		}
+
+		// A marker stuck to a word, i.e. "array[0]", is only a citation if it
+		// references known sections
+		previous, _ := utf8.DecodeLastRuneInString(answer[:start])
+		if start > 0 && (unicode.IsLetter(previous) || unicode.IsDigit(previous)) && len(valid) != len(numbers) {
+			continue
+		}
+
+		for _, n := range numbers {
+			if !slices.Contains(valid, n) && !slices.Contains(invalid, n) {
+				invalid = append(invalid, n)
+			}
+		}
+
+		before := answer[last:start]
+		if len(valid) == 0 {
+			before = strings.TrimRight(before, " \t")
+		}
+
+		write(before)
+
+		for _, n := range valid {
+			section := byNumber[n]
+			marker := "[" + strconv.Itoa(n) + "]"
+
+			for _, sectionID := range section.SectionIDs {
+				citations = append(citations, Citation{
+					Marker:     n,
+					SectionID:  sectionID,
+					DocumentID: section.DocumentID,
+					Source:     section.Source,
+					Start:      length,
+					End:        length + utf8.RuneCountInString(marker),
+				})
+			}
+
+			write(marker)
+		}
+
+		last = end
+	}
+
+	write(answer[last:])
+
+	return sb.String(), citations, invalid
+}
+
+func parseCitationNumbers(raw string) []int {
+	numbers := make([]int, 0)
+
+	for _, s := range strings.Split(raw, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || slices.Contains(numbers, n) {
+			continue
+		}
+
+		numbers = append(numbers, n)
+	}
+
+	return numbers
+}
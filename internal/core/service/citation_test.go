@@ -0,0 +1,87 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+func TestExtractCitations(t *testing.T) {
+	sections := []contextSection{
+		{Number: 1, Source: "https://example.net/a", DocumentID: "doc-a", SectionIDs: []model.SectionID{"sec-1"}},
+		{Number: 2, Source: "https://example.net/b", DocumentID: "doc-b", SectionIDs: []model.SectionID{"sec-2", "sec-3"}},
+	}
+
+	type testCase struct {
+		Name              string
+		Answer            string
+		ExpectedAnswer    string
+		ExpectedCitations []Citation
+		ExpectedInvalid   []int
+	}
+
+	testCases := []testCase{
+		{
+			Name:              "NoCitation",
+			Answer:            "Paris is the capital of France.",
+			ExpectedAnswer:    "Paris is the capital of France.",
+			ExpectedCitations: []Citation{},
+			ExpectedInvalid:   []int{},
+		},
+		{
+			Name:           "ValidCitations",
+			Answer:         "Paris is the capital of France [1]. It is populous [2].",
+			ExpectedAnswer: "Paris is the capital of France [1]. It is populous [2].",
+			ExpectedCitations: []Citation{
+				{Marker: 1, SectionID: "sec-1", DocumentID: "doc-a", Source: "https://example.net/a", Start: 31, End: 34},
+				{Marker: 2, SectionID: "sec-2", DocumentID: "doc-b", Source: "https://example.net/b", Start: 51, End: 54},
+				{Marker: 2, SectionID: "sec-3", DocumentID: "doc-b", Source: "https://example.net/b", Start: 51, End: 54},
+			},
+			ExpectedInvalid: []int{},
+		},
+		{
+			Name:           "InvalidCitationsStripped",
+			Answer:         "Paris [3] is the capital [1, 4].",
+			ExpectedAnswer: "Paris is the capital [1].",
+			ExpectedCitations: []Citation{
+				{Marker: 1, SectionID: "sec-1", DocumentID: "doc-a", Source: "https://example.net/a", Start: 21, End: 24},
+			},
+			ExpectedInvalid: []int{3, 4},
+		},
+		{
+			Name:              "NotCitations",
+			Answer:            "See [1](https://example.net) and values[5].",
+			ExpectedAnswer:    "See [1](https://example.net) and values[5].",
+			ExpectedCitations: []Citation{},
+			ExpectedInvalid:   []int{},
+		},
+		{
+			Name:           "RuneOffsets",
+			Answer:         "Élément été [1]",
+			ExpectedAnswer: "Élément été [1]",
+			ExpectedCitations: []Citation{
+				{Marker: 1, SectionID: "sec-1", DocumentID: "doc-a", Source: "https://example.net/a", Start: 12, End: 15},
+			},
+			ExpectedInvalid: []int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			answer, citations, invalid := extractCitations(tc.Answer, sections)
+
+			if e, g := tc.ExpectedAnswer, answer; e != g {
+				t.Errorf("answer: expected %q, got %q", e, g)
+			}
+
+			if e, g := tc.ExpectedCitations, citations; !reflect.DeepEqual(e, g) {
+				t.Errorf("citations: expected %+v, got %+v", e, g)
+			}
+
+			if e, g := tc.ExpectedInvalid, invalid; !reflect.DeepEqual(e, g) {
+				t.Errorf("invalid: expected %v, got %v", e, g)
+			}
+		})
+	}
+}
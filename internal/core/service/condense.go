@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/prompt"
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+// QueryCondenser rewrites the follow-up question of a conversation into a
+// standalone question, resolving its references to the previous turns, so
+// that it can be used for retrieval.
+type QueryCondenser interface {
+	Condense(ctx context.Context, history []model.ConversationTurn, question string) (string, error)
+}
+
+const defaultCondensePrompt = `
+You are a search query optimizer for a retrieval-augmented answering system.
+
+Given the following conversation and a follow-up question, rewrite the follow-up
+question into a single standalone question which can be understood without the
+conversation. Replace the pronouns and the implicit references by the entities
+they refer to. If the follow-up question is already standalone, return it
+unchanged. Keep the language of the follow-up question and output only the
+rewritten question, nothing else.
+
+## Conversation
+{{ range .History }}
+**Question:** {{ .Question }}
+
+**Answer:** {{ .Answer }}
+{{ end }}
+## Follow-up question
+
+{{ .Question }}
+`
+
+// LLMQueryCondenser implements QueryCondenser with a single LLM call.
+type LLMQueryCondenser struct {
+	llm llm.Client
+}
+
+func NewLLMQueryCondenser(client llm.Client) *LLMQueryCondenser {
+	return &LLMQueryCondenser{llm: client}
+}
+
+type condenseTurn struct {
+	Question string
+	Answer   string
+}
+
+// Condense implements QueryCondenser. The question is returned unchanged when
+// the history is empty.
+func (c *LLMQueryCondenser) Condense(ctx context.Context, history []model.ConversationTurn, question string) (string, error) {
+	if len(history) == 0 {
+		return question, nil
+	}
+
+	turns := make([]condenseTurn, 0, len(history))
+	for _, t := range history {
+		turns = append(turns, condenseTurn{
+			Question: t.Question(),
+			Answer:   t.Answer(),
+		})
+	}
+
+	systemPrompt, err := prompt.Template(defaultCondensePrompt, struct {
+		History  []condenseTurn
+		Question string
+	}{
+		History:  turns,
+		Question: question,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	seed, err := text.IntHash(systemPrompt)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	ctx = slogx.WithAttrs(ctx, slog.Int("seed", seed))
+
+	completion, err := c.llm.ChatCompletion(ctx,
+		llm.WithMessages(
+			llm.NewMessage(llm.RoleUser, systemPrompt),
+		),
+		llm.WithTemperature(0),
+		llm.WithSeed(seed),
+	)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	condensed := strings.TrimSpace(completion.Message().Content())
+	if condensed == "" {
+		return question, nil
+	}
+
+	return condensed, nil
+}
+
+var _ QueryCondenser = &LLMQueryCondenser{}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoReadableCollection = errors.New("no readable collection")
+)
+
+type ConversationManagerOptions struct {
+	// QueryCondenser rewrites the follow-up questions into standalone ones
+	// before retrieval, the questions being used as is when nil
+	QueryCondenser QueryCondenser
+	// MaxHistoryTurns bounds the number of previous turns given to the
+	// QueryCondenser
+	MaxHistoryTurns int
+}
+
+type ConversationManagerOptionFunc func(opts *ConversationManagerOptions)
+
+// WithConversationQueryCondenser enables the rewriting of the follow-up
+// questions of the conversations into standalone questions before retrieval.
+func WithConversationQueryCondenser(condenser QueryCondenser) ConversationManagerOptionFunc {
+	return func(opts *ConversationManagerOptions) {
+		opts.QueryCondenser = condenser
+	}
+}
+
+// WithConversationMaxHistoryTurns sets the maximum number of previous turns
+// used to condense a follow-up question (default 5).
+func WithConversationMaxHistoryTurns(maxTurns int) ConversationManagerOptionFunc {
+	return func(opts *ConversationManagerOptions) {
+		opts.MaxHistoryTurns = maxTurns
+	}
+}
+
+func NewConversationManagerOptions(funcs ...ConversationManagerOptionFunc) *ConversationManagerOptions {
+	opts := &ConversationManagerOptions{
+		MaxHistoryTurns: 5,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+// ConversationManager answers the successive questions of the conversations,
+// each question being condensed with the previous turns before retrieval and
+// the turns being persisted with the sections they cite.
+type ConversationManager struct {
+	port.ConversationStore
+
+	documentManager *DocumentManager
+	queryCondenser  QueryCondenser
+	maxHistoryTurns int
+}
+
+// ConversationAskResult is the outcome of a question asked in a conversation:
+// the result of the retrieval and the persisted turn
+type ConversationAskResult struct {
+	*AskResult
+	Turn model.PersistedConversationTurn
+}
+
+// Ask answers the question in the light of the previous turns of the
+// conversation and appends the new turn to it.
+func (m *ConversationManager) Ask(ctx context.Context, conversation model.PersistedConversation, question string, funcs ...DocumentManagerAskOptionFunc) (*ConversationAskResult, error) {
+	standaloneQuestion, collections, err := m.prepare(ctx, conversation, question)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	result, err := m.documentManager.AskWithRetrieval(ctx, standaloneQuestion, collections, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	turn, err := m.appendTurn(ctx, conversation, question, standaloneQuestion, result)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &ConversationAskResult{AskResult: result, Turn: turn}, nil
+}
+
+// AskStream is the streaming variant of Ask, the new turn being appended to
+// the conversation before the done event is emitted.
+func (m *ConversationManager) AskStream(ctx context.Context, conversation model.PersistedConversation, question string, fn AskStreamFunc, funcs ...DocumentManagerAskOptionFunc) (*ConversationAskResult, error) {
+	standaloneQuestion, collections, err := m.prepare(ctx, conversation, question)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var turn model.PersistedConversationTurn
+
+	stream := func(ctx context.Context, event AskEvent) error {
+		if event.Type == AskEventDone {
+			t, err := m.appendTurn(ctx, conversation, question, standaloneQuestion, event.Result)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			turn = t
+		}
+
+		return fn(ctx, event)
+	}
+
+	result, err := m.documentManager.AskWithRetrievalStream(ctx, standaloneQuestion, collections, stream, funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &ConversationAskResult{AskResult: result, Turn: turn}, nil
+}
+
+// prepare returns the standalone version of the question and the collections
+// of the conversation still readable by its owner
+func (m *ConversationManager) prepare(ctx context.Context, conversation model.PersistedConversation, question string) (string, []model.CollectionID, error) {
+	collections, err := m.readableCollections(ctx, conversation)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	if m.queryCondenser == nil {
+		return question, collections, nil
+	}
+
+	turns, err := m.ConversationStore.GetConversationTurns(ctx, conversation.ID())
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	if len(turns) == 0 {
+		return question, collections, nil
+	}
+
+	if len(turns) > m.maxHistoryTurns {
+		turns = turns[len(turns)-m.maxHistoryTurns:]
+	}
+
+	history := make([]model.ConversationTurn, 0, len(turns))
+	for _, t := range turns {
+		history = append(history, t)
+	}
+
+	standaloneQuestion, err := m.queryCondenser.Condense(ctx, history, question)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	slog.DebugContext(ctx, "condensed follow-up question",
+		slog.String("question", question),
+		slog.String("standalone_question", standaloneQuestion),
+	)
+
+	return standaloneQuestion, collections, nil
+}
+
+// readableCollections returns the collections of the conversation readable by
+// its owner, all the readable ones if the conversation is not restricted
+func (m *ConversationManager) readableCollections(ctx context.Context, conversation model.PersistedConversation) ([]model.CollectionID, error) {
+	readable, _, err := m.documentManager.DocumentStore.QueryUserReadableCollections(ctx, conversation.Owner().ID(), port.QueryCollectionsOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	scope := conversation.Collections()
+
+	collections := make([]model.CollectionID, 0, len(readable))
+	for _, c := range readable {
+		if len(scope) > 0 && !slices.Contains(scope, c.ID()) {
+			continue
+		}
+
+		collections = append(collections, c.ID())
+	}
+
+	if len(collections) == 0 {
+		return nil, errors.WithStack(ErrNoReadableCollection)
+	}
+
+	return collections, nil
+}
+
+// appendTurn persists the turn with the sections cited by the answer, or all
+// the sections given as context when the answer has no citation
+func (m *ConversationManager) appendTurn(ctx context.Context, conversation model.PersistedConversation, question string, standaloneQuestion string, result *AskResult) (model.PersistedConversationTurn, error) {
+	sections := make([]model.SectionID, 0, len(result.Contents))
+	for _, c := range result.Citations {
+		if !slices.Contains(sections, c.SectionID) {
+			sections = append(sections, c.SectionID)
+		}
+	}
+
+	if len(sections) == 0 {
+		for id := range result.Contents {
+			sections = append(sections, id)
+		}
+	}
+
+	slices.Sort(sections)
+
+	turn, err := m.ConversationStore.AppendConversationTurn(ctx, conversation.ID(), model.NewConversationTurn(question, standaloneQuestion, result.Answer, sections...))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return turn, nil
+}
+
+func NewConversationManager(store port.ConversationStore, documentManager *DocumentManager, funcs ...ConversationManagerOptionFunc) *ConversationManager {
+	opts := NewConversationManagerOptions(funcs...)
+
+	return &ConversationManager{
+		ConversationStore: store,
+		documentManager:   documentManager,
+		queryCondenser:    opts.QueryCondenser,
+		maxHistoryTurns:   opts.MaxHistoryTurns,
+	}
+}
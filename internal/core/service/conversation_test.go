@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// stubConversationStore implements port.ConversationStore by embedding the
+// interface; only the methods used by the ConversationManager are
+// implemented.
+type stubConversationStore struct {
+	port.ConversationStore
+	turns map[model.ConversationID][]model.PersistedConversationTurn
+}
+
+type stubPersistedTurn struct {
+	model.ConversationTurn
+}
+
+func (t *stubPersistedTurn) CreatedAt() time.Time { return time.Time{} }
+func (t *stubPersistedTurn) UpdatedAt() time.Time { return time.Time{} }
+
+func (s *stubConversationStore) AppendConversationTurn(ctx context.Context, id model.ConversationID, turn model.ConversationTurn) (model.PersistedConversationTurn, error) {
+	persisted := &stubPersistedTurn{turn}
+	s.turns[id] = append(s.turns[id], persisted)
+	return persisted, nil
+}
+
+func (s *stubConversationStore) GetConversationTurns(ctx context.Context, id model.ConversationID) ([]model.PersistedConversationTurn, error) {
+	return s.turns[id], nil
+}
+
+type stubPersistedConversation struct {
+	model.OwnedConversation
+}
+
+func (c *stubPersistedConversation) CreatedAt() time.Time { return time.Time{} }
+func (c *stubPersistedConversation) UpdatedAt() time.Time { return time.Time{} }
+
+type stubPersistedCollection struct {
+	model.PersistedCollection
+	id model.CollectionID
+}
+
+func (c *stubPersistedCollection) ID() model.CollectionID { return c.id }
+
+// readableStore adds the readable collections of the users to the stubStore
+type readableStore struct {
+	*stubStore
+	readable []model.PersistedCollection
+}
+
+func (s *readableStore) QueryUserReadableCollections(ctx context.Context, userID model.UserID, opts port.QueryCollectionsOptions) ([]model.PersistedCollection, int64, error) {
+	return s.readable, int64(len(s.readable)), nil
+}
+
+func (s *readableStore) GetCollectionByID(ctx context.Context, id model.CollectionID, full bool) (model.PersistedCollection, error) {
+	return &stubPersistedCollection{id: id}, nil
+}
+
+type fakeCondenser struct {
+	out     string
+	history []model.ConversationTurn
+	calls   int
+}
+
+func (f *fakeCondenser) Condense(ctx context.Context, history []model.ConversationTurn, question string) (string, error) {
+	f.calls++
+	f.history = history
+	return f.out, nil
+}
+
+func newTestConversationManager(index *stubIndex, condenser QueryCondenser) (*ConversationManager, *stubConversationStore) {
+	store := &readableStore{
+		stubStore: storeWithSections("sec-1", "sec-2"),
+		readable:  []model.PersistedCollection{&stubPersistedCollection{id: "c1"}},
+	}
+
+	dm := NewDocumentManager(store, index, nil, &stubLLM{response: "answer"})
+
+	conversations := &stubConversationStore{turns: map[model.ConversationID][]model.PersistedConversationTurn{}}
+
+	return NewConversationManager(conversations, dm, WithConversationQueryCondenser(condenser)), conversations
+}
+
+func TestConversationManager_Ask(t *testing.T) {
+	index := &stubIndex{byQuery: map[string][]*port.IndexSearchResult{
+		"What is the capital of France?":   {resultWith("sec-1")},
+		"What is the population of Paris?": {resultWith("sec-2")},
+	}}
+	condenser := &fakeCondenser{out: "What is the population of Paris?"}
+
+	manager, store := newTestConversationManager(index, condenser)
+
+	owner := model.NewUser("test", "test", "test@example.com", "Test", true)
+	conversation := &stubPersistedConversation{model.NewConversation(owner, "Test")}
+
+	ctx := context.Background()
+
+	first, err := manager.Ask(ctx, conversation, "What is the capital of France?")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if condenser.calls != 0 {
+		t.Fatalf("expected the first question not to be condensed, got %d calls", condenser.calls)
+	}
+	if e, g := "What is the capital of France?", first.Turn.StandaloneQuestion(); e != g {
+		t.Fatalf("standalone question: expected %q, got %q", e, g)
+	}
+
+	second, err := manager.Ask(ctx, conversation, "And its population?")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if condenser.calls != 1 || len(condenser.history) != 1 {
+		t.Fatalf("expected the follow-up to be condensed with 1 previous turn, got %d calls and %d turns", condenser.calls, len(condenser.history))
+	}
+	if e, g := "What is the population of Paris?", index.calls[1]; e != g {
+		t.Fatalf("retrieval: expected the standalone question %q, got %q", e, g)
+	}
+	if e, g := "And its population?", second.Turn.Question(); e != g {
+		t.Fatalf("question: expected %q, got %q", e, g)
+	}
+	if sections := second.Turn.Sections(); len(sections) != 1 || sections[0] != "sec-2" {
+		t.Fatalf("expected the turn to cite sec-2, got %v", sections)
+	}
+
+	turns := store.turns[conversation.ID()]
+	if len(turns) != 2 {
+		t.Fatalf("expected 2 persisted turns, got %d", len(turns))
+	}
+	if e, g := "answer", turns[1].Answer(); e != g {
+		t.Fatalf("answer: expected %q, got %q", e, g)
+	}
+}
+
+func TestConversationManager_AskUnreadableCollections(t *testing.T) {
+	manager, _ := newTestConversationManager(&stubIndex{}, nil)
+
+	owner := model.NewUser("test", "test", "test@example.com", "Test", true)
+	conversation := &stubPersistedConversation{model.NewConversation(owner, "Test", "c2")}
+
+	_, err := manager.Ask(context.Background(), conversation, "What is the capital of France?")
+	if !errors.Is(err, ErrNoReadableCollection) {
+		t.Fatalf("expected ErrNoReadableCollection, got %v", err)
+	}
+}
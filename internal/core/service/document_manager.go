@@ -30,6 +30,18 @@ type DocumentManagerOptions struct {
 	QueryReformulator  QueryReformulator
 	QueryDecomposer    QueryDecomposer
 	IterativeMaxRounds int
+	// MaxTotalWords bounds the number of words of the context given to the LLM
+	// when expanding the retrieved sections
+	MaxTotalWords int
+	// ContextExpansion is the default expansion of the retrieved sections,
+	// ContextExpansionSiblings the number of siblings added before and after
+	// each section with ContextExpansionSiblings
+	ContextExpansion         ContextExpansion
+	ContextExpansionSiblings int
+	// AgentMaxSteps and AgentMaxTokens are the default budget of the agent
+	// answering with AskAgent
+	AgentMaxSteps  int
+	AgentMaxTokens int
 }
 
 type DocumentManagerOptionFunc func(opts *DocumentManagerOptions)
@@ -58,11 +70,46 @@ func WithGroundingMinScore(minScore float64) DocumentManagerOptionFunc {
 	}
 }
 
+// WithMaxTotalWords sets the maximum number of words of the context given to
+// the LLM, the retrieved sections being expanded while they fit (default
+// 50000).
+func WithMaxTotalWords(maxTotalWords int) DocumentManagerOptionFunc {
+	return func(opts *DocumentManagerOptions) {
+		opts.MaxTotalWords = maxTotalWords
+	}
+}
+
+// WithContextExpansion sets the default expansion of the retrieved sections
+// when answering, siblings being the number of sections added before and after
+// each retrieved section with ContextExpansionSiblings. Overridden per request
+// with WithAskContextExpansion.
+func WithContextExpansion(expansion ContextExpansion, siblings int) DocumentManagerOptionFunc {
+	return func(opts *DocumentManagerOptions) {
+		opts.ContextExpansion = expansion
+		opts.ContextExpansionSiblings = siblings
+	}
+}
+
+// WithAgentBudget sets the default maximum number of tool calling steps and of
+// tokens consumed by the agent of AskAgent (default 8 steps and 100000
+// tokens). Lowered per request with WithAgentMaxSteps and WithAgentMaxTokens.
+func WithAgentBudget(maxSteps int, maxTokens int) DocumentManagerOptionFunc {
+	return func(opts *DocumentManagerOptions) {
+		opts.AgentMaxSteps = maxSteps
+		opts.AgentMaxTokens = maxTokens
+	}
+}
+
 func NewDocumentManagerOptions(funcs ...DocumentManagerOptionFunc) *DocumentManagerOptions {
 	opts := &DocumentManagerOptions{
-		MaxWordPerSection:  250,
-		GroundingMinScore:  0.4,
-		IterativeMaxRounds: 1,
+		MaxWordPerSection:        250,
+		GroundingMinScore:        0.4,
+		IterativeMaxRounds:       1,
+		MaxTotalWords:            DefaultMaxTotalWords,
+		ContextExpansion:         ContextExpansionNone,
+		ContextExpansionSiblings: 1,
+		AgentMaxSteps:            DefaultAgentMaxSteps,
+		AgentMaxTokens:           DefaultAgentMaxTokens,
 	}
 	for _, fn := range funcs {
 		fn(opts)
@@ -85,12 +132,23 @@ type DocumentManager struct {
 	queryReformulator  QueryReformulator
 	queryDecomposer    QueryDecomposer
 	iterativeMaxRounds int
+	maxTotalWords      int
+	contextExpansion   ContextExpansion
+	expansionSiblings  int
+	agentMaxSteps      int
+	agentMaxTokens     int
 }
 
 type DocumentManagerSearchOptions struct {
 	MaxResults int
 	// Names of the collection the query will be restricted to
 	Collections []model.CollectionID
+	// Filter restricts the query to the matching documents
+	Filter *port.IndexSearchFilter
+	// Highlight requests the fragments of each section matching the query
+	Highlight bool
+	// Boost ranks higher the documents having the given metadata key/values
+	Boost map[string]string
 }
 
 type DocumentManagerSearchOptionFunc func(opts *DocumentManagerSearchOptions)
@@ -118,6 +176,26 @@ func WithDocumentManagerSearchCollections(collections ...model.CollectionID) Doc
 	}
 }
 
+func WithDocumentManagerSearchFilter(filter *port.IndexSearchFilter) DocumentManagerSearchOptionFunc {
+	return func(opts *DocumentManagerSearchOptions) {
+		opts.Filter = filter
+	}
+}
+
+func WithDocumentManagerSearchHighlight(highlight bool) DocumentManagerSearchOptionFunc {
+	return func(opts *DocumentManagerSearchOptions) {
+		opts.Highlight = highlight
+	}
+}
+
+// WithDocumentManagerSearchBoost ranks higher the sections of the documents
+// having the given metadata key/values, without excluding the other ones.
+func WithDocumentManagerSearchBoost(boost map[string]string) DocumentManagerSearchOptionFunc {
+	return func(opts *DocumentManagerSearchOptions) {
+		opts.Boost = boost
+	}
+}
+
 func (m *DocumentManager) Search(ctx context.Context, query string, funcs ...DocumentManagerSearchOptionFunc) ([]*port.IndexSearchResult, error) {
 	metrics.TotalSearchRequests.Add(1)
 
@@ -136,11 +214,19 @@ func (m *DocumentManager) Search(ctx context.Context, query string, funcs ...Doc
 	searchResults, err := m.index.Search(ctx, query, port.IndexSearchOptions{
 		MaxResults:  opts.MaxResults,
 		Collections: collections,
+		Filter:      opts.Filter,
+		Highlight:   opts.Highlight,
+		Boost:       opts.Boost,
 	})
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	searchResults, err = m.discardTrashedResults(ctx, searchResults)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	return searchResults, nil
 }
 
@@ -149,10 +235,40 @@ type DocumentManagerAskOptions struct {
 	// GroundingOut, when non-nil, is populated with the grounding verdict
 	// computed during Ask (only when a GroundingChecker is configured).
 	GroundingOut *GroundingResult
+	// Filter restricts the retrieval to the matching documents (only used
+	// by AskWithRetrieval)
+	Filter *port.IndexSearchFilter
+	// ContextExpansion overrides the expansion of the retrieved sections
+	// configured on the manager, ContextExpansionSiblings the number of
+	// siblings added with ContextExpansionSiblings
+	ContextExpansion         ContextExpansion
+	ContextExpansionSiblings int
+
+	// stream, when set, receives the events of the answer as it is
+	// generated (see AskStream and AskWithRetrievalStream)
+	stream AskStreamFunc
 }
 
 type DocumentManagerAskOptionFunc func(opts *DocumentManagerAskOptions)
 
+// WithAskFilter restricts the retrieval performed by AskWithRetrieval to the
+// documents matching the given filter.
+func WithAskFilter(filter *port.IndexSearchFilter) DocumentManagerAskOptionFunc {
+	return func(opts *DocumentManagerAskOptions) {
+		opts.Filter = filter
+	}
+}
+
+// WithAskContextExpansion overrides the expansion of the retrieved sections
+// for this request, siblings being the number of sections added before and
+// after each retrieved section with ContextExpansionSiblings.
+func WithAskContextExpansion(expansion ContextExpansion, siblings int) DocumentManagerAskOptionFunc {
+	return func(opts *DocumentManagerAskOptions) {
+		opts.ContextExpansion = expansion
+		opts.ContextExpansionSiblings = siblings
+	}
+}
+
 func WithAskSystemPromptTemplate(promptTemplate string) DocumentManagerAskOptionFunc {
 	return func(opts *DocumentManagerAskOptions) {
 		opts.SystemPromptTemplate = promptTemplate
@@ -180,6 +296,7 @@ const defaultSystemPromptTemplate string = `
 - Your goal is to provide precise, concise, and relevant answers based solely on the available data. 
 - If the data provided is insufficient or inconsistent, you should clearly state that a reliable answer cannot be given. 
 - Always respond in the language used by the user and do not add any additional content to your response.
+- Cite the context sections supporting each statement of your response with their number between square brackets, for example [1] or [1][3]. Only use the numbers of the sections given in the context.
 
 **Important Security Note:**
 
@@ -189,7 +306,7 @@ const defaultSystemPromptTemplate string = `
 
 ## Context
 {{ range .Sections }}
-### {{ .Source }}
+### [{{ .Number }}] {{ .Source }}
 
 {{ .Content }}
 {{ end }}
@@ -210,13 +327,29 @@ var (
 )
 
 func (m *DocumentManager) Ask(ctx context.Context, query string, results []*port.IndexSearchResult, funcs ...DocumentManagerAskOptionFunc) (string, map[model.SectionID]string, error) {
+	opts := NewDocumentManagerAskOptions(funcs...)
+
+	result, err := m.ask(ctx, query, results, opts)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	if opts.GroundingOut != nil && result.Grounding != nil {
+		*opts.GroundingOut = *result.Grounding
+	}
+
+	return result.Answer, result.Contents, nil
+}
+
+func (m *DocumentManager) ask(ctx context.Context, query string, results []*port.IndexSearchResult, opts *DocumentManagerAskOptions) (*AskResult, error) {
 	metrics.TotalAskRequests.Add(1)
 
-	opts := NewDocumentManagerAskOptions(funcs...)
+	result := &AskResult{
+		Results: results,
+	}
 
-	systemPromptTemplate := opts.SystemPromptTemplate
-	if systemPromptTemplate == "" {
-		systemPromptTemplate = defaultSystemPromptTemplate
+	if err := opts.emit(ctx, AskEvent{Type: AskEventResults, Results: results}); err != nil {
+		return nil, errors.WithStack(err)
 	}
 
 	// Grounding (γ) gate: when a checker is configured, verify the retrieved
@@ -225,12 +358,10 @@ func (m *DocumentManager) Ask(ctx context.Context, query string, results []*port
 	if m.groundingChecker != nil {
 		grounding, err := m.groundingChecker.Check(ctx, query, results)
 		if err != nil {
-			return "", nil, errors.WithStack(err)
+			return nil, errors.WithStack(err)
 		}
 
-		if opts.GroundingOut != nil {
-			*opts.GroundingOut = *grounding
-		}
+		result.Grounding = grounding
 
 		if grounding.Status == GroundingInvalid || grounding.Score < m.groundingMinScore {
 			slog.InfoContext(ctx, "abstaining: retrieved evidence is not sufficiently grounded",
@@ -239,27 +370,70 @@ func (m *DocumentManager) Ask(ctx context.Context, query string, results []*port
 				slog.Float64("min_score", m.groundingMinScore),
 			)
 
-			return abstentionAnswer(grounding), map[model.SectionID]string{}, nil
+			if err := m.abstain(ctx, opts, result); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			return result, nil
 		}
 	}
 
-	response, contents, err := m.generateResponse(ctx, systemPromptTemplate, query, results)
-	if err != nil {
-		return "", nil, errors.WithStack(err)
+	if err := m.generateResponse(ctx, opts, query, results, result); err != nil {
+		return nil, errors.WithStack(err)
 	}
 
-	return response, contents, nil
+	return result, nil
 }
 
-func (m *DocumentManager) generateResponse(ctx context.Context, systemPromptTemplate string, query string, results []*port.IndexSearchResult) (string, map[model.SectionID]string, error) {
-	type contextSection struct {
-		Source  string
-		Content string
+// abstain sets the abstention answer on the result, emitting it as a single
+// fragment when the answer is streamed
+func (m *DocumentManager) abstain(ctx context.Context, opts *DocumentManagerAskOptions, result *AskResult) error {
+	result.Answer = abstentionAnswer(result.Grounding)
+	result.Abstained = true
+	result.Contents = map[model.SectionID]string{}
+	result.Citations = []Citation{}
+
+	if err := opts.emit(ctx, AskEvent{Type: AskEventDelta, Delta: result.Answer}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// contextSection is an excerpt of a document given to the LLM as context,
+// numbered to be cited in the answer
+type contextSection struct {
+	Number     int
+	Source     string
+	DocumentID model.DocumentID
+	// SectionIDs are the retrieved sections covered by the excerpt
+	SectionIDs []model.SectionID
+	Content    string
+}
+
+// generateResponse generates the answer to the query from the retrieved
+// sections, filling the answer, the section contents and the verified
+// citations of the result
+func (m *DocumentManager) generateResponse(ctx context.Context, opts *DocumentManagerAskOptions, query string, results []*port.IndexSearchResult, result *AskResult) error {
+	systemPromptTemplate := opts.SystemPromptTemplate
+	if systemPromptTemplate == "" {
+		systemPromptTemplate = defaultSystemPromptTemplate
+	}
+
+	expansion, siblings := m.contextExpansion, m.expansionSiblings
+	if opts.ContextExpansion != "" {
+		expansion, siblings = opts.ContextExpansion, opts.ContextExpansionSiblings
+	}
+
+	if !expansion.Valid() {
+		return errors.Errorf("unknown context expansion '%s'", expansion)
 	}
 
 	contents := map[model.SectionID]string{}
 
 	contextSections := make([]contextSection, 0)
+	window := newContextWindow(m.maxTotalWords)
+
 	for _, r := range results {
 		for _, sectionID := range r.Sections {
 			section, err := m.GetSectionByID(ctx, sectionID)
@@ -270,47 +444,106 @@ func (m *DocumentManager) generateResponse(ctx context.Context, systemPromptTemp
 
 			content, err := section.Content()
 			if err != nil {
-				return "", contents, errors.WithStack(err)
+				return errors.WithStack(err)
 			}
 
 			contents[sectionID] = string(content)
 
-			contextSections = append(contextSections, contextSection{
-				Source:  r.Source.String(),
-				Content: string(content),
-			})
+			if expansion == ContextExpansionNone {
+				contextSections = append(contextSections, contextSection{
+					Source:     r.Source.String(),
+					DocumentID: section.Document().ID(),
+					SectionIDs: []model.SectionID{sectionID},
+					Content:    string(content),
+				})
+				continue
+			}
+
+			expanded, err := m.expandSection(ctx, section, expansion, siblings)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			added, err := window.add(r.Source.String(), section, expanded, false)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			if added {
+				continue
+			}
+
+			slog.DebugContext(ctx, "expanded section exceeds the context budget", slog.String("sectionID", string(sectionID)), slog.String("expansion", string(expansion)))
+
+			// The retrieved section is kept as is
+			if _, err := window.add(r.Source.String(), section, model.Range{Start: section.Start(), End: section.End()}, true); err != nil {
+				return errors.WithStack(err)
+			}
 		}
 	}
 
+	if expansion != ContextExpansionNone {
+		sections, err := window.sections()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		contextSections = sections
+	}
+
+	for i := range contextSections {
+		contextSections[i].Number = i + 1
+	}
+
+	result.Contents = contents
+
 	systemPrompt, err := prompt.Template(systemPromptTemplate, struct {
 		Sections []contextSection
 	}{
 		Sections: contextSections,
 	})
 	if err != nil {
-		return "", contents, errors.WithStack(err)
+		return errors.WithStack(err)
 	}
 
 	seed, err := text.IntHash(systemPrompt + query)
 	if err != nil {
-		return "", contents, errors.WithStack(err)
+		return errors.WithStack(err)
 	}
 
 	ctx = slogx.WithAttrs(ctx, slog.Int("seed", seed))
 
-	res, err := m.llm.ChatCompletion(
-		ctx,
+	completionFuncs := []llm.ChatCompletionOptionFunc{
 		llm.WithMessages(
 			llm.NewMessage(llm.RoleSystem, systemPrompt),
 			llm.NewMessage(llm.RoleUser, query),
 		),
 		llm.WithSeed(seed),
-	)
-	if err != nil {
-		return "", contents, errors.WithStack(err)
 	}
 
-	return res.Message().Content(), contents, nil
+	var answer string
+
+	if opts.stream != nil {
+		answer, err = streamCompletion(ctx, m.llm, opts.stream, completionFuncs...)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		res, err := m.llm.ChatCompletion(ctx, completionFuncs...)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		answer = res.Message().Content()
+	}
+
+	result.Answer, result.Citations, result.InvalidCitations = extractCitations(answer, contextSections)
+
+	if len(result.InvalidCitations) > 0 {
+		slog.WarnContext(ctx, "stripped invalid citations from the answer", slog.Any("citations", result.InvalidCitations), slog.Int("sections", len(contextSections)))
+	}
+
+	return nil
 }
 
 func (m *DocumentManager) SupportedExtensions() []string {
@@ -322,6 +555,8 @@ type DocumentManagerIndexFileOptions struct {
 	Source *url.URL
 	// Names of the collection to associate with the document
 	Collections []model.CollectionID
+	// Metadata of the document, overriding the ones of its front matter
+	Metadata map[string][]string
 }
 
 type DocumentManagerIndexFileOptionFunc func(opts *DocumentManagerIndexFileOptions)
@@ -338,6 +573,14 @@ func WithDocumentManagerIndexFileSource(source *url.URL) DocumentManagerIndexFil
 	}
 }
 
+// WithDocumentManagerIndexFileMetadata sets metadata on the indexed document,
+// each key replacing the values of its front matter.
+func WithDocumentManagerIndexFileMetadata(metadata map[string][]string) DocumentManagerIndexFileOptionFunc {
+	return func(opts *DocumentManagerIndexFileOptions) {
+		opts.Metadata = metadata
+	}
+}
+
 func WithDocumentManagerIndexFileETag(etag string) DocumentManagerIndexFileOptionFunc {
 	return func(opts *DocumentManagerIndexFileOptions) {
 		opts.ETag = etag
@@ -374,7 +617,7 @@ func (m *DocumentManager) IndexFile(ctx context.Context, owner model.User, filen
 		return "", errors.WithStack(err)
 	}
 
-	indexFileTask := documentTask.NewIndexFileTask(owner, path, filename, opts.ETag, opts.Source, opts.Collections)
+	indexFileTask := documentTask.NewIndexFileTask(owner, path, filename, opts.ETag, opts.Source, opts.Collections, opts.Metadata)
 
 	taskCtx := log.WithAttrs(context.Background(), slog.String("filename", filename), slog.String("filepath", path))
 
@@ -397,6 +640,20 @@ func (m *DocumentManager) CleanupIndex(ctx context.Context, owner model.User, co
 	return taskID, nil
 }
 
+// CheckConsistency schedules a task comparing the sections of the document
+// store with the ones of each index, restricted to the given collections if
+// any. If repair is true, orphaned sections are removed from the indexes and
+// missing ones indexed.
+func (m *DocumentManager) CheckConsistency(ctx context.Context, owner model.User, repair bool, collections ...model.CollectionID) (model.TaskID, error) {
+	checkTask := documentTask.NewCheckConsistencyTask(owner, collections, repair)
+
+	if err := m.taskRunner.ScheduleTask(ctx, checkTask); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return checkTask.ID(), nil
+}
+
 func (m *DocumentManager) ReindexCollection(ctx context.Context, owner model.User, collectionID model.CollectionID) (model.TaskID, error) {
 	reindexTask := documentTask.NewReindexCollectionTask(owner, collectionID)
 
@@ -422,6 +679,11 @@ func NewDocumentManager(store port.DocumentStore, index port.Index, taskRunner p
 		queryReformulator:  opts.QueryReformulator,
 		queryDecomposer:    opts.QueryDecomposer,
 		iterativeMaxRounds: opts.IterativeMaxRounds,
+		maxTotalWords:      opts.MaxTotalWords,
+		contextExpansion:   opts.ContextExpansion,
+		expansionSiblings:  opts.ContextExpansionSiblings,
+		agentMaxSteps:      opts.AgentMaxSteps,
+		agentMaxTokens:     opts.AgentMaxTokens,
 	}
 
 	return documentManager
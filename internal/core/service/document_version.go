@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const versionDiffContextLines = 3
+
+// DocumentVersionDiff returns the unified diff of the markdown of the given
+// version of the document with the one of its current version.
+func (m *DocumentManager) DocumentVersionDiff(ctx context.Context, documentID model.DocumentID, versionID model.DocumentVersionID) (string, error) {
+	document, version, err := m.getDocumentVersion(ctx, documentID, versionID)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	previous, err := version.Content()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	current, err := document.Content()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	source := document.Source().String()
+
+	diff, err := unifiedDiff(
+		previous, source, version.CreatedAt(),
+		current, source, document.UpdatedAt(),
+	)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return diff, nil
+}
+
+// RollbackDocument schedules the indexing of the given version of the
+// document, which replaces its current version (archived in turn).
+func (m *DocumentManager) RollbackDocument(ctx context.Context, owner model.User, documentID model.DocumentID, versionID model.DocumentVersionID) (model.TaskID, error) {
+	document, version, err := m.getDocumentVersion(ctx, documentID, versionID)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	content, err := version.Content()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	collections := slices.Collect(func(yield func(c model.CollectionID) bool) {
+		for _, c := range document.Collections() {
+			if !yield(c.ID()) {
+				return
+			}
+		}
+	})
+
+	taskID, err := m.IndexFile(
+		ctx, owner, "file.md", bytes.NewReader(content),
+		WithDocumentManagerIndexFileCollections(collections...),
+		WithDocumentManagerIndexFileSource(document.Source()),
+		WithDocumentManagerIndexFileETag(version.ETag()),
+		WithDocumentManagerIndexFileMetadata(version.Metadata()),
+	)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return taskID, nil
+}
+
+// getDocumentVersion returns the document and its given version, or
+// port.ErrNotFound if the version does not belong to the document
+func (m *DocumentManager) getDocumentVersion(ctx context.Context, documentID model.DocumentID, versionID model.DocumentVersionID) (model.PersistedDocument, model.DocumentVersion, error) {
+	version, err := m.DocumentStore.GetDocumentVersionByID(ctx, versionID)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	if version.DocumentID() != documentID {
+		return nil, nil, errors.WithStack(port.ErrNotFound)
+	}
+
+	document, err := m.DocumentStore.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return document, version, nil
+}
+
+func unifiedDiff(from []byte, fromName string, fromDate time.Time, to []byte, toName string, toDate time.Time) (string, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		FromFile: fromName,
+		FromDate: fromDate.Format(time.RFC3339),
+		B:        splitLines(to),
+		ToFile:   toName,
+		ToDate:   toDate.Format(time.RFC3339),
+		Context:  versionDiffContextLines,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return diff, nil
+}
+
+// splitLines splits the text in lines keeping their line ending, the last one
+// being terminated if it is not
+func splitLines(text []byte) []string {
+	if len(text) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(text), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+
+	return lines
+}
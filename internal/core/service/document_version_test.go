@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// versionedDocument adds a content and an update time to the
+// stubPersistedDocument
+type versionedDocument struct {
+	stubPersistedDocument
+	content   string
+	updatedAt time.Time
+}
+
+func (d *versionedDocument) Content() ([]byte, error) { return []byte(d.content), nil }
+func (d *versionedDocument) UpdatedAt() time.Time     { return d.updatedAt }
+
+type stubDocumentVersion struct {
+	id         model.DocumentVersionID
+	documentID model.DocumentID
+	content    string
+	createdAt  time.Time
+}
+
+func (v *stubDocumentVersion) ID() model.DocumentVersionID   { return v.id }
+func (v *stubDocumentVersion) DocumentID() model.DocumentID  { return v.documentID }
+func (v *stubDocumentVersion) ETag() string                  { return "" }
+func (v *stubDocumentVersion) Metadata() map[string][]string { return nil }
+func (v *stubDocumentVersion) Content() ([]byte, error)      { return []byte(v.content), nil }
+func (v *stubDocumentVersion) TaskID() model.TaskID          { return "" }
+func (v *stubDocumentVersion) CreatedAt() time.Time          { return v.createdAt }
+
+// versionStore implements port.DocumentStore by embedding the interface; only
+// the document and version accessors are implemented.
+type versionStore struct {
+	port.DocumentStore
+	documents map[model.DocumentID]model.PersistedDocument
+	versions  map[model.DocumentVersionID]model.DocumentVersion
+}
+
+func (s *versionStore) GetDocumentByID(ctx context.Context, id model.DocumentID) (model.PersistedDocument, error) {
+	document, exists := s.documents[id]
+	if !exists {
+		return nil, errors.WithStack(port.ErrNotFound)
+	}
+
+	return document, nil
+}
+
+func (s *versionStore) GetDocumentVersionByID(ctx context.Context, id model.DocumentVersionID) (model.DocumentVersion, error) {
+	version, exists := s.versions[id]
+	if !exists {
+		return nil, errors.WithStack(port.ErrNotFound)
+	}
+
+	return version, nil
+}
+
+func TestDocumentVersionDiff(t *testing.T) {
+	ctx := context.Background()
+
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	store := &versionStore{
+		documents: map[model.DocumentID]model.PersistedDocument{
+			"doc": &versionedDocument{
+				stubPersistedDocument: stubPersistedDocument{id: "doc"},
+				content:               "# Policy\n\nPasswords must have 12 characters.\n\nThey expire every year.\n",
+				updatedAt:             date.Add(24 * time.Hour),
+			},
+			"other": &versionedDocument{
+				stubPersistedDocument: stubPersistedDocument{id: "other"},
+			},
+		},
+		versions: map[model.DocumentVersionID]model.DocumentVersion{
+			"v1": &stubDocumentVersion{
+				id:         "v1",
+				documentID: "doc",
+				content:    "# Policy\n\nPasswords must have 8 characters.\n\nThey expire every year.\n",
+				createdAt:  date,
+			},
+		},
+	}
+
+	manager := NewDocumentManager(store, nil, nil, nil)
+
+	diff, err := manager.DocumentVersionDiff(ctx, "doc", "v1")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	expected := "--- test://doc\t2026-01-02T03:04:05Z\n" +
+		"+++ test://doc\t2026-01-03T03:04:05Z\n" +
+		"@@ -1,5 +1,5 @@\n" +
+		" # Policy\n" +
+		" \n" +
+		"-Passwords must have 8 characters.\n" +
+		"+Passwords must have 12 characters.\n" +
+		" \n" +
+		" They expire every year.\n"
+
+	if e, g := expected, diff; e != g {
+		t.Errorf("diff: expected\n%s\ngot\n%s", e, g)
+	}
+
+	if _, err := manager.DocumentVersionDiff(ctx, "other", "v1"); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("expected port.ErrNotFound for a version of another document, got %v", err)
+	}
+}
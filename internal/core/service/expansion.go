@@ -0,0 +1,290 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/pkg/errors"
+)
+
+// ContextExpansion selects the surroundings of the retrieved sections added to
+// the context given to the LLM when answering.
+type ContextExpansion string
+
+const (
+	// ContextExpansionNone gives the retrieved sections only
+	ContextExpansionNone ContextExpansion = "none"
+	// ContextExpansionParent replaces each retrieved section by its parent
+	// section
+	ContextExpansionParent ContextExpansion = "parent"
+	// ContextExpansionSiblings adds the N sibling sections preceding and
+	// following each retrieved section
+	ContextExpansionSiblings ContextExpansion = "siblings"
+	// ContextExpansionDocument replaces each retrieved section by its whole
+	// document
+	ContextExpansionDocument ContextExpansion = "document"
+)
+
+const DefaultMaxTotalWords = 50000
+
+// Valid returns true if the expansion is known
+func (e ContextExpansion) Valid() bool {
+	switch e {
+	case ContextExpansionNone, ContextExpansionParent, ContextExpansionSiblings, ContextExpansionDocument:
+		return true
+	default:
+		return false
+	}
+}
+
+// contextWindow gathers the ranges of the documents given to the LLM, merging
+// the overlapping ones and bounding their total number of words. Expanded
+// ranges are only added while they fit, the retrieved sections always are.
+type contextWindow struct {
+	maxWords   int
+	totalWords int
+	documents  []*windowDocument
+}
+
+type windowDocument struct {
+	id       model.DocumentID
+	source   string
+	document model.Document
+	ranges   []model.Range
+	words    int
+	// sections are the retrieved sections of the document added to the
+	// window
+	sections []windowSection
+}
+
+type windowSection struct {
+	id    model.SectionID
+	start int
+	end   int
+}
+
+// add adds the given range of the section document to the window, the range
+// covering the retrieved section. The range is not added if it would exceed
+// the maximum number of words, unless forced.
+func (w *contextWindow) add(source string, section model.Section, r model.Range, force bool) (bool, error) {
+	document := section.Document()
+
+	idx := slices.IndexFunc(w.documents, func(d *windowDocument) bool {
+		return d.id == document.ID()
+	})
+
+	if idx == -1 {
+		w.documents = append(w.documents, &windowDocument{
+			id:       document.ID(),
+			source:   source,
+			document: document,
+			ranges:   []model.Range{},
+			sections: []windowSection{},
+		})
+		idx = len(w.documents) - 1
+	}
+
+	doc := w.documents[idx]
+
+	ranges := mergeRanges(append(slices.Clone(doc.ranges), r))
+
+	words := 0
+	for _, r := range ranges {
+		chunk, err := doc.document.Chunk(r.Start, r.End)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+
+		words += len(text.SplitByWords(string(chunk)))
+	}
+
+	if !force && w.totalWords-doc.words+words > w.maxWords {
+		return false, nil
+	}
+
+	w.totalWords += words - doc.words
+	doc.ranges = ranges
+	doc.words = words
+
+	if !slices.ContainsFunc(doc.sections, func(s windowSection) bool { return s.id == section.ID() }) {
+		doc.sections = append(doc.sections, windowSection{
+			id:    section.ID(),
+			start: section.Start(),
+			end:   section.End(),
+		})
+	}
+
+	return true, nil
+}
+
+// sections returns the content of the window, the ranges of each document
+// being ordered by offset
+func (w *contextWindow) sections() ([]contextSection, error) {
+	sections := make([]contextSection, 0)
+
+	for _, d := range w.documents {
+		slices.SortFunc(d.sections, func(s1, s2 windowSection) int {
+			return cmp.Compare(s1.start, s2.start)
+		})
+
+		for _, r := range d.ranges {
+			chunk, err := d.document.Chunk(r.Start, r.End)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			content := strings.TrimSpace(string(chunk))
+			if content == "" {
+				continue
+			}
+
+			sectionIDs := make([]model.SectionID, 0)
+			for _, s := range d.sections {
+				if s.start >= r.Start && s.end <= r.End {
+					sectionIDs = append(sectionIDs, s.id)
+				}
+			}
+
+			sections = append(sections, contextSection{
+				Source:     d.source,
+				DocumentID: d.id,
+				SectionIDs: sectionIDs,
+				Content:    content,
+			})
+		}
+	}
+
+	return sections, nil
+}
+
+func newContextWindow(maxWords int) *contextWindow {
+	if maxWords <= 0 {
+		maxWords = DefaultMaxTotalWords
+	}
+
+	return &contextWindow{
+		maxWords:  maxWords,
+		documents: make([]*windowDocument, 0),
+	}
+}
+
+// mergeRanges sorts the given ranges and merges the overlapping or adjacent
+// ones
+func mergeRanges(ranges []model.Range) []model.Range {
+	slices.SortFunc(ranges, func(a, b model.Range) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	merged := make([]model.Range, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Empty() {
+			continue
+		}
+
+		if last := len(merged) - 1; last >= 0 && r.Start <= merged[last].End {
+			merged[last].End = max(merged[last].End, r.End)
+			continue
+		}
+
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// expandSection returns the range of the document covering the given section
+// and its surroundings, according to the expansion
+func (m *DocumentManager) expandSection(ctx context.Context, section model.Section, expansion ContextExpansion, siblings int) (model.Range, error) {
+	sectionRange := model.Range{Start: section.Start(), End: section.End()}
+
+	switch expansion {
+	case ContextExpansionParent:
+		parent := section.Parent()
+		if parent == nil {
+			return sectionRange, nil
+		}
+
+		return model.Range{Start: parent.Start(), End: parent.End()}, nil
+
+	case ContextExpansionSiblings:
+		sections, err := m.getSiblingSections(ctx, section)
+		if err != nil {
+			return model.Range{}, errors.WithStack(err)
+		}
+
+		idx := slices.IndexFunc(sections, func(s model.Section) bool {
+			return s.ID() == section.ID()
+		})
+		if idx == -1 {
+			return sectionRange, nil
+		}
+
+		first := sections[max(idx-siblings, 0)]
+		last := sections[min(idx+siblings, len(sections)-1)]
+
+		return model.Range{
+			Start: min(first.Start(), section.Start()),
+			End:   max(last.End(), section.End()),
+		}, nil
+
+	case ContextExpansionDocument:
+		content, err := section.Document().Content()
+		if err != nil {
+			return model.Range{}, errors.WithStack(err)
+		}
+
+		return model.Range{Start: 0, End: len(content)}, nil
+
+	default:
+		return sectionRange, nil
+	}
+}
+
+// getSiblingSections returns the sections sharing the parent of the given
+// section, itself included, ordered by offset
+func (m *DocumentManager) getSiblingSections(ctx context.Context, section model.Section) ([]model.Section, error) {
+	contains := func(sections []model.Section) bool {
+		return slices.ContainsFunc(sections, func(s model.Section) bool {
+			return s.ID() == section.ID()
+		})
+	}
+
+	var sections []model.Section
+
+	if parent := section.Parent(); parent != nil {
+		sections = parent.Sections()
+
+		// The stores may not load the children of the parent section with it
+		if !contains(sections) {
+			reloaded, err := m.GetSectionByID(ctx, parent.ID())
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			sections = reloaded.Sections()
+		}
+	} else {
+		document := section.Document()
+		sections = document.Sections()
+
+		if !contains(sections) {
+			reloaded, err := m.GetDocumentByID(ctx, document.ID())
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			sections = reloaded.Sections()
+		}
+	}
+
+	sections = slices.Clone(sections)
+	slices.SortFunc(sections, func(a, b model.Section) int {
+		return cmp.Compare(a.Start(), b.Start())
+	})
+
+	return sections, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// promptRecorderLLM records the system prompt of each chat completion
+type promptRecorderLLM struct {
+	llm.Client
+	prompts []string
+}
+
+func (m *promptRecorderLLM) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	opts := llm.NewChatCompletionOptions(funcs...)
+	for _, message := range opts.Messages {
+		if message.Role() == llm.RoleSystem {
+			m.prompts = append(m.prompts, message.Content())
+		}
+	}
+
+	return llm.NewChatCompletionResponse(
+		llm.NewMessage(llm.RoleAssistant, "answer"),
+		llm.NewChatCompletionUsage(0, 0, 0),
+	), nil
+}
+
+func TestContextExpansion(t *testing.T) {
+	data := strings.Join([]string{
+		"---",
+		"source: https://example.net/glossary",
+		"---",
+		"",
+		"# Glossary",
+		"",
+		"## Alpha",
+		"",
+		"Alpha is the first letter.",
+		"",
+		"## Beta",
+		"",
+		"Beta is the second letter.",
+		"",
+		"## Gamma",
+		"",
+		"Gamma is the third letter.",
+		"",
+		"## Delta",
+		"",
+		"Delta is the fourth letter.",
+		"",
+	}, "\n")
+
+	document, err := markdown.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	store := &stubStore{sections: map[model.SectionID]model.Section{}}
+
+	var beta, gamma model.SectionID
+	model.WalkSections(document, func(s model.Section) error {
+		store.sections[s.ID()] = s
+
+		content, _ := s.Content()
+		switch {
+		case strings.HasPrefix(string(content), "## Beta"):
+			beta = s.ID()
+		case strings.HasPrefix(string(content), "## Gamma"):
+			gamma = s.ID()
+		}
+
+		return nil
+	})
+
+	results := []*port.IndexSearchResult{
+		{Source: &url.URL{Scheme: "https", Host: "example.net", Path: "/glossary"}, Sections: []model.SectionID{beta, gamma}},
+	}
+
+	type testCase struct {
+		Name             string
+		Expansion        ContextExpansion
+		Siblings         int
+		MaxTotalWords    int
+		ExpectedIncluded []string
+		ExpectedExcluded []string
+		// ExpectedCount is the number of occurrences of "Beta is" in the prompt
+		ExpectedCount int
+	}
+
+	testCases := []testCase{
+		{
+			Name:             "None",
+			Expansion:        ContextExpansionNone,
+			ExpectedIncluded: []string{"Beta is", "Gamma is", "### [2] https://example.net/glossary"},
+			ExpectedExcluded: []string{"Alpha is", "Delta is"},
+			ExpectedCount:    1,
+		},
+		{
+			Name:             "Siblings",
+			Expansion:        ContextExpansionSiblings,
+			Siblings:         1,
+			ExpectedIncluded: []string{"Alpha is", "Beta is", "Gamma is", "Delta is"},
+			ExpectedCount:    1,
+		},
+		{
+			Name:             "Parent",
+			Expansion:        ContextExpansionParent,
+			ExpectedIncluded: []string{"# Glossary", "Alpha is", "Delta is"},
+			ExpectedCount:    1,
+		},
+		{
+			Name:             "Document",
+			Expansion:        ContextExpansionDocument,
+			ExpectedIncluded: []string{"# Glossary", "Alpha is", "Delta is"},
+			ExpectedCount:    1,
+		},
+		{
+			Name:             "ExceedingBudget",
+			Expansion:        ContextExpansionDocument,
+			MaxTotalWords:    10,
+			ExpectedIncluded: []string{"Beta is", "Gamma is"},
+			ExpectedExcluded: []string{"Alpha is", "Delta is"},
+			ExpectedCount:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			recorder := &promptRecorderLLM{}
+
+			dm := NewDocumentManager(store, &stubIndex{}, nil, recorder, WithMaxTotalWords(tc.MaxTotalWords))
+
+			if _, _, err := dm.Ask(context.Background(), "q", results, WithAskContextExpansion(tc.Expansion, tc.Siblings)); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if e, g := 1, len(recorder.prompts); e != g {
+				t.Fatalf("len(recorder.prompts): expected %d, got %d", e, g)
+			}
+
+			prompt := recorder.prompts[0]
+
+			for _, s := range tc.ExpectedIncluded {
+				if !strings.Contains(prompt, s) {
+					t.Errorf("expected prompt to contain '%s', got '%s'", s, prompt)
+				}
+			}
+
+			for _, s := range tc.ExpectedExcluded {
+				if strings.Contains(prompt, s) {
+					t.Errorf("expected prompt not to contain '%s', got '%s'", s, prompt)
+				}
+			}
+
+			// Overlapping expansions are deduplicated
+			if e, g := tc.ExpectedCount, strings.Count(prompt, "Beta is"); e != g {
+				t.Errorf("strings.Count(prompt, 'Beta is'): expected %d, got %d", e, g)
+			}
+		})
+	}
+}
@@ -37,6 +37,15 @@ type stubSection struct {
 
 func (s *stubSection) ID() model.SectionID      { return s.id }
 func (s *stubSection) Content() ([]byte, error) { return []byte(s.content), nil }
+func (s *stubSection) Document() model.Document { return &stubDocument{id: "doc"} }
+
+// stubDocument implements model.Document by embedding the interface.
+type stubDocument struct {
+	model.Document
+	id model.DocumentID
+}
+
+func (d *stubDocument) ID() model.DocumentID { return d.id }
 
 // stubStore implements port.DocumentStore by embedding the interface; only the
 // two section accessors used by the grounding checker / generateResponse are
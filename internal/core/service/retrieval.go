@@ -36,14 +36,19 @@ func WithIterativeMaxRounds(rounds int) DocumentManagerOptionFunc {
 
 // AskResult is the outcome of AskWithRetrieval: the generated (or abstention)
 // answer, the section contents used, the final fused result set (for source
-// display), the grounding verdict (nil when the checker is disabled) and the
-// number of extra re-retrieval rounds performed.
+// display), the grounding verdict (nil when the checker is disabled), whether
+// the answer is an abstention, the number of extra re-retrieval rounds
+// performed and the citations of the answer, the invalid ones having been
+// stripped from it.
 type AskResult struct {
-	Answer    string
-	Contents  map[model.SectionID]string
-	Results   []*port.IndexSearchResult
-	Grounding *GroundingResult
-	Rounds    int
+	Answer           string
+	Contents         map[model.SectionID]string
+	Results          []*port.IndexSearchResult
+	Grounding        *GroundingResult
+	Abstained        bool
+	Rounds           int
+	Citations        []Citation
+	InvalidCitations []int
 }
 
 // abstentionAnswer builds the user-facing message returned when the grounding
@@ -72,15 +77,13 @@ func abstentionAnswer(grounding *GroundingResult) string {
 func (m *DocumentManager) AskWithRetrieval(ctx context.Context, query string, collections []model.CollectionID, funcs ...DocumentManagerAskOptionFunc) (*AskResult, error) {
 	askOpts := NewDocumentManagerAskOptions(funcs...)
 
-	systemPromptTemplate := askOpts.SystemPromptTemplate
-	if systemPromptTemplate == "" {
-		systemPromptTemplate = defaultSystemPromptTemplate
-	}
-
-	searchFuncs := make([]DocumentManagerSearchOptionFunc, 0, 1)
+	searchFuncs := make([]DocumentManagerSearchOptionFunc, 0, 2)
 	if len(collections) > 0 {
 		searchFuncs = append(searchFuncs, WithDocumentManagerSearchCollections(collections...))
 	}
+	if !askOpts.Filter.IsZero() {
+		searchFuncs = append(searchFuncs, WithDocumentManagerSearchFilter(askOpts.Filter))
+	}
 
 	// Round 0: (optionally decomposed) retrieval.
 	results, err := m.retrieve(ctx, query, searchFuncs)
@@ -136,6 +139,10 @@ func (m *DocumentManager) AskWithRetrieval(ctx context.Context, query string, co
 	result.Rounds = rounds
 	result.Results = results
 
+	if err := askOpts.emit(ctx, AskEvent{Type: AskEventResults, Results: results}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	// No evidence at all: leave it to the caller (no-results handling).
 	if len(results) == 0 {
 		return result, nil
@@ -150,19 +157,17 @@ func (m *DocumentManager) AskWithRetrieval(ctx context.Context, query string, co
 			slog.Int("rounds", rounds),
 		)
 
-		result.Answer = abstentionAnswer(grounding)
-		result.Contents = map[model.SectionID]string{}
+		if err := m.abstain(ctx, askOpts, result); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
 		return result, nil
 	}
 
-	answer, contents, err := m.generateResponse(ctx, systemPromptTemplate, query, results)
-	if err != nil {
+	if err := m.generateResponse(ctx, askOpts, query, results, result); err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	result.Answer = answer
-	result.Contents = contents
-
 	return result, nil
 }
 
@@ -228,8 +233,11 @@ func fuseResults(groups ...[]*port.IndexSearchResult) []*port.IndexSearchResult
 			}
 
 			out = append(out, &port.IndexSearchResult{
-				Source:   r.Source,
-				Sections: kept,
+				Source:     r.Source,
+				Sections:   kept,
+				Scores:     r.Scores,
+				Ranks:      r.Ranks,
+				Highlights: r.Highlights,
 			})
 		}
 	}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// discardTrashedResults removes from the results the sections of the
+// documents moved to the trash, which are kept in the index until the trash
+// is purged but are not returned by the store anymore.
+func (m *DocumentManager) discardTrashedResults(ctx context.Context, results []*port.IndexSearchResult) ([]*port.IndexSearchResult, error) {
+	sectionIDs := make([]model.SectionID, 0)
+	for _, r := range results {
+		sectionIDs = append(sectionIDs, r.Sections...)
+	}
+
+	if len(sectionIDs) == 0 {
+		return results, nil
+	}
+
+	sections, err := m.DocumentStore.GetSectionsByIDs(ctx, sectionIDs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	filtered := make([]*port.IndexSearchResult, 0, len(results))
+	for _, r := range results {
+		kept := make([]model.SectionID, 0, len(r.Sections))
+		for _, id := range r.Sections {
+			if _, exists := sections[id]; exists {
+				kept = append(kept, id)
+			}
+		}
+
+		if len(kept) == 0 {
+			continue
+		}
+
+		r.Sections = kept
+		filtered = append(filtered, r)
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestSearchDiscardsTrashedDocuments(t *testing.T) {
+	ctx := context.Background()
+
+	// The sections of the trashed documents are still in the index but are
+	// not returned by the store anymore
+	store := &stubStore{
+		sections: map[model.SectionID]model.Section{
+			"kept-1": &stubSection{id: "kept-1"},
+			"kept-2": &stubSection{id: "kept-2"},
+		},
+	}
+
+	index := &stubIndex{
+		byQuery: map[string][]*port.IndexSearchResult{
+			"query": {
+				{Source: &url.URL{Scheme: "test", Host: "kept"}, Sections: []model.SectionID{"kept-1", "kept-2"}},
+				{Source: &url.URL{Scheme: "test", Host: "trashed"}, Sections: []model.SectionID{"trashed-1"}},
+				{Source: &url.URL{Scheme: "test", Host: "partial"}, Sections: []model.SectionID{"trashed-2", "kept-1"}},
+			},
+		},
+	}
+
+	manager := NewDocumentManager(store, index, nil, nil)
+
+	results, err := manager.Search(ctx, "query")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 2, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	if e, g := "test://kept", results[0].Source.String(); e != g {
+		t.Errorf("results[0].Source: expected '%s', got '%s'", e, g)
+	}
+
+	if e, g := []model.SectionID{"kept-1"}, results[1].Sections; !slices.Equal(e, g) {
+		t.Errorf("results[1].Sections: expected %v, got %v", e, g)
+	}
+}
@@ -9,17 +9,52 @@ import (
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/internal/core/service"
 	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	"github.com/bornholm/corpus/internal/http/sse"
 	corpusLLM "github.com/bornholm/corpus/internal/llm"
 	"github.com/bornholm/go-x/slogx"
 	"github.com/pkg/errors"
 )
 
 type AskResponse struct {
-	Response  string                     `json:"response"`
-	Contents  map[model.SectionID]string `json:"contents"`
-	Grounding *service.GroundingResult   `json:"grounding,omitempty"`
+	Response         string                     `json:"response"`
+	Contents         map[model.SectionID]string `json:"contents"`
+	Grounding        *service.GroundingResult   `json:"grounding,omitempty"`
+	Abstained        bool                       `json:"abstained,omitempty"`
+	Citations        []service.Citation         `json:"citations"`
+	InvalidCitations []int                      `json:"invalid_citations,omitempty"`
 }
 
+// AskResultsEvent is the first event of a streamed answer, listing the
+// retrieved sections
+type AskResultsEvent struct {
+	Results []*AskResultsEventSource `json:"results"`
+}
+
+type AskResultsEventSource struct {
+	Source   string            `json:"source"`
+	Sections []model.SectionID `json:"sections"`
+}
+
+// AskDeltaEvent carries the next fragment of a streamed answer
+type AskDeltaEvent struct {
+	Delta string `json:"delta"`
+}
+
+// AskErrorEvent ends a streamed answer which could not be completed
+type AskErrorEvent struct {
+	Message string `json:"message"`
+}
+
+// AskNoResultsEvent ends a streamed answer for which no result was found in
+// the documents
+type AskNoResultsEvent struct {
+	Message string `json:"message"`
+}
+
+// askEventNoResults is the event ending a streamed answer without result,
+// the counterpart of the 204 status of the non streamed answer
+const askEventNoResults = "no_results"
+
 func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	query := r.URL.Query().Get("query")
@@ -42,7 +77,54 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.doAsk(ctx, query, collections)
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", askModeRetrieval:
+	case askModeAgent:
+		h.handleAskAgent(w, r, query, collections)
+		return
+	default:
+		http.Error(w, "mode parameter must be one of 'retrieval' or 'agent'", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := getSearchFilterFromRequest(r)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not parse search filter", slogx.Error(err))
+		var httpErr common.HTTPError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.Error(), httpErr.StatusCode())
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	askOptions := []service.DocumentManagerAskOptionFunc{service.WithAskFilter(filter)}
+
+	expansion, err := getContextExpansionFromRequest(r)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not parse context expansion", slogx.Error(err))
+		var httpErr common.HTTPError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.Error(), httpErr.StatusCode())
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if expansion != nil {
+		askOptions = append(askOptions, expansion)
+	}
+
+	if sse.Accepted(r) {
+		h.streamAsk(w, r, query, collections, askOptions...)
+		return
+	}
+
+	res, err := h.doAsk(ctx, query, collections, askOptions...)
 	if err != nil {
 		var httpErr common.HTTPError
 		if errors.As(err, &httpErr) {
@@ -70,12 +152,12 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) doAsk(ctx context.Context, query string, collections []model.CollectionID) (*AskResponse, error) {
+func (h *Handler) doAsk(ctx context.Context, query string, collections []model.CollectionID, funcs ...service.DocumentManagerAskOptionFunc) (*AskResponse, error) {
 	slog.DebugContext(ctx, "executing ask query", slog.String("query", query), slog.Any("collections", collections))
 
 	ctx = corpusLLM.WithHighPriority(ctx)
 
-	result, err := h.documentManager.AskWithRetrieval(ctx, query, collections)
+	result, err := h.documentManager.AskWithRetrieval(ctx, query, collections, funcs...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -85,10 +167,95 @@ func (h *Handler) doAsk(ctx context.Context, query string, collections []model.C
 	}
 
 	res := &AskResponse{
-		Response:  result.Answer,
-		Contents:  result.Contents,
-		Grounding: result.Grounding,
+		Response:         result.Answer,
+		Contents:         result.Contents,
+		Grounding:        result.Grounding,
+		Abstained:        result.Abstained,
+		Citations:        result.Citations,
+		InvalidCitations: result.InvalidCitations,
 	}
 
 	return res, nil
 }
+
+// streamAsk sends the answer as server-sent events: "results" once the
+// retrieval is done, "delta" for each fragment of the answer and finally
+// "done" with the complete response, "no_results" if nothing was retrieved
+// or "error"
+func (h *Handler) streamAsk(w http.ResponseWriter, r *http.Request, query string, collections []model.CollectionID, funcs ...service.DocumentManagerAskOptionFunc) {
+	ctx := r.Context()
+
+	slog.DebugContext(ctx, "executing streamed ask query", slog.String("query", query), slog.Any("collections", collections))
+
+	ctx = corpusLLM.WithHighPriority(ctx)
+
+	events := sse.NewWriter(w)
+
+	forward := newAskStreamFunc(events)
+
+	stream := func(ctx context.Context, event service.AskEvent) error {
+		if event.Type == service.AskEventDone && len(event.Result.Results) == 0 {
+			return events.Send(askEventNoResults, &AskNoResultsEvent{
+				Message: "no matching results in document collection",
+			})
+		}
+
+		return forward(ctx, event)
+	}
+
+	if _, err := h.documentManager.AskWithRetrievalStream(ctx, query, collections, stream, funcs...); err != nil {
+		sendAskStreamError(ctx, events, err)
+	}
+}
+
+// newAskStreamFunc returns a function forwarding the events of a streamed
+// answer to the client
+func newAskStreamFunc(events *sse.Writer) service.AskStreamFunc {
+	return func(ctx context.Context, event service.AskEvent) error {
+		switch event.Type {
+		case service.AskEventResults:
+			payload := &AskResultsEvent{
+				Results: make([]*AskResultsEventSource, 0, len(event.Results)),
+			}
+
+			for _, r := range event.Results {
+				payload.Results = append(payload.Results, &AskResultsEventSource{
+					Source:   r.Source.String(),
+					Sections: r.Sections,
+				})
+			}
+
+			return events.Send(string(event.Type), payload)
+
+		case service.AskEventDelta:
+			return events.Send(string(event.Type), &AskDeltaEvent{Delta: event.Delta})
+
+		case service.AskEventDone:
+			return events.Send(string(event.Type), &AskResponse{
+				Response:         event.Result.Answer,
+				Contents:         event.Result.Contents,
+				Grounding:        event.Result.Grounding,
+				Abstained:        event.Result.Abstained,
+				Citations:        event.Result.Citations,
+				InvalidCitations: event.Result.InvalidCitations,
+			})
+
+		default:
+			return nil
+		}
+	}
+}
+
+// sendAskStreamError ends a streamed answer which could not be completed
+func sendAskStreamError(ctx context.Context, events *sse.Writer, err error) {
+	slog.ErrorContext(ctx, "could not stream answer", slogx.Error(err))
+
+	message := http.StatusText(http.StatusInternalServerError)
+	if corpusLLM.IsRateLimit(err) {
+		message = http.StatusText(http.StatusServiceUnavailable)
+	}
+
+	if err := events.Send("error", &AskErrorEvent{Message: message}); err != nil {
+		slog.ErrorContext(ctx, "could not send error event", slogx.Error(err))
+	}
+}
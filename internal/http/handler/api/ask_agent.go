@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bornholm/corpus/internal/core/service"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	corpusLLM "github.com/bornholm/corpus/internal/llm"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+const (
+	askModeRetrieval = "retrieval"
+	askModeAgent     = "agent"
+)
+
+type AskAgentResponse struct {
+	Response         string                     `json:"response"`
+	Contents         map[model.SectionID]string `json:"contents"`
+	Citations        []service.Citation         `json:"citations"`
+	InvalidCitations []int                      `json:"invalid_citations,omitempty"`
+	Steps            []service.AgentStep        `json:"steps"`
+	Tokens           int64                      `json:"tokens"`
+	Exhausted        bool                       `json:"exhausted,omitempty"`
+}
+
+// handleAskAgent answers the query with the agent, which explores the
+// collections readable by the user with tools. The answer is not streamed.
+func (h *Handler) handleAskAgent(w http.ResponseWriter, r *http.Request, query string, collections []model.CollectionID) {
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+
+	slog.DebugContext(ctx, "executing agent ask query", slog.String("query", query), slog.Any("collections", collections))
+
+	ctx = corpusLLM.WithHighPriority(ctx)
+
+	agentOptions := []service.AgentOptionFunc{service.WithAgentUser(user)}
+
+	if maxSteps := getQueryInt(r.URL.Query(), "max_steps", 0); maxSteps > 0 {
+		agentOptions = append(agentOptions, service.WithAgentMaxSteps(maxSteps))
+	}
+
+	if maxTokens := getQueryInt(r.URL.Query(), "max_tokens", 0); maxTokens > 0 {
+		agentOptions = append(agentOptions, service.WithAgentMaxTokens(maxTokens))
+	}
+
+	result, err := h.documentManager.AskAgent(ctx, query, collections, agentOptions...)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoReadableCollection):
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		case corpusLLM.IsRateLimit(err):
+			common.HandleError(w, r, common.NewHTTPError(http.StatusServiceUnavailable))
+		default:
+			slog.ErrorContext(ctx, "could not ask agent", slogx.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	res := &AskAgentResponse{
+		Response:         result.Answer,
+		Contents:         result.Contents,
+		Citations:        result.Citations,
+		InvalidCitations: result.InvalidCitations,
+		Steps:            result.Steps,
+		Tokens:           result.Tokens,
+		Exhausted:        result.Exhausted,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := encoder.Encode(res); err != nil {
+		slog.ErrorContext(ctx, "could not encode response", slogx.Error(err))
+	}
+}
@@ -17,19 +17,44 @@ import (
 type CollectionShareResponse struct {
 	ID           string `json:"id"`
 	CollectionID string `json:"collectionId"`
-	UserID       string `json:"userId"`
-	UserEmail    string `json:"userEmail"`
-	UserName     string `json:"userName"`
+	UserID       string `json:"userId,omitempty"`
+	UserEmail    string `json:"userEmail,omitempty"`
+	UserName     string `json:"userName,omitempty"`
+	GroupID      string `json:"groupId,omitempty"`
+	GroupName    string `json:"groupName,omitempty"`
 	Level        string `json:"level"`
 }
 
+func toCollectionShareResponse(share model.CollectionShare) CollectionShareResponse {
+	res := CollectionShareResponse{
+		ID:           string(share.ID()),
+		CollectionID: string(share.CollectionID()),
+		Level:        string(share.Level()),
+	}
+
+	if user := share.SharedWith(); user != nil {
+		res.UserID = string(user.ID())
+		res.UserEmail = user.Email()
+		res.UserName = user.DisplayName()
+	}
+
+	if group := share.SharedWithGroup(); group != nil {
+		res.GroupID = string(group.ID())
+		res.GroupName = group.Name()
+	}
+
+	return res
+}
+
 type ListCollectionSharesResponse struct {
 	Shares []CollectionShareResponse `json:"shares"`
 }
 
+// CreateCollectionShareRequest targets either a user or a group
 type CreateCollectionShareRequest struct {
-	UserID string `json:"userId"`
-	Level  string `json:"level"`
+	UserID  string `json:"userId,omitempty"`
+	GroupID string `json:"groupId,omitempty"`
+	Level   string `json:"level"`
 }
 
 type ListCollectionsResponse struct {
@@ -100,7 +125,9 @@ type GetCollectionResponse struct {
 
 type Collection struct {
 	CollectionHeader
-	Stats *CollectionStats `json:"stats,omitempty"`
+	// Number of previous versions kept for each document of the collection
+	VersionRetention int              `json:"versionRetention"`
+	Stats            *CollectionStats `json:"stats,omitempty"`
 }
 
 func (h *Handler) handleGetCollection(w http.ResponseWriter, r *http.Request) {
@@ -134,6 +161,7 @@ func (h *Handler) handleGetCollection(w http.ResponseWriter, r *http.Request) {
 				Label:       collection.Label(),
 				Description: collection.Description(),
 			},
+			VersionRetention: model.CollectionVersionRetention(collection),
 			Stats: &CollectionStats{
 				TotalDocuments: stats.TotalDocuments,
 			},
@@ -151,8 +179,9 @@ func (h *Handler) handleGetCollection(w http.ResponseWriter, r *http.Request) {
 }
 
 type UpdateCollectionRequest struct {
-	Label       *string `json:"label,omitempty"`
-	Description *string `json:"description,omitempty"`
+	Label            *string `json:"label,omitempty"`
+	Description      *string `json:"description,omitempty"`
+	VersionRetention *int    `json:"versionRetention,omitempty"`
 }
 
 func (h *Handler) handleUpdateCollection(w http.ResponseWriter, r *http.Request) {
@@ -167,9 +196,15 @@ func (h *Handler) handleUpdateCollection(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.VersionRetention != nil && *req.VersionRetention < 0 {
+		http.Error(w, "versionRetention must be positive or zero", http.StatusBadRequest)
+		return
+	}
+
 	updates := port.CollectionUpdates{
-		Label:       req.Label,
-		Description: req.Description,
+		Label:            req.Label,
+		Description:      req.Description,
+		VersionRetention: req.VersionRetention,
 	}
 
 	collection, err := h.documentManager.DocumentStore.UpdateCollection(ctx, collectionID, updates)
@@ -191,6 +226,7 @@ func (h *Handler) handleUpdateCollection(w http.ResponseWriter, r *http.Request)
 				Label:       collection.Label(),
 				Description: collection.Description(),
 			},
+			VersionRetention: model.CollectionVersionRetention(collection),
 		},
 	}
 
@@ -237,14 +273,7 @@ func (h *Handler) handleListCollectionShares(w http.ResponseWriter, r *http.Requ
 		Shares: make([]CollectionShareResponse, 0, len(shares)),
 	}
 	for _, s := range shares {
-		res.Shares = append(res.Shares, CollectionShareResponse{
-			ID:           string(s.ID()),
-			CollectionID: string(s.CollectionID()),
-			UserID:       string(s.SharedWith().ID()),
-			UserEmail:    s.SharedWith().Email(),
-			UserName:     s.SharedWith().DisplayName(),
-			Level:        string(s.Level()),
-		})
+		res.Shares = append(res.Shares, toCollectionShareResponse(s))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -295,21 +324,29 @@ func (h *Handler) handleCreateCollectionShare(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	share, err := h.documentManager.DocumentStore.CreateCollectionShare(ctx, collectionID, model.UserID(req.UserID), level)
+	if (req.UserID == "") == (req.GroupID == "") {
+		http.Error(w, "exactly one of 'userId' or 'groupId' is required", http.StatusBadRequest)
+		return
+	}
+
+	var share model.PersistedCollectionShare
+	if req.GroupID != "" {
+		share, err = h.documentManager.DocumentStore.CreateCollectionGroupShare(ctx, collectionID, model.GroupID(req.GroupID), level)
+	} else {
+		share, err = h.documentManager.DocumentStore.CreateCollectionShare(ctx, collectionID, model.UserID(req.UserID), level)
+	}
 	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
 		slog.ErrorContext(ctx, "could not create collection share", slogx.Error(err))
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
-	res := CollectionShareResponse{
-		ID:           string(share.ID()),
-		CollectionID: string(share.CollectionID()),
-		UserID:       string(share.SharedWith().ID()),
-		UserEmail:    share.SharedWith().Email(),
-		UserName:     share.SharedWith().DisplayName(),
-		Level:        string(share.Level()),
-	}
+	res := toCollectionShareResponse(share)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -360,9 +397,10 @@ func (h *Handler) handleDeleteCollection(w http.ResponseWriter, r *http.Request)
 	collectionID := model.CollectionID(r.PathValue("collectionID"))
 
 	ctx := r.Context()
+	user := httpCtx.User(ctx)
 
 	// First check if collection exists
-	_, err := h.documentManager.DocumentStore.GetCollectionByID(ctx, collectionID, false)
+	collection, err := h.documentManager.DocumentStore.GetCollectionByID(ctx, collectionID, false)
 	if err != nil {
 		if errors.Is(err, port.ErrNotFound) {
 			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -374,8 +412,22 @@ func (h *Handler) handleDeleteCollection(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Note: The actual deletion logic would need to be implemented in the DocumentStore
-	// For now, we'll return a method not implemented error
-	slog.ErrorContext(ctx, "collection deletion not implemented")
-	http.Error(w, "Collection deletion not implemented", http.StatusNotImplemented)
+	// Only the owner can delete the collection
+	if collection.Owner().ID() != user.ID() {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if err := h.documentManager.DocumentStore.TrashCollection(ctx, collectionID); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not trash collection", slogx.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bornholm/corpus/internal/core/service"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/sse"
+	corpusLLM "github.com/bornholm/corpus/internal/llm"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+type ConversationResponse struct {
+	ID            string                      `json:"id"`
+	Title         string                      `json:"title"`
+	CollectionIDs []model.CollectionID        `json:"collection_ids"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	UpdatedAt     time.Time                   `json:"updated_at"`
+	Turns         []*ConversationTurnResponse `json:"turns,omitempty"`
+}
+
+type ConversationTurnResponse struct {
+	ID                 string            `json:"id"`
+	Question           string            `json:"question"`
+	StandaloneQuestion string            `json:"standalone_question"`
+	Answer             string            `json:"answer"`
+	SectionIDs         []model.SectionID `json:"section_ids"`
+	CreatedAt          time.Time         `json:"created_at"`
+}
+
+type ListConversationsResponse struct {
+	Conversations []*ConversationResponse `json:"conversations"`
+	Total         int64                   `json:"total"`
+	Page          int                     `json:"page"`
+	Limit         int                     `json:"limit"`
+}
+
+type CreateConversationRequest struct {
+	Title         string               `json:"title"`
+	CollectionIDs []model.CollectionID `json:"collection_ids,omitempty"`
+}
+
+type AskConversationRequest struct {
+	Question string `json:"question"`
+}
+
+type AskConversationResponse struct {
+	Turn             *ConversationTurnResponse  `json:"turn"`
+	Contents         map[model.SectionID]string `json:"contents"`
+	Grounding        *service.GroundingResult   `json:"grounding,omitempty"`
+	Citations        []service.Citation         `json:"citations"`
+	InvalidCitations []int                      `json:"invalid_citations,omitempty"`
+}
+
+func toConversationResponse(conversation model.PersistedConversation, turns []model.PersistedConversationTurn) *ConversationResponse {
+	resp := &ConversationResponse{
+		ID:            string(conversation.ID()),
+		Title:         conversation.Title(),
+		CollectionIDs: conversation.Collections(),
+		CreatedAt:     conversation.CreatedAt(),
+		UpdatedAt:     conversation.UpdatedAt(),
+	}
+
+	if resp.CollectionIDs == nil {
+		resp.CollectionIDs = []model.CollectionID{}
+	}
+
+	for _, t := range turns {
+		resp.Turns = append(resp.Turns, toConversationTurnResponse(t))
+	}
+
+	return resp
+}
+
+func toConversationTurnResponse(turn model.PersistedConversationTurn) *ConversationTurnResponse {
+	resp := &ConversationTurnResponse{
+		ID:                 string(turn.ID()),
+		Question:           turn.Question(),
+		StandaloneQuestion: turn.StandaloneQuestion(),
+		Answer:             turn.Answer(),
+		SectionIDs:         turn.Sections(),
+		CreatedAt:          turn.CreatedAt(),
+	}
+
+	if resp.SectionIDs == nil {
+		resp.SectionIDs = []model.SectionID{}
+	}
+
+	return resp
+}
+
+func (h *Handler) handleListConversations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+
+	page := getQueryPage(r.URL.Query(), 0)
+	limit := getQueryLimit(r.URL.Query(), 20)
+
+	conversations, total, err := h.conversationManager.QueryUserConversations(ctx, user.ID(), port.QueryConversationsOptions{
+		Page:  &page,
+		Limit: &limit,
+	})
+	if err != nil {
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	resp := ListConversationsResponse{
+		Conversations: make([]*ConversationResponse, 0, len(conversations)),
+		Total:         total,
+		Page:          page,
+		Limit:         limit,
+	}
+
+	for _, c := range conversations {
+		resp.Conversations = append(resp.Conversations, toConversationResponse(c, nil))
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *Handler) handleCreateConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+
+	var req CreateConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.WithStack(err), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.CollectionIDs) > 0 {
+		readableCollections, _, err := h.documentManager.DocumentStore.QueryUserReadableCollections(ctx, user.ID(), port.QueryCollectionsOptions{})
+		if err != nil {
+			writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+			return
+		}
+
+		for _, id := range req.CollectionIDs {
+			isReadable := slices.ContainsFunc(readableCollections, func(c model.PersistedCollection) bool {
+				return id == c.ID()
+			})
+
+			if !isReadable {
+				writeError(w, errors.Errorf("collection '%s' is not readable", id), http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	conversation, err := h.conversationManager.SaveConversation(ctx, model.NewConversation(user, strings.TrimSpace(req.Title), req.CollectionIDs...))
+	if err != nil {
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, toConversationResponse(conversation, nil))
+}
+
+func (h *Handler) handleGetConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	conversation, ok := h.getUserConversation(w, r)
+	if !ok {
+		return
+	}
+
+	turns, err := h.conversationManager.GetConversationTurns(ctx, conversation.ID())
+	if err != nil {
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, toConversationResponse(conversation, turns))
+}
+
+func (h *Handler) handleDeleteConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	conversation, ok := h.getUserConversation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.conversationManager.DeleteConversation(ctx, conversation.ID()); err != nil {
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAskConversation answers the next question of the conversation, as
+// server-sent events when the client accepts them
+func (h *Handler) handleAskConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	conversation, ok := h.getUserConversation(w, r)
+	if !ok {
+		return
+	}
+
+	var req AskConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.WithStack(err), http.StatusBadRequest)
+		return
+	}
+
+	question := strings.TrimSpace(req.Question)
+	if question == "" {
+		writeError(w, errors.New("question is required"), http.StatusBadRequest)
+		return
+	}
+
+	slog.DebugContext(ctx, "executing conversation ask query", slog.String("question", question), slog.String("conversation", string(conversation.ID())))
+
+	ctx = corpusLLM.WithHighPriority(ctx)
+
+	if sse.Accepted(r) {
+		events := sse.NewWriter(w)
+
+		if _, err := h.conversationManager.AskStream(ctx, conversation, question, newAskStreamFunc(events)); err != nil {
+			sendAskStreamError(ctx, events, err)
+		}
+
+		return
+	}
+
+	result, err := h.conversationManager.Ask(ctx, conversation, question)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoReadableCollection):
+			writeError(w, errors.WithStack(err), http.StatusForbidden)
+		case corpusLLM.IsRateLimit(err):
+			writeError(w, errors.New(http.StatusText(http.StatusServiceUnavailable)), http.StatusServiceUnavailable)
+		default:
+			slog.ErrorContext(ctx, "could not ask conversation", slogx.Error(err))
+			writeError(w, errors.New(http.StatusText(http.StatusInternalServerError)), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, &AskConversationResponse{
+		Turn:             toConversationTurnResponse(result.Turn),
+		Contents:         result.Contents,
+		Grounding:        result.Grounding,
+		Citations:        result.Citations,
+		InvalidCitations: result.InvalidCitations,
+	})
+}
+
+// getUserConversation returns the conversation designated by the request if
+// it is owned by the current user, writing a not found error otherwise
+func (h *Handler) getUserConversation(w http.ResponseWriter, r *http.Request) (model.PersistedConversation, bool) {
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+
+	conversationID := model.ConversationID(r.PathValue("conversationID"))
+
+	conversation, err := h.conversationManager.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			writeError(w, errors.New("conversation not found"), http.StatusNotFound)
+			return nil, false
+		}
+
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if conversation.Owner().ID() != user.ID() {
+		writeError(w, errors.New("conversation not found"), http.StatusNotFound)
+		return nil, false
+	}
+
+	return conversation, true
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/bornholm/corpus/pkg/port"
 	"github.com/bornholm/corpus/internal/core/service"
 	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
 	"github.com/pkg/errors"
 )
 
@@ -24,9 +25,10 @@ type ListDocumentsResponse struct {
 }
 
 type DocumentHeader struct {
-	ID     string `json:"id"`
-	Source string `json:"source"`
-	ETag   string `json:"etag,omitempty"`
+	ID       string              `json:"id"`
+	Source   string              `json:"source"`
+	ETag     string              `json:"etag,omitempty"`
+	Metadata map[string][]string `json:"metadata,omitempty"`
 }
 
 type ListDocumentDigestsResponse struct {
@@ -101,6 +103,20 @@ func (h *Handler) handleListDocuments(w http.ResponseWriter, r *http.Request) {
 		opts.MatchingSource = source
 	}
 
+	metadata, err := getMetadataValues(query["metadata"], "metadata")
+	if err != nil {
+		var httpErr common.HTTPError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.Error(), httpErr.StatusCode())
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	opts.Metadata = lastMetadataValues(metadata)
+
 	user := httpCtx.User(ctx)
 
 	readableDocuments, total, err := h.documentManager.DocumentStore.QueryUserReadableDocuments(ctx, user.ID(), opts)
@@ -119,9 +135,10 @@ func (h *Handler) handleListDocuments(w http.ResponseWriter, r *http.Request) {
 
 	for _, d := range readableDocuments {
 		res.Documents = append(res.Documents, DocumentHeader{
-			ID:     string(d.ID()),
-			ETag:   d.ETag(),
-			Source: d.Source().String(),
+			ID:       string(d.ID()),
+			ETag:     d.ETag(),
+			Source:   d.Source().String(),
+			Metadata: model.DocumentMetadata(d),
 		})
 	}
 
@@ -165,8 +182,9 @@ func (h *Handler) handleGetDocument(w http.ResponseWriter, r *http.Request) {
 	res := GetDocumentResponse{
 		Document: Document{
 			DocumentHeader: DocumentHeader{
-				ID:     string(document.ID()),
-				Source: document.Source().String(),
+				ID:       string(document.ID()),
+				Source:   document.Source().String(),
+				Metadata: model.DocumentMetadata(document),
 			},
 			Collections: slices.Collect(func(yield func(string) bool) {
 				for _, c := range document.Collections() {
@@ -380,9 +398,14 @@ func (h *Handler) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	err := h.documentManager.DocumentStore.DeleteDocumentByID(ctx, documentID)
+	err := h.documentManager.DocumentStore.TrashDocumentByID(ctx, documentID)
 	if err != nil {
-		slog.ErrorContext(ctx, "could not delete document", slog.Any("error", errors.WithStack(err)))
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not trash document", slog.Any("error", errors.WithStack(err)))
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
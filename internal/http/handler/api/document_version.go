@@ -0,0 +1,166 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/pkg/errors"
+)
+
+type ListDocumentVersionsResponse struct {
+	Versions []DocumentVersion `json:"versions"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	Limit    int               `json:"limit"`
+}
+
+type DocumentVersion struct {
+	ID        string              `json:"id"`
+	ETag      string              `json:"etag,omitempty"`
+	Metadata  map[string][]string `json:"metadata,omitempty"`
+	TaskID    string              `json:"taskId,omitempty"`
+	CreatedAt time.Time           `json:"createdAt"`
+}
+
+func (h *Handler) handleListDocumentVersions(w http.ResponseWriter, r *http.Request) {
+	documentID := model.DocumentID(r.PathValue("documentID"))
+
+	query := r.URL.Query()
+	page := getQueryPage(query, 0)
+	limit := getQueryLimit(query, 10)
+
+	ctx := r.Context()
+
+	versions, total, err := h.documentManager.DocumentStore.QueryDocumentVersions(ctx, documentID, port.QueryDocumentVersionsOptions{
+		Page:  &page,
+		Limit: &limit,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not query document versions", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	res := ListDocumentVersionsResponse{
+		Versions: make([]DocumentVersion, 0, len(versions)),
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}
+
+	for _, v := range versions {
+		res.Versions = append(res.Versions, DocumentVersion{
+			ID:        string(v.ID()),
+			ETag:      v.ETag(),
+			Metadata:  v.Metadata(),
+			TaskID:    string(v.TaskID()),
+			CreatedAt: v.CreatedAt(),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := encoder.Encode(res); err != nil {
+		slog.ErrorContext(ctx, "could not encode response", slog.Any("error", errors.WithStack(err)))
+	}
+}
+
+func (h *Handler) handleGetDocumentVersionContent(w http.ResponseWriter, r *http.Request) {
+	documentID := model.DocumentID(r.PathValue("documentID"))
+	versionID := model.DocumentVersionID(r.PathValue("versionID"))
+
+	ctx := r.Context()
+
+	version, err := h.documentManager.DocumentStore.GetDocumentVersionByID(ctx, versionID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not get document version", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if version.DocumentID() != documentID {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	content, err := version.Content()
+	if err != nil {
+		slog.ErrorContext(ctx, "could not retrieve document version content", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown")
+
+	if _, err := io.Copy(w, bytes.NewBuffer(content)); err != nil {
+		slog.ErrorContext(ctx, "could not retrieve document version content", slog.Any("error", errors.WithStack(err)))
+		return
+	}
+}
+
+func (h *Handler) handleGetDocumentVersionDiff(w http.ResponseWriter, r *http.Request) {
+	documentID := model.DocumentID(r.PathValue("documentID"))
+	versionID := model.DocumentVersionID(r.PathValue("versionID"))
+
+	ctx := r.Context()
+
+	diff, err := h.documentManager.DocumentVersionDiff(ctx, documentID, versionID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not compute document version diff", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-diff")
+
+	if _, err := io.WriteString(w, diff); err != nil {
+		slog.ErrorContext(ctx, "could not write document version diff", slog.Any("error", errors.WithStack(err)))
+		return
+	}
+}
+
+func (h *Handler) handleRollbackDocument(w http.ResponseWriter, r *http.Request) {
+	documentID := model.DocumentID(r.PathValue("documentID"))
+	versionID := model.DocumentVersionID(r.PathValue("versionID"))
+
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+
+	taskID, err := h.documentManager.RollbackDocument(ctx, user, documentID, versionID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not rollback document", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	baseURL := httpCtx.BaseURL(ctx)
+
+	taskURL := baseURL.JoinPath("/api/v1/tasks", string(taskID))
+
+	http.Redirect(w, r, taskURL.String(), http.StatusSeeOther)
+}
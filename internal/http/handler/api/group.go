@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/middleware/authz"
+	"github.com/pkg/errors"
+)
+
+type GroupMember struct {
+	ID string `json:"id"`
+	// Email is only disclosed to the managers of the group
+	Email       string `json:"email,omitempty"`
+	DisplayName string `json:"displayName"`
+}
+
+type GroupResponse struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Provider    string        `json:"provider,omitempty"`
+	OwnerID     string        `json:"ownerId,omitempty"`
+	Members     []GroupMember `json:"members"`
+}
+
+type ListGroupsResponse struct {
+	Groups []GroupResponse `json:"groups"`
+	Total  int64           `json:"total"`
+	Page   int             `json:"page"`
+	Limit  int             `json:"limit"`
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UpdateGroupRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+type AddGroupMembersRequest struct {
+	UserIDs []string `json:"userIds"`
+}
+
+// toGroupResponse converts the group, disclosing the emails of its members
+// only if withEmails is true
+func toGroupResponse(group model.Group, withEmails bool) GroupResponse {
+	res := GroupResponse{
+		ID:          string(group.ID()),
+		Name:        group.Name(),
+		Description: group.Description(),
+		Provider:    group.Provider(),
+		Members:     make([]GroupMember, 0),
+	}
+
+	if owner := group.Owner(); owner != nil {
+		res.OwnerID = string(owner.ID())
+	}
+
+	for _, m := range group.Members() {
+		member := GroupMember{
+			ID:          string(m.ID()),
+			DisplayName: m.DisplayName(),
+		}
+
+		if withEmails {
+			member.Email = m.Email()
+		}
+
+		res.Members = append(res.Members, member)
+	}
+
+	return res
+}
+
+func (h *Handler) handleListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+	page := getQueryPage(r.URL.Query(), 0)
+	limit := getQueryLimit(r.URL.Query(), 20)
+
+	groups, total, err := h.groupStore.QueryGroups(ctx, port.QueryGroupsOptions{
+		Page:  &page,
+		Limit: &limit,
+	})
+	if err != nil {
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	res := ListGroupsResponse{
+		Groups: make([]GroupResponse, 0, len(groups)),
+		Total:  total,
+		Page:   page,
+		Limit:  limit,
+	}
+	for _, g := range groups {
+		res.Groups = append(res.Groups, toGroupResponse(g, isGroupManager(user, g)))
+	}
+
+	writeJSON(w, res)
+}
+
+func (h *Handler) handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+
+	var req CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.WithStack(err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, errors.New("name is required"), http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groupStore.CreateGroup(ctx, user.ID(), req.Name, req.Description)
+	if err != nil {
+		if errors.Is(err, port.ErrAlreadyExists) {
+			writeError(w, errors.New("group already exists"), http.StatusConflict)
+			return
+		}
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, toGroupResponse(group, true))
+}
+
+func (h *Handler) handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user := httpCtx.User(ctx)
+	id := model.GroupID(r.PathValue("groupID"))
+
+	group, err := h.groupStore.GetGroupByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			writeError(w, errors.New("group not found"), http.StatusNotFound)
+			return
+		}
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, toGroupResponse(group, isGroupManager(user, group)))
+}
+
+func (h *Handler) handleUpdateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("groupID"))
+
+	var req UpdateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.WithStack(err), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name != nil && *req.Name == "" {
+		writeError(w, errors.New("name cannot be empty"), http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groupStore.UpdateGroup(ctx, id, port.GroupUpdates{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			writeError(w, errors.New("group not found"), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, port.ErrAlreadyExists) {
+			writeError(w, errors.New("group already exists"), http.StatusConflict)
+			return
+		}
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	// Only the managers of the group can update it
+	writeJSON(w, toGroupResponse(group, true))
+}
+
+func (h *Handler) handleDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("groupID"))
+
+	if err := h.groupStore.DeleteGroup(ctx, id); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			writeError(w, errors.New("group not found"), http.StatusNotFound)
+			return
+		}
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleAddGroupMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("groupID"))
+
+	var req AddGroupMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.WithStack(err), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.UserIDs) == 0 {
+		writeError(w, errors.New("userIds is required"), http.StatusBadRequest)
+		return
+	}
+
+	userIDs := make([]model.UserID, 0, len(req.UserIDs))
+	for _, id := range req.UserIDs {
+		userIDs = append(userIDs, model.UserID(id))
+	}
+
+	if err := h.groupStore.AddGroupMembers(ctx, id, userIDs...); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			writeError(w, errors.New("group or user not found"), http.StatusNotFound)
+			return
+		}
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleRemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("groupID"))
+	userID := model.UserID(r.PathValue("userID"))
+
+	if err := h.groupStore.RemoveGroupMembers(ctx, id, userID); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			writeError(w, errors.New("group not found"), http.StatusNotFound)
+			return
+		}
+		writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// assertGroupManageable only lets the owner of the group or an administrator
+// through
+func (h *Handler) assertGroupManageable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := httpCtx.User(ctx)
+
+		groupID := model.GroupID(r.PathValue("groupID"))
+
+		canManage, err := h.canManageGroup(ctx, user, groupID)
+		if err != nil {
+			if errors.Is(err, port.ErrNotFound) {
+				writeError(w, errors.New("group not found"), http.StatusNotFound)
+				return
+			}
+			writeError(w, errors.WithStack(err), http.StatusInternalServerError)
+			return
+		}
+
+		if !canManage {
+			writeError(w, errors.New("only the group owner or an administrator can manage the group"), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Handler) canManageGroup(ctx context.Context, user model.User, groupID model.GroupID) (bool, error) {
+	group, err := h.groupStore.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return isGroupManager(user, group), nil
+}
+
+// isGroupManager returns true if the user is the owner of the group or an
+// administrator
+func isGroupManager(user model.User, group model.Group) bool {
+	if slices.Contains(user.Roles(), authz.RoleAdmin) {
+		return true
+	}
+
+	owner := group.Owner()
+
+	return owner != nil && owner.ID() == user.ID()
+}
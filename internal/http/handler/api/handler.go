@@ -11,9 +11,11 @@ import (
 
 type Handler struct {
 	documentManager       *service.DocumentManager
+	conversationManager   *service.ConversationManager
 	backupManager         *backup.Manager
 	taskRunner            port.TaskRunner
 	filesystemSourceStore port.FilesystemSourceStore
+	groupStore            port.GroupStore
 	mux                   *http.ServeMux
 }
 
@@ -22,12 +24,14 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.mux.ServeHTTP(w, r)
 }
 
-func NewHandler(documentManager *service.DocumentManager, backupManager *backup.Manager, taskRunner port.TaskRunner, filesystemSourceStore port.FilesystemSourceStore) *Handler {
+func NewHandler(documentManager *service.DocumentManager, conversationManager *service.ConversationManager, backupManager *backup.Manager, taskRunner port.TaskRunner, filesystemSourceStore port.FilesystemSourceStore, groupStore port.GroupStore) *Handler {
 	h := &Handler{
 		documentManager:       documentManager,
+		conversationManager:   conversationManager,
 		backupManager:         backupManager,
 		taskRunner:            taskRunner,
 		filesystemSourceStore: filesystemSourceStore,
+		groupStore:            groupStore,
 		mux:                   &http.ServeMux{},
 	}
 
@@ -43,12 +47,18 @@ func NewHandler(documentManager *service.DocumentManager, backupManager *backup.
 	h.mux.Handle("GET /backup", assertAdmin(http.HandlerFunc(h.handleGenerateBackup)))
 	h.mux.Handle("PUT /backup", assertAdmin(http.HandlerFunc(h.handleRestoreBackup)))
 
+	h.mux.Handle("POST /index/check", assertAdmin(http.HandlerFunc(h.handleCheckConsistency)))
+
 	h.mux.Handle("GET /documents/digests", assertUser(http.HandlerFunc(h.handleListDocumentDigests)))
 	h.mux.Handle("GET /documents", assertUser(http.HandlerFunc(h.handleListDocuments)))
 	h.mux.Handle("GET /documents/{documentID}", assertUser(h.assertDocumentReadable(http.HandlerFunc(h.handleGetDocument))))
 	h.mux.Handle("DELETE /documents/{documentID}", assertUser(h.assertDocumentWritable(http.HandlerFunc(h.handleDeleteDocument))))
 	h.mux.Handle("GET /documents/{documentID}/content", assertUser(h.assertDocumentReadable(http.HandlerFunc(h.handleGetDocumentContent))))
 	h.mux.Handle("POST /documents/{documentID}/reindex", assertUser(h.assertDocumentWritable(http.HandlerFunc(h.handleReindexDocument))))
+	h.mux.Handle("GET /documents/{documentID}/versions", assertUser(h.assertDocumentReadable(http.HandlerFunc(h.handleListDocumentVersions))))
+	h.mux.Handle("GET /documents/{documentID}/versions/{versionID}/content", assertUser(h.assertDocumentReadable(http.HandlerFunc(h.handleGetDocumentVersionContent))))
+	h.mux.Handle("GET /documents/{documentID}/versions/{versionID}/diff", assertUser(h.assertDocumentReadable(http.HandlerFunc(h.handleGetDocumentVersionDiff))))
+	h.mux.Handle("POST /documents/{documentID}/versions/{versionID}/rollback", assertUser(h.assertDocumentWritable(http.HandlerFunc(h.handleRollbackDocument))))
 	h.mux.Handle("GET /documents/{documentID}/sections/{sectionID}", assertUser(h.assertDocumentReadable(http.HandlerFunc(h.handleGetDocumentSection))))
 	h.mux.Handle("GET /documents/{documentID}/sections/{sectionID}/content", assertUser(h.assertDocumentReadable(http.HandlerFunc(h.handleGetSectionContent))))
 
@@ -60,6 +70,25 @@ func NewHandler(documentManager *service.DocumentManager, backupManager *backup.
 	h.mux.Handle("POST /collections/{collectionID}/shares", assertUser(http.HandlerFunc(h.handleCreateCollectionShare)))
 	h.mux.Handle("DELETE /collections/{collectionID}/shares/{shareID}", assertUser(http.HandlerFunc(h.handleDeleteCollectionShare)))
 
+	h.mux.Handle("GET /groups", assertUser(http.HandlerFunc(h.handleListGroups)))
+	h.mux.Handle("POST /groups", assertUser(http.HandlerFunc(h.handleCreateGroup)))
+	h.mux.Handle("GET /groups/{groupID}", assertUser(http.HandlerFunc(h.handleGetGroup)))
+	h.mux.Handle("PUT /groups/{groupID}", assertUser(h.assertGroupManageable(http.HandlerFunc(h.handleUpdateGroup))))
+	h.mux.Handle("DELETE /groups/{groupID}", assertUser(h.assertGroupManageable(http.HandlerFunc(h.handleDeleteGroup))))
+	h.mux.Handle("POST /groups/{groupID}/members", assertUser(h.assertGroupManageable(http.HandlerFunc(h.handleAddGroupMembers))))
+	h.mux.Handle("DELETE /groups/{groupID}/members/{userID}", assertUser(h.assertGroupManageable(http.HandlerFunc(h.handleRemoveGroupMember))))
+
+	h.mux.Handle("GET /trash/documents", assertUser(http.HandlerFunc(h.handleListTrashedDocuments)))
+	h.mux.Handle("POST /trash/documents/{documentID}/restore", assertUser(http.HandlerFunc(h.handleRestoreDocument)))
+	h.mux.Handle("GET /trash/collections", assertUser(http.HandlerFunc(h.handleListTrashedCollections)))
+	h.mux.Handle("POST /trash/collections/{collectionID}/restore", assertUser(http.HandlerFunc(h.handleRestoreCollection)))
+
+	h.mux.Handle("GET /conversations", assertUser(http.HandlerFunc(h.handleListConversations)))
+	h.mux.Handle("POST /conversations", assertUser(http.HandlerFunc(h.handleCreateConversation)))
+	h.mux.Handle("GET /conversations/{conversationID}", assertUser(http.HandlerFunc(h.handleGetConversation)))
+	h.mux.Handle("DELETE /conversations/{conversationID}", assertUser(http.HandlerFunc(h.handleDeleteConversation)))
+	h.mux.Handle("POST /conversations/{conversationID}/ask", assertUser(http.HandlerFunc(h.handleAskConversation)))
+
 	h.mux.Handle("GET /filesystem-sources/backend-schemas", assertAdmin(http.HandlerFunc(h.handleGetFilesystemBackendSchemas)))
 	h.mux.Handle("GET /filesystem-sources", assertAdmin(http.HandlerFunc(h.handleListFilesystemSources)))
 	h.mux.Handle("POST /filesystem-sources", assertAdmin(http.HandlerFunc(h.handleCreateFilesystemSource)))
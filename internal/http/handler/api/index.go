@@ -56,6 +56,22 @@ func (h *Handler) handleIndexDocument(w http.ResponseWriter, r *http.Request) {
 		options = append(options, service.WithDocumentManagerIndexFileETag(etag))
 	}
 
+	metadata, err := getMetadataValues(r.Form["metadata"], "metadata")
+	if err != nil {
+		var httpErr common.HTTPError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.Error(), httpErr.StatusCode())
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if metadata != nil {
+		options = append(options, service.WithDocumentManagerIndexFileMetadata(metadata))
+	}
+
 	if rawCollections, exists := r.Form["collection"]; exists {
 		collections, err := h.assertWritableCollections(ctx, rawCollections)
 		if err != nil {
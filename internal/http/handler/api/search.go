@@ -26,6 +26,12 @@ type SearchResult struct {
 type SearchResultSection struct {
 	ID      model.SectionID `json:"id"`
 	Content string          `json:"content"`
+	// Normalized relevance score, in [0, 1]
+	Score float64 `json:"score"`
+	// Rank of the section in each underlying index results
+	Ranks map[string]int `json:"ranks,omitempty"`
+	// Fragments of the content matching the query, when requested
+	Highlights []*port.IndexSearchHighlight `json:"highlights,omitempty"`
 }
 
 func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
@@ -50,6 +56,19 @@ func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filter, err := getSearchFilterFromRequest(r)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not parse search filter", slogx.Error(err))
+		var httpErr common.HTTPError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.Error(), httpErr.StatusCode())
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
 	rawSize := r.URL.Query().Get("size")
 	var (
 		size int64
@@ -65,7 +84,30 @@ func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
 		size = 3
 	}
 
-	res, err := h.doSearch(ctx, query, collections, size)
+	var highlight bool
+	if rawHighlight := r.URL.Query().Get("highlight"); rawHighlight != "" {
+		highlight, err = strconv.ParseBool(rawHighlight)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not parse highlight parameter", slog.Any("error", errors.WithStack(err)))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+	}
+
+	boost, err := getMetadataValues(r.URL.Query()["boost"], "boost")
+	if err != nil {
+		slog.ErrorContext(ctx, "could not parse boost parameter", slogx.Error(err))
+		var httpErr common.HTTPError
+		if errors.As(err, &httpErr) {
+			http.Error(w, httpErr.Error(), httpErr.StatusCode())
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.doSearch(ctx, query, collections, filter, boost, size, highlight)
 	if err != nil {
 		slog.ErrorContext(ctx, "could not search sections", slog.Any("error", errors.WithStack(err)))
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -82,8 +124,8 @@ func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) doSearch(ctx context.Context, query string, collections []model.CollectionID, size int64) (*SearchResponse, error) {
-	slog.DebugContext(ctx, "executing search", slog.String("query", query), slog.Any("collections", collections), slog.Any("size", size))
+func (h *Handler) doSearch(ctx context.Context, query string, collections []model.CollectionID, filter *port.IndexSearchFilter, boost map[string][]string, size int64, highlight bool) (*SearchResponse, error) {
+	slog.DebugContext(ctx, "executing search", slog.String("query", query), slog.Any("collections", collections), slog.Any("filter", filter), slog.Any("boost", boost), slog.Any("size", size), slog.Bool("highlight", highlight))
 
 	res := &SearchResponse{
 		Results: []*SearchResult{},
@@ -96,6 +138,9 @@ func (h *Handler) doSearch(ctx context.Context, query string, collections []mode
 	searchResults, err := h.documentManager.Search(ctx, query,
 		service.WithDocumentManagerSearchCollections(collections...),
 		service.WithDocumentManagerSearchMaxResults(int(size)),
+		service.WithDocumentManagerSearchFilter(filter),
+		service.WithDocumentManagerSearchHighlight(highlight),
+		service.WithDocumentManagerSearchBoost(lastMetadataValues(boost)),
 	)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -123,8 +168,11 @@ func (h *Handler) doSearch(ctx context.Context, query string, collections []mode
 			}
 
 			result.Sections = append(result.Sections, &SearchResultSection{
-				ID:      sectionID,
-				Content: string(content),
+				ID:         sectionID,
+				Content:    string(content),
+				Score:      r.Score(sectionID),
+				Ranks:      r.Ranks[sectionID],
+				Highlights: r.Highlights[sectionID],
 			})
 		}
 
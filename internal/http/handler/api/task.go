@@ -6,10 +6,12 @@ import (
 	"log/slog"
 	"net/http"
 	"slices"
+	"strconv"
 	"time"
 
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
 	"github.com/bornholm/corpus/internal/http/handler/webui/common"
 	"github.com/pkg/errors"
 )
@@ -125,3 +127,34 @@ func (h *Handler) writeTask(ctx context.Context, w http.ResponseWriter, taskID m
 		slog.ErrorContext(ctx, "could not encode response", slog.Any("error", errors.WithStack(err)))
 	}
 }
+
+func (h *Handler) handleCheckConsistency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collections := make([]model.CollectionID, 0)
+	for _, rawCollectionID := range r.URL.Query()["collection"] {
+		collections = append(collections, model.CollectionID(rawCollectionID))
+	}
+
+	var repair bool
+	if rawRepair := r.URL.Query().Get("repair"); rawRepair != "" {
+		var err error
+		repair, err = strconv.ParseBool(rawRepair)
+		if err != nil {
+			slog.ErrorContext(ctx, "could not parse repair parameter", slog.Any("error", errors.WithStack(err)))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+	}
+
+	user := httpCtx.User(ctx)
+
+	taskID, err := h.documentManager.CheckConsistency(ctx, user, repair, collections...)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not schedule consistency check", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTask(ctx, w, taskID)
+}
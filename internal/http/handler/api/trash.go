@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/pkg/errors"
+)
+
+type ListTrashedDocumentsResponse struct {
+	Documents []TrashedDocument `json:"documents"`
+	Total     int64             `json:"total"`
+	Page      int               `json:"page"`
+	Limit     int               `json:"limit"`
+}
+
+type TrashedDocument struct {
+	DocumentHeader
+	TrashedAt *time.Time `json:"trashedAt"`
+}
+
+type ListTrashedCollectionsResponse struct {
+	Collections []TrashedCollection `json:"collections"`
+	Total       int64               `json:"total"`
+	Page        int                 `json:"page"`
+	Limit       int                 `json:"limit"`
+}
+
+type TrashedCollection struct {
+	CollectionHeader
+	TrashedAt *time.Time `json:"trashedAt"`
+}
+
+func (h *Handler) handleListTrashedDocuments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page := getQueryPage(query, 0)
+	limit := getQueryLimit(query, 10)
+
+	ctx := r.Context()
+	userID := httpCtx.User(ctx).ID()
+
+	documents, total, err := h.documentManager.DocumentStore.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{
+		Page:    &page,
+		Limit:   &limit,
+		OwnerID: &userID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not query trashed documents", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	res := ListTrashedDocumentsResponse{
+		Documents: make([]TrashedDocument, 0, len(documents)),
+		Total:     total,
+		Page:      page,
+		Limit:     limit,
+	}
+
+	for _, d := range documents {
+		res.Documents = append(res.Documents, TrashedDocument{
+			DocumentHeader: DocumentHeader{
+				ID:       string(d.ID()),
+				ETag:     d.ETag(),
+				Source:   d.Source().String(),
+				Metadata: model.DocumentMetadata(d),
+			},
+			TrashedAt: model.TrashedAt(d),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := encoder.Encode(res); err != nil {
+		slog.ErrorContext(ctx, "could not encode response", slog.Any("error", errors.WithStack(err)))
+	}
+}
+
+func (h *Handler) handleRestoreDocument(w http.ResponseWriter, r *http.Request) {
+	documentID := model.DocumentID(r.PathValue("documentID"))
+
+	ctx := r.Context()
+	userID := httpCtx.User(ctx).ID()
+
+	// Only the owner of a trashed document can restore it
+	_, total, err := h.documentManager.DocumentStore.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{
+		IDs:     []model.DocumentID{documentID},
+		OwnerID: &userID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not query trashed documents", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if total == 0 {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err := h.documentManager.DocumentStore.RestoreDocumentByID(ctx, documentID); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not restore document", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleListTrashedCollections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page := getQueryPage(query, 0)
+	limit := getQueryLimit(query, 10)
+
+	ctx := r.Context()
+	userID := httpCtx.User(ctx).ID()
+
+	collections, total, err := h.documentManager.DocumentStore.QueryTrashedCollections(ctx, port.QueryTrashedCollectionsOptions{
+		Page:    &page,
+		Limit:   &limit,
+		OwnerID: &userID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not query trashed collections", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	res := ListTrashedCollectionsResponse{
+		Collections: make([]TrashedCollection, 0, len(collections)),
+		Total:       total,
+		Page:        page,
+		Limit:       limit,
+	}
+
+	for _, c := range collections {
+		res.Collections = append(res.Collections, TrashedCollection{
+			CollectionHeader: CollectionHeader{
+				ID:          string(c.ID()),
+				Label:       c.Label(),
+				Description: c.Description(),
+			},
+			TrashedAt: model.TrashedAt(c),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := encoder.Encode(res); err != nil {
+		slog.ErrorContext(ctx, "could not encode response", slog.Any("error", errors.WithStack(err)))
+	}
+}
+
+func (h *Handler) handleRestoreCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID := model.CollectionID(r.PathValue("collectionID"))
+
+	ctx := r.Context()
+	userID := httpCtx.User(ctx).ID()
+
+	// Only the owner of a trashed collection can restore it
+	_, total, err := h.documentManager.DocumentStore.QueryTrashedCollections(ctx, port.QueryTrashedCollectionsOptions{
+		IDs:     []model.CollectionID{collectionID},
+		OwnerID: &userID,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not query trashed collections", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if total == 0 {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err := h.documentManager.DocumentStore.RestoreCollection(ctx, collectionID); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not restore collection", slog.Any("error", errors.WithStack(err)))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
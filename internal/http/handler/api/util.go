@@ -2,13 +2,17 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/corpus/internal/core/service"
 	httpCtx "github.com/bornholm/corpus/internal/http/context"
 	"github.com/bornholm/corpus/internal/http/handler/webui/common"
 	"github.com/pkg/errors"
@@ -54,6 +58,116 @@ func (h *Handler) getSelectedCollectionsFromRequest(r *http.Request) ([]model.Co
 	return collections, nil
 }
 
+// getSearchFilterFromRequest parses the search filter parameters of the request:
+// source_prefix, source_glob, updated_after, updated_before (RFC3339 or
+// YYYY-MM-DD) and metadata (repeatable, "key:value").
+func getSearchFilterFromRequest(r *http.Request) (*port.IndexSearchFilter, error) {
+	query := r.URL.Query()
+
+	filter := &port.IndexSearchFilter{
+		SourcePrefix: query.Get("source_prefix"),
+		SourceGlob:   query.Get("source_glob"),
+	}
+
+	var err error
+
+	if filter.UpdatedAfter, err = getQueryTime(query, "updated_after"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if filter.UpdatedBefore, err = getQueryTime(query, "updated_before"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	metadata, err := getMetadataValues(query["metadata"], "metadata")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	filter.Metadata = lastMetadataValues(metadata)
+
+	if filter.IsZero() {
+		return nil, nil
+	}
+
+	return filter, nil
+}
+
+// lastMetadataValues keeps the last value of each metadata key, nil if there
+// is none
+func lastMetadataValues(metadata map[string][]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(metadata))
+	for key, v := range metadata {
+		values[key] = v[len(v)-1]
+	}
+
+	return values
+}
+
+// getMetadataValues parses the "key:value" formatted values of the given
+// parameter, a key being possibly repeated
+func getMetadataValues(rawValues []string, param string) (map[string][]string, error) {
+	if len(rawValues) == 0 {
+		return nil, nil
+	}
+
+	metadata := map[string][]string{}
+
+	for _, raw := range rawValues {
+		key, value, found := strings.Cut(raw, ":")
+		if !found || key == "" {
+			return nil, common.NewError(fmt.Sprintf("invalid %s parameter", param), fmt.Sprintf("%s parameter must be formatted as 'key:value'", param), http.StatusBadRequest)
+		}
+
+		metadata[key] = append(metadata[key], value)
+	}
+
+	return metadata, nil
+}
+
+// getContextExpansionFromRequest parses the context expansion parameters of
+// the request: expansion (none, parent, siblings or document) and siblings.
+// Nil is returned when the request does not override the configured expansion.
+func getContextExpansionFromRequest(r *http.Request) (service.DocumentManagerAskOptionFunc, error) {
+	query := r.URL.Query()
+
+	raw := query.Get("expansion")
+	if raw == "" {
+		return nil, nil
+	}
+
+	expansion := service.ContextExpansion(raw)
+	if !expansion.Valid() {
+		return nil, common.NewError("invalid expansion parameter", "expansion parameter must be one of 'none', 'parent', 'siblings' or 'document'", http.StatusBadRequest)
+	}
+
+	siblings := getQueryInt(query, "siblings", 1)
+	if siblings < 0 {
+		return nil, common.NewError("invalid siblings parameter", "siblings parameter must be a positive integer", http.StatusBadRequest)
+	}
+
+	return service.WithAskContextExpansion(expansion, siblings), nil
+}
+
+func getQueryTime(query url.Values, name string) (time.Time, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, common.NewError("invalid "+name+" parameter", name+" parameter must be a RFC3339 date-time or a YYYY-MM-DD date", http.StatusBadRequest)
+}
+
 func getQueryPage(query url.Values, defaultValue int) int {
 	return getQueryInt(query, "page", defaultValue)
 }
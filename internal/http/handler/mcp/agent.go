@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/core/service"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/pkg/errors"
+)
+
+func (h *Handler) handleAskAgent(ctx context.Context, request *sdkmcp.CallToolRequest) (*sdkmcp.CallToolResult, error) {
+	var args map[string]any
+	if err := json.Unmarshal(request.Params.Arguments, &args); err != nil {
+		return &sdkmcp.CallToolResult{
+			Content: []sdkmcp.Content{
+				&sdkmcp.TextContent{Text: "Invalid arguments: " + err.Error()},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	question, ok := args["question"].(string)
+	if !ok || question == "" {
+		return &sdkmcp.CallToolResult{
+			Content: []sdkmcp.Content{
+				&sdkmcp.TextContent{Text: "The 'question' required argument is missing."},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	agentOptions := []service.AgentOptionFunc{service.WithAgentUser(httpCtx.User(ctx))}
+
+	if rawMaxSteps, exists := args["maxSteps"].(float64); exists && rawMaxSteps >= 1 {
+		agentOptions = append(agentOptions, service.WithAgentMaxSteps(int(rawMaxSteps)))
+	}
+
+	collections, err := h.resolveSessionCollections(ctx)
+	if err != nil {
+		var invalidCollectionErr InvalidCollectionError
+		if errors.As(err, &invalidCollectionErr) {
+			return &sdkmcp.CallToolResult{
+				Content: []sdkmcp.Content{
+					&sdkmcp.TextContent{Text: invalidCollectionErr.Error()},
+				},
+				IsError: true,
+			}, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	result, err := h.documentManager.AskAgent(ctx, question, collections, agentOptions...)
+	if err != nil {
+		if errors.Is(err, service.ErrNoReadableCollection) {
+			return &sdkmcp.CallToolResult{
+				Content: []sdkmcp.Content{
+					&sdkmcp.TextContent{Text: "No collection available to the user."},
+				},
+				IsError: true,
+			}, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("# Response \n\n")
+	sb.WriteString(result.Answer)
+
+	if len(result.Citations) > 0 {
+		sb.WriteString("\n\n## Citations\n\n")
+
+		cited := make([]int, 0, len(result.Citations))
+		for _, c := range result.Citations {
+			if slices.Contains(cited, c.Marker) {
+				continue
+			}
+
+			cited = append(cited, c.Marker)
+			fmt.Fprintf(&sb, "- [%d] %s\n", c.Marker, c.Source)
+		}
+	}
+
+	content := []sdkmcp.Content{
+		&sdkmcp.TextContent{Text: sb.String()},
+	}
+
+	sb.Reset()
+	sb.WriteString("# Steps\n\n")
+
+	if len(result.Steps) == 0 {
+		sb.WriteString("The agent answered without exploring the documents.\n")
+	}
+
+	for _, step := range result.Steps {
+		arguments, err := json.Marshal(step.Arguments)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		fmt.Fprintf(&sb, "- %d. `%s` %s\n", step.Step, step.Tool, arguments)
+	}
+
+	if result.Exhausted {
+		sb.WriteString("\nThe exploration budget was exhausted before the agent answered.\n")
+	}
+
+	fmt.Fprintf(&sb, "\n**Tokens:** %d\n", result.Tokens)
+
+	content = append(content, &sdkmcp.TextContent{Text: sb.String()})
+
+	return &sdkmcp.CallToolResult{
+		Content: content,
+	}, nil
+}
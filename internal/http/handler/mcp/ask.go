@@ -3,12 +3,16 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"slices"
 	"strings"
 
+	"github.com/bornholm/corpus/internal/core/service"
 	httpCtx "github.com/bornholm/corpus/internal/http/context"
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/go-x/slogx"
 	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/pkg/errors"
 )
@@ -34,6 +38,45 @@ func (h *Handler) handleAsk(ctx context.Context, request *sdkmcp.CallToolRequest
 		}, nil
 	}
 
+	var filter *port.IndexSearchFilter
+	if rawFilter, exists := args["filter"]; exists && rawFilter != nil {
+		data, err := json.Marshal(rawFilter)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		filter = &port.IndexSearchFilter{}
+		if err := json.Unmarshal(data, filter); err != nil {
+			return &sdkmcp.CallToolResult{
+				Content: []sdkmcp.Content{
+					&sdkmcp.TextContent{Text: "Invalid 'filter' argument: " + err.Error()},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	askOptions := []service.DocumentManagerAskOptionFunc{service.WithAskFilter(filter)}
+
+	if rawExpansion, exists := args["expansion"].(string); exists && rawExpansion != "" {
+		expansion := service.ContextExpansion(rawExpansion)
+		if !expansion.Valid() {
+			return &sdkmcp.CallToolResult{
+				Content: []sdkmcp.Content{
+					&sdkmcp.TextContent{Text: "Invalid 'expansion' argument: must be one of 'none', 'parent', 'siblings' or 'document'."},
+				},
+				IsError: true,
+			}, nil
+		}
+
+		siblings := 1
+		if rawSiblings, exists := args["siblings"].(float64); exists && rawSiblings >= 0 {
+			siblings = int(rawSiblings)
+		}
+
+		askOptions = append(askOptions, service.WithAskContextExpansion(expansion, siblings))
+	}
+
 	collections, err := h.resolveSessionCollections(ctx)
 	if err != nil {
 		var invalidCollectionErr InvalidCollectionError
@@ -49,7 +92,12 @@ func (h *Handler) handleAsk(ctx context.Context, request *sdkmcp.CallToolRequest
 		return nil, errors.WithStack(err)
 	}
 
-	result, err := h.documentManager.AskWithRetrieval(ctx, question, collections)
+	var result *service.AskResult
+	if progressToken := request.Params.GetProgressToken(); progressToken != nil {
+		result, err = h.documentManager.AskWithRetrievalStream(ctx, question, collections, newAskProgressFunc(request.Session, progressToken), askOptions...)
+	} else {
+		result, err = h.documentManager.AskWithRetrieval(ctx, question, collections, askOptions...)
+	}
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -72,6 +120,20 @@ func (h *Handler) handleAsk(ctx context.Context, request *sdkmcp.CallToolRequest
 	sb.WriteString("# Response \n\n")
 	sb.WriteString(result.Answer)
 
+	if len(result.Citations) > 0 {
+		sb.WriteString("\n\n## Citations\n\n")
+
+		cited := make([]int, 0, len(result.Citations))
+		for _, c := range result.Citations {
+			if slices.Contains(cited, c.Marker) {
+				continue
+			}
+
+			cited = append(cited, c.Marker)
+			fmt.Fprintf(&sb, "- [%d] %s\n", c.Marker, c.Source)
+		}
+	}
+
 	content = append(content, &sdkmcp.TextContent{
 		Text: sb.String(),
 	})
@@ -108,6 +170,40 @@ func (h *Handler) handleAsk(ctx context.Context, request *sdkmcp.CallToolRequest
 	}, nil
 }
 
+// newAskProgressFunc returns a function reporting the progress of a streamed
+// answer to the client: the number of retrieved sources then each fragment of
+// the answer as it is generated
+func newAskProgressFunc(session *sdkmcp.ServerSession, progressToken any) service.AskStreamFunc {
+	var progress float64
+
+	return func(ctx context.Context, event service.AskEvent) error {
+		var message string
+
+		switch event.Type {
+		case service.AskEventResults:
+			message = fmt.Sprintf("Found %d matching sources, generating the response...", len(event.Results))
+		case service.AskEventDelta:
+			message = event.Delta
+		default:
+			return nil
+		}
+
+		progress++
+
+		err := session.NotifyProgress(ctx, &sdkmcp.ProgressNotificationParams{
+			ProgressToken: progressToken,
+			Progress:      progress,
+			Message:       message,
+		})
+		if err != nil {
+			// The answer is still returned if the client can not be notified
+			slog.WarnContext(ctx, "could not notify progress", slogx.Error(err))
+		}
+
+		return nil
+	}
+}
+
 // resolveSessionCollections returns the (validated) collection IDs the current
 // MCP session restricts search to. An empty slice means "no restriction".
 func (h *Handler) resolveSessionCollections(ctx context.Context) ([]model.CollectionID, error) {
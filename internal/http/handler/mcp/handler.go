@@ -51,6 +51,7 @@ func NewHandler(baseURL string, basePath string, documentManager *service.Docume
 	}, nil)
 
 	mcpServer.AddTool(getAskTool(), h.handleAsk)
+	mcpServer.AddTool(getAskAgentTool(), h.handleAskAgent)
 	mcpServer.AddTool(getListCollectionsTool(), h.handleListCollections)
 	mcpServer.AddReceivingMiddleware(h.loggingMiddleware)
 
@@ -114,6 +115,45 @@ func getAskTool() *sdkmcp.Tool {
 			"collection": {
 				"type": "string",
 				"description": "Optional. The collection ID to restrict the search to. If not provided, searches across all collections the user has access to.\n\n**How to get collection IDs:** Use the 'list_collections' tool first to retrieve available collections with their IDs.\n\n**Example collection ID format:** \"c9v3jk2p3n4f9d8e7h6g5r4t3\" (xid format)"
+			},
+			"filter": {
+				"type": "object",
+				"description": "Optional. Restricts the search to the documents matching all the given criteria.",
+				"properties": {
+					"sourcePrefix": {
+						"type": "string",
+						"description": "Only documents whose source URL starts with this prefix"
+					},
+					"sourceGlob": {
+						"type": "string",
+						"description": "Only documents whose source URL matches this pattern, '*' matching any sequence of characters and '?' a single character"
+					},
+					"updatedAfter": {
+						"type": "string",
+						"format": "date-time",
+						"description": "Only documents updated at or after this RFC3339 date-time"
+					},
+					"updatedBefore": {
+						"type": "string",
+						"format": "date-time",
+						"description": "Only documents updated before this RFC3339 date-time"
+					},
+					"metadata": {
+						"type": "object",
+						"additionalProperties": { "type": "string" },
+						"description": "Only documents having the given metadata key/values, e.g. {\"tags\": \"policy\"}"
+					}
+				}
+			},
+			"expansion": {
+				"type": "string",
+				"enum": ["none", "parent", "siblings", "document"],
+				"description": "Optional. The surroundings of the found passages given to generate the answer: 'none' (the passages only), 'parent' (their parent section), 'siblings' (the neighbouring sections) or 'document' (the whole document, if it fits). Use a broader expansion when the passages are likely to lack definitions given elsewhere in the documents."
+			},
+			"siblings": {
+				"type": "integer",
+				"minimum": 0,
+				"description": "Optional. The number of sections added before and after each passage with the 'siblings' expansion. Defaults to 1."
 			}
 		},
 		"required": ["question"]
@@ -132,6 +172,35 @@ func getAskTool() *sdkmcp.Tool {
 	}
 }
 
+func getAskAgentTool() *sdkmcp.Tool {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"question": {
+				"type": "string",
+				"description": "The question to ask the knowledge base. Always use the language of the user."
+			},
+			"maxSteps": {
+				"type": "integer",
+				"minimum": 1,
+				"description": "Optional. The maximum number of exploration steps, lowering the limit configured on the server."
+			}
+		},
+		"required": ["question"]
+	}`)
+	return &sdkmcp.Tool{
+		Name: "ask_agent",
+		Description: `Use this tool to answer complex questions from the indexed documents with a research agent. The agent explores the knowledge base by itself: it lists the collections, runs several searches and reads the sections and the outline of the documents before answering.
+
+**When to use:**
+- When the answer is spread across several documents or sections
+- When the 'ask' tool could not find a satisfying answer with a single search
+
+**Returns:** The response with its citations, followed by the trace of the exploration steps of the agent.`,
+		InputSchema: schema,
+	}
+}
+
 func getListCollectionsTool() *sdkmcp.Tool {
 	return &sdkmcp.Tool{
 		Name: "list_collections",
@@ -0,0 +1,326 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/handler/webui/admin/component"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	commonComp "github.com/bornholm/corpus/internal/http/handler/webui/common/component"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// --- Page handlers ---
+
+func (h *Handler) getGroupsPage(w http.ResponseWriter, r *http.Request) {
+	vmodel, err := h.fillGroupsPageViewModel(r)
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+	templ.Handler(component.GroupsPage(*vmodel)).ServeHTTP(w, r)
+}
+
+func (h *Handler) getNewGroupPage(w http.ResponseWriter, r *http.Request) {
+	vmodel, err := h.fillNewGroupPageViewModel(r, "")
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+	templ.Handler(component.NewGroupPage(*vmodel)).ServeHTTP(w, r)
+}
+
+func (h *Handler) postGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := httpCtx.User(ctx)
+	if user == nil {
+		common.HandleError(w, r, errors.New("could not retrieve user from context"))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	description := strings.TrimSpace(r.FormValue("description"))
+
+	if name == "" {
+		h.renderNewGroupPage(w, r, "Le nom du groupe est obligatoire.")
+		return
+	}
+
+	group, err := h.groupStore.CreateGroup(ctx, user.ID(), name, description)
+	if err != nil {
+		if errors.Is(err, port.ErrAlreadyExists) {
+			h.renderNewGroupPage(w, r, "Un groupe portant ce nom existe déjà.")
+			return
+		}
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	redirectURL := commonComp.BaseURL(r.Context(), commonComp.WithPath("/admin/groups", string(group.ID())))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
+func (h *Handler) getGroupPage(w http.ResponseWriter, r *http.Request) {
+	vmodel, err := h.fillGroupPageViewModel(r, "")
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+	templ.Handler(component.GroupPage(*vmodel)).ServeHTTP(w, r)
+}
+
+func (h *Handler) postEditGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("id"))
+
+	if err := r.ParseForm(); err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	description := strings.TrimSpace(r.FormValue("description"))
+
+	if name == "" {
+		h.renderGroupPage(w, r, "Le nom du groupe est obligatoire.")
+		return
+	}
+
+	_, err := h.groupStore.UpdateGroup(ctx, id, port.GroupUpdates{
+		Name:        &name,
+		Description: &description,
+	})
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+			return
+		}
+		if errors.Is(err, port.ErrAlreadyExists) {
+			h.renderGroupPage(w, r, "Un groupe portant ce nom existe déjà.")
+			return
+		}
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	redirectURL := commonComp.BaseURL(r.Context(), commonComp.WithPath("/admin/groups", string(id)))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
+func (h *Handler) postDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("id"))
+
+	if err := h.groupStore.DeleteGroup(ctx, id); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+			return
+		}
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	redirectURL := commonComp.BaseURL(r.Context(), commonComp.WithPath("/admin/groups"))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
+func (h *Handler) postGroupMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("id"))
+
+	if err := r.ParseForm(); err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	userID := model.UserID(r.FormValue("user_id"))
+	if userID == "" {
+		h.renderGroupPage(w, r, "Veuillez sélectionner un utilisateur.")
+		return
+	}
+
+	if err := h.groupStore.AddGroupMembers(ctx, id, userID); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+			return
+		}
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	redirectURL := commonComp.BaseURL(r.Context(), commonComp.WithPath("/admin/groups", string(id)))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
+func (h *Handler) postDeleteGroupMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := model.GroupID(r.PathValue("id"))
+	userID := model.UserID(r.PathValue("userID"))
+
+	if err := h.groupStore.RemoveGroupMembers(ctx, id, userID); err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+			return
+		}
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	redirectURL := commonComp.BaseURL(r.Context(), commonComp.WithPath("/admin/groups", string(id)))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
+func (h *Handler) renderNewGroupPage(w http.ResponseWriter, r *http.Request, errMsg string) {
+	vmodel, err := h.fillNewGroupPageViewModel(r, errMsg)
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+	templ.Handler(component.NewGroupPage(*vmodel)).ServeHTTP(w, r)
+}
+
+func (h *Handler) renderGroupPage(w http.ResponseWriter, r *http.Request, errMsg string) {
+	vmodel, err := h.fillGroupPageViewModel(r, errMsg)
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+	templ.Handler(component.GroupPage(*vmodel)).ServeHTTP(w, r)
+}
+
+// --- ViewModel builders ---
+
+func (h *Handler) fillGroupsPageViewModel(r *http.Request) (*component.GroupsPageVModel, error) {
+	vmodel := &component.GroupsPageVModel{}
+	ctx := r.Context()
+
+	if err := common.FillViewModel(ctx, vmodel, r,
+		h.fillGroupsAppLayout,
+		h.fillGroupsList,
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return vmodel, nil
+}
+
+func (h *Handler) fillGroupsAppLayout(ctx context.Context, vmodel *component.GroupsPageVModel, r *http.Request) error {
+	return fillAdminAppLayout(ctx, &vmodel.AppLayoutVModel, "groups")
+}
+
+func (h *Handler) fillGroupsList(ctx context.Context, vmodel *component.GroupsPageVModel, r *http.Request) error {
+	page := 0
+	if p := r.URL.Query().Get("page"); p != "" {
+		if n, err := strconv.Atoi(p); err == nil && n > 0 {
+			page = n - 1
+		}
+	}
+	limit := 20
+
+	groups, total, err := h.groupStore.QueryGroups(ctx, port.QueryGroupsOptions{
+		Page:  &page,
+		Limit: &limit,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Groups = groups
+	vmodel.CurrentPage = page + 1
+	vmodel.PageSize = limit
+	vmodel.TotalGroups = int(total)
+
+	return nil
+}
+
+func (h *Handler) fillNewGroupPageViewModel(r *http.Request, errMsg string) (*component.NewGroupPageVModel, error) {
+	vmodel := &component.NewGroupPageVModel{Error: errMsg}
+	ctx := r.Context()
+
+	if err := common.FillViewModel(ctx, vmodel, r,
+		h.fillNewGroupAppLayout,
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return vmodel, nil
+}
+
+func (h *Handler) fillNewGroupAppLayout(ctx context.Context, vmodel *component.NewGroupPageVModel, r *http.Request) error {
+	return fillAdminAppLayout(ctx, &vmodel.AppLayoutVModel, "groups")
+}
+
+func (h *Handler) fillGroupPageViewModel(r *http.Request, errMsg string) (*component.GroupPageVModel, error) {
+	vmodel := &component.GroupPageVModel{Error: errMsg}
+	ctx := r.Context()
+
+	if err := common.FillViewModel(ctx, vmodel, r,
+		h.fillGroupAppLayout,
+		h.fillGroupDetail,
+		h.fillGroupAvailableUsers,
+	); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return vmodel, nil
+}
+
+func (h *Handler) fillGroupAppLayout(ctx context.Context, vmodel *component.GroupPageVModel, r *http.Request) error {
+	return fillAdminAppLayout(ctx, &vmodel.AppLayoutVModel, "groups")
+}
+
+func (h *Handler) fillGroupDetail(ctx context.Context, vmodel *component.GroupPageVModel, r *http.Request) error {
+	id := model.GroupID(r.PathValue("id"))
+
+	group, err := h.groupStore.GetGroupByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			return common.NewHTTPError(http.StatusNotFound)
+		}
+		return errors.WithStack(err)
+	}
+
+	vmodel.Group = group
+
+	return nil
+}
+
+func (h *Handler) fillGroupAvailableUsers(ctx context.Context, vmodel *component.GroupPageVModel, r *http.Request) error {
+	// Members of synchronized groups are managed by the identity provider
+	if vmodel.Group == nil || vmodel.Group.Provider() != "" {
+		return nil
+	}
+
+	users, err := h.userStore.QueryUsers(ctx, port.QueryUsersOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	members := make(map[model.UserID]struct{}, len(vmodel.Group.Members()))
+	for _, m := range vmodel.Group.Members() {
+		members[m.ID()] = struct{}{}
+	}
+
+	available := make([]model.User, 0, len(users))
+	for _, u := range users {
+		if _, exists := members[u.ID()]; !exists {
+			available = append(available, u)
+		}
+	}
+
+	vmodel.AvailableUsers = available
+
+	return nil
+}
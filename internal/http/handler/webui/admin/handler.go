@@ -11,6 +11,7 @@ import (
 
 type DocumentManagerInterface interface {
 	ReindexCollection(ctx context.Context, owner model.User, collectionID model.CollectionID) (model.TaskID, error)
+	CheckConsistency(ctx context.Context, owner model.User, repair bool, collections ...model.CollectionID) (model.TaskID, error)
 	QueryUserWritableCollections(ctx context.Context, userID model.UserID, opts port.QueryCollectionsOptions) ([]model.PersistedCollection, int64, error)
 }
 
@@ -22,6 +23,7 @@ type Handler struct {
 	taskRunner            port.TaskRunner
 	documentManager       DocumentManagerInterface
 	filesystemSourceStore port.FilesystemSourceStore
+	groupStore            port.GroupStore
 }
 
 // ServeHTTP implements http.Handler.
@@ -29,7 +31,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.mux.ServeHTTP(w, r)
 }
 
-func NewHandler(userStore port.UserStore, documentStore port.DocumentStore, publicShareStore port.PublicShareStore, taskRunner port.TaskRunner, documentManager DocumentManagerInterface, filesystemSourceStore port.FilesystemSourceStore) *Handler {
+func NewHandler(userStore port.UserStore, documentStore port.DocumentStore, publicShareStore port.PublicShareStore, taskRunner port.TaskRunner, documentManager DocumentManagerInterface, filesystemSourceStore port.FilesystemSourceStore, groupStore port.GroupStore) *Handler {
 	h := &Handler{
 		mux:                   http.NewServeMux(),
 		userStore:             userStore,
@@ -38,6 +40,7 @@ func NewHandler(userStore port.UserStore, documentStore port.DocumentStore, publ
 		taskRunner:            taskRunner,
 		documentManager:       documentManager,
 		filesystemSourceStore: filesystemSourceStore,
+		groupStore:            groupStore,
 	}
 
 	// Admin middleware - only allow admin users
@@ -50,6 +53,17 @@ func NewHandler(userStore port.UserStore, documentStore port.DocumentStore, publ
 	h.mux.Handle("GET /users/{id}/edit", assertAdmin(http.HandlerFunc(h.getEditUserPage)))
 	h.mux.Handle("POST /users/{id}/edit", assertAdmin(http.HandlerFunc(h.postEditUser)))
 
+	// Group routes
+	// NOTE: literal paths must come before {id} wildcard routes
+	h.mux.Handle("GET /groups", assertAdmin(http.HandlerFunc(h.getGroupsPage)))
+	h.mux.Handle("GET /groups/new", assertAdmin(http.HandlerFunc(h.getNewGroupPage)))
+	h.mux.Handle("POST /groups", assertAdmin(http.HandlerFunc(h.postGroup)))
+	h.mux.Handle("GET /groups/{id}", assertAdmin(http.HandlerFunc(h.getGroupPage)))
+	h.mux.Handle("POST /groups/{id}", assertAdmin(http.HandlerFunc(h.postEditGroup)))
+	h.mux.Handle("POST /groups/{id}/delete", assertAdmin(http.HandlerFunc(h.postDeleteGroup)))
+	h.mux.Handle("POST /groups/{id}/members", assertAdmin(http.HandlerFunc(h.postGroupMember)))
+	h.mux.Handle("POST /groups/{id}/members/{userID}/delete", assertAdmin(http.HandlerFunc(h.postDeleteGroupMember)))
+
 	h.mux.Handle("GET /public-shares", assertAdmin(http.HandlerFunc(h.getPublicSharesPage)))
 	h.mux.Handle("GET /public-shares/new", assertAdmin(http.HandlerFunc(h.getNewPublicSharePage)))
 	h.mux.Handle("POST /public-shares", assertAdmin(http.HandlerFunc(h.postPublicShare)))
@@ -74,6 +88,7 @@ func NewHandler(userStore port.UserStore, documentStore port.DocumentStore, publ
 
 	// Actions
 	h.mux.Handle("POST /tasks/reindex", assertAdmin(http.HandlerFunc(h.postReindexCollection)))
+	h.mux.Handle("POST /tasks/check-consistency", assertAdmin(http.HandlerFunc(h.postCheckConsistency)))
 
 	// Filesystem source routes
 	// NOTE: literal paths must come before {id} wildcard routes
@@ -299,6 +299,39 @@ func (h *Handler) postReindexCollection(w http.ResponseWriter, r *http.Request)
 	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
 }
 
+func (h *Handler) postCheckConsistency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	user := httpCtx.User(ctx)
+	if user == nil {
+		common.HandleError(w, r, errors.New("could not retrieve user from context"))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		common.HandleError(w, r, errors.Wrap(err, "could not parse form"))
+		return
+	}
+
+	// An empty collection checks all collections
+	collections := make([]model.CollectionID, 0)
+	if collectionID := r.Form.Get("collection_id"); collectionID != "" {
+		collections = append(collections, model.CollectionID(collectionID))
+	}
+
+	repair := r.Form.Get("repair") == "true"
+
+	taskID, err := h.documentManager.CheckConsistency(ctx, user, repair, collections...)
+	if err != nil {
+		common.HandleError(w, r, errors.Wrap(err, "could not schedule consistency check task"))
+		return
+	}
+
+	// Redirect to the task page
+	redirectURL := commonComp.BaseURL(r.Context(), commonComp.WithPath("/admin/tasks", string(taskID)))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
 func (h *Handler) postCancelTask(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
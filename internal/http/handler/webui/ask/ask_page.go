@@ -10,11 +10,13 @@ import (
 	"github.com/a-h/templ"
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/corpus/internal/core/service"
 	httpCtx "github.com/bornholm/corpus/internal/http/context"
 	"github.com/bornholm/corpus/internal/http/handler/webui/ask/component"
 	"github.com/bornholm/corpus/internal/http/handler/webui/common"
 	commonComp "github.com/bornholm/corpus/internal/http/handler/webui/common/component"
 	"github.com/bornholm/corpus/internal/http/middleware/authz"
+	"github.com/bornholm/corpus/internal/http/sse"
 	corpusLLM "github.com/bornholm/corpus/internal/llm"
 	"github.com/bornholm/go-x/slogx"
 
@@ -67,23 +69,128 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 		}
 	})
 
-	collections, err := h.getReadableCollections(ctx, rawSelectedCollections)
-	if err != nil {
+	// Ensures the selected collections are readable, the retrieval being then
+	// restricted to them by the conversation
+	if _, err := h.getReadableCollections(ctx, rawSelectedCollections); err != nil {
 		common.HandleError(w, r, errors.WithStack(err))
 		return
 	}
 
-	result, err := h.documentManager.AskWithRetrieval(ctx, vmodel.Query, collections)
+	if vmodel.Conversation == nil {
+		if err := h.startConversation(ctx, vmodel); err != nil {
+			common.HandleError(w, r, errors.WithStack(err))
+			return
+		}
+
+		if err := h.fillAskPageVModelConversations(ctx, vmodel, r); err != nil {
+			common.HandleError(w, r, errors.WithStack(err))
+			return
+		}
+	}
+
+	result, err := h.conversationManager.Ask(ctx, vmodel.Conversation, vmodel.Query)
 	if err != nil {
 		if corpusLLM.IsRateLimit(err) {
 			common.HandleError(w, r, common.NewError(err.Error(), "Service surchargé. Veuillez réessayer ultérieurement.", http.StatusServiceUnavailable))
 			return
 		}
 
+		common.HandleError(w, r, errors.WithStack(askError(err)))
+		return
+	}
+
+	fillAskPageVModelResult(ctx, vmodel, result.AskResult)
+
+	renderPage()
+}
+
+// handleAskStream streams the answer to the question given in the query
+// string, the results block of the page being rendered once the sections are
+// retrieved and once the answer is complete
+func (h *Handler) handleAskStream(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	vmodel := &component.AskPageVModel{}
+
+	ctx := r.Context()
+
+	err := common.FillViewModel(
+		ctx,
+		vmodel, r,
+		h.fillAskPageVModelQuery,
+		h.fillAskPageVModelSelectedCollectionIDs,
+		h.fillAskPageVModelConversation,
+	)
+	if err != nil {
 		common.HandleError(w, r, errors.WithStack(err))
 		return
 	}
 
+	if vmodel.Query == "" {
+		common.HandleError(w, r, common.NewHTTPError(http.StatusBadRequest))
+		return
+	}
+
+	vmodel.Submitted = true
+
+	ctx = corpusLLM.WithHighPriority(ctx)
+
+	ctx = slogx.WithAttrs(ctx, slog.String("origin", "webui"))
+
+	rawSelectedCollections := slices.Collect(func(yield func(string) bool) {
+		for _, id := range vmodel.SelectedCollections {
+			if !yield(string(id)) {
+				return
+			}
+		}
+	})
+
+	// Ensures the selected collections are readable, the retrieval being then
+	// restricted to them by the conversation
+	if _, err := h.getReadableCollections(ctx, rawSelectedCollections); err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if vmodel.Conversation == nil {
+		if err := h.startConversation(ctx, vmodel); err != nil {
+			common.HandleError(w, r, errors.WithStack(err))
+			return
+		}
+
+		if err := h.fillAskPageVModelConversations(ctx, vmodel, r); err != nil {
+			common.HandleError(w, r, errors.WithStack(err))
+			return
+		}
+
+		vmodel.RefreshConversations = true
+	}
+
+	events := sse.NewWriter(w)
+
+	render := func(ctx context.Context, event service.AskEvent) templ.Component {
+		switch event.Type {
+		case service.AskEventResults:
+			vmodel.Results = event.Results
+			vmodel.Streaming = true
+
+		case service.AskEventDone:
+			fillAskPageVModelResult(ctx, vmodel, event.Result)
+			vmodel.Streaming = false
+			vmodel.Duration = time.Since(start)
+		}
+
+		return component.AskResults(*vmodel)
+	}
+
+	if _, err := h.conversationManager.AskStream(ctx, vmodel.Conversation, vmodel.Query, common.NewAskStreamFunc(events, render)); err != nil {
+		common.SendAskStreamError(ctx, events, errors.WithStack(err))
+	}
+}
+
+// fillAskPageVModelResult sets the response of the page, its citations and its
+// sources
+func fillAskPageVModelResult(ctx context.Context, vmodel *component.AskPageVModel, result *service.AskResult) {
 	vmodel.Results = result.Results
 
 	if result.Grounding != nil {
@@ -97,9 +204,10 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 	if len(result.Results) > 0 {
 		vmodel.Response = result.Answer
 		vmodel.SectionContents = result.Contents
+		vmodel.Citations = common.NewCitationVModels(result.Citations, func(c service.Citation) string {
+			return string(commonComp.BaseURL(ctx, commonComp.WithPath("/api/v1/documents", string(c.DocumentID), "sections", string(c.SectionID), "content")))
+		})
 	}
-
-	renderPage()
 }
 
 func (h *Handler) fillAskPageViewModel(r *http.Request) (*component.AskPageVModel, error) {
@@ -113,6 +221,8 @@ func (h *Handler) fillAskPageViewModel(r *http.Request) (*component.AskPageVMode
 		h.fillAskPageVModelTotalDocuments,
 		h.fillAskPageVModelQuery,
 		h.fillAskPageVModelSelectedCollectionIDs,
+		h.fillAskPageVModelConversation,
+		h.fillAskPageVModelConversations,
 		h.fillAskPageVModelCollections,
 		h.fillAskPageVModelAppLayout,
 	)
@@ -153,26 +263,41 @@ func (h *Handler) fillAskPageVModelQuery(ctx context.Context, vmodel *component.
 }
 
 func (h *Handler) fillAskPageVModelCollections(ctx context.Context, vmodel *component.AskPageVModel, r *http.Request) error {
+	collections, stats, err := h.listReadableCollections(ctx, vmodel.SelectedCollections)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Collections = collections
+	vmodel.CollectionStats = stats
+
+	return nil
+}
+
+// listReadableCollections returns the collections readable by the current
+// user and their stats, the selected ones first then by descending number of
+// documents.
+func (h *Handler) listReadableCollections(ctx context.Context, selected []model.CollectionID) ([]model.PersistedCollection, map[model.CollectionID]*model.CollectionStats, error) {
 	user := httpCtx.User(ctx)
 	collections, _, err := h.documentManager.DocumentStore.QueryUserReadableCollections(ctx, user.ID(), port.QueryCollectionsOptions{})
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, nil, errors.WithStack(err)
 	}
 
-	vmodel.CollectionStats = make(map[model.CollectionID]*model.CollectionStats)
+	collectionStats := make(map[model.CollectionID]*model.CollectionStats)
 
 	for _, c := range collections {
 		stats, err := h.documentManager.DocumentStore.GetCollectionStats(ctx, c.ID())
 		if err != nil {
-			return errors.WithStack(err)
+			return nil, nil, errors.WithStack(err)
 		}
 
-		vmodel.CollectionStats[c.ID()] = stats
+		collectionStats[c.ID()] = stats
 	}
 
 	slices.SortFunc(collections, func(c1, c2 model.PersistedCollection) int {
-		selected1 := slices.Contains(vmodel.SelectedCollections, c1.ID())
-		selected2 := slices.Contains(vmodel.SelectedCollections, c2.ID())
+		selected1 := slices.Contains(selected, c1.ID())
+		selected2 := slices.Contains(selected, c2.ID())
 
 		if selected1 && !selected2 {
 			return -1
@@ -181,15 +306,13 @@ func (h *Handler) fillAskPageVModelCollections(ctx context.Context, vmodel *comp
 			return 1
 		}
 
-		stats1 := vmodel.CollectionStats[c1.ID()]
-		stats2 := vmodel.CollectionStats[c2.ID()]
+		stats1 := collectionStats[c1.ID()]
+		stats2 := collectionStats[c2.ID()]
 
 		return int(stats2.TotalDocuments - stats1.TotalDocuments)
 	})
 
-	vmodel.Collections = collections
-
-	return nil
+	return collections, collectionStats, nil
 }
 
 func (h *Handler) fillAskPageVModelSelectedCollectionIDs(ctx context.Context, vmodel *component.AskPageVModel, r *http.Request) error {
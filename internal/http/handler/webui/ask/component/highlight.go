@@ -0,0 +1,37 @@
+package component
+
+import (
+	"github.com/bornholm/corpus/pkg/port"
+)
+
+type highlightSegment struct {
+	Text  string
+	Match bool
+}
+
+// highlightSegments splits the highlight fragment in alternating unmatched
+// and matched segments.
+func highlightSegments(h *port.IndexSearchHighlight) []highlightSegment {
+	segments := make([]highlightSegment, 0, len(h.Terms)*2+1)
+
+	offset := 0
+	for _, t := range h.Terms {
+		if t.Start < offset || t.End > len(h.Fragment) || t.Start >= t.End {
+			continue
+		}
+
+		if t.Start > offset {
+			segments = append(segments, highlightSegment{Text: h.Fragment[offset:t.Start]})
+		}
+
+		segments = append(segments, highlightSegment{Text: h.Fragment[t.Start:t.End], Match: true})
+
+		offset = t.End
+	}
+
+	if offset < len(h.Fragment) {
+		segments = append(segments, highlightSegment{Text: h.Fragment[offset:]})
+	}
+
+	return segments
+}
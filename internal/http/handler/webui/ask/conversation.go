@@ -0,0 +1,145 @@
+package ask
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/core/service"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/handler/webui/ask/component"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	"github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxConversationTitleWords = 12
+	maxListedConversations    = 20
+)
+
+func (h *Handler) handleDeleteConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	conversation, err := h.getUserConversation(ctx, model.ConversationID(r.PathValue("conversationID")))
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+			return
+		}
+
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if err := h.conversationManager.DeleteConversation(ctx, conversation.ID()); err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// fillAskPageVModelConversation loads the conversation designated by the
+// request and its turns
+func (h *Handler) fillAskPageVModelConversation(ctx context.Context, vmodel *component.AskPageVModel, r *http.Request) error {
+	rawConversationID := r.URL.Query().Get("conversation")
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err == nil && r.FormValue("conversation") != "" {
+			rawConversationID = r.FormValue("conversation")
+		}
+	}
+
+	if rawConversationID == "" {
+		return nil
+	}
+
+	conversation, err := h.getUserConversation(ctx, model.ConversationID(rawConversationID))
+	if err != nil {
+		// The conversation may have been deleted in the meantime, a new one
+		// will be started
+		if errors.Is(err, port.ErrNotFound) {
+			return nil
+		}
+
+		return errors.WithStack(err)
+	}
+
+	turns, err := h.conversationManager.GetConversationTurns(ctx, conversation.ID())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Conversation = conversation
+	vmodel.Turns = turns
+
+	if len(conversation.Collections()) > 0 {
+		vmodel.SelectedCollections = conversation.Collections()
+	}
+
+	return nil
+}
+
+func (h *Handler) fillAskPageVModelConversations(ctx context.Context, vmodel *component.AskPageVModel, r *http.Request) error {
+	user := httpCtx.User(ctx)
+
+	limit := maxListedConversations
+
+	conversations, _, err := h.conversationManager.QueryUserConversations(ctx, user.ID(), port.QueryConversationsOptions{
+		Limit: &limit,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Conversations = conversations
+
+	return nil
+}
+
+// startConversation creates a new conversation for the question of the page,
+// restricted to the selected collections
+func (h *Handler) startConversation(ctx context.Context, vmodel *component.AskPageVModel) error {
+	user := httpCtx.User(ctx)
+
+	title := text.Truncate(strings.TrimSpace(vmodel.Query), maxConversationTitleWords)
+
+	conversation, err := h.conversationManager.SaveConversation(ctx, model.NewConversation(user, title, vmodel.SelectedCollections...))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Conversation = conversation
+	vmodel.RefreshConversations = true
+
+	return nil
+}
+
+// getUserConversation returns the conversation if it is owned by the current
+// user, or port.ErrNotFound
+func (h *Handler) getUserConversation(ctx context.Context, conversationID model.ConversationID) (model.PersistedConversation, error) {
+	user := httpCtx.User(ctx)
+
+	conversation, err := h.conversationManager.GetConversationByID(ctx, conversationID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if conversation.Owner().ID() != user.ID() {
+		return nil, errors.WithStack(common.NewHTTPError(http.StatusForbidden))
+	}
+
+	return conversation, nil
+}
+
+// askError returns the error displayed to the user when a question could not
+// be answered
+func askError(err error) error {
+	if errors.Is(err, service.ErrNoReadableCollection) {
+		return common.NewError(err.Error(), "Vous n'avez plus accès aux collections de cette conversation.", http.StatusForbidden)
+	}
+
+	return err
+}
@@ -9,9 +9,10 @@ import (
 )
 
 type Handler struct {
-	mux             *http.ServeMux
-	documentManager *service.DocumentManager
-	llm             llm.Client
+	mux                 *http.ServeMux
+	documentManager     *service.DocumentManager
+	conversationManager *service.ConversationManager
+	llm                 llm.Client
 }
 
 // ServeHTTP implements http.Handler.
@@ -19,17 +20,21 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.mux.ServeHTTP(w, r)
 }
 
-func NewHandler(documentManager *service.DocumentManager, llm llm.Client) *Handler {
+func NewHandler(documentManager *service.DocumentManager, conversationManager *service.ConversationManager, llm llm.Client) *Handler {
 	h := &Handler{
-		mux:             http.NewServeMux(),
-		documentManager: documentManager,
-		llm:             llm,
+		mux:                 http.NewServeMux(),
+		documentManager:     documentManager,
+		conversationManager: conversationManager,
+		llm:                 llm,
 	}
 
 	assertUser := authz.Middleware(http.HandlerFunc(h.getForbiddenPage), authz.OneOf(authz.Has(authz.RoleUser), authz.Has(authz.RoleAdmin)))
 
 	h.mux.Handle("GET /", assertUser(http.HandlerFunc(h.getAskPage)))
 	h.mux.Handle("POST /", assertUser(http.HandlerFunc(h.handleAsk)))
+	h.mux.Handle("GET /stream", assertUser(http.HandlerFunc(h.handleAskStream)))
+	h.mux.Handle("DELETE /conversations/{conversationID}", assertUser(http.HandlerFunc(h.handleDeleteConversation)))
+	h.mux.Handle("GET /search", assertUser(http.HandlerFunc(h.getSearchPage)))
 
 	return h
 }
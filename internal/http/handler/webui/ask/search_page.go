@@ -0,0 +1,136 @@
+package ask
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/bornholm/corpus/internal/core/service"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/handler/webui/ask/component"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	commonComp "github.com/bornholm/corpus/internal/http/handler/webui/common/component"
+	"github.com/bornholm/corpus/internal/http/middleware/authz"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+const searchPageMaxResults = 10
+
+func (h *Handler) getSearchPage(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	vmodel, err := h.fillSearchPageViewModel(r)
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if vmodel.Query != "" {
+		ctx := slogx.WithAttrs(r.Context(), slog.String("origin", "webui"))
+
+		rawSelectedCollections := slices.Collect(func(yield func(string) bool) {
+			for _, id := range vmodel.SelectedCollections {
+				if !yield(string(id)) {
+					return
+				}
+			}
+		})
+
+		collections, err := h.getReadableCollections(ctx, rawSelectedCollections)
+		if err != nil {
+			common.HandleError(w, r, errors.WithStack(err))
+			return
+		}
+
+		results, err := h.documentManager.Search(ctx, vmodel.Query,
+			service.WithDocumentManagerSearchCollections(collections...),
+			service.WithDocumentManagerSearchMaxResults(searchPageMaxResults),
+			service.WithDocumentManagerSearchHighlight(true),
+		)
+		if err != nil {
+			common.HandleError(w, r, errors.WithStack(err))
+			return
+		}
+
+		vmodel.Submitted = true
+		vmodel.Results = results
+		vmodel.Duration = time.Since(start)
+	}
+
+	searchPage := component.SearchPage(*vmodel)
+
+	templ.Handler(searchPage).ServeHTTP(w, r)
+}
+
+func (h *Handler) fillSearchPageViewModel(r *http.Request) (*component.SearchPageVModel, error) {
+	vmodel := &component.SearchPageVModel{}
+
+	ctx := r.Context()
+
+	err := common.FillViewModel(
+		ctx,
+		vmodel, r,
+		h.fillSearchPageVModelQuery,
+		h.fillSearchPageVModelCollections,
+		h.fillSearchPageVModelAppLayout,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return vmodel, nil
+}
+
+func (h *Handler) fillSearchPageVModelQuery(ctx context.Context, vmodel *component.SearchPageVModel, r *http.Request) error {
+	vmodel.Query = r.URL.Query().Get("q")
+
+	vmodel.SelectedCollections = slices.Collect(func(yield func(model.CollectionID) bool) {
+		for _, rawCollectionID := range r.URL.Query()["collection"] {
+			if !yield(model.CollectionID(rawCollectionID)) {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (h *Handler) fillSearchPageVModelCollections(ctx context.Context, vmodel *component.SearchPageVModel, r *http.Request) error {
+	collections, stats, err := h.listReadableCollections(ctx, vmodel.SelectedCollections)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Collections = collections
+	vmodel.CollectionStats = stats
+
+	return nil
+}
+
+func (h *Handler) fillSearchPageVModelAppLayout(ctx context.Context, vmodel *component.SearchPageVModel, r *http.Request) error {
+	user := httpCtx.User(ctx)
+	if user == nil {
+		return errors.New("could not retrieve user from context")
+	}
+
+	isAdmin := slices.Contains(user.Roles(), authz.RoleAdmin)
+
+	vmodel.AppLayoutVModel = commonComp.AppLayoutVModel{
+		User:         user,
+		IsAdmin:      isAdmin,
+		SelectedItem: "search",
+		NavigationItems: func(vmodel commonComp.AppLayoutVModel) templ.Component {
+			return commonComp.AppNavigationItems(vmodel)
+		},
+		FooterItems: func(vmodel commonComp.AppLayoutVModel) templ.Component {
+			return commonComp.AppFooterItems(vmodel)
+		},
+	}
+
+	return nil
+}
@@ -45,27 +45,19 @@ func (h *Handler) handleCollectionDelete(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	slog.InfoContext(ctx, "deleting collection",
+	slog.InfoContext(ctx, "moving collection to the trash",
 		slog.String("collection_id", string(collectionID)),
 		slog.String("collection_label", collection.Label()),
 		slog.String("user_id", string(user.ID())))
 
-	// Delete the collection (this will cascade delete the documents via GORM)
-	if err := h.documentManager.DocumentStore.DeleteCollection(ctx, collectionID); err != nil {
+	// The collection and its documents are permanently deleted, with their
+	// index entries, when the trash is purged
+	if err := h.documentManager.DocumentStore.TrashCollection(ctx, collectionID); err != nil {
 		common.HandleError(w, r, errors.WithStack(err))
 		return
 	}
 
-	// Schedule index cleanup to remove orphaned entries
-	// This is done asynchronously to avoid blocking the user
-	if _, err := h.documentManager.CleanupIndex(ctx, user); err != nil {
-		slog.ErrorContext(ctx, "failed to schedule index cleanup after collection deletion",
-			slog.String("collection_id", string(collectionID)),
-			slog.Any("error", err))
-		// Don't fail the request if cleanup scheduling fails
-	}
-
-	slog.InfoContext(ctx, "collection deleted successfully",
+	slog.InfoContext(ctx, "collection moved to the trash",
 		slog.String("collection_id", string(collectionID)))
 
 	// Redirect back to collections list
@@ -107,13 +99,12 @@ func (h *Handler) handleDocumentDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.InfoContext(ctx, "deleting document",
+	slog.InfoContext(ctx, "moving document to the trash",
 		slog.String("collection_id", string(collectionID)),
 		slog.String("document_id", string(documentID)),
 		slog.String("user_id", string(user.ID())))
 
-	// Delete the document
-	if err := h.documentManager.DocumentStore.DeleteDocumentByID(ctx, documentID); err != nil {
+	if err := h.documentManager.DocumentStore.TrashDocumentByID(ctx, documentID); err != nil {
 		if errors.Is(err, port.ErrNotFound) {
 			common.HandleError(w, r, errors.New("document not found"))
 			return
@@ -122,7 +113,7 @@ func (h *Handler) handleDocumentDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slog.InfoContext(ctx, "document deleted successfully",
+	slog.InfoContext(ctx, "document moved to the trash",
 		slog.String("document_id", string(documentID)))
 
 	// Redirect back to collection edit page
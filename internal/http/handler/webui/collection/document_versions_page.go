@@ -0,0 +1,186 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/handler/webui/collection/component"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	commonComp "github.com/bornholm/corpus/internal/http/handler/webui/common/component"
+	"github.com/pkg/errors"
+)
+
+func (h *Handler) getDocumentVersionsPage(w http.ResponseWriter, r *http.Request) {
+	vmodel, err := h.fillDocumentVersionsPageViewModel(r)
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	versionsPage := component.DocumentVersionsPage(*vmodel)
+
+	templ.Handler(versionsPage).ServeHTTP(w, r)
+}
+
+func (h *Handler) handleDocumentRollback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collectionID := model.CollectionID(r.PathValue("collectionID"))
+	documentID := model.DocumentID(r.PathValue("docID"))
+	versionID := model.DocumentVersionID(r.PathValue("versionID"))
+
+	user := httpCtx.User(ctx)
+	if user == nil {
+		common.HandleError(w, r, errors.New("could not retrieve user from context"))
+		return
+	}
+
+	canWrite, err := h.documentManager.DocumentStore.CanWriteDocument(ctx, user.ID(), documentID)
+	if err != nil {
+		common.HandleError(w, r, errors.Wrap(err, "could not check document access"))
+		return
+	}
+
+	if !canWrite {
+		common.HandleError(w, r, common.NewError("document not writable", "Vous n'avez pas la permission de restaurer ce document.", http.StatusForbidden))
+		return
+	}
+
+	taskID, err := h.documentManager.RollbackDocument(ctx, user, documentID, versionID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewError(err.Error(), "La version n'a pas pu être trouvée.", http.StatusNotFound))
+			return
+		}
+
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "document rollback scheduled",
+		slog.String("document_id", string(documentID)),
+		slog.String("version_id", string(versionID)),
+		slog.String("task_id", string(taskID)))
+
+	baseURL := httpCtx.BaseURL(ctx)
+
+	taskURL := baseURL.JoinPath(fmt.Sprintf("/collections/%s/tasks/%s", collectionID, taskID))
+
+	http.Redirect(w, r, taskURL.String(), http.StatusSeeOther)
+}
+
+func (h *Handler) fillDocumentVersionsPageViewModel(r *http.Request) (*component.DocumentVersionsPageVModel, error) {
+	vmodel := &component.DocumentVersionsPageVModel{}
+
+	ctx := r.Context()
+
+	err := common.FillViewModel(
+		ctx,
+		vmodel, r,
+		h.fillDocumentVersionsPageVModelDocument,
+		h.fillDocumentVersionsPageVModelVersions,
+		h.fillDocumentVersionsPageVModelDiff,
+		h.fillDocumentVersionsPageVModelAppLayout,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return vmodel, nil
+}
+
+func (h *Handler) fillDocumentVersionsPageVModelDocument(ctx context.Context, vmodel *component.DocumentVersionsPageVModel, r *http.Request) error {
+	documentID := model.DocumentID(r.PathValue("docID"))
+
+	user := httpCtx.User(ctx)
+	if user == nil {
+		return errors.New("could not retrieve user from context")
+	}
+
+	canRead, err := h.documentManager.DocumentStore.CanReadDocument(ctx, user.ID(), documentID)
+	if err != nil && !errors.Is(err, port.ErrNotFound) {
+		return errors.WithStack(err)
+	}
+
+	if !canRead {
+		return errors.WithStack(common.NewError("document not readable", "Le document n'a pas pu être trouvé.", http.StatusNotFound))
+	}
+
+	document, err := h.documentManager.DocumentStore.GetDocumentByID(ctx, documentID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			return errors.WithStack(common.NewError(err.Error(), "Le document n'a pas pu être trouvé.", http.StatusNotFound))
+		}
+
+		return errors.WithStack(err)
+	}
+
+	canWrite, err := h.documentManager.DocumentStore.CanWriteDocument(ctx, user.ID(), documentID)
+	if err != nil && !errors.Is(err, port.ErrNotFound) {
+		return errors.WithStack(err)
+	}
+
+	vmodel.CollectionID = model.CollectionID(r.PathValue("collectionID"))
+	vmodel.Document = document
+	vmodel.IsWritable = canWrite
+
+	return nil
+}
+
+func (h *Handler) fillDocumentVersionsPageVModelVersions(ctx context.Context, vmodel *component.DocumentVersionsPageVModel, r *http.Request) error {
+	versions, _, err := h.documentManager.DocumentStore.QueryDocumentVersions(ctx, vmodel.Document.ID(), port.QueryDocumentVersionsOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Versions = versions
+
+	return nil
+}
+
+func (h *Handler) fillDocumentVersionsPageVModelDiff(ctx context.Context, vmodel *component.DocumentVersionsPageVModel, r *http.Request) error {
+	versionID := model.DocumentVersionID(r.URL.Query().Get("version"))
+	if versionID == "" {
+		return nil
+	}
+
+	diff, err := h.documentManager.DocumentVersionDiff(ctx, vmodel.Document.ID(), versionID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			return errors.WithStack(common.NewError(err.Error(), "La version n'a pas pu être trouvée.", http.StatusNotFound))
+		}
+
+		return errors.WithStack(err)
+	}
+
+	vmodel.SelectedVersion = versionID
+	vmodel.Diff = diff
+
+	return nil
+}
+
+func (h *Handler) fillDocumentVersionsPageVModelAppLayout(ctx context.Context, vmodel *component.DocumentVersionsPageVModel, r *http.Request) error {
+	user := httpCtx.User(ctx)
+	if user == nil {
+		return errors.New("could not retrieve user from context")
+	}
+
+	vmodel.AppLayoutVModel = commonComp.AppLayoutVModel{
+		User:         user,
+		SelectedItem: "collections",
+		NavigationItems: func(vmodel commonComp.AppLayoutVModel) templ.Component {
+			return commonComp.AppNavigationItems(vmodel)
+		},
+		FooterItems: func(vmodel commonComp.AppLayoutVModel) templ.Component {
+			return commonComp.AppFooterItems(vmodel)
+		},
+	}
+
+	return nil
+}
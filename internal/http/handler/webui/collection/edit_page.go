@@ -76,6 +76,16 @@ func (h *Handler) handleCollectionUpdate(w http.ResponseWriter, r *http.Request)
 		Description: &description,
 	}
 
+	if rawVersionRetention := r.FormValue("version_retention"); rawVersionRetention != "" {
+		versionRetention, err := strconv.Atoi(rawVersionRetention)
+		if err != nil || versionRetention < 0 {
+			common.HandleError(w, r, common.NewError("invalid version retention", "Le nombre de versions conservées doit être un entier positif ou nul.", http.StatusBadRequest))
+			return
+		}
+
+		updates.VersionRetention = &versionRetention
+	}
+
 	_, err = h.documentManager.DocumentStore.UpdateCollection(ctx, collectionID, updates)
 	if err != nil {
 		common.HandleError(w, r, errors.WithStack(err))
@@ -123,8 +133,9 @@ func (h *Handler) handleCollectionShareCreate(w http.ResponseWriter, r *http.Req
 	}
 
 	targetUserID := model.UserID(r.FormValue("user_id"))
-	if targetUserID == "" {
-		common.HandleError(w, r, errors.New("user_id is required"))
+	targetGroupID := model.GroupID(r.FormValue("group_id"))
+	if (targetUserID == "") == (targetGroupID == "") {
+		common.HandleError(w, r, errors.New("either user_id or group_id is required"))
 		return
 	}
 
@@ -140,7 +151,11 @@ func (h *Handler) handleCollectionShareCreate(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	_, err = h.documentManager.DocumentStore.CreateCollectionShare(ctx, collectionID, targetUserID, level)
+	if targetGroupID != "" {
+		_, err = h.documentManager.DocumentStore.CreateCollectionGroupShare(ctx, collectionID, targetGroupID, level)
+	} else {
+		_, err = h.documentManager.DocumentStore.CreateCollectionShare(ctx, collectionID, targetUserID, level)
+	}
 	if err != nil {
 		common.HandleError(w, r, errors.WithStack(err))
 		return
@@ -149,6 +164,7 @@ func (h *Handler) handleCollectionShareCreate(w http.ResponseWriter, r *http.Req
 	slog.InfoContext(ctx, "collection share created",
 		slog.String("collection_id", string(collectionID)),
 		slog.String("shared_with_user_id", string(targetUserID)),
+		slog.String("shared_with_group_id", string(targetGroupID)),
 		slog.String("level", string(level)),
 	)
 
@@ -281,8 +297,14 @@ func (h *Handler) fillCollectionEditPageVModelShares(ctx context.Context, vmodel
 	if user != nil {
 		sharedUserIDs[user.ID()] = struct{}{} // exclude owner
 	}
+	sharedGroupIDs := make(map[model.GroupID]struct{})
 	for _, s := range shares {
-		sharedUserIDs[s.SharedWith().ID()] = struct{}{}
+		if sharedWith := s.SharedWith(); sharedWith != nil {
+			sharedUserIDs[sharedWith.ID()] = struct{}{}
+		}
+		if group := s.SharedWithGroup(); group != nil {
+			sharedGroupIDs[group.ID()] = struct{}{}
+		}
 	}
 
 	// Fetch all active users to offer as potential share targets
@@ -300,6 +322,23 @@ func (h *Handler) fillCollectionEditPageVModelShares(ctx context.Context, vmodel
 	}
 	vmodel.AvailableUsers = available
 
+	// Fetch all groups to offer as potential share targets
+	groups, _, err := h.groupStore.QueryGroups(ctx, port.QueryGroupsOptions{
+		HeaderOnly: true,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "could not fetch groups for share form", slog.Any("error", err))
+		return nil
+	}
+
+	availableGroups := make([]model.Group, 0)
+	for _, g := range groups {
+		if _, excluded := sharedGroupIDs[g.ID()]; !excluded {
+			availableGroups = append(availableGroups, g)
+		}
+	}
+	vmodel.AvailableGroups = availableGroups
+
 	return nil
 }
 
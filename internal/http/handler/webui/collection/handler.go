@@ -12,6 +12,7 @@ type Handler struct {
 	mux             *http.ServeMux
 	documentManager *service.DocumentManager
 	userStore       port.UserStore
+	groupStore      port.GroupStore
 	taskRunner      port.TaskRunner
 }
 
@@ -20,11 +21,12 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.mux.ServeHTTP(w, r)
 }
 
-func NewHandler(documentManager *service.DocumentManager, userStore port.UserStore, taskRunner port.TaskRunner) *Handler {
+func NewHandler(documentManager *service.DocumentManager, userStore port.UserStore, groupStore port.GroupStore, taskRunner port.TaskRunner) *Handler {
 	h := &Handler{
 		mux:             http.NewServeMux(),
 		documentManager: documentManager,
 		userStore:       userStore,
+		groupStore:      groupStore,
 		taskRunner:      taskRunner,
 	}
 
@@ -33,12 +35,17 @@ func NewHandler(documentManager *service.DocumentManager, userStore port.UserSto
 	h.mux.Handle("GET /", assertUser(http.HandlerFunc(h.getCollectionListPage)))
 	h.mux.Handle("GET /new", assertUser(http.HandlerFunc(h.getCollectionCreatePage)))
 	h.mux.Handle("POST /new", assertUser(http.HandlerFunc(h.handleCollectionCreate)))
+	h.mux.Handle("GET /trash", assertUser(http.HandlerFunc(h.getTrashPage)))
+	h.mux.Handle("POST /trash/documents/{docID}/restore", assertUser(http.HandlerFunc(h.handleTrashedDocumentRestore)))
+	h.mux.Handle("POST /trash/{collectionID}/restore", assertUser(http.HandlerFunc(h.handleTrashedCollectionRestore)))
 	h.mux.Handle("GET /{collectionID}/edit", assertUser(http.HandlerFunc(h.getCollectionEditPage)))
 	h.mux.Handle("POST /{collectionID}/edit", assertUser(http.HandlerFunc(h.handleCollectionUpdate)))
 	h.mux.Handle("DELETE /{collectionID}", assertUser(http.HandlerFunc(h.handleCollectionDelete)))
 	h.mux.Handle("POST /{collectionID}/shares", assertUser(http.HandlerFunc(h.handleCollectionShareCreate)))
 	h.mux.Handle("DELETE /{collectionID}/shares/{shareID}", assertUser(http.HandlerFunc(h.handleCollectionShareDelete)))
 	h.mux.Handle("DELETE /{collectionID}/documents/{docID}", assertUser(http.HandlerFunc(h.handleDocumentDelete)))
+	h.mux.Handle("GET /{collectionID}/documents/{docID}/versions", assertUser(http.HandlerFunc(h.getDocumentVersionsPage)))
+	h.mux.Handle("POST /{collectionID}/documents/{docID}/versions/{versionID}/rollback", assertUser(http.HandlerFunc(h.handleDocumentRollback)))
 
 	h.mux.Handle("POST /{collectionID}/index", assertUser(http.HandlerFunc(h.handleIndex)))
 	h.mux.Handle("GET /{collectionID}/tasks/{taskID}", assertUser(http.HandlerFunc(h.getTaskPage)))
@@ -0,0 +1,183 @@
+package collection
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/handler/webui/collection/component"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	commonComp "github.com/bornholm/corpus/internal/http/handler/webui/common/component"
+	"github.com/pkg/errors"
+)
+
+const trashPageLimit = 50
+
+func (h *Handler) getTrashPage(w http.ResponseWriter, r *http.Request) {
+	vmodel, err := h.fillTrashPageViewModel(r)
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	trashPage := component.TrashPage(*vmodel)
+
+	templ.Handler(trashPage).ServeHTTP(w, r)
+}
+
+func (h *Handler) handleTrashedCollectionRestore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collectionID := model.CollectionID(r.PathValue("collectionID"))
+
+	user := httpCtx.User(ctx)
+	if user == nil {
+		common.HandleError(w, r, errors.New("could not retrieve user from context"))
+		return
+	}
+
+	userID := user.ID()
+
+	// Only the owner of a trashed collection can restore it
+	_, total, err := h.documentManager.DocumentStore.QueryTrashedCollections(ctx, port.QueryTrashedCollectionsOptions{
+		IDs:     []model.CollectionID{collectionID},
+		OwnerID: &userID,
+	})
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if total == 0 {
+		common.HandleError(w, r, common.NewError("trashed collection not found", "La collection n'a pas pu être trouvée dans la corbeille.", http.StatusNotFound))
+		return
+	}
+
+	if err := h.documentManager.DocumentStore.RestoreCollection(ctx, collectionID); err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "collection restored",
+		slog.String("collection_id", string(collectionID)),
+		slog.String("user_id", string(userID)))
+
+	redirectURL := commonComp.BaseURL(ctx, commonComp.WithPath("/collections", string(collectionID), "edit"))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
+func (h *Handler) handleTrashedDocumentRestore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	documentID := model.DocumentID(r.PathValue("docID"))
+
+	user := httpCtx.User(ctx)
+	if user == nil {
+		common.HandleError(w, r, errors.New("could not retrieve user from context"))
+		return
+	}
+
+	userID := user.ID()
+
+	// Only the owner of a trashed document can restore it
+	_, total, err := h.documentManager.DocumentStore.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{
+		IDs:     []model.DocumentID{documentID},
+		OwnerID: &userID,
+	})
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	if total == 0 {
+		common.HandleError(w, r, common.NewError("trashed document not found", "Le document n'a pas pu être trouvé dans la corbeille.", http.StatusNotFound))
+		return
+	}
+
+	if err := h.documentManager.DocumentStore.RestoreDocumentByID(ctx, documentID); err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	slog.InfoContext(ctx, "document restored",
+		slog.String("document_id", string(documentID)),
+		slog.String("user_id", string(userID)))
+
+	redirectURL := commonComp.BaseURL(ctx, commonComp.WithPath("/collections/trash"))
+	http.Redirect(w, r, string(redirectURL), http.StatusSeeOther)
+}
+
+func (h *Handler) fillTrashPageViewModel(r *http.Request) (*component.TrashPageVModel, error) {
+	vmodel := &component.TrashPageVModel{}
+
+	ctx := r.Context()
+
+	err := common.FillViewModel(
+		ctx,
+		vmodel, r,
+		h.fillTrashPageVModelItems,
+		h.fillTrashPageVModelAppLayout,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return vmodel, nil
+}
+
+func (h *Handler) fillTrashPageVModelItems(ctx context.Context, vmodel *component.TrashPageVModel, r *http.Request) error {
+	user := httpCtx.User(ctx)
+	if user == nil {
+		return errors.New("could not retrieve user from context")
+	}
+
+	userID := user.ID()
+	limit := trashPageLimit
+
+	collections, totalCollections, err := h.documentManager.DocumentStore.QueryTrashedCollections(ctx, port.QueryTrashedCollectionsOptions{
+		Limit:   &limit,
+		OwnerID: &userID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	documents, totalDocuments, err := h.documentManager.DocumentStore.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{
+		Limit:   &limit,
+		OwnerID: &userID,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	vmodel.Collections = collections
+	vmodel.TotalCollections = totalCollections
+	vmodel.Documents = documents
+	vmodel.TotalDocuments = totalDocuments
+
+	return nil
+}
+
+func (h *Handler) fillTrashPageVModelAppLayout(ctx context.Context, vmodel *component.TrashPageVModel, r *http.Request) error {
+	user := httpCtx.User(ctx)
+	if user == nil {
+		return errors.New("could not retrieve user from context")
+	}
+
+	vmodel.AppLayoutVModel = commonComp.AppLayoutVModel{
+		User:         user,
+		SelectedItem: "collections",
+		NavigationItems: func(vmodel commonComp.AppLayoutVModel) templ.Component {
+			return commonComp.AppNavigationItems(vmodel)
+		},
+		FooterItems: func(vmodel commonComp.AppLayoutVModel) templ.Component {
+			return commonComp.AppFooterItems(vmodel)
+		},
+	}
+
+	return nil
+}
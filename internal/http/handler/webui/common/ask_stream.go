@@ -0,0 +1,72 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+
+	"github.com/a-h/templ"
+	"github.com/bornholm/corpus/internal/core/service"
+	"github.com/bornholm/corpus/internal/http/sse"
+	corpusLLM "github.com/bornholm/corpus/internal/llm"
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+// AskStreamEvent is the payload of the events streamed to the ask pages: the
+// HTML of the results block for the "results" and "done" events, the next
+// fragment of the answer for the "delta" events and the user message for the
+// "error" event
+type AskStreamEvent struct {
+	HTML    string `json:"html,omitempty"`
+	Delta   string `json:"delta,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// AskStreamRenderFunc renders the results block of the ask page for the
+// "results" and "done" events
+type AskStreamRenderFunc func(ctx context.Context, event service.AskEvent) templ.Component
+
+// NewAskStreamFunc returns a function forwarding the events of a streamed
+// answer to the ask page
+func NewAskStreamFunc(events *sse.Writer, render AskStreamRenderFunc) service.AskStreamFunc {
+	return func(ctx context.Context, event service.AskEvent) error {
+		payload := &AskStreamEvent{}
+
+		switch event.Type {
+		case service.AskEventDelta:
+			payload.Delta = event.Delta
+
+		case service.AskEventResults, service.AskEventDone:
+			var buf bytes.Buffer
+			if err := render(ctx, event).Render(ctx, &buf); err != nil {
+				return errors.WithStack(err)
+			}
+
+			payload.HTML = buf.String()
+
+		default:
+			return nil
+		}
+
+		if err := events.Send(string(event.Type), payload); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}
+}
+
+// SendAskStreamError ends a streamed answer which could not be completed
+func SendAskStreamError(ctx context.Context, events *sse.Writer, err error) {
+	slog.ErrorContext(ctx, "could not stream answer", slogx.Error(err))
+
+	message := "Une erreur inattendue est survenue. Veuillez réessayer ultérieurement."
+	if corpusLLM.IsRateLimit(err) {
+		message = "Service surchargé. Veuillez réessayer ultérieurement."
+	}
+
+	if err := events.Send("error", &AskStreamEvent{Message: message}); err != nil {
+		slog.ErrorContext(ctx, "could not send error event", slogx.Error(err))
+	}
+}
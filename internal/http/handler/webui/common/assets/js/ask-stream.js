@@ -0,0 +1,87 @@
+(function () {
+  if (!window.EventSource || window.askStreamLoaded) {
+    return;
+  }
+
+  window.askStreamLoaded = true;
+
+  var defaultErrorMessage = 'Une erreur inattendue est survenue. Veuillez réessayer ultérieurement.';
+
+  function render(target, html) {
+    if (window.htmx) {
+      window.htmx.swap(target, html, { swapStyle: 'innerHTML' });
+    } else {
+      target.innerHTML = html;
+    }
+  }
+
+  function renderError(target, message) {
+    var el = document.createElement('div');
+    el.className = 'flex items-center gap-2 text-red-600 py-4';
+    el.textContent = message;
+    target.replaceChildren(el);
+  }
+
+  // Streams the answer to the forms having a data-ask-stream attribute instead
+  // of submitting them, the results block being rendered in the element
+  // designated by their data-ask-stream-target attribute
+  document.addEventListener('submit', function (evt) {
+    var form = evt.target;
+    if (!form.matches || !form.matches('form[data-ask-stream]')) {
+      return;
+    }
+
+    var target = document.querySelector(form.dataset.askStreamTarget);
+    var params = new URLSearchParams(new FormData(form));
+    if (!target || !params.get('q')) {
+      return;
+    }
+
+    // Prevents htmx from submitting the form
+    evt.preventDefault();
+    evt.stopPropagation();
+
+    var button = form.querySelector('[type="submit"]');
+    if (button) {
+      button.classList.add('htmx-request');
+      button.disabled = true;
+    }
+
+    var source = new EventSource(form.dataset.askStream + '?' + params.toString());
+    var response = null;
+
+    function close() {
+      source.close();
+      if (button) {
+        button.classList.remove('htmx-request');
+        button.disabled = false;
+      }
+    }
+
+    source.addEventListener('results', function (e) {
+      render(target, JSON.parse(e.data).html);
+      response = target.querySelector('[data-ask-stream-response]');
+    });
+
+    source.addEventListener('delta', function (e) {
+      if (response) {
+        response.textContent += JSON.parse(e.data).delta;
+      }
+    });
+
+    source.addEventListener('done', function (e) {
+      close();
+      render(target, JSON.parse(e.data).html);
+    });
+
+    // Receives both the errors sent by the server and the connection errors
+    source.addEventListener('error', function (e) {
+      close();
+      var message = defaultErrorMessage;
+      if (e.data) {
+        message = JSON.parse(e.data).message || message;
+      }
+      renderError(target, message);
+    });
+  }, true);
+})();
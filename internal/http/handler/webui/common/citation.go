@@ -0,0 +1,26 @@
+package common
+
+import (
+	"github.com/bornholm/corpus/internal/core/service"
+	"github.com/bornholm/corpus/internal/http/handler/webui/common/component"
+)
+
+// CitationURLFunc returns the URL of the content of the cited section
+type CitationURLFunc func(citation service.Citation) string
+
+// NewCitationVModels returns the view models of the citations of an answer
+func NewCitationVModels(citations []service.Citation, url CitationURLFunc) []component.CitationVModel {
+	vmodels := make([]component.CitationVModel, 0, len(citations))
+
+	for _, c := range citations {
+		vmodels = append(vmodels, component.CitationVModel{
+			Marker: c.Marker,
+			Start:  c.Start,
+			End:    c.End,
+			Source: c.Source,
+			URL:    url(c),
+		})
+	}
+
+	return vmodels
+}
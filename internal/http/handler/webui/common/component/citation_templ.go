@@ -0,0 +1,89 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.1001
+package component
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CitationVModel links a citation marker of a generated answer, located by its
+// offsets in runes, to the content of the cited section.
+type CitationVModel struct {
+	Marker int
+	Start  int
+	End    int
+	Source string
+	URL    string
+}
+
+// CitedMarkdown renders a generated answer as markdown, its citation markers
+// being replaced by links to the cited sections.
+func CitedMarkdown(source string, citations []CitationVModel) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = Markdown(linkCitations(source, citations)).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// linkCitations replaces the citation markers of the source by markdown links,
+// the first citation of a marker citing multiple sections being used.
+func linkCitations(source string, citations []CitationVModel) string {
+	sorted := slices.Clone(citations)
+	slices.SortStableFunc(sorted, func(c1, c2 CitationVModel) int {
+		return c1.Start - c2.Start
+	})
+
+	runes := []rune(source)
+
+	var sb strings.Builder
+
+	last := 0
+	for _, c := range sorted {
+		if c.Start < last || c.End > len(runes) || c.Start >= c.End {
+			continue
+		}
+
+		sb.WriteString(string(runes[last:c.Start]))
+
+		title := strings.ReplaceAll(c.Source, `"`, `\"`)
+		sb.WriteString(`[\[` + strconv.Itoa(c.Marker) + `\]](` + c.URL + ` "` + title + `")`)
+
+		last = c.End
+	}
+
+	sb.WriteString(string(runes[last:]))
+
+	return sb.String()
+}
+
+var _ = templruntime.GeneratedTemplate
@@ -27,6 +27,8 @@ func NewHandler(documentManager *service.DocumentManager, pubShareStore port.Pub
 
 	h.mux.Handle("GET /{publicShareToken}", h.assertToken(http.HandlerFunc(h.getPublicSharePage)))
 	h.mux.Handle("POST /{publicShareToken}", h.assertToken(http.HandlerFunc(h.handleAsk)))
+	h.mux.Handle("GET /{publicShareToken}/stream", h.assertToken(http.HandlerFunc(h.handleAskStream)))
+	h.mux.Handle("GET /{publicShareToken}/sections/{sectionID}/content", h.assertToken(http.HandlerFunc(h.handleGetSectionContent)))
 
 	return h
 }
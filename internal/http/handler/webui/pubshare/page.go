@@ -9,9 +9,11 @@ import (
 
 	"github.com/a-h/templ"
 	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/internal/core/service"
 	"github.com/bornholm/corpus/internal/http/handler/webui/common"
 	commonComp "github.com/bornholm/corpus/internal/http/handler/webui/common/component"
 	"github.com/bornholm/corpus/internal/http/handler/webui/pubshare/component"
+	"github.com/bornholm/corpus/internal/http/sse"
 	corpusLLM "github.com/bornholm/corpus/internal/llm"
 	"github.com/bornholm/corpus/internal/metrics"
 	"github.com/pkg/errors"
@@ -53,27 +55,13 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 		metrics.LabelPublicShareID: string(vmodel.PublicShare.ID()),
 	}).Add(1)
 
-	incrementFailures := func() {
-		metrics.PublicShareFailedQuestions.With(prometheus.Labels{
-			metrics.LabelPublicShareID: string(vmodel.PublicShare.ID()),
-		}).Add(1)
-	}
-
 	ctx := r.Context()
 
 	ctx = corpusLLM.WithHighPriority(ctx)
 
-	collectionIDs := slices.Collect(func(yield func(id model.CollectionID) bool) {
-		for _, c := range vmodel.PublicShare.Collections() {
-			if !yield(c.ID()) {
-				return
-			}
-		}
-	})
-
-	result, err := h.documentManager.AskWithRetrieval(ctx, vmodel.Query, collectionIDs)
+	result, err := h.documentManager.AskWithRetrieval(ctx, vmodel.Query, publicShareCollections(vmodel.PublicShare))
 	if err != nil {
-		defer incrementFailures()
+		defer incrementFailedQuestions(vmodel.PublicShare)
 
 		if corpusLLM.IsRateLimit(err) {
 			common.HandleError(w, r, common.NewError(err.Error(), "Service surchargé. Veuillez réessayer ultérieurement.", http.StatusServiceUnavailable))
@@ -84,6 +72,63 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fillPublicSharePageVModelResult(ctx, vmodel, result)
+
+	renderPage()
+}
+
+// handleAskStream streams the answer to the question given in the query
+// string, the results block of the page being rendered once the sections are
+// retrieved and once the answer is complete
+func (h *Handler) handleAskStream(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	vmodel, err := h.fillPublicSharePageViewModel(r)
+	if err != nil {
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	vmodel.Query = r.URL.Query().Get("q")
+	if vmodel.Query == "" {
+		common.HandleError(w, r, common.NewHTTPError(http.StatusBadRequest))
+		return
+	}
+
+	metrics.PublicShareTotalQuestions.With(prometheus.Labels{
+		metrics.LabelPublicShareID: string(vmodel.PublicShare.ID()),
+	}).Add(1)
+
+	ctx := r.Context()
+
+	ctx = corpusLLM.WithHighPriority(ctx)
+
+	events := sse.NewWriter(w)
+
+	render := func(ctx context.Context, event service.AskEvent) templ.Component {
+		switch event.Type {
+		case service.AskEventResults:
+			vmodel.Results = event.Results
+			vmodel.Streaming = true
+
+		case service.AskEventDone:
+			fillPublicSharePageVModelResult(ctx, vmodel, event.Result)
+			vmodel.Streaming = false
+			vmodel.Duration = time.Since(start)
+		}
+
+		return component.PublicShareResults(*vmodel)
+	}
+
+	if _, err := h.documentManager.AskWithRetrievalStream(ctx, vmodel.Query, publicShareCollections(vmodel.PublicShare), common.NewAskStreamFunc(events, render)); err != nil {
+		incrementFailedQuestions(vmodel.PublicShare)
+		common.SendAskStreamError(ctx, events, errors.WithStack(err))
+	}
+}
+
+// fillPublicSharePageVModelResult sets the response of the page, its
+// citations and its sources, counting the answered and unanswered questions
+func fillPublicSharePageVModelResult(ctx context.Context, vmodel *component.PublicSharePageVModel, result *service.AskResult) {
 	vmodel.Results = result.Results
 
 	if result.Grounding != nil {
@@ -97,6 +142,9 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 	if len(result.Results) > 0 {
 		vmodel.Response = result.Answer
 		vmodel.SectionContents = result.Contents
+		vmodel.Citations = common.NewCitationVModels(result.Citations, func(c service.Citation) string {
+			return string(commonComp.BaseURL(ctx, commonComp.WithPath("/shares", vmodel.PublicShare.Token(), "sections", string(c.SectionID), "content")))
+		})
 
 		metrics.PublicShareSucceededQuestions.With(prometheus.Labels{
 			metrics.LabelPublicShareID: string(vmodel.PublicShare.ID()),
@@ -106,8 +154,22 @@ func (h *Handler) handleAsk(w http.ResponseWriter, r *http.Request) {
 			metrics.LabelPublicShareID: string(vmodel.PublicShare.ID()),
 		}).Add(1)
 	}
+}
 
-	renderPage()
+func incrementFailedQuestions(publicShare model.PersistedPublicShare) {
+	metrics.PublicShareFailedQuestions.With(prometheus.Labels{
+		metrics.LabelPublicShareID: string(publicShare.ID()),
+	}).Add(1)
+}
+
+func publicShareCollections(publicShare model.PersistedPublicShare) []model.CollectionID {
+	return slices.Collect(func(yield func(id model.CollectionID) bool) {
+		for _, c := range publicShare.Collections() {
+			if !yield(c.ID()) {
+				return
+			}
+		}
+	})
 }
 
 func (h *Handler) fillPublicSharePageViewModel(r *http.Request) (*component.PublicSharePageVModel, error) {
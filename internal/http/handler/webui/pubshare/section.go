@@ -0,0 +1,69 @@
+package pubshare
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/bornholm/corpus/internal/http/handler/webui/common"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+// handleGetSectionContent serves the content of a section cited by an answer,
+// provided its document belongs to one of the collections of the share
+func (h *Handler) handleGetSectionContent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	publicShare := ctxPubShare(ctx)
+
+	sectionID := model.SectionID(r.PathValue("sectionID"))
+
+	section, err := h.documentManager.DocumentStore.GetSectionByID(ctx, sectionID)
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not get section", slogx.Error(err))
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	document, err := h.documentManager.DocumentStore.GetDocumentByID(ctx, section.Document().ID())
+	if err != nil {
+		if errors.Is(err, port.ErrNotFound) {
+			common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+			return
+		}
+
+		slog.ErrorContext(ctx, "could not get document", slogx.Error(err))
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	shareCollections := publicShareCollections(publicShare)
+
+	isShared := slices.ContainsFunc(document.Collections(), func(c model.Collection) bool {
+		return slices.Contains(shareCollections, c.ID())
+	})
+	if !isShared {
+		common.HandleError(w, r, common.NewHTTPError(http.StatusNotFound))
+		return
+	}
+
+	content, err := section.Content()
+	if err != nil {
+		slog.ErrorContext(ctx, "could not retrieve section content", slogx.Error(err))
+		common.HandleError(w, r, errors.WithStack(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown")
+
+	if _, err := w.Write(content); err != nil {
+		slog.ErrorContext(ctx, "could not write section content", slogx.Error(err))
+	}
+}
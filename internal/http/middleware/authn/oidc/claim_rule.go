@@ -0,0 +1,142 @@
+package oidc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/http/middleware/authn"
+)
+
+const anyClaimValue = "*"
+
+// ClaimRule grants roles, groups or an activation state to the users whose
+// claim matches a value
+type ClaimRule struct {
+	Claim string
+	Value string
+
+	Roles  []string
+	Groups []string
+	Active *bool
+}
+
+// Matches returns true if the claims satisfy the rule
+func (r ClaimRule) Matches(claims map[string]any) bool {
+	values := getClaimValues(lookupClaim(claims, r.Claim))
+
+	if r.Value == anyClaimValue {
+		return len(values) > 0
+	}
+
+	return slices.Contains(values, r.Value)
+}
+
+// applyClaimRules evaluates the rules against the claims of the user.
+//
+// As soon as a rule grants roles (or groups), the roles (or groups) of the
+// user are governed by the rules: the user only gets the ones of the matching
+// rules. The activation state is given by the last matching rule defining it.
+func applyClaimRules(user *authn.User, claims map[string]any, rules []ClaimRule) {
+	var (
+		roles  []string
+		groups []string
+	)
+
+	for _, r := range rules {
+		if r.Roles != nil && roles == nil {
+			roles = make([]string, 0)
+		}
+
+		if r.Groups != nil && groups == nil {
+			groups = make([]string, 0)
+		}
+
+		if !r.Matches(claims) {
+			continue
+		}
+
+		roles = appendMissing(roles, r.Roles...)
+		groups = appendMissing(groups, r.Groups...)
+
+		if r.Active != nil {
+			active := *r.Active
+			user.Active = &active
+		}
+	}
+
+	if roles != nil {
+		user.Roles = roles
+	}
+
+	if groups != nil {
+		user.Groups = appendMissing(groups, user.Groups...)
+	}
+}
+
+// lookupClaim returns the raw value of a claim, following dotted paths to
+// nested claims if the claim does not exist at the top level
+func lookupClaim(claims map[string]any, name string) any {
+	if value, exists := claims[name]; exists {
+		return value
+	}
+
+	var current any = claims
+
+	for _, key := range strings.Split(name, ".") {
+		nested, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		current, ok = nested[key]
+		if !ok {
+			return nil
+		}
+	}
+
+	return current
+}
+
+// getClaimValues returns the non empty values of a raw claim, either a scalar
+// or a list of scalars
+func getClaimValues(rawValue any) []string {
+	values := make([]string, 0)
+
+	appendValue := func(v any) {
+		switch typ := v.(type) {
+		case nil:
+		case string:
+			if typ != "" {
+				values = append(values, typ)
+			}
+		case bool, float64, int, int64:
+			values = append(values, fmt.Sprint(typ))
+		}
+	}
+
+	switch typ := rawValue.(type) {
+	case []string:
+		for _, v := range typ {
+			appendValue(v)
+		}
+	case []any:
+		for _, v := range typ {
+			appendValue(v)
+		}
+	default:
+		appendValue(typ)
+	}
+
+	return values
+}
+
+func appendMissing(values []string, others ...string) []string {
+	for _, o := range others {
+		if !slices.Contains(values, o) {
+			values = append(values, o)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,196 @@
+package oidc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/http/middleware/authn"
+)
+
+func TestLookupClaim(t *testing.T) {
+	claims := map[string]any{
+		"email": "jdoe@example.net",
+		"realm_access": map[string]any{
+			"roles": []any{"corpus-admin", "offline_access"},
+		},
+		"resource.roles": "flat",
+		"resource": map[string]any{
+			"roles": "nested",
+		},
+	}
+
+	type testCase struct {
+		Name     string
+		Claim    string
+		Expected []string
+	}
+
+	testCases := []testCase{
+		{Name: "TopLevel", Claim: "email", Expected: []string{"jdoe@example.net"}},
+		{Name: "Nested", Claim: "realm_access.roles", Expected: []string{"corpus-admin", "offline_access"}},
+		{Name: "TopLevelWithDotFirst", Claim: "resource.roles", Expected: []string{"flat"}},
+		{Name: "Missing", Claim: "groups", Expected: []string{}},
+		{Name: "MissingNested", Claim: "realm_access.groups", Expected: []string{}},
+		{Name: "ScalarParent", Claim: "email.domain", Expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if e, g := tc.Expected, getClaimValues(lookupClaim(claims, tc.Claim)); !slices.Equal(e, g) {
+				t.Errorf("values: expected %v, got %v", e, g)
+			}
+		})
+	}
+}
+
+func TestGetClaimValues(t *testing.T) {
+	type testCase struct {
+		Name     string
+		Raw      any
+		Expected []string
+	}
+
+	testCases := []testCase{
+		{Name: "Nil", Raw: nil, Expected: []string{}},
+		{Name: "String", Raw: "legal", Expected: []string{"legal"}},
+		{Name: "EmptyString", Raw: "", Expected: []string{}},
+		{Name: "Bool", Raw: true, Expected: []string{"true"}},
+		{Name: "Number", Raw: float64(42), Expected: []string{"42"}},
+		{Name: "Strings", Raw: []string{"a", "", "b"}, Expected: []string{"a", "b"}},
+		{Name: "Mixed", Raw: []any{"a", nil, false, map[string]any{"b": "c"}}, Expected: []string{"a", "false"}},
+		{Name: "Object", Raw: map[string]any{"a": "b"}, Expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if e, g := tc.Expected, getClaimValues(tc.Raw); !slices.Equal(e, g) {
+				t.Errorf("values: expected %v, got %v", e, g)
+			}
+		})
+	}
+}
+
+func TestApplyClaimRules(t *testing.T) {
+	active := true
+	inactive := false
+
+	claims := map[string]any{
+		"sub":        "jdoe",
+		"department": "legal",
+		"realm_access": map[string]any{
+			"roles": []any{"corpus-user"},
+		},
+	}
+
+	type testCase struct {
+		Name           string
+		Groups         []string
+		Rules          []ClaimRule
+		ExpectedRoles  []string
+		ExpectedGroups []string
+		ExpectedActive *bool
+	}
+
+	testCases := []testCase{
+		{
+			Name:           "NoRules",
+			Groups:         []string{"idp"},
+			Rules:          []ClaimRule{},
+			ExpectedRoles:  nil,
+			ExpectedGroups: []string{"idp"},
+			ExpectedActive: nil,
+		},
+		{
+			Name: "NestedArrayClaim",
+			Rules: []ClaimRule{
+				{Claim: "realm_access.roles", Value: "corpus-admin", Roles: []string{"admin", "user"}},
+				{Claim: "realm_access.roles", Value: "corpus-user", Roles: []string{"user"}},
+			},
+			ExpectedRoles: []string{"user"},
+		},
+		{
+			Name: "ScalarClaim",
+			Rules: []ClaimRule{
+				{Claim: "department", Value: "legal", Roles: []string{"user"}},
+				{Claim: "department", Value: "sales", Roles: []string{"admin"}},
+			},
+			ExpectedRoles: []string{"user"},
+		},
+		{
+			Name: "AnyValue",
+			Rules: []ClaimRule{
+				{Claim: "department", Value: anyClaimValue, Roles: []string{"user"}},
+				{Claim: "missing", Value: anyClaimValue, Roles: []string{"admin"}},
+			},
+			ExpectedRoles: []string{"user"},
+		},
+		{
+			Name: "MergedRoles",
+			Rules: []ClaimRule{
+				{Claim: "sub", Value: anyClaimValue, Roles: []string{"user"}},
+				{Claim: "department", Value: "legal", Roles: []string{"user", "admin"}},
+			},
+			ExpectedRoles: []string{"user", "admin"},
+		},
+		{
+			// Roles are governed by the rules: a user matching none of them
+			// gets no role
+			Name: "NoMatch",
+			Rules: []ClaimRule{
+				{Claim: "realm_access.roles", Value: "corpus-admin", Roles: []string{"admin"}},
+			},
+			ExpectedRoles: []string{},
+		},
+		{
+			Name:   "Groups",
+			Groups: []string{"idp"},
+			Rules: []ClaimRule{
+				{Claim: "department", Value: "legal", Groups: []string{"Juridique", "idp"}},
+				{Claim: "department", Value: "sales", Groups: []string{"Commercial"}},
+			},
+			ExpectedGroups: []string{"Juridique", "idp"},
+		},
+		{
+			Name: "LastActiveWins",
+			Rules: []ClaimRule{
+				{Claim: "sub", Value: anyClaimValue, Active: &inactive},
+				{Claim: "realm_access.roles", Value: "corpus-user", Active: &active},
+				{Claim: "department", Value: "sales", Active: &inactive},
+			},
+			ExpectedActive: &active,
+		},
+		{
+			Name: "ActiveWithoutRoles",
+			Rules: []ClaimRule{
+				{Claim: "department", Value: "legal", Active: &inactive},
+			},
+			ExpectedRoles:  nil,
+			ExpectedActive: &inactive,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			user := &authn.User{Groups: tc.Groups}
+
+			applyClaimRules(user, claims, tc.Rules)
+
+			if e, g := tc.ExpectedRoles, user.Roles; (e == nil) != (g == nil) || !slices.Equal(e, g) {
+				t.Errorf("user.Roles: expected %#v, got %#v", e, g)
+			}
+
+			if e, g := tc.ExpectedGroups, user.Groups; !slices.Equal(e, g) {
+				t.Errorf("user.Groups: expected %v, got %v", e, g)
+			}
+
+			switch {
+			case tc.ExpectedActive == nil && user.Active != nil:
+				t.Errorf("user.Active: expected nil, got %v", *user.Active)
+			case tc.ExpectedActive != nil && user.Active == nil:
+				t.Errorf("user.Active: expected %v, got nil", *tc.ExpectedActive)
+			case tc.ExpectedActive != nil && *tc.ExpectedActive != *user.Active:
+				t.Errorf("user.Active: expected %v, got %v", *tc.ExpectedActive, *user.Active)
+			}
+		})
+	}
+}
@@ -12,6 +12,9 @@ type Handler struct {
 	sessionStore sessions.Store
 	sessionName  string
 	providers    []Provider
+	groupsClaims map[string]string
+	claimRules   map[string][]ClaimRule
+	onLogin      LoginFunc
 }
 
 // ServeHTTP implements http.Handler.
@@ -26,6 +29,9 @@ func NewHandler(sessionStore sessions.Store, funcs ...OptionFunc) *Handler {
 		sessionStore: sessionStore,
 		sessionName:  opts.SessionName,
 		providers:    opts.Providers,
+		groupsClaims: opts.GroupsClaims,
+		claimRules:   opts.ClaimRules,
+		onLogin:      opts.OnLogin,
 	}
 
 	h.mux.HandleFunc("GET /login", h.getLoginPage)
@@ -1,20 +1,40 @@
 package oidc
 
-import "github.com/bornholm/corpus/internal/http/middleware/authn/oidc/component"
+import (
+	"context"
+
+	"github.com/bornholm/corpus/internal/http/middleware/authn"
+	"github.com/bornholm/corpus/internal/http/middleware/authn/oidc/component"
+)
 
 type Provider = component.Provider
 
 type Options struct {
 	Providers   []component.Provider
 	SessionName string
+	// Name of the claim listing the groups of the user, by provider
+	GroupsClaims map[string]string
+	// Rules mapping the claims of the users to roles, activation and groups,
+	// by provider
+	ClaimRules map[string][]ClaimRule
+	// Function called with each user logging in, before the user is stored in
+	// the session
+	OnLogin LoginFunc
 }
 
+// LoginFunc is called with each user logging in. Its groups are only given to
+// this function, the session keeping them out to stay within the size limit
+// of the cookies.
+type LoginFunc func(ctx context.Context, user *authn.User) error
+
 type OptionFunc func(opts *Options)
 
 func NewOptions(funcs ...OptionFunc) *Options {
 	opts := &Options{
-		Providers:   make([]Provider, 0),
-		SessionName: "corpus_auth_oidc",
+		Providers:    make([]Provider, 0),
+		SessionName:  "corpus_auth_oidc",
+		GroupsClaims: make(map[string]string),
+		ClaimRules:   make(map[string][]ClaimRule),
 	}
 
 	for _, fn := range funcs {
@@ -35,3 +55,26 @@ func WithSessionName(sessionName string) OptionFunc {
 		opts.SessionName = sessionName
 	}
 }
+
+// WithGroupsClaim reads the groups of the users authenticated with the given
+// provider from the given claim
+func WithGroupsClaim(provider string, claim string) OptionFunc {
+	return func(opts *Options) {
+		opts.GroupsClaims[provider] = claim
+	}
+}
+
+// WithClaimRules evaluates the given rules against the claims of the users
+// authenticated with the given provider on each login
+func WithClaimRules(provider string, rules ...ClaimRule) OptionFunc {
+	return func(opts *Options) {
+		opts.ClaimRules[provider] = append(opts.ClaimRules[provider], rules...)
+	}
+}
+
+// WithOnLogin calls the given function with each user logging in
+func WithOnLogin(fn LoginFunc) OptionFunc {
+	return func(opts *Options) {
+		opts.OnLogin = fn
+	}
+}
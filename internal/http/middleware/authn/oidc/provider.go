@@ -48,6 +48,29 @@ func (h *Handler) handleProviderCallback(w http.ResponseWriter, r *http.Request)
 		user.Subject = gothUser.UserID
 	}
 
+	if claim, exists := h.groupsClaims[gothUser.Provider]; exists {
+		user.Groups = getClaimValues(lookupClaim(gothUser.RawData, claim))
+	}
+
+	if rules, exists := h.claimRules[gothUser.Provider]; exists {
+		applyClaimRules(user, gothUser.RawData, rules)
+
+		// The roles and the activation state given by the rules override the
+		// ones stored on each request, until the next login
+		attrs := []any{
+			slog.String("email", user.Email),
+			slog.String("provider", user.Provider),
+			slog.Any("roles", user.Roles),
+			slog.Any("groups", user.Groups),
+		}
+
+		if user.Active != nil {
+			attrs = append(attrs, slog.Bool("active", *user.Active))
+		}
+
+		slog.InfoContext(ctx, "claim rules evaluated at login", attrs...)
+	}
+
 	if user.Subject == "" {
 		slog.ErrorContext(r.Context(), "could not authenticate user", slog.Any("error", errors.New("user subject missing")))
 		http.Redirect(w, r, "/auth/logout", http.StatusTemporaryRedirect)
@@ -66,7 +89,20 @@ func (h *Handler) handleProviderCallback(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.storeSessionUser(w, r, user); err != nil {
+	if h.onLogin != nil {
+		if err := h.onLogin(ctx, user); err != nil {
+			slog.ErrorContext(r.Context(), "could not log user in", slog.Any("error", errors.WithStack(err)))
+			http.Redirect(w, r, "/auth/oidc/logout", http.StatusTemporaryRedirect)
+			return
+		}
+	}
+
+	// The groups may be too numerous to fit in the session cookie: they are
+	// only handled at login
+	sessionUser := *user
+	sessionUser.Groups = nil
+
+	if err := h.storeSessionUser(w, r, &sessionUser); err != nil {
 		slog.ErrorContext(r.Context(), "could not store session user", slog.Any("error", errors.WithStack(err)))
 		http.Redirect(w, r, "/auth/oidc/logout", http.StatusTemporaryRedirect)
 		return
@@ -0,0 +1,157 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	httpCtx "github.com/bornholm/corpus/internal/http/context"
+	"github.com/bornholm/corpus/internal/http/middleware/authn"
+	"github.com/bornholm/corpus/internal/http/middleware/authz"
+	"github.com/bornholm/corpus/internal/http/middleware/bridge"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/gorilla/sessions"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/markbates/goth/providers/faux"
+	"github.com/pkg/errors"
+)
+
+func TestProviderCallbackClaimRules(t *testing.T) {
+	inactive := false
+
+	sessionStore := sessions.NewCookieStore([]byte("0123456789abcdef0123456789abcdef"))
+
+	goth.UseProviders(&claimsProvider{
+		Provider: &faux.Provider{},
+		claims: map[string]any{
+			"sub":    "jdoe",
+			"groups": []any{"legal", "staff"},
+		},
+	})
+	t.Cleanup(goth.ClearProviders)
+
+	previousStore := gothic.Store
+	gothic.Store = sessionStore
+	t.Cleanup(func() {
+		gothic.Store = previousStore
+	})
+
+	var loginUser *authn.User
+
+	handler := NewHandler(sessionStore,
+		WithGroupsClaim("faux", "groups"),
+		WithClaimRules("faux",
+			ClaimRule{Claim: "groups", Value: "staff", Roles: []string{authz.RoleUser}},
+			ClaimRule{Claim: "groups", Value: "legal", Active: &inactive},
+		),
+		WithOnLogin(func(ctx context.Context, user *authn.User) error {
+			loginUser = user
+			return nil
+		}),
+	)
+
+	// Start the authentication flow with the provider
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/providers/faux/callback", nil)
+
+	if err := gothic.StoreInSession("faux", `{"ID":"jdoe","Email":"jdoe@example.net","AccessToken":"access"}`, req, res); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/providers/faux/callback", nil)
+	for _, c := range res.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	res = httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	if e, g := http.StatusSeeOther, res.Code; e != g {
+		t.Fatalf("res.Code: expected %d, got %d", e, g)
+	}
+
+	if loginUser == nil {
+		t.Fatalf("loginUser: expected a user, got nil")
+	}
+
+	if e, g := []string{"legal", "staff"}, loginUser.Groups; !slices.Equal(e, g) {
+		t.Errorf("loginUser.Groups: expected %v, got %v", e, g)
+	}
+
+	// The roles and the activation state given by the rules at login override
+	// the ones edited in the store on the following requests
+	userStore := &memoryUserStore{
+		user: model.NewUser("faux", "jdoe", "jdoe@example.net", "jdoe", true, authz.RoleAdmin),
+	}
+
+	var requestUser model.User
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestUser = httpCtx.User(r.Context())
+	})
+
+	onUnauthorized := func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request: expected an authenticated user")
+	}
+
+	middleware := authn.Middleware(onUnauthorized, handler)(bridge.Middleware(userStore, true)(next))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range res.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	middleware.ServeHTTP(httptest.NewRecorder(), req)
+
+	if requestUser == nil {
+		t.Fatalf("requestUser: expected a user, got nil")
+	}
+
+	if e, g := []string{authz.RoleUser}, requestUser.Roles(); !slices.Equal(e, g) {
+		t.Errorf("requestUser.Roles(): expected %v, got %v", e, g)
+	}
+
+	if e, g := false, requestUser.Active(); e != g {
+		t.Errorf("requestUser.Active(): expected %v, got %v", e, g)
+	}
+}
+
+// claimsProvider is a fake identity provider exposing the given claims
+type claimsProvider struct {
+	*faux.Provider
+	claims map[string]any
+}
+
+// FetchUser implements goth.Provider.
+func (p *claimsProvider) FetchUser(session goth.Session) (goth.User, error) {
+	user, err := p.Provider.FetchUser(session)
+	if err != nil {
+		return user, errors.WithStack(err)
+	}
+
+	user.RawData = p.claims
+
+	return user, nil
+}
+
+// memoryUserStore keeps a single user in memory
+type memoryUserStore struct {
+	port.UserStore
+	user model.User
+}
+
+// FindOrCreateUser implements port.UserStore.
+func (s *memoryUserStore) FindOrCreateUser(ctx context.Context, provider, subject string) (model.User, error) {
+	return s.user, nil
+}
+
+// SaveUser implements port.UserStore.
+func (s *memoryUserStore) SaveUser(ctx context.Context, user model.User) error {
+	s.user = user
+	return nil
+}
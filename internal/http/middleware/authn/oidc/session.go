@@ -16,6 +16,16 @@ var errSessionNotFound = errors.New("session not found")
 
 func init() {
 	gob.Register(&authn.User{})
+	gob.Register(&sessionUser{})
+}
+
+// sessionUser is the user stored in the session. As gob omits the zero values,
+// it flags the roles and the activation state governed by the identity
+// provider, which may be empty or false.
+type sessionUser struct {
+	User           *authn.User
+	GovernedRoles  bool
+	GovernedActive bool
 }
 
 func (h *Handler) storeSessionUser(w http.ResponseWriter, r *http.Request, user *authn.User) error {
@@ -24,7 +34,11 @@ func (h *Handler) storeSessionUser(w http.ResponseWriter, r *http.Request, user
 		return errors.WithStack(err)
 	}
 
-	sess.Values[userAttr] = user
+	sess.Values[userAttr] = &sessionUser{
+		User:           user,
+		GovernedRoles:  user.Roles != nil,
+		GovernedActive: user.Active != nil,
+	}
 
 	if err := sess.Save(r, w); err != nil {
 		return errors.WithStack(err)
@@ -39,11 +53,22 @@ func (h *Handler) retrieveSessionUser(r *http.Request) (*authn.User, error) {
 		return nil, errors.WithStack(err)
 	}
 
-	user, ok := sess.Values[userAttr].(*authn.User)
-	if !ok {
+	stored, ok := sess.Values[userAttr].(*sessionUser)
+	if !ok || stored.User == nil {
 		return nil, errors.WithStack(errSessionNotFound)
 	}
 
+	user := stored.User
+
+	if stored.GovernedRoles && user.Roles == nil {
+		user.Roles = []string{}
+	}
+
+	if stored.GovernedActive && user.Active == nil {
+		active := false
+		user.Active = &active
+	}
+
 	return user, nil
 }
 
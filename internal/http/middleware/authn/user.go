@@ -5,4 +5,13 @@ type User struct {
 	Provider    string
 	Subject     string
 	DisplayName string
+	// Groups of the user provided by the identity provider, or nil if the
+	// provider does not expose them
+	Groups []string
+	// Roles of the user granted by the identity provider, or nil if they are
+	// not governed by it
+	Roles []string
+	// Activation state of the user given by the identity provider, or nil if
+	// it is not governed by it
+	Active *bool
 }
@@ -0,0 +1,31 @@
+package bridge
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/internal/http/middleware/authn"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// GroupsSynchronizer returns a function making the authenticated user a
+// member of the groups given by its identity provider, if any. It is meant to
+// be called once at login, the groups being kept out of the session.
+func GroupsSynchronizer(userStore port.UserStore, groupStore port.GroupStore) func(ctx context.Context, authnUser *authn.User) error {
+	return func(ctx context.Context, authnUser *authn.User) error {
+		if authnUser.Groups == nil {
+			return nil
+		}
+
+		user, err := userStore.FindOrCreateUser(ctx, authnUser.Provider, authnUser.Subject)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := groupStore.SyncUserGroups(ctx, user.ID(), authnUser.Provider, authnUser.Groups); err != nil {
+			return errors.Wrap(err, "could not synchronize user groups")
+		}
+
+		return nil
+	}
+}
@@ -2,6 +2,7 @@ package bridge
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
 	"slices"
 
@@ -38,30 +39,51 @@ func Middleware(userStore port.UserStore, activeByDefault bool, defaultAdmins ..
 				}
 			}
 
-			missingRole := len(user.Roles()) == 0
-			shouldBeAdmin := slices.Contains(defaultAdmins, authnUser.Email) && !slices.Contains(user.Roles(), authz.RoleAdmin)
+			roles, active := resolveAccess(user, authnUser, defaultAdmins)
+
+			if authnUser.Roles != nil && len(roles) == 0 {
+				slog.WarnContext(ctx, "no claim rule granted a role to the user",
+					slog.String("user_id", string(user.ID())),
+					slog.String("email", authnUser.Email),
+					slog.String("provider", authnUser.Provider),
+				)
+			}
+
+			rolesChanged := !sameRoles(user.Roles(), roles)
+			activeChanged := user.Active() != active
 
 			changed := user.DisplayName() != authnUser.DisplayName ||
 				user.Email() != authnUser.Email
 
-			if changed || shouldBeAdmin || missingRole {
+			if changed || rolesChanged || activeChanged {
 				updatable := model.CopyUser(user)
 				updatable.SetDisplayName(authnUser.DisplayName)
 				updatable.SetEmail(authnUser.Email)
+				updatable.SetRoles(roles...)
+				updatable.SetActive(active)
 
-				if missingRole {
-					updatable.SetRoles(authz.RoleUser)
+				if err := userStore.SaveUser(ctx, updatable); err != nil {
+					common.HandleError(w, r, err)
+					return
 				}
 
-				if shouldBeAdmin {
-					newRoles := append(user.Roles(), authz.RoleAdmin)
-					updatable.SetRoles(newRoles...)
-					updatable.SetActive(true)
+				if rolesChanged {
+					slog.WarnContext(ctx, "user roles changed",
+						slog.String("user_id", string(user.ID())),
+						slog.String("email", authnUser.Email),
+						slog.String("provider", authnUser.Provider),
+						slog.Any("previous_roles", user.Roles()),
+						slog.Any("roles", roles),
+					)
 				}
 
-				if err := userStore.SaveUser(ctx, updatable); err != nil {
-					common.HandleError(w, r, err)
-					return
+				if activeChanged {
+					slog.WarnContext(ctx, "user activation changed",
+						slog.String("user_id", string(user.ID())),
+						slog.String("email", authnUser.Email),
+						slog.String("provider", authnUser.Provider),
+						slog.Bool("active", active),
+					)
 				}
 
 				user = updatable
@@ -76,3 +98,61 @@ func Middleware(userStore port.UserStore, activeByDefault bool, defaultAdmins ..
 		return fn
 	}
 }
+
+// resolveAccess returns the roles and the activation state of the user,
+// given the ones stored and the ones governed by the identity provider.
+//
+// Users with roles governed by the identity provider only get the roles it
+// grants, possibly none: the fallback role must be granted explicitly by a
+// claim rule. Default administrators are activated once, when promoted to
+// administrators, and keep the administrator role whatever the identity
+// provider grants. When the activation is governed by the identity provider,
+// default administrators stay active.
+func resolveAccess(user model.User, authnUser *authn.User, defaultAdmins []string) ([]string, bool) {
+	isDefaultAdmin := slices.Contains(defaultAdmins, authnUser.Email)
+	promoted := isDefaultAdmin && !slices.Contains(user.Roles(), authz.RoleAdmin)
+
+	var roles []string
+
+	switch {
+	case authnUser.Roles != nil:
+		roles = slices.Clone(authnUser.Roles)
+	case promoted:
+		roles = append(slices.Clone(user.Roles()), authz.RoleAdmin)
+	case len(user.Roles()) == 0:
+		roles = []string{authz.RoleUser}
+	default:
+		roles = slices.Clone(user.Roles())
+	}
+
+	if isDefaultAdmin && !slices.Contains(roles, authz.RoleAdmin) {
+		roles = append(roles, authz.RoleAdmin)
+	}
+
+	active := user.Active()
+
+	switch {
+	case authnUser.Active != nil:
+		active = *authnUser.Active || isDefaultAdmin
+	case promoted:
+		active = true
+	}
+
+	return roles, active
+}
+
+// sameRoles returns true if both lists contain the same roles, regardless of
+// their order
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := slices.Clone(a)
+	slices.Sort(sortedA)
+
+	sortedB := slices.Clone(b)
+	slices.Sort(sortedB)
+
+	return slices.Equal(sortedA, sortedB)
+}
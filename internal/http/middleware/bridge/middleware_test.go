@@ -0,0 +1,156 @@
+package bridge
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/http/middleware/authn"
+	"github.com/bornholm/corpus/internal/http/middleware/authz"
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+func TestResolveAccess(t *testing.T) {
+	active := true
+	inactive := false
+
+	const adminEmail = "admin@example.net"
+
+	type testCase struct {
+		Name           string
+		User           model.User
+		AuthnUser      *authn.User
+		ExpectedRoles  []string
+		ExpectedActive bool
+	}
+
+	testCases := []testCase{
+		{
+			Name:           "StoredRoles",
+			User:           model.NewUser("oidc", "jdoe", "jdoe@example.net", "John", true, authz.RoleAdmin),
+			AuthnUser:      &authn.User{Email: "jdoe@example.net"},
+			ExpectedRoles:  []string{authz.RoleAdmin},
+			ExpectedActive: true,
+		},
+		{
+			Name:           "MissingRole",
+			User:           model.NewUser("oidc", "jdoe", "jdoe@example.net", "John", false),
+			AuthnUser:      &authn.User{Email: "jdoe@example.net"},
+			ExpectedRoles:  []string{authz.RoleUser},
+			ExpectedActive: false,
+		},
+		{
+			Name:           "GovernedRoles",
+			User:           model.NewUser("oidc", "jdoe", "jdoe@example.net", "John", true, authz.RoleAdmin),
+			AuthnUser:      &authn.User{Email: "jdoe@example.net", Roles: []string{authz.RoleUser}},
+			ExpectedRoles:  []string{authz.RoleUser},
+			ExpectedActive: true,
+		},
+		{
+			// No implicit role is granted when the identity provider grants
+			// none
+			Name:           "GovernedNoRole",
+			User:           model.NewUser("oidc", "jdoe", "jdoe@example.net", "John", true, authz.RoleAdmin),
+			AuthnUser:      &authn.User{Email: "jdoe@example.net", Roles: []string{}},
+			ExpectedRoles:  []string{},
+			ExpectedActive: true,
+		},
+		{
+			Name:           "GovernedActivation",
+			User:           model.NewUser("oidc", "jdoe", "jdoe@example.net", "John", false, authz.RoleUser),
+			AuthnUser:      &authn.User{Email: "jdoe@example.net", Active: &active},
+			ExpectedRoles:  []string{authz.RoleUser},
+			ExpectedActive: true,
+		},
+		{
+			Name:           "GovernedDeactivation",
+			User:           model.NewUser("oidc", "jdoe", "jdoe@example.net", "John", true, authz.RoleUser),
+			AuthnUser:      &authn.User{Email: "jdoe@example.net", Active: &inactive},
+			ExpectedRoles:  []string{authz.RoleUser},
+			ExpectedActive: false,
+		},
+		{
+			Name:           "DefaultAdmin",
+			User:           model.NewUser("oidc", "admin", adminEmail, "Admin", false, authz.RoleUser),
+			AuthnUser:      &authn.User{Email: adminEmail},
+			ExpectedRoles:  []string{authz.RoleUser, authz.RoleAdmin},
+			ExpectedActive: true,
+		},
+		{
+			Name:           "DefaultAdminNoRole",
+			User:           model.NewUser("oidc", "admin", adminEmail, "Admin", false),
+			AuthnUser:      &authn.User{Email: adminEmail},
+			ExpectedRoles:  []string{authz.RoleAdmin},
+			ExpectedActive: true,
+		},
+		{
+			// Default administrators already promoted are not activated again
+			Name:           "DefaultAdminDeactivated",
+			User:           model.NewUser("oidc", "admin", adminEmail, "Admin", false, authz.RoleAdmin),
+			AuthnUser:      &authn.User{Email: adminEmail},
+			ExpectedRoles:  []string{authz.RoleAdmin},
+			ExpectedActive: false,
+		},
+		{
+			Name:           "DefaultAdminGovernedRoles",
+			User:           model.NewUser("oidc", "admin", adminEmail, "Admin", false, authz.RoleAdmin),
+			AuthnUser:      &authn.User{Email: adminEmail, Roles: []string{authz.RoleUser}},
+			ExpectedRoles:  []string{authz.RoleUser, authz.RoleAdmin},
+			ExpectedActive: false,
+		},
+		{
+			// Default administrators keep their access whatever the identity
+			// provider grants
+			Name:           "DefaultAdminGoverned",
+			User:           model.NewUser("oidc", "admin", adminEmail, "Admin", true, authz.RoleAdmin),
+			AuthnUser:      &authn.User{Email: adminEmail, Roles: []string{}, Active: &inactive},
+			ExpectedRoles:  []string{authz.RoleAdmin},
+			ExpectedActive: true,
+		},
+		{
+			Name:           "DefaultAdminAlreadyAdmin",
+			User:           model.NewUser("oidc", "admin", adminEmail, "Admin", true, authz.RoleAdmin),
+			AuthnUser:      &authn.User{Email: adminEmail, Roles: []string{authz.RoleUser, authz.RoleAdmin}},
+			ExpectedRoles:  []string{authz.RoleUser, authz.RoleAdmin},
+			ExpectedActive: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			roles, active := resolveAccess(tc.User, tc.AuthnUser, []string{adminEmail})
+
+			if e, g := tc.ExpectedRoles, roles; !slices.Equal(e, g) {
+				t.Errorf("roles: expected %v, got %v", e, g)
+			}
+
+			if e, g := tc.ExpectedActive, active; e != g {
+				t.Errorf("active: expected %v, got %v", e, g)
+			}
+		})
+	}
+}
+
+func TestSameRoles(t *testing.T) {
+	type testCase struct {
+		Name     string
+		A        []string
+		B        []string
+		Expected bool
+	}
+
+	testCases := []testCase{
+		{Name: "Empty", A: nil, B: []string{}, Expected: true},
+		{Name: "Equal", A: []string{"user", "admin"}, B: []string{"user", "admin"}, Expected: true},
+		{Name: "Unordered", A: []string{"admin", "user"}, B: []string{"user", "admin"}, Expected: true},
+		{Name: "Different", A: []string{"user"}, B: []string{"admin"}, Expected: false},
+		{Name: "Subset", A: []string{"user"}, B: []string{"user", "admin"}, Expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if e, g := tc.Expected, sameRoles(tc.A, tc.B); e != g {
+				t.Errorf("sameRoles(%v, %v): expected %v, got %v", tc.A, tc.B, e, g)
+			}
+		})
+	}
+}
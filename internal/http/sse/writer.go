@@ -0,0 +1,70 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const ContentType = "text/event-stream"
+
+// Writer sends server-sent events, each one being flushed to the client as
+// soon as it is written
+type Writer struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+}
+
+// Send writes an event of the given type whose data is the JSON encoding of
+// the given value
+func (w *Writer) Send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err := fmt.Fprintf(w.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := w.controller.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// NewWriter sets the headers of the event stream on the response and returns
+// a writer for its events
+func NewWriter(w http.ResponseWriter) *Writer {
+	header := w.Header()
+	header.Set("Content-Type", ContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Prevents reverse proxies from buffering the events
+	header.Set("X-Accel-Buffering", "no")
+
+	w.WriteHeader(http.StatusOK)
+
+	return &Writer{
+		w:          w,
+		controller: http.NewResponseController(w),
+	}
+}
+
+// Accepted returns true if the client accepts an event stream as response
+func Accepted(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == ContentType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
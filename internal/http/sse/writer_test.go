@@ -0,0 +1,56 @@
+package sse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	events := NewWriter(recorder)
+
+	if err := events.Send("delta", map[string]string{"delta": "Hello"}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := events.Send("done", struct{}{}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := ContentType, recorder.Header().Get("Content-Type"); e != g {
+		t.Errorf("Content-Type: expected '%s', got '%s'", e, g)
+	}
+
+	if !recorder.Flushed {
+		t.Error("expected the events to be flushed")
+	}
+
+	expected := "event: delta\ndata: {\"delta\":\"Hello\"}\n\nevent: done\ndata: {}\n\n"
+	if g := recorder.Body.String(); expected != g {
+		t.Errorf("body: expected '%s', got '%s'", expected, g)
+	}
+}
+
+func TestAccepted(t *testing.T) {
+	testCases := map[string]bool{
+		"":                  false,
+		"application/json":  false,
+		"text/event-stream": true,
+		"application/json, text/event-stream;q=0.9": true,
+	}
+
+	for accept, expected := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/ask", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+
+		if g := Accepted(r); expected != g {
+			t.Errorf("Accepted('%s'): expected %v, got %v", accept, expected, g)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package markdown
+
+import (
+	"bytes"
+
+	corpusText "github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// atomicBlock is a table or a fenced code block, which should not be split
+// arbitrarily
+type atomicBlock struct {
+	start int
+	end   int
+	// header is repeated at the start of each part but the first one when the
+	// block is split, i.e. the table header and delimiter rows or the code
+	// block opening fence
+	header model.Range
+	// footer is repeated at the end of each part but the last one when the
+	// block is split, i.e. the code block closing fence
+	footer model.Range
+	// units are the ranges the block can be split between, i.e. the table
+	// rows or the code block paragraphs
+	units []model.Range
+}
+
+// parts groups the block units into parts not exceeding maxWords, header and
+// footer included. The block is kept whole if it does not exceed maxWords or
+// can not be split.
+func (b *atomicBlock) parts(data []byte, maxWords int) []model.Range {
+	whole := model.Range{Start: b.start, End: b.end}
+
+	if len(b.units) < 2 || countRangeWords(data, whole) <= maxWords {
+		return []model.Range{whole}
+	}
+
+	budget := max(maxWords-countRangeWords(data, b.header)-countRangeWords(data, b.footer), 1)
+
+	parts := make([]model.Range, 0)
+
+	current := model.Range{Start: b.start, End: b.units[0].End}
+	currentWords := countRangeWords(data, b.units[0])
+
+	for _, u := range b.units[1:] {
+		words := countRangeWords(data, u)
+
+		if currentWords+words > budget {
+			parts = append(parts, current)
+			current = u
+			currentWords = words
+			continue
+		}
+
+		current.End = u.End
+		currentWords += words
+	}
+
+	current.End = b.end
+	parts = append(parts, current)
+
+	return parts
+}
+
+// tableBlock returns the block of the given table: its lines are the header
+// line, the delimiter line then one line per row
+func tableBlock(data []byte, table *east.Table) *atomicBlock {
+	// Locate the table from its first non empty cell
+	rows := 0
+	position := -1
+	line := 0
+
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		if position == -1 {
+			for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				if lines := cell.Lines(); lines.Len() > 0 {
+					position = lines.At(0).Start
+					line = rows
+					// The delimiter line follows the header one
+					if rows > 0 {
+						line++
+					}
+					break
+				}
+			}
+		}
+
+		rows++
+	}
+
+	if position == -1 || rows < 2 {
+		return nil
+	}
+
+	start := lineStart(data, position)
+	for range line {
+		if start == 0 {
+			return nil
+		}
+
+		start = lineStart(data, start-1)
+	}
+
+	lines := make([]model.Range, 0, rows+1)
+	offset := start
+
+	for range rows + 1 {
+		end := lineEnd(data, offset)
+		lines = append(lines, model.Range{Start: offset, End: end})
+		offset = end
+	}
+
+	return &atomicBlock{
+		start:  start,
+		end:    lines[len(lines)-1].End,
+		header: model.Range{Start: lines[0].Start, End: lines[1].End},
+		units:  lines[2:],
+	}
+}
+
+// codeBlock returns the block of the given fenced code block, which can be
+// split at its blank lines
+func codeBlock(data []byte, code *ast.FencedCodeBlock) *atomicBlock {
+	lines := code.Lines()
+	if lines.Len() == 0 {
+		return nil
+	}
+
+	contentStart := lineStart(data, lines.At(0).Start)
+	if contentStart == 0 {
+		return nil
+	}
+
+	contentEnd := lineEnd(data, lines.At(lines.Len()-1).Start)
+
+	block := &atomicBlock{
+		start:  lineStart(data, contentStart-1),
+		end:    contentEnd,
+		header: model.Range{Start: lineStart(data, contentStart-1), End: contentStart},
+		units:  make([]model.Range, 0),
+	}
+
+	// The closing fence is missing when the block is left open at the end of
+	// the document
+	if contentEnd < len(data) {
+		closingEnd := lineEnd(data, contentEnd)
+		fence := bytes.TrimSpace(data[contentEnd:closingEnd])
+		if bytes.HasPrefix(fence, []byte("```")) || bytes.HasPrefix(fence, []byte("~~~")) {
+			block.footer = model.Range{Start: contentEnd, End: closingEnd}
+			block.end = closingEnd
+		}
+	}
+
+	// Units are the runs of non blank lines, the blank lines separating them
+	// being dropped when the block is split
+	unitStart := -1
+	unitEnd := -1
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		if len(bytes.TrimSpace(line.Value(data))) > 0 {
+			if unitStart == -1 {
+				unitStart = lineStart(data, line.Start)
+			}
+			unitEnd = lineEnd(data, line.Start)
+			continue
+		}
+
+		if unitStart != -1 {
+			block.units = append(block.units, model.Range{Start: unitStart, End: unitEnd})
+			unitStart = -1
+		}
+	}
+
+	if unitStart != -1 {
+		block.units = append(block.units, model.Range{Start: unitStart, End: unitEnd})
+	}
+
+	return block
+}
+
+// lineStart returns the offset of the start of the line containing the given
+// offset
+func lineStart(data []byte, offset int) int {
+	return bytes.LastIndexByte(data[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset following the end of the line containing the
+// given offset, line feed included
+func lineEnd(data []byte, offset int) int {
+	idx := bytes.IndexByte(data[offset:], '\n')
+	if idx == -1 {
+		return len(data)
+	}
+
+	return offset + idx + 1
+}
+
+func countRangeWords(data []byte, r model.Range) int {
+	if r.Empty() {
+		return 0
+	}
+
+	return len(corpusText.SplitByWords(string(data[r.Start:r.End])))
+}
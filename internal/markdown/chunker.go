@@ -0,0 +1,258 @@
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"slices"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+	meta "github.com/yuin/goldmark-meta"
+	gmParser "github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Chunker splits the data of a markdown document into sections. Whatever the
+// strategy, sections keep their byte offsets into the document data.
+type Chunker interface {
+	Chunk(ctx context.Context, data []byte) (*Document, error)
+}
+
+type ChunkerFunc func(ctx context.Context, data []byte) (*Document, error)
+
+func (fn ChunkerFunc) Chunk(ctx context.Context, data []byte) (*Document, error) {
+	return fn(ctx, data)
+}
+
+// Available chunking strategies
+const (
+	ChunkerHeading        = "heading"
+	ChunkerSentenceWindow = "sentence"
+	ChunkerFixed          = "fixed"
+	ChunkerSemantic       = "semantic"
+)
+
+type ChunkerOptions struct {
+	// MaxWords is the maximum number of words of a section (heading and
+	// semantic strategies) or the number of words of each chunk (fixed
+	// strategy)
+	MaxWords int
+	// Overlap is the number of words shared by consecutive chunks (fixed
+	// strategy)
+	Overlap int
+	// Sentences is the number of sentences of each window and SentenceOverlap
+	// the number of sentences shared by consecutive windows (sentence strategy)
+	Sentences       int
+	SentenceOverlap int
+	// Percentile is the percentile of the distances between consecutive
+	// sentences above which a new section is started (semantic strategy)
+	Percentile float64
+	// Embeddings is the client used to embed the sentences (semantic strategy)
+	Embeddings llm.EmbeddingsClient
+}
+
+type ChunkerOptionFunc func(opts *ChunkerOptions)
+
+func NewChunkerOptions(funcs ...ChunkerOptionFunc) *ChunkerOptions {
+	opts := &ChunkerOptions{
+		MaxWords:        DefaultChunkWords,
+		Overlap:         DefaultChunkOverlap,
+		Sentences:       DefaultWindowSentences,
+		SentenceOverlap: DefaultWindowOverlap,
+		Percentile:      DefaultSemanticPercentile,
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+func WithChunkerMaxWords(maxWords int) ChunkerOptionFunc {
+	return func(opts *ChunkerOptions) {
+		opts.MaxWords = maxWords
+	}
+}
+
+func WithChunkerOverlap(overlap int) ChunkerOptionFunc {
+	return func(opts *ChunkerOptions) {
+		opts.Overlap = overlap
+	}
+}
+
+func WithChunkerSentences(sentences int, overlap int) ChunkerOptionFunc {
+	return func(opts *ChunkerOptions) {
+		opts.Sentences = sentences
+		opts.SentenceOverlap = overlap
+	}
+}
+
+func WithChunkerPercentile(percentile float64) ChunkerOptionFunc {
+	return func(opts *ChunkerOptions) {
+		opts.Percentile = percentile
+	}
+}
+
+func WithChunkerEmbeddings(client llm.EmbeddingsClient) ChunkerOptionFunc {
+	return func(opts *ChunkerOptions) {
+		opts.Embeddings = client
+	}
+}
+
+// NewChunker creates the chunker implementing the given strategy
+func NewChunker(strategy string, funcs ...ChunkerOptionFunc) (Chunker, error) {
+	opts := NewChunkerOptions(funcs...)
+
+	switch strategy {
+	case ChunkerHeading, "":
+		return NewHeadingChunker(WithMaxWordPerSection(opts.MaxWords)), nil
+	case ChunkerSentenceWindow:
+		return NewSentenceWindowChunker(opts.Sentences, opts.SentenceOverlap), nil
+	case ChunkerFixed:
+		return NewFixedTokenChunker(opts.MaxWords, opts.Overlap), nil
+	case ChunkerSemantic:
+		if opts.Embeddings == nil {
+			return nil, errors.New("the semantic chunker requires an embeddings client")
+		}
+
+		return NewSemanticChunker(opts.Embeddings, opts.Percentile, opts.MaxWords), nil
+	default:
+		return nil, errors.Errorf("unknown chunking strategy '%s'", strategy)
+	}
+}
+
+// HeadingChunker splits the document on its headings, sections exceeding the
+// maximum number of words being split in turn. See Parse.
+type HeadingChunker struct {
+	funcs []OptionFunc
+}
+
+// Chunk implements Chunker.
+func (c *HeadingChunker) Chunk(ctx context.Context, data []byte) (*Document, error) {
+	document, err := Parse(data, c.funcs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return document, nil
+}
+
+func NewHeadingChunker(funcs ...OptionFunc) *HeadingChunker {
+	return &HeadingChunker{
+		funcs: funcs,
+	}
+}
+
+var _ Chunker = &HeadingChunker{}
+
+// span is a byte range of the document data
+type span struct {
+	start int
+	end   int
+}
+
+// newChunkedDocument creates a document whose sections are the given chunks,
+// children of a root section spanning the document body, the same way Parse
+// structures a document without headings.
+func newChunkedDocument(data []byte, body int, chunks []span) (*Document, error) {
+	document := &Document{
+		id:          model.NewDocumentID(),
+		collections: make([]model.Collection, 0),
+		data:        data,
+	}
+
+	source, metadata, err := parseFrontMatter(data[:body])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	document.source = source
+	document.metadata = metadata
+
+	root := &Section{
+		id:       model.NewSectionID(),
+		document: document,
+		level:    0,
+		sections: make([]*Section, 0),
+		start:    body,
+		end:      len(data),
+	}
+
+	root.branch = []model.SectionID{root.id}
+
+	document.sections = []*Section{root}
+
+	// A single chunk is the root section itself
+	if len(chunks) > 1 {
+		for _, c := range chunks {
+			section := &Section{
+				id:       model.NewSectionID(),
+				document: document,
+				level:    1,
+				parent:   root,
+				sections: make([]*Section, 0),
+				start:    c.start,
+				end:      c.end,
+			}
+
+			section.branch = append(slices.Clone(root.branch), section.id)
+
+			root.sections = append(root.sections, section)
+		}
+	}
+
+	document.assignSectionIDs()
+
+	return document, nil
+}
+
+// frontMatterEnd returns the offset of the document body, i.e. after the
+// front matter block if any
+func frontMatterEnd(data []byte) int {
+	firstLine, rest, found := bytes.Cut(data, []byte("\n"))
+	if !found || string(bytes.TrimSpace(firstLine)) != "---" {
+		return 0
+	}
+
+	offset := len(firstLine) + 1
+
+	for len(rest) > 0 {
+		var line []byte
+		line, rest, found = bytes.Cut(rest, []byte("\n"))
+
+		offset += len(line)
+		if found {
+			offset++
+		}
+
+		if trimmed := string(bytes.TrimSpace(line)); trimmed == "---" || trimmed == "..." {
+			return offset
+		}
+	}
+
+	return 0
+}
+
+// parseFrontMatter returns the source url and the metadata declared in the
+// given front matter block, if any
+func parseFrontMatter(frontMatter []byte) (*url.URL, map[string][]string, error) {
+	if len(frontMatter) == 0 {
+		return nil, nil, nil
+	}
+
+	context := gmParser.NewContext()
+
+	New().Parser().Parse(text.NewReader(frontMatter), gmParser.WithContext(context))
+
+	values := meta.Get(context)
+
+	source, err := sourceFromMetadata(values)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return source, metadataFromFrontMatter(values), nil
+}
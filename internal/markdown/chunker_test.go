@@ -0,0 +1,177 @@
+package markdown
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// topicsEmbeddingsClient embeds each input on the axis of the first known
+// topic it mentions
+type topicsEmbeddingsClient struct {
+	topics []string
+}
+
+func (c *topicsEmbeddingsClient) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	embeddings := make([][]float64, len(inputs))
+	for idx, input := range inputs {
+		vector := make([]float64, len(c.topics)+1)
+		vector[len(c.topics)] = 0.1
+		for topicIdx, topic := range c.topics {
+			if strings.Contains(strings.ToLower(input), topic) {
+				vector[topicIdx] = 1
+				break
+			}
+		}
+		embeddings[idx] = vector
+	}
+
+	return &embeddingsResponse{embeddings: embeddings}, nil
+}
+
+type embeddingsResponse struct {
+	embeddings [][]float64
+}
+
+func (r *embeddingsResponse) Embeddings() [][]float64 {
+	return r.embeddings
+}
+
+func (r *embeddingsResponse) Usage() llm.EmbeddingsUsage {
+	return llm.NewEmbeddingsUsage(0, 0)
+}
+
+func TestChunkers(t *testing.T) {
+	const frontMatter = "---\nsource: https://example.net/transcript\n---\n"
+
+	sentences := strings.Join([]string{
+		"Cats sleep most of the day.",
+		"A cat purrs when it is happy!",
+		"Do cats really have nine lives?",
+		"Rust has no garbage collector.",
+		"The rust borrow checker validates references.",
+		"Cargo builds rust crates.",
+		"Rust compiles to native code.",
+	}, " ")
+
+	words := make([]string, 25)
+	for idx := range words {
+		words[idx] = "word"
+	}
+
+	type testCase struct {
+		Name     string
+		Data     string
+		Chunker  Chunker
+		Expected []string
+	}
+
+	testCases := []testCase{
+		{
+			Name:    "Fixed",
+			Data:    frontMatter + strings.Join(words, " ") + ".",
+			Chunker: NewFixedTokenChunker(10, 2),
+			Expected: []string{
+				strings.Join(words[0:10], " "),
+				strings.Join(words[8:18], " "),
+				strings.Join(words[16:25], " ") + ".",
+			},
+		},
+		{
+			Name:    "SentenceWindow",
+			Data:    frontMatter + sentences,
+			Chunker: NewSentenceWindowChunker(3, 1),
+			Expected: []string{
+				"Cats sleep most of the day. A cat purrs when it is happy! Do cats really have nine lives?",
+				"Do cats really have nine lives? Rust has no garbage collector. The rust borrow checker validates references.",
+				"The rust borrow checker validates references. Cargo builds rust crates. Rust compiles to native code.",
+			},
+		},
+		{
+			Name:    "Semantic",
+			Data:    frontMatter + sentences,
+			Chunker: NewSemanticChunker(&topicsEmbeddingsClient{topics: []string{"cat", "rust"}}, 0.9, 250),
+			Expected: []string{
+				"Cats sleep most of the day. A cat purrs when it is happy! Do cats really have nine lives?",
+				"Rust has no garbage collector. The rust borrow checker validates references. Cargo builds rust crates. Rust compiles to native code.",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			document, err := tc.Chunker.Chunk(context.Background(), []byte(tc.Data))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if document.Source() == nil || document.Source().String() != "https://example.net/transcript" {
+				t.Errorf("document.Source(): expected front matter source, got '%v'", document.Source())
+			}
+
+			if e, g := 1, len(document.Sections()); e != g {
+				t.Fatalf("len(document.Sections()): expected %d, got %d", e, g)
+			}
+
+			root := document.Sections()[0]
+			chunks := root.Sections()
+
+			if e, g := len(tc.Expected), len(chunks); e != g {
+				t.Fatalf("len(chunks): expected %d, got %d", e, g)
+			}
+
+			for idx, chunk := range chunks {
+				// Sections keep their byte offsets into the document data
+				data, err := document.Chunk(chunk.Start(), chunk.End())
+				if err != nil {
+					t.Fatalf("%+v", errors.WithStack(err))
+				}
+
+				if e, g := tc.Expected[idx], strings.TrimSpace(string(data)); e != g {
+					t.Errorf("chunks[%d]: expected '%s', got '%s'", idx, e, g)
+				}
+
+				if e, g := []model.SectionID{root.ID(), chunk.ID()}, chunk.Branch(); len(g) != 2 || e[0] != g[0] || e[1] != g[1] {
+					t.Errorf("chunks[%d].Branch(): expected %v, got %v", idx, e, g)
+				}
+
+				if e, g := uint(1), chunk.Level(); e != g {
+					t.Errorf("chunks[%d].Level(): expected %d, got %d", idx, e, g)
+				}
+			}
+		})
+	}
+}
+
+func TestSemanticChunkerMaxWords(t *testing.T) {
+	data := []byte("One two three. Four five six. Seven eight nine. Ten eleven twelve.")
+
+	chunker := NewSemanticChunker(&topicsEmbeddingsClient{}, 0.9, 6)
+
+	document, err := chunker.Chunk(context.Background(), data)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	chunks := document.Sections()[0].Sections()
+
+	if e, g := 2, len(chunks); e != g {
+		t.Fatalf("len(chunks): expected %d, got %d", e, g)
+	}
+
+	for idx, chunk := range chunks {
+		content, err := document.Chunk(chunk.Start(), chunk.End())
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		if total := len(text.SplitByWords(string(content))); total > 6 {
+			t.Errorf("chunks[%d]: expected at most 6 words, got %d", idx, total)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package markdown
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net/url"
+	"slices"
+	"strconv"
 
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/pkg/errors"
@@ -14,6 +18,7 @@ type Document struct {
 	source      *url.URL
 	collections []model.Collection
 	sections    []*Section
+	metadata    map[string][]string
 }
 
 // ETag implements model.Document.
@@ -95,6 +100,26 @@ func (d *Document) Sections() []model.Section {
 	return sections
 }
 
+// Metadata implements model.WithMetadata.
+func (d *Document) Metadata() map[string][]string {
+	return d.metadata
+}
+
+// SetMetadata replaces the values of the given metadata key, removing it if
+// no value is given.
+func (d *Document) SetMetadata(key string, values ...string) {
+	if len(values) == 0 {
+		delete(d.metadata, key)
+		return
+	}
+
+	if d.metadata == nil {
+		d.metadata = map[string][]string{}
+	}
+
+	d.metadata[key] = values
+}
+
 // Source implements model.Document.
 func (d *Document) Source() *url.URL {
 	return d.source
@@ -102,9 +127,62 @@ func (d *Document) Source() *url.URL {
 
 func (d *Document) SetSource(source *url.URL) {
 	d.source = source
+	d.assignSectionIDs()
 }
 
-var _ model.Document = &Document{}
+// assignSectionIDs derives the identity of each section from the document
+// source and the section content, so that unchanged sections keep their
+// identity across parsings of the same document.
+func (d *Document) assignSectionIDs() {
+	source := ""
+	if d.source != nil {
+		source = d.source.String()
+	}
+
+	occurrences := map[string]int{}
+
+	var assign func(s *Section)
+	assign = func(s *Section) {
+		// The chunk is read from the document data, it cannot fail
+		content, _ := s.Content()
+
+		hash := sha256.New()
+		hash.Write([]byte(source))
+		hash.Write([]byte{0})
+		hash.Write(content)
+
+		// Sections sharing the same content are told apart by their order of
+		// appearance
+		key := string(hash.Sum(nil))
+		hash.Write([]byte(strconv.Itoa(occurrences[key])))
+		occurrences[key]++
+
+		s.id = model.SectionID(hex.EncodeToString(hash.Sum(nil))[:sectionIDLength])
+
+		if s.parent != nil {
+			s.branch = append(slices.Clone(s.parent.branch), s.id)
+		} else {
+			s.branch = []model.SectionID{s.id}
+		}
+
+		for _, child := range s.sections {
+			assign(child)
+		}
+	}
+
+	for _, s := range d.sections {
+		assign(s)
+	}
+}
+
+// sectionIDLength is the length of the content derived section identifiers,
+// matching the length of the random ones
+const sectionIDLength = 20
+
+var (
+	_ model.Document     = &Document{}
+	_ model.WithMetadata = &Document{}
+)
 
 type Section struct {
 	id       model.SectionID
@@ -115,11 +193,15 @@ type Section struct {
 	sections []*Section
 	start    int
 	end      int
+	// prefix and suffix frame the content of the parts of a split table or
+	// code block
+	prefix model.Range
+	suffix model.Range
 }
 
 // Content implements model.Section.
 func (s *Section) Content() ([]byte, error) {
-	chunk, err := s.Document().Chunk(s.start, s.end)
+	chunk, err := model.FramedChunk(s.Document(), s.start, s.end, s.prefix, s.suffix)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -142,6 +224,11 @@ func (s *Section) Start() int {
 	return s.start
 }
 
+// Frame implements model.FramedSection.
+func (s *Section) Frame() (model.Range, model.Range) {
+	return s.prefix, s.suffix
+}
+
 // Branch implements model.Section.
 func (s *Section) Branch() []model.SectionID {
 	return s.branch
@@ -185,4 +272,4 @@ func (s *Section) Sections() []model.Section {
 	return sections
 }
 
-var _ model.Section = &Section{}
+var _ model.FramedSection = &Section{}
@@ -0,0 +1,71 @@
+package markdown
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/internal/text"
+)
+
+const (
+	DefaultChunkWords   = 250
+	DefaultChunkOverlap = 50
+)
+
+// FixedTokenChunker splits the document into chunks of a fixed number of
+// tokens, consecutive chunks sharing some tokens. As everywhere else in the
+// indexing, tokens are approximated by words.
+type FixedTokenChunker struct {
+	size    int
+	overlap int
+}
+
+// Chunk implements Chunker.
+func (c *FixedTokenChunker) Chunk(ctx context.Context, data []byte) (*Document, error) {
+	body := frontMatterEnd(data)
+	words := text.SplitByWords(string(data[body:]))
+
+	chunks := make([]span, 0)
+	stride := c.size - c.overlap
+
+	for first := 0; first < len(words); first += stride {
+		last := min(first+c.size, len(words))
+
+		chunk := span{
+			start: body + words[first].Start,
+			end:   len(data),
+		}
+
+		// The chunk ends right before the first word of the next one, keeping
+		// the trailing punctuation
+		if last < len(words) {
+			chunk.end = body + words[last].Start
+		}
+
+		chunks = append(chunks, chunk)
+
+		if last == len(words) {
+			break
+		}
+	}
+
+	return newChunkedDocument(data, body, chunks)
+}
+
+// NewFixedTokenChunker creates a chunker splitting the document into chunks of
+// size words, consecutive chunks sharing overlap words.
+func NewFixedTokenChunker(size int, overlap int) *FixedTokenChunker {
+	if size <= 0 {
+		size = DefaultChunkWords
+	}
+
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	return &FixedTokenChunker{
+		size:    size,
+		overlap: overlap,
+	}
+}
+
+var _ Chunker = &FixedTokenChunker{}
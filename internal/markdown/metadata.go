@@ -0,0 +1,79 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sourceMetadataKey is the front matter key declaring the document source,
+// kept apart from the other metadata
+const sourceMetadataKey = "source"
+
+// metadataFromFrontMatter converts the values of the front matter into
+// metadata: scalars are formatted as strings, lists give one value per item
+// and nested mappings are flattened with dotted keys (e.g. "author.name").
+// The source is excluded, see sourceFromMetadata.
+func metadataFromFrontMatter(values map[string]any) map[string][]string {
+	metadata := map[string][]string{}
+
+	for key, value := range values {
+		if key == sourceMetadataKey {
+			continue
+		}
+
+		flattenMetadata(metadata, key, value)
+	}
+
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	return metadata
+}
+
+func flattenMetadata(metadata map[string][]string, key string, value any) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return
+
+	case map[string]any:
+		for k, nested := range v {
+			flattenMetadata(metadata, key+"."+k, nested)
+		}
+
+	case map[any]any:
+		for k, nested := range v {
+			flattenMetadata(metadata, key+"."+fmt.Sprintf("%v", k), nested)
+		}
+
+	case []any:
+		for _, item := range v {
+			flattenMetadata(metadata, key, item)
+		}
+
+	default:
+		if s := formatMetadataValue(v); s != "" {
+			metadata[key] = append(metadata[key], s)
+		}
+	}
+}
+
+func formatMetadataValue(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(time.DateOnly)
+		}
+
+		return v.Format(time.RFC3339)
+
+	default:
+		return strings.TrimSpace(fmt.Sprintf("%v", v))
+	}
+}
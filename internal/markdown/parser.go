@@ -1,6 +1,7 @@
 package markdown
 
 import (
+	"fmt"
 	"net/url"
 	"slices"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
 	gmParser "github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
@@ -91,19 +93,96 @@ func Parse(data []byte, funcs ...OptionFunc) (*Document, error) {
 
 	document.sections = []*Section{current}
 
-	metadata := meta.Get(context)
-	if rawSource, exists := metadata["source"]; exists {
-		source, err := url.Parse(rawSource.(string))
+	frontMatter := meta.Get(context)
+
+	source, err := sourceFromMetadata(frontMatter)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	document.source = source
+	document.metadata = metadataFromFrontMatter(frontMatter)
+
+	split := false
+
+	// splitSection starts a new section at the given offset, child of the
+	// current section on its first split and sibling of it on the following
+	// ones
+	splitSection := func(start int) {
+		previous := current
+
+		current = &Section{
+			document: document,
+			id:       model.NewSectionID(),
+			level:    uint(previous.level + 1),
+			sections: make([]*Section, 0),
+			parent:   previous,
+			start:    start,
+			end:      start,
+		}
+
+		if split {
+			current.level = previous.level
+			current.parent = previous.parent
+		}
+
+		current.branch = append(slices.Clone(current.parent.branch), current.id)
+		current.parent.sections = append(current.parent.sections, current)
+
+		split = true
+	}
+
+	// splitIfFull starts a new section if the current one reached the maximum
+	// number of words
+	splitIfFull := func() error {
+		currentChunk, err := current.Content()
 		if err != nil {
-			return nil, errors.Wrapf(err, "could not parse metadata source url '%v'", rawSource)
+			return errors.WithStack(err)
 		}
 
-		document.source = source
+		if totalWords := len(corpusText.SplitByWords(string(currentChunk))); totalWords < opts.MaxWordPerSection {
+			return nil
+		}
+
+		splitSection(current.end)
+
+		return nil
 	}
 
-	split := false
+	// appendBlock appends a table or a code block to the current section,
+	// keeping it whole if possible. Oversized blocks are split into parts, each
+	// one in its own section framed by the block header and footer.
+	appendBlock := func(block *atomicBlock) error {
+		parts := block.parts(data, opts.MaxWordPerSection)
 
-	err := ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if len(parts) < 2 {
+			current.AppendRange(block.end)
+			return errors.WithStack(splitIfFull())
+		}
+
+		for idx, part := range parts {
+			// Reuse the section freshly started by a previous split
+			if idx == 0 && split && current.start == current.end {
+				current.start = part.Start
+			} else {
+				splitSection(part.Start)
+			}
+
+			current.AppendRange(part.End)
+
+			if idx > 0 {
+				current.prefix = block.header
+			}
+
+			if idx < len(parts)-1 {
+				current.suffix = block.footer
+			}
+		}
+
+		return errors.WithStack(splitIfFull())
+	}
+
+	err = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			if !split && current.parent != nil && current.start == current.parent.start && current.end == current.parent.end {
 				current.parent.sections = slices.DeleteFunc(current.parent.sections, func(s *Section) bool { return s == current })
@@ -165,6 +244,30 @@ func Parse(data []byte, funcs ...OptionFunc) (*Document, error) {
 				return ast.WalkContinue, nil
 			}
 
+		case *east.Table:
+			block := tableBlock(data, el)
+			if block == nil {
+				return ast.WalkContinue, nil
+			}
+
+			if err := appendBlock(block); err != nil {
+				return ast.WalkStop, errors.WithStack(err)
+			}
+
+			return ast.WalkSkipChildren, nil
+
+		case *ast.FencedCodeBlock:
+			block := codeBlock(data, el)
+			if block == nil {
+				return ast.WalkContinue, nil
+			}
+
+			if err := appendBlock(block); err != nil {
+				return ast.WalkStop, errors.WithStack(err)
+			}
+
+			return ast.WalkSkipChildren, nil
+
 		default:
 			var (
 				end int
@@ -174,9 +277,6 @@ func Parse(data []byte, funcs ...OptionFunc) (*Document, error) {
 				if lines := n.Lines(); lines.Len() > 0 {
 					lastLine := lines.At(lines.Len() - 1)
 					end = lastLine.Stop
-					if _, isCodeBlock := n.(*ast.FencedCodeBlock); isCodeBlock {
-						end += 4
-					}
 				} else {
 					return ast.WalkContinue, nil
 				}
@@ -190,38 +290,9 @@ func Parse(data []byte, funcs ...OptionFunc) (*Document, error) {
 
 			current.AppendRange(end)
 
-			currentChunk, err := current.Content()
-			if err != nil {
+			if err := splitIfFull(); err != nil {
 				return ast.WalkStop, errors.WithStack(err)
 			}
-
-			if totalWords := len(corpusText.SplitByWords(string(currentChunk))); totalWords < opts.MaxWordPerSection {
-				return ast.WalkContinue, nil
-			}
-
-			current = &Section{
-				document: document,
-				id:       model.NewSectionID(),
-				level:    uint(current.level + 1),
-				sections: make([]*Section, 0),
-				parent:   previous,
-				start:    current.end,
-				end:      end,
-			}
-
-			current.branch = append(previous.branch, current.id)
-
-			if split {
-				current.level = previous.level
-				current.parent = previous.parent
-				current.branch = append(previous.parent.branch, current.id)
-				previous.parent.sections = append(previous.parent.sections, current)
-			} else {
-				current.start = previous.end
-				current.parent.sections = append(current.parent.sections, current)
-			}
-
-			split = true
 		}
 
 		return ast.WalkContinue, nil
@@ -230,9 +301,27 @@ func Parse(data []byte, funcs ...OptionFunc) (*Document, error) {
 		return nil, errors.WithStack(err)
 	}
 
+	document.assignSectionIDs()
+
 	return document, nil
 }
 
+// sourceFromMetadata returns the source url declared in the document front
+// matter, if any
+func sourceFromMetadata(metadata map[string]any) (*url.URL, error) {
+	rawSource, exists := metadata[sourceMetadataKey]
+	if !exists {
+		return nil, nil
+	}
+
+	source, err := url.Parse(fmt.Sprintf("%v", rawSource))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse metadata source url '%v'", rawSource)
+	}
+
+	return source, nil
+}
+
 func findClosestAncestor(from *Section, level uint) *Section {
 	if from == nil {
 		return nil
@@ -1,8 +1,11 @@
 package markdown
 
 import (
+	"context"
+	"net/url"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -112,3 +115,211 @@ func dumpSection(t *testing.T, section model.Section, indent string) {
 		}
 	}
 }
+
+func TestSectionIdentities(t *testing.T) {
+	source, _ := url.Parse("https://example.net/doc")
+
+	parse := func(content string) *Document {
+		doc, err := Parse([]byte(content))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		doc.SetSource(source)
+
+		return doc
+	}
+
+	sectionIDs := func(doc *Document) []model.SectionID {
+		ids := make([]model.SectionID, 0)
+		model.WalkSections(doc, func(s model.Section) error {
+			ids = append(ids, s.ID())
+			return nil
+		})
+		return ids
+	}
+
+	original := "Introduction\n\n# Title\n\n## First\n\nFirst paragraph.\n\n## Second\n\nSecond paragraph.\n"
+
+	previous := sectionIDs(parse(original))
+
+	if e, g := previous, sectionIDs(parse(original)); !slices.Equal(e, g) {
+		t.Fatalf("sectionIDs: expected '%v', got '%v'", e, g)
+	}
+
+	// Modifying the second section keeps the identity of the first one
+	next := parse(strings.Replace(original, "Second paragraph.", "Modified paragraph.", 1))
+
+	diff := model.DiffSections(previous, next)
+
+	if diff.Empty() {
+		t.Fatalf("diff.Empty(): expected false")
+	}
+
+	if e, g := len(diff.Added), len(diff.Removed); e != g {
+		t.Errorf("len(diff.Removed): expected '%d', got '%d'", e, g)
+	}
+
+	first := sectionIDs(next)[2]
+	if !slices.Contains(diff.Unchanged, first) {
+		t.Errorf("diff.Unchanged: expected '%v' to contain '%s'", diff.Unchanged, first)
+	}
+}
+
+func TestParserAtomicBlocks(t *testing.T) {
+	type testCase struct {
+		File              string
+		MaxWordPerSection int
+		// Marker identifies the sections holding a part of the split block
+		Marker        string
+		ExpectedParts int
+		Assert        func(t *testing.T, content string)
+	}
+
+	testCases := []testCase{
+		{
+			File:              "testdata/table.md",
+			MaxWordPerSection: 40,
+			Marker:            "| `param",
+			ExpectedParts:     6,
+			Assert: func(t *testing.T, content string) {
+				// The last part may be followed by the next blocks of the section
+				table, _, _ := strings.Cut(content, "\n\n")
+				lines := strings.Split(table, "\n")
+
+				if !strings.HasPrefix(lines[0], "| Name") {
+					t.Errorf("expected table part to start with the header row, got '%s'", lines[0])
+				}
+
+				if len(lines) < 2 || !strings.HasPrefix(lines[1], "|--") {
+					t.Errorf("expected table part to repeat the delimiter row, got '%s'", content)
+				}
+
+				for _, l := range lines[2:] {
+					if !strings.HasPrefix(l, "| `param") || !strings.HasSuffix(l, "|") {
+						t.Errorf("expected table part to contain whole rows, got '%s'", l)
+					}
+				}
+			},
+		},
+		{
+			File:              "testdata/code.md",
+			MaxWordPerSection: 40,
+			Marker:            "func handler",
+			ExpectedParts:     4,
+			Assert: func(t *testing.T, content string) {
+				if !strings.HasPrefix(content, "```go\n") {
+					t.Errorf("expected code part to start with the opening fence, got '%s'", content)
+				}
+
+				if !strings.Contains(content, "}\n```") {
+					t.Errorf("expected code part to end with the closing fence, got '%s'", content)
+				}
+
+				if e, g := strings.Count(content, "func handler"), strings.Count(content, "response number"); e != g {
+					t.Errorf("expected code part to contain whole functions, got '%s'", content)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.File, func(t *testing.T) {
+			data, err := os.ReadFile(tc.File)
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			doc, err := Parse(data, WithMaxWordPerSection(tc.MaxWordPerSection))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			parts := 0
+
+			err = model.WalkSections(doc, func(s model.Section) error {
+				if len(s.Sections()) > 0 {
+					return nil
+				}
+
+				content, err := s.Content()
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				if !strings.Contains(string(content), tc.Marker) {
+					return nil
+				}
+
+				parts++
+
+				tc.Assert(t, strings.TrimSpace(string(content)))
+
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if e, g := tc.ExpectedParts, parts; e != g {
+				dumpDocument(t, doc)
+				t.Errorf("parts: expected '%d', got '%d'", e, g)
+			}
+		})
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	data := []byte(`---
+source: https://example.net/guide
+title: The guide
+tags: [go, rag]
+author:
+  name: Jane Doe
+date: 2024-05-01
+draft: false
+---
+
+# The guide
+
+Some content.
+`)
+
+	expected := map[string][]string{
+		"title":       {"The guide"},
+		"tags":        {"go", "rag"},
+		"author.name": {"Jane Doe"},
+		"date":        {"2024-05-01"},
+		"draft":       {"false"},
+	}
+
+	chunkers := map[string]Chunker{
+		ChunkerHeading: NewHeadingChunker(),
+		ChunkerFixed:   NewFixedTokenChunker(5, 0),
+	}
+
+	for name, chunker := range chunkers {
+		t.Run(name, func(t *testing.T) {
+			doc, err := chunker.Chunk(context.Background(), data)
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if e, g := "https://example.net/guide", doc.Source().String(); e != g {
+				t.Errorf("doc.Source(): expected '%s', got '%s'", e, g)
+			}
+
+			metadata := doc.Metadata()
+
+			if e, g := len(expected), len(metadata); e != g {
+				t.Errorf("len(doc.Metadata()): expected %d, got %d (%v)", e, g, metadata)
+			}
+
+			for key, values := range expected {
+				if !slices.Equal(values, metadata[key]) {
+					t.Errorf("doc.Metadata()[%s]: expected %v, got %v", key, values, metadata[key])
+				}
+			}
+		})
+	}
+}
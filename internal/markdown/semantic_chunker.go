@@ -0,0 +1,132 @@
+package markdown
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+const DefaultSemanticPercentile = 0.9
+
+// semanticEmbeddingsBatchSize is the maximum number of sentences embedded per
+// request
+const semanticEmbeddingsBatchSize = 64
+
+// SemanticChunker embeds each sentence of the document and starts a new
+// section where the distance between two consecutive sentences is among the
+// highest ones, i.e. where the topic shifts. Sections exceeding the maximum
+// number of words are split too.
+type SemanticChunker struct {
+	embeddings llm.EmbeddingsClient
+	percentile float64
+	maxWords   int
+}
+
+// Chunk implements Chunker.
+func (c *SemanticChunker) Chunk(ctx context.Context, data []byte) (*Document, error) {
+	body := frontMatterEnd(data)
+	sentences := splitSentences(data, body)
+
+	if len(sentences) < 2 {
+		return newChunkedDocument(data, body, sentences)
+	}
+
+	vectors := make([][]float64, 0, len(sentences))
+
+	for batch := range slices.Chunk(sentences, semanticEmbeddingsBatchSize) {
+		inputs := make([]string, 0, len(batch))
+		for _, s := range batch {
+			inputs = append(inputs, string(data[s.start:s.end]))
+		}
+
+		res, err := c.embeddings.Embeddings(ctx, inputs)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not embed sentences")
+		}
+
+		embeddings := res.Embeddings()
+		if len(embeddings) != len(batch) {
+			return nil, errors.New("vector count mismatch")
+		}
+
+		vectors = append(vectors, embeddings...)
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for idx := range distances {
+		distances[idx] = 1 - cosineSimilarity(vectors[idx], vectors[idx+1])
+	}
+
+	sorted := slices.Sorted(slices.Values(distances))
+	threshold := sorted[int(c.percentile*float64(len(sorted)-1))]
+
+	chunks := make([]span, 0)
+	current := sentences[0]
+	words := countWords(data, sentences[0])
+
+	for idx, s := range sentences[1:] {
+		sentenceWords := countWords(data, s)
+
+		if distances[idx] > threshold || words+sentenceWords > c.maxWords {
+			chunks = append(chunks, current)
+			current = s
+			words = sentenceWords
+			continue
+		}
+
+		current.end = s.end
+		words += sentenceWords
+	}
+
+	chunks = append(chunks, current)
+
+	return newChunkedDocument(data, body, chunks)
+}
+
+// NewSemanticChunker creates a chunker starting a new section where the
+// distance between consecutive sentences exceeds the given percentile of all
+// the distances, sections not exceeding maxWords.
+func NewSemanticChunker(embeddings llm.EmbeddingsClient, percentile float64, maxWords int) *SemanticChunker {
+	if percentile <= 0 || percentile > 1 {
+		percentile = DefaultSemanticPercentile
+	}
+
+	if maxWords <= 0 {
+		maxWords = DefaultChunkWords
+	}
+
+	return &SemanticChunker{
+		embeddings: embeddings,
+		percentile: percentile,
+		maxWords:   maxWords,
+	}
+}
+
+var _ Chunker = &SemanticChunker{}
+
+func countWords(data []byte, s span) int {
+	return len(text.SplitByWords(string(data[s.start:s.end])))
+}
+
+func cosineSimilarity(v1, v2 []float64) float64 {
+	if len(v1) == 0 || len(v1) != len(v2) {
+		return 0
+	}
+
+	var dot, norm1, norm2 float64
+	for idx := range v1 {
+		dot += v1[idx] * v2[idx]
+		norm1 += v1[idx] * v1[idx]
+		norm2 += v2[idx] * v2[idx]
+	}
+
+	if norm1 == 0 || norm2 == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(norm1) * math.Sqrt(norm2))
+}
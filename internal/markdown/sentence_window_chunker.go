@@ -0,0 +1,152 @@
+package markdown
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	DefaultWindowSentences = 5
+	DefaultWindowOverlap   = 1
+)
+
+// SentenceWindowChunker splits the document into windows of consecutive
+// sentences, each window sharing some sentences with the previous one.
+type SentenceWindowChunker struct {
+	sentences int
+	overlap   int
+}
+
+// Chunk implements Chunker.
+func (c *SentenceWindowChunker) Chunk(ctx context.Context, data []byte) (*Document, error) {
+	body := frontMatterEnd(data)
+	sentences := splitSentences(data, body)
+
+	chunks := make([]span, 0)
+	stride := c.sentences - c.overlap
+
+	for first := 0; first < len(sentences); first += stride {
+		last := min(first+c.sentences, len(sentences)) - 1
+
+		chunks = append(chunks, span{
+			start: sentences[first].start,
+			end:   sentences[last].end,
+		})
+
+		if last == len(sentences)-1 {
+			break
+		}
+	}
+
+	return newChunkedDocument(data, body, chunks)
+}
+
+// NewSentenceWindowChunker creates a chunker grouping the given number of
+// sentences per section, consecutive sections sharing overlap sentences.
+func NewSentenceWindowChunker(sentences int, overlap int) *SentenceWindowChunker {
+	if sentences <= 0 {
+		sentences = DefaultWindowSentences
+	}
+
+	if overlap < 0 || overlap >= sentences {
+		overlap = 0
+	}
+
+	return &SentenceWindowChunker{
+		sentences: sentences,
+		overlap:   overlap,
+	}
+}
+
+var _ Chunker = &SentenceWindowChunker{}
+
+// splitSentences returns the spans of the sentences of data, starting at the
+// given offset. Sentences end with a terminal punctuation followed by a space
+// or with a blank line.
+func splitSentences(data []byte, offset int) []span {
+	sentences := make([]span, 0)
+	start := -1
+
+	for idx := offset; idx < len(data); {
+		r, size := utf8.DecodeRune(data[idx:])
+		next := idx + size
+
+		if start == -1 {
+			if !unicode.IsSpace(r) {
+				start = idx
+			}
+
+			idx = next
+			continue
+		}
+
+		switch {
+		case isTerminalPunct(r):
+			// Include the following punctuations and closing marks
+			for next < len(data) {
+				r, size := utf8.DecodeRune(data[next:])
+				if !isTerminalPunct(r) && !isClosingMark(r) {
+					break
+				}
+				next += size
+			}
+
+			if next >= len(data) {
+				sentences = append(sentences, span{start: start, end: next})
+				start = -1
+				break
+			}
+
+			if r, _ := utf8.DecodeRune(data[next:]); unicode.IsSpace(r) {
+				sentences = append(sentences, span{start: start, end: next})
+				start = -1
+			}
+
+		case r == '\n' && isBlankLine(data[next:]):
+			sentences = append(sentences, span{start: start, end: idx})
+			start = -1
+		}
+
+		idx = next
+	}
+
+	if start != -1 {
+		end := len(data)
+		for end > start {
+			r, size := utf8.DecodeLastRune(data[start:end])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			end -= size
+		}
+
+		sentences = append(sentences, span{start: start, end: end})
+	}
+
+	return sentences
+}
+
+func isTerminalPunct(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+func isClosingMark(r rune) bool {
+	return r == '"' || r == '\'' || r == ')' || r == ']' || r == '»' || r == '”' || r == '’'
+}
+
+// isBlankLine returns true if the given data starts with a line containing
+// only spaces
+func isBlankLine(data []byte) bool {
+	for _, r := range string(data) {
+		if r == '\n' {
+			return true
+		}
+
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	NameEmbeddingsCacheHits      = "embeddings_cache_hits"
+	NameEmbeddingsCacheMisses    = "embeddings_cache_misses"
+	NameEmbeddingsCacheEvictions = "embeddings_cache_evictions"
+	NameEmbeddingsCacheEntries   = "embeddings_cache_entries"
+)
+
+var EmbeddingsCacheHits = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name:      NameEmbeddingsCacheHits,
+		Help:      "Total embeddings served from the cache",
+		Namespace: Namespace,
+	},
+	[]string{LabelModel},
+)
+
+var EmbeddingsCacheMisses = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name:      NameEmbeddingsCacheMisses,
+		Help:      "Total embeddings missing from the cache",
+		Namespace: Namespace,
+	},
+	[]string{LabelModel},
+)
+
+var EmbeddingsCacheEvictions = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name:      NameEmbeddingsCacheEvictions,
+		Help:      "Total embeddings evicted from the cache",
+		Namespace: Namespace,
+	},
+)
+
+var EmbeddingsCacheEntries = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name:      NameEmbeddingsCacheEntries,
+		Help:      "Current embeddings cache entries",
+		Namespace: Namespace,
+	},
+)
@@ -14,6 +14,11 @@ func getAPIHandlerFromConfig(ctx context.Context, conf *config.Config) (*api.Han
 		return nil, errors.WithStack(err)
 	}
 
+	conversationManager, err := getConversationManager(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create conversation manager from config")
+	}
+
 	backupManager, err := getBackupManager(ctx, conf)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -29,7 +34,12 @@ func getAPIHandlerFromConfig(ctx context.Context, conf *config.Config) (*api.Han
 		return nil, errors.Wrap(err, "could not create filesystem source store from config")
 	}
 
-	handler := api.NewHandler(documentManager, backupManager, taskRunner, filesystemSourceStore)
+	groupStore, err := getGroupStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create group store from config")
+	}
+
+	handler := api.NewHandler(documentManager, conversationManager, backupManager, taskRunner, filesystemSourceStore, groupStore)
 
 	return handler, nil
 }
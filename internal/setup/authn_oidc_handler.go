@@ -8,6 +8,7 @@ import (
 
 	"github.com/bornholm/corpus/internal/config"
 	"github.com/bornholm/corpus/internal/http/middleware/authn/oidc"
+	"github.com/bornholm/corpus/internal/http/middleware/bridge"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/gothic"
 	"github.com/markbates/goth/providers/gitea"
@@ -106,8 +107,38 @@ func getOIDCAuthnHandlerFromConfig(ctx context.Context, conf *config.Config) (*o
 	goth.UseProviders(gothProviders...)
 	gothic.Store = sessionStore
 
+	userStore, err := getUserStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	groupStore, err := getGroupStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	opts := []oidc.OptionFunc{
 		oidc.WithProviders(providers...),
+		oidc.WithOnLogin(bridge.GroupsSynchronizer(userStore, groupStore)),
+	}
+
+	if groupsClaim := conf.HTTP.Authn.Providers.OIDC.GroupsClaim; groupsClaim != "" {
+		opts = append(opts, oidc.WithGroupsClaim("openid-connect", groupsClaim))
+	}
+
+	if claimRules := conf.HTTP.Authn.Providers.OIDC.ClaimRules; len(claimRules) > 0 {
+		rules := make([]oidc.ClaimRule, 0, len(claimRules))
+		for _, r := range claimRules {
+			rules = append(rules, oidc.ClaimRule{
+				Claim:  r.Claim,
+				Value:  r.Value,
+				Roles:  r.Roles,
+				Groups: r.Groups,
+				Active: r.Active,
+			})
+		}
+
+		opts = append(opts, oidc.WithClaimRules("openid-connect", rules...))
 	}
 
 	handler := oidc.NewHandler(
@@ -0,0 +1,53 @@
+package setup
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/internal/config"
+	"github.com/bornholm/corpus/internal/markdown"
+	documentTask "github.com/bornholm/corpus/internal/task/document"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/pkg/errors"
+)
+
+var getChunkersFromConfig = createFromConfigOnce(func(ctx context.Context, conf *config.Config) (*documentTask.Chunkers, error) {
+	llmClient, err := getLLMClientFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get llm client from config")
+	}
+
+	chunkerOptions := []markdown.ChunkerOptionFunc{
+		markdown.WithChunkerMaxWords(conf.LLM.Index.MaxWords),
+		markdown.WithChunkerOverlap(conf.LLM.Index.ChunkOverlap),
+		markdown.WithChunkerSentences(conf.LLM.Index.ChunkSentences, conf.LLM.Index.ChunkSentenceOverlap),
+		markdown.WithChunkerPercentile(conf.LLM.Index.ChunkSemanticPercentile),
+		markdown.WithChunkerEmbeddings(llmClient),
+	}
+
+	defaultChunker, err := markdown.NewChunker(conf.LLM.Index.Chunker, chunkerOptions...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	chunkers := documentTask.NewChunkers(defaultChunker)
+
+	for ext, strategy := range conf.LLM.Index.ChunkerExtensions {
+		chunker, err := markdown.NewChunker(strategy, chunkerOptions...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create chunker of extension '%s'", ext)
+		}
+
+		chunkers.SetExtensionChunker(ext, chunker)
+	}
+
+	for collectionID, strategy := range conf.LLM.Index.ChunkerCollections {
+		chunker, err := markdown.NewChunker(strategy, chunkerOptions...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create chunker of collection '%s'", collectionID)
+		}
+
+		chunkers.SetCollectionChunker(model.CollectionID(collectionID), chunker)
+	}
+
+	return chunkers, nil
+})
@@ -0,0 +1,38 @@
+package setup
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/internal/config"
+	"github.com/bornholm/corpus/internal/core/service"
+	"github.com/pkg/errors"
+)
+
+var getConversationManager = createFromConfigOnce(func(ctx context.Context, conf *config.Config) (*service.ConversationManager, error) {
+	store, err := getConversationStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create conversation store from config")
+	}
+
+	documentManager, err := getDocumentManager(ctx, conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	options := []service.ConversationManagerOptionFunc{
+		service.WithConversationMaxHistoryTurns(conf.LLM.Index.ConversationHistoryTurns),
+	}
+
+	if conf.LLM.Index.QueryCondensation {
+		llmClient, err := getLLMClientFromConfig(ctx, conf)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create llm client from config")
+		}
+
+		options = append(options, service.WithConversationQueryCondenser(service.NewLLMQueryCondenser(llmClient)))
+	}
+
+	conversationManager := service.NewConversationManager(store, documentManager, options...)
+
+	return conversationManager, nil
+})
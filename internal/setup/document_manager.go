@@ -19,7 +19,16 @@ var getDocumentManager = createFromConfigOnce(func(ctx context.Context, conf *co
 		return nil, errors.Wrap(err, "could not create index from config")
 	}
 
-	options := []service.DocumentManagerOptionFunc{}
+	expansion := service.ContextExpansion(conf.LLM.Index.ContextExpansion)
+	if !expansion.Valid() {
+		return nil, errors.Errorf("unknown context expansion '%s'", conf.LLM.Index.ContextExpansion)
+	}
+
+	options := []service.DocumentManagerOptionFunc{
+		service.WithMaxTotalWords(conf.LLM.Index.MaxTotalWords),
+		service.WithContextExpansion(expansion, conf.LLM.Index.ContextExpansionSiblings),
+		service.WithAgentBudget(conf.LLM.Index.AgentMaxSteps, conf.LLM.Index.AgentMaxTokens),
+	}
 
 	if conf.FileConverter.Enabled {
 		fileConverter, err := getFileConverterFromConfig(ctx, conf)
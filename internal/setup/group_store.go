@@ -0,0 +1,36 @@
+package setup
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/adapter/cache"
+	"github.com/bornholm/corpus/internal/config"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+
+	_ "github.com/asg017/sqlite-vec-go-bindings/ncruces"
+)
+
+var getGroupStoreFromConfig = createFromConfigOnce(func(ctx context.Context, conf *config.Config) (port.GroupStore, error) {
+	var (
+		store port.GroupStore
+		err   error
+	)
+
+	store, err = getGormStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	documentStore, err := getDocumentStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Group changes must invalidate the cached collection authorizations
+	if cachedDocumentStore, ok := documentStore.(*cache.DocumentStore); ok {
+		store = cache.NewGroupStore(store, cachedDocumentStore)
+	}
+
+	return store, nil
+})
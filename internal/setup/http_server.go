@@ -115,8 +115,19 @@ func NewHTTPServerFromConfig(ctx context.Context, conf *config.Config) (*http.Se
 	}
 
 	startFilesystemSourceScheduler(ctx, conf, taskRunner, filesystemSourceStore)
+	startTrashPurgeScheduler(ctx, conf, taskRunner)
 
-	webui := webui.NewHandler(documentManager, llm, taskRunner, userStore, documentStore, publicShareStore, filesystemSourceStore)
+	groupStore, err := getGroupStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create group store from config")
+	}
+
+	conversationManager, err := getConversationManager(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create conversation manager from config")
+	}
+
+	webui := webui.NewHandler(documentManager, conversationManager, llm, taskRunner, userStore, documentStore, publicShareStore, filesystemSourceStore, groupStore)
 
 	options = append(options, http.WithMount("/", authChain(webui)))
 
@@ -15,9 +15,9 @@ var getIndexFromConfig = createFromConfigOnce(func(ctx context.Context, conf *co
 		return nil, errors.Wrap(err, "could not create bleve index from config")
 	}
 
-	sqlitevecIndex, err := NewSQLiteVecIndexFromConfig(ctx, conf)
+	vectorIndex, err := newVectorIndexFromConfig(ctx, conf)
 	if err != nil {
-		return nil, errors.Wrap(err, "could not create sqlitevec index from config")
+		return nil, errors.Wrap(err, "could not create vector index from config")
 	}
 
 	llmClient, err := getLLMClientFromConfig(ctx, conf)
@@ -31,20 +31,99 @@ var getIndexFromConfig = createFromConfigOnce(func(ctx context.Context, conf *co
 	}
 
 	weightedIndexes := pipeline.WeightedIndexes{
-		pipeline.NewIdentifiedIndex("bleve", bleveIndex):         0.4,
-		pipeline.NewIdentifiedIndex("sqlitevec", sqlitevecIndex): 0.6,
+		pipeline.NewIdentifiedIndex("bleve", bleveIndex): 0.4,
+		vectorIndex: 0.6,
 	}
 
-	pipelinedIndex := pipeline.NewIndex(
-		weightedIndexes,
-		pipeline.WithQueryTransformers(
-			pipeline.NewHyDEQueryTransformer(llmClient, documentStore),
-		),
-		pipeline.WithResultsTransformers(
-			pipeline.NewDuplicateContentResultsTransformer(documentStore),
-			pipeline.NewJudgeResultsTransformer(llmClient, documentStore, conf.LLM.Index.MaxTotalWords),
-		),
-	)
+	fusion, err := getFusionFromConfig(conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	resultsTransformers := []pipeline.ResultsTransformer{
+		pipeline.NewDuplicateContentResultsTransformer(documentStore),
+	}
+
+	if conf.LLM.Index.MMR {
+		embeddingsIndex, ok := vectorIndex.Index().(port.EmbeddingsIndex)
+		if !ok {
+			return nil, errors.Errorf("vector index '%s' does not provide sections embeddings, required by mmr", vectorIndex.ID())
+		}
+
+		resultsTransformers = append(resultsTransformers, pipeline.NewMMRResultsTransformer(embeddingsIndex, conf.LLM.Index.MMRLambda, conf.LLM.Index.MMRMaxSimilarity))
+	}
+
+	resultsTransformers = append(resultsTransformers, pipeline.NewJudgeResultsTransformer(llmClient, documentStore, conf.LLM.Index.MaxTotalWords))
+
+	queryTransformers := []pipeline.QueryTransformer{}
+	queryExpanders := []pipeline.QueryExpander{}
+
+	switch conf.LLM.Index.HyDE {
+	case "replace":
+		queryTransformers = append(queryTransformers, pipeline.NewHyDEQueryTransformer(llmClient, documentStore))
+	case "expand":
+		queryExpanders = append(queryExpanders, pipeline.NewTransformerQueryExpander(pipeline.NewHyDEQueryTransformer(llmClient, documentStore)))
+	case "disabled":
+	default:
+		return nil, errors.Errorf("unknown hyde mode '%s'", conf.LLM.Index.HyDE)
+	}
+
+	if conf.LLM.Index.MultiQuery > 0 {
+		queryExpanders = append(queryExpanders, pipeline.NewParaphraseQueryExpander(llmClient, conf.LLM.Index.MultiQuery))
+	}
+
+	pipelineOptions := []pipeline.OptionFunc{
+		pipeline.WithFusion(fusion),
+		pipeline.WithQueryTransformers(queryTransformers...),
+		pipeline.WithQueryExpanders(queryExpanders...),
+		pipeline.WithResultsTransformers(resultsTransformers...),
+	}
+
+	switch conf.LLM.Index.Contextualize {
+	case "headings":
+		pipelineOptions = append(pipelineOptions, pipeline.WithContextualizer(pipeline.NewDocumentContextualizer(nil)))
+	case "summary":
+		pipelineOptions = append(pipelineOptions, pipeline.WithContextualizer(pipeline.NewDocumentContextualizer(llmClient)))
+	case "disabled":
+	default:
+		return nil, errors.Errorf("unknown contextualize mode '%s'", conf.LLM.Index.Contextualize)
+	}
+
+	pipelinedIndex := pipeline.NewIndex(weightedIndexes, pipelineOptions...)
 
 	return pipelinedIndex, nil
 })
+
+// newVectorIndexFromConfig creates the configured vector index, identified by
+// its kind in the pipeline
+func newVectorIndexFromConfig(ctx context.Context, conf *config.Config) (*pipeline.IdentifiedIndex, error) {
+	switch conf.Storage.VectorIndex {
+	case "", "sqlitevec":
+		index, err := NewSQLiteVecIndexFromConfig(ctx, conf)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create sqlitevec index from config")
+		}
+
+		return pipeline.NewIdentifiedIndex("sqlitevec", index), nil
+	case "memvec":
+		index, err := NewMemVecIndexFromConfig(ctx, conf)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create memvec index from config")
+		}
+
+		return pipeline.NewIdentifiedIndex("memvec", index), nil
+	default:
+		return nil, errors.Errorf("unknown vector index '%s'", conf.Storage.VectorIndex)
+	}
+}
+
+func getFusionFromConfig(conf *config.Config) (pipeline.Fusion, error) {
+	switch conf.LLM.Index.Fusion {
+	case "", "rrf":
+		return pipeline.NewReciprocalRankFusion(conf.LLM.Index.FusionRRFK), nil
+	case "weighted":
+		return pipeline.NewWeightedFusion(), nil
+	default:
+		return nil, errors.Errorf("unknown fusion strategy '%s'", conf.LLM.Index.Fusion)
+	}
+}
@@ -29,7 +29,12 @@ var getIndexFileTaskHandler = createFromConfigOnce(func(ctx context.Context, con
 		return nil, errors.Wrap(err, "could not create index from config")
 	}
 
-	handler := documentTask.NewIndexFileHandler(userStore, documentStore, fileConverter, index, conf.LLM.Index.MaxWords)
+	chunkers, err := getChunkersFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create chunkers from config")
+	}
+
+	handler := documentTask.NewIndexFileHandler(userStore, documentStore, fileConverter, index, chunkers)
 
 	return handler, nil
 })
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 
 	"github.com/bornholm/corpus/internal/config"
+	"github.com/bornholm/corpus/pkg/adapter/fakellm"
 	"github.com/bornholm/genai/llm"
 	"github.com/pkg/errors"
 
@@ -61,6 +62,16 @@ var getLLMClientFromConfig = createFromConfigOnce(func(ctx context.Context, conf
 		options = append(options, provider.WithEmbeddings(openrouter.Name, mistral.Options{
 			CommonOptions: embeddingsOptions,
 		}))
+
+	case fakellm.Name:
+		fakeOptions := fakellm.Options{
+			Mode:       fakellm.Mode(conf.LLM.Provider.Fake.Mode),
+			Script:     conf.LLM.Provider.Fake.Script,
+			Dimensions: conf.LLM.Provider.Fake.Dimensions,
+		}
+
+		options = append(options, provider.WithChatCompletion(fakellm.Name, fakeOptions))
+		options = append(options, provider.WithEmbeddings(fakellm.Name, fakeOptions))
 	}
 
 	client, err := provider.Create(ctx, options...)
@@ -0,0 +1,25 @@
+package setup
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/internal/config"
+	"github.com/bornholm/corpus/pkg/adapter/memvec"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func NewMemVecIndexFromConfig(ctx context.Context, conf *config.Config) (port.Index, error) {
+	llm, err := getLLMClientFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	index := memvec.NewIndex(
+		llm, conf.LLM.Provider.EmbeddingsModel, conf.LLM.Index.MaxWords,
+		memvec.WithDimensions(conf.Storage.MemVec.Dimensions),
+		memvec.WithPath(conf.Storage.MemVec.Path),
+	)
+
+	return index, nil
+}
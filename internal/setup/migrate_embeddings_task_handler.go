@@ -0,0 +1,49 @@
+package setup
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/internal/config"
+	documentTask "github.com/bornholm/corpus/internal/task/document"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// migrateEmbeddingsHandler is a TaskHandler that resolves the sqlitevec index
+// lazily, like reindexBleveHandler, to avoid a circular initialization.
+type migrateEmbeddingsHandler struct {
+	conf          *config.Config
+	documentStore port.DocumentStore
+}
+
+func (h *migrateEmbeddingsHandler) Handle(ctx context.Context, task model.Task, events chan port.TaskEvent) error {
+	if cachedSQLiteVecIndex == nil {
+		// The index is opened with the pipeline index
+		if _, err := getIndexFromConfig(ctx, h.conf); err != nil {
+			return errors.Wrap(err, "could not get index")
+		}
+	}
+
+	if cachedSQLiteVecIndex == nil {
+		return errors.New("sqlitevec index is not available")
+	}
+
+	delegate := documentTask.NewMigrateEmbeddingsHandler(h.documentStore, cachedSQLiteVecIndex)
+
+	return delegate.Handle(ctx, task, events)
+}
+
+var _ port.TaskHandler = &migrateEmbeddingsHandler{}
+
+var getMigrateEmbeddingsTaskHandler = createFromConfigOnce(func(ctx context.Context, conf *config.Config) (*migrateEmbeddingsHandler, error) {
+	documentStore, err := getDocumentStoreFromConfig(ctx, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create document store from config")
+	}
+
+	return &migrateEmbeddingsHandler{
+		conf:          conf,
+		documentStore: documentStore,
+	}, nil
+})
@@ -2,6 +2,7 @@ package setup
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/bornholm/corpus/pkg/adapter/sqlitevec"
 	"github.com/bornholm/corpus/internal/config"
@@ -10,6 +11,16 @@ import (
 	"github.com/pkg/errors"
 )
 
+// sqlitevecMigrationNeeded is set to true when the vectors of a previous
+// embeddings model are still in use at startup, or when the attributes of
+// indexed documents must be backfilled. The actual scheduling is
+// deferred to setupTaskHandlers, after handlers are registered.
+var sqlitevecMigrationNeeded bool
+
+// cachedSQLiteVecIndex is a cached reference to the sqlitevec index, used by the
+// embeddings migration task handler.
+var cachedSQLiteVecIndex *sqlitevec.Index
+
 func NewSQLiteVecIndexFromConfig(ctx context.Context, conf *config.Config) (port.Index, error) {
 
 	llm, err := getLLMClientFromConfig(ctx, conf)
@@ -22,5 +33,34 @@ func NewSQLiteVecIndexFromConfig(ctx context.Context, conf *config.Config) (port
 		return nil, errors.WithStack(err)
 	}
 
-	return sqlitevec.NewIndex(db, llm, conf.LLM.Provider.EmbeddingsModel, conf.LLM.Index.MaxWords), nil
+	index := sqlitevec.NewIndex(
+		db, llm, conf.LLM.Provider.EmbeddingsModel, conf.LLM.Index.MaxWords,
+		sqlitevec.WithDimensions(conf.Storage.SQLiteVec.Dimensions),
+		sqlitevec.WithCacheSize(conf.Storage.SQLiteVec.CacheSize),
+	)
+
+	// Check the recorded embeddings dimension at startup
+	pending, err := index.MigrationPending(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if pending {
+		slog.InfoContext(ctx, "embeddings model has changed, documents will be re-embedded", slog.String("model", conf.LLM.Provider.EmbeddingsModel))
+		sqlitevecMigrationNeeded = true
+	}
+
+	sourcesPending, err := index.SourcesPending(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if sourcesPending {
+		slog.InfoContext(ctx, "indexed documents have no recorded attributes, they will be backfilled")
+		sqlitevecMigrationNeeded = true
+	}
+
+	cachedSQLiteVecIndex = index
+
+	return index, nil
 }
@@ -119,9 +119,12 @@ func setupTaskHandlers(ctx context.Context, conf *config.Config, taskRunner port
 	if persistentRunner, ok := taskRunner.(port.PersistentTaskRunner); ok {
 		persistentRunner.RegisterFactory(documentTask.TaskTypeIndexFile, documentTask.RestoreIndexFileTask)
 		persistentRunner.RegisterFactory(documentTask.TaskTypeCleanup, documentTask.RestoreCleanupTask)
+		persistentRunner.RegisterFactory(documentTask.TaskTypeCheckConsistency, documentTask.RestoreCheckConsistencyTask)
 		persistentRunner.RegisterFactory(documentTask.TaskTypeReindexCollection, documentTask.RestoreReindexCollectionTask)
 		persistentRunner.RegisterFactory(documentTask.TaskTypeReindexBleve, documentTask.RestoreReindexBleveTask)
+		persistentRunner.RegisterFactory(documentTask.TaskTypeMigrateEmbeddings, documentTask.RestoreMigrateEmbeddingsTask)
 		persistentRunner.RegisterFactory(documentTask.TaskTypeSyncFilesystemSource, documentTask.RestoreSyncFilesystemSourceTask)
+		persistentRunner.RegisterFactory(documentTask.TaskTypePurgeTrash, documentTask.RestorePurgeTrashTask)
 		persistentRunner.RegisterFactory(backup.TaskTypeRestoreBackup, backup.RestoreRestoreBackupTask)
 	}
 
@@ -146,6 +149,13 @@ func setupTaskHandlers(ctx context.Context, conf *config.Config, taskRunner port
 
 	taskRunner.RegisterTask(documentTask.TaskTypeCleanup, cleanupHandler)
 
+	checkConsistencyHandler, err := getCheckConsistencyTaskHandler(ctx, conf)
+	if err != nil {
+		return errors.Wrap(err, "could not create check consistency task handler from config")
+	}
+
+	taskRunner.RegisterTask(documentTask.TaskTypeCheckConsistency, checkConsistencyHandler)
+
 	reindexCollectionHandler, err := getReindexCollectionTaskHandler(ctx, conf)
 	if err != nil {
 		return errors.Wrap(err, "could not reindex collection task handler from config")
@@ -160,6 +170,13 @@ func setupTaskHandlers(ctx context.Context, conf *config.Config, taskRunner port
 
 	taskRunner.RegisterTask(documentTask.TaskTypeReindexBleve, reindexBleveHandler)
 
+	migrateEmbeddingsHandler, err := getMigrateEmbeddingsTaskHandler(ctx, conf)
+	if err != nil {
+		return errors.Wrap(err, "could not create migrate embeddings task handler from config")
+	}
+
+	taskRunner.RegisterTask(documentTask.TaskTypeMigrateEmbeddings, migrateEmbeddingsHandler)
+
 	syncFilesystemSourceHandler, err := getSyncFilesystemSourceTaskHandler(ctx, conf)
 	if err != nil {
 		return errors.Wrap(err, "could not create sync filesystem source task handler from config")
@@ -167,6 +184,13 @@ func setupTaskHandlers(ctx context.Context, conf *config.Config, taskRunner port
 
 	taskRunner.RegisterTask(documentTask.TaskTypeSyncFilesystemSource, syncFilesystemSourceHandler)
 
+	purgeTrashHandler, err := getPurgeTrashTaskHandler(ctx, conf)
+	if err != nil {
+		return errors.Wrap(err, "could not create purge trash task handler from config")
+	}
+
+	taskRunner.RegisterTask(documentTask.TaskTypePurgeTrash, purgeTrashHandler)
+
 	// Schedule bleve reindex if a mapping change was detected during startup.
 	// This is done here, after all handlers are registered, to avoid a race where
 	// the task goroutine fires before TaskTypeReindexBleve has a handler.
@@ -180,5 +204,16 @@ func setupTaskHandlers(ctx context.Context, conf *config.Config, taskRunner port
 		}
 	}
 
+	// Schedule the embeddings migration if a model change was detected during startup.
+	if sqlitevecMigrationNeeded {
+		sqlitevecMigrationNeeded = false
+		migrateTask := documentTask.NewMigrateEmbeddingsTask(nil)
+		if err := taskRunner.ScheduleTask(ctx, migrateTask); err != nil {
+			slog.ErrorContext(ctx, "could not schedule embeddings migration task", slog.Any("error", errors.WithStack(err)))
+		} else {
+			slog.InfoContext(ctx, "scheduled embeddings migration task", slog.String("task_id", string(migrateTask.ID())))
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,43 @@
+package setup
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bornholm/corpus/internal/config"
+	documentTask "github.com/bornholm/corpus/internal/task/document"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// startTrashPurgeScheduler launches a background goroutine that periodically
+// schedules the purge of the documents and collections trashed for longer
+// than the configured delay.
+func startTrashPurgeScheduler(ctx context.Context, conf *config.Config, taskRunner port.TaskRunner) {
+	interval := conf.Storage.Trash.PurgeInterval
+	if interval <= 0 {
+		slog.InfoContext(ctx, "trash purge disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purgeTask := documentTask.NewPurgeTrashTask(nil, time.Now().Add(-conf.Storage.Trash.Delay))
+				if err := taskRunner.ScheduleTask(ctx, purgeTask); err != nil {
+					slog.ErrorContext(ctx, "could not schedule trash purge task", slog.Any("error", errors.WithStack(err)))
+					continue
+				}
+
+				slog.InfoContext(ctx, "scheduled trash purge", slog.String("taskID", string(purgeTask.ID())))
+			}
+		}
+	}()
+}
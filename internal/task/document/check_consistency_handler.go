@@ -0,0 +1,428 @@
+package document
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+type CheckConsistencyHandler struct {
+	index         port.Index
+	documentStore port.DocumentStore
+}
+
+func NewCheckConsistencyHandler(index port.Index, documentStore port.DocumentStore) *CheckConsistencyHandler {
+	return &CheckConsistencyHandler{
+		index:         index,
+		documentStore: documentStore,
+	}
+}
+
+// storeSections holds the sections expected in the indexes, as found in the
+// document store
+type storeSections struct {
+	// Documents of each expected section
+	sections map[model.SectionID]model.DocumentID
+	// Collections of each document
+	documents map[model.DocumentID][]model.CollectionID
+	// Sources of each document
+	sources map[model.DocumentID]*url.URL
+	// Labels of the encountered collections
+	labels map[model.CollectionID]string
+}
+
+// IndexConsistency describes the differences between an index and the
+// document store
+type IndexConsistency struct {
+	// Index is the identifier of the checked index
+	Index string
+	// Orphaned lists the indexed sections which do not exist in the document
+	// store anymore
+	Orphaned []model.SectionID
+	// Missing lists, for each collection, the sections of the document store
+	// absent from the index. Documents without collection are listed with an
+	// empty collection identifier.
+	Missing map[model.CollectionID][]model.SectionID
+	// Documents lists, for each document with missing sections, the sections
+	// to index
+	Documents map[model.DocumentID][]model.SectionID
+}
+
+// Consistent returns true if no difference has been found
+func (c *IndexConsistency) Consistent() bool {
+	return len(c.Orphaned) == 0 && len(c.Documents) == 0
+}
+
+// Handle implements [port.TaskHandler].
+func (h *CheckConsistencyHandler) Handle(ctx context.Context, task model.Task, events chan port.TaskEvent) error {
+	checkTask, ok := task.(*CheckConsistencyTask)
+	if !ok {
+		return errors.Errorf("unexpected task type '%T'", task)
+	}
+
+	events <- port.NewTaskEvent(port.WithTaskMessage("collecting document sections"))
+
+	expected, err := h.collectStoreSections(ctx, checkTask.collections)
+	if err != nil {
+		return errors.Wrap(err, "could not collect document sections")
+	}
+
+	indexes := map[string]port.Index{"index": h.index}
+	if composite, ok := h.index.(port.CompositeIndex); ok {
+		indexes = composite.Indexes()
+	}
+
+	indexIDs := slices.Sorted(maps.Keys(indexes))
+	reports := make([]*IndexConsistency, 0, len(indexIDs))
+
+	for idx, id := range indexIDs {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		default:
+		}
+
+		events <- port.NewTaskEvent(
+			port.WithTaskMessage(fmt.Sprintf("checking index '%s'", id)),
+			port.WithTaskProgress(float32(idx)/float32(len(indexIDs))),
+		)
+
+		report, err := h.checkIndex(ctx, id, indexes[id], expected)
+		if err != nil {
+			return errors.Wrapf(err, "could not check index '%s'", id)
+		}
+
+		slog.InfoContext(ctx, "index checked",
+			slog.String("index", id),
+			slog.Int("orphaned", len(report.Orphaned)),
+			slog.Int("documents", len(report.Documents)),
+		)
+
+		if checkTask.repair && !report.Consistent() {
+			events <- port.NewTaskEvent(port.WithTaskMessage(fmt.Sprintf("repairing index '%s'", id)))
+
+			if err := h.repairIndex(ctx, indexes[id], report, expected); err != nil {
+				return errors.Wrapf(err, "could not repair index '%s'", id)
+			}
+		}
+
+		reports = append(reports, report)
+	}
+
+	events <- port.NewTaskEvent(
+		port.WithTaskMessage(formatConsistencyReports(reports, expected.labels, checkTask.repair)),
+		port.WithTaskProgress(1),
+	)
+
+	return nil
+}
+
+func (h *CheckConsistencyHandler) collectStoreSections(ctx context.Context, collections []model.CollectionID) (*storeSections, error) {
+	expected := &storeSections{
+		sections:  map[model.SectionID]model.DocumentID{},
+		documents: map[model.DocumentID][]model.CollectionID{},
+		sources:   map[model.DocumentID]*url.URL{},
+		labels:    map[model.CollectionID]string{},
+	}
+
+	query := func(page, limit int) ([]model.PersistedDocument, error) {
+		documents, _, err := h.documentStore.QueryDocuments(ctx, port.QueryDocumentsOptions{
+			Page:  &page,
+			Limit: &limit,
+		})
+		return documents, errors.WithStack(err)
+	}
+
+	if len(collections) == 0 {
+		if err := h.collectDocumentsSections(ctx, expected, nil, query); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return expected, nil
+	}
+
+	for _, collectionID := range collections {
+		query := func(page, limit int) ([]model.PersistedDocument, error) {
+			documents, _, err := h.documentStore.QueryDocumentsByCollectionID(ctx, collectionID, port.QueryDocumentsOptions{
+				Page:  &page,
+				Limit: &limit,
+			})
+			return documents, errors.WithStack(err)
+		}
+
+		if err := h.collectDocumentsSections(ctx, expected, collections, query); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return expected, nil
+}
+
+func (h *CheckConsistencyHandler) collectDocumentsSections(ctx context.Context, expected *storeSections, collections []model.CollectionID, query func(page, limit int) ([]model.PersistedDocument, error)) error {
+	limit := 50
+
+	for page := 0; ; page++ {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		default:
+		}
+
+		documents, err := query(page, limit)
+		if err != nil {
+			return errors.Wrap(err, "could not query documents")
+		}
+
+		for _, d := range documents {
+			if _, exists := expected.documents[d.ID()]; exists {
+				continue
+			}
+
+			documentCollections := make([]model.CollectionID, 0)
+			for _, c := range d.Collections() {
+				expected.labels[c.ID()] = c.Label()
+
+				// Only report the collections restricting the check, if any
+				if len(collections) > 0 && !slices.Contains(collections, c.ID()) {
+					continue
+				}
+
+				documentCollections = append(documentCollections, c.ID())
+			}
+
+			expected.documents[d.ID()] = documentCollections
+			expected.sources[d.ID()] = d.Source()
+
+			err := model.WalkSections(d, func(s model.Section) error {
+				content, err := s.Content()
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				// Empty sections are not indexed
+				if len(content) == 0 {
+					return nil
+				}
+
+				expected.sections[s.ID()] = d.ID()
+
+				return nil
+			})
+			if err != nil {
+				return errors.Wrapf(err, "could not walk sections of document '%s'", d.ID())
+			}
+		}
+
+		if len(documents) < limit {
+			return nil
+		}
+	}
+}
+
+func (h *CheckConsistencyHandler) checkIndex(ctx context.Context, id string, index port.Index, expected *storeSections) (*IndexConsistency, error) {
+	const checkBatchSize = 500
+
+	report := &IndexConsistency{
+		Index:     id,
+		Orphaned:  make([]model.SectionID, 0),
+		Missing:   map[model.CollectionID][]model.SectionID{},
+		Documents: map[model.DocumentID][]model.SectionID{},
+	}
+
+	indexed := make(map[model.SectionID]struct{}, len(expected.sections))
+	checkBatch := make([]model.SectionID, 0, checkBatchSize)
+
+	var checkErr error
+
+	// Sections unknown from the collected ones may belong to documents out of
+	// the checked collections: only those absent from the store are orphaned
+	flushCheckBatch := func() {
+		if len(checkBatch) == 0 {
+			return
+		}
+
+		existMap, err := h.documentStore.SectionsExist(ctx, checkBatch)
+		if err != nil {
+			checkErr = errors.Wrap(err, "could not bulk-check sections existence")
+			return
+		}
+
+		for _, id := range checkBatch {
+			if !existMap[id] {
+				report.Orphaned = append(report.Orphaned, id)
+			}
+		}
+
+		checkBatch = checkBatch[:0]
+	}
+
+	err := index.All(ctx, func(id model.SectionID) bool {
+		if _, exists := expected.sections[id]; exists {
+			indexed[id] = struct{}{}
+			return true
+		}
+
+		checkBatch = append(checkBatch, id)
+		if len(checkBatch) >= checkBatchSize {
+			flushCheckBatch()
+		}
+
+		return checkErr == nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	flushCheckBatch()
+
+	if checkErr != nil {
+		return nil, errors.WithStack(checkErr)
+	}
+
+	for sectionID, documentID := range expected.sections {
+		if _, exists := indexed[sectionID]; exists {
+			continue
+		}
+
+		report.Documents[documentID] = append(report.Documents[documentID], sectionID)
+
+		collections := expected.documents[documentID]
+		if len(collections) == 0 {
+			report.Missing[""] = append(report.Missing[""], sectionID)
+			continue
+		}
+
+		for _, collectionID := range collections {
+			report.Missing[collectionID] = append(report.Missing[collectionID], sectionID)
+		}
+	}
+
+	return report, nil
+}
+
+func (h *CheckConsistencyHandler) repairIndex(ctx context.Context, index port.Index, report *IndexConsistency, expected *storeSections) error {
+	const deleteBatchSize = 5000
+
+	for batch := range slices.Chunk(report.Orphaned, deleteBatchSize) {
+		slog.InfoContext(ctx, "deleting orphaned sections from index", slog.String("index", report.Index), slog.Int("count", len(batch)))
+
+		if err := index.DeleteByID(ctx, batch...); err != nil {
+			return errors.Wrap(err, "could not delete orphaned sections")
+		}
+	}
+
+	for documentID, sections := range report.Documents {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		default:
+		}
+
+		document, err := h.getDocument(ctx, documentID, expected.sources[documentID])
+		if err != nil {
+			if errors.Is(err, port.ErrNotFound) {
+				// The document has been deleted since the check
+				continue
+			}
+
+			return errors.Wrapf(err, "could not retrieve document '%s'", documentID)
+		}
+
+		slog.InfoContext(ctx, "indexing missing sections", slog.String("index", report.Index), slog.String("documentID", string(documentID)), slog.Int("count", len(sections)))
+
+		indexOptions := []port.IndexOptionFunc{port.WithIndexSections(sections...)}
+
+		// The underlying indexes are given the contexts computed by the
+		// composite index
+		if contextual, ok := h.index.(port.ContextualIndex); ok {
+			contexts, err := contextual.Contextualize(ctx, document)
+			if err != nil {
+				return errors.Wrapf(err, "could not contextualize document '%s'", documentID)
+			}
+
+			if contexts != nil {
+				indexOptions = append(indexOptions, port.WithIndexContexts(contexts))
+			}
+		}
+
+		if err := index.Index(ctx, document, indexOptions...); err != nil {
+			return errors.Wrapf(err, "could not index document '%s'", documentID)
+		}
+	}
+
+	return nil
+}
+
+// getDocument retrieves the document with all its sections, as the indexes
+// expect them
+func (h *CheckConsistencyHandler) getDocument(ctx context.Context, documentID model.DocumentID, source *url.URL) (model.PersistedDocument, error) {
+	documents, _, err := h.documentStore.QueryDocuments(ctx, port.QueryDocumentsOptions{
+		MatchingSource: source,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, d := range documents {
+		if d.ID() == documentID {
+			return d, nil
+		}
+	}
+
+	return nil, errors.WithStack(port.ErrNotFound)
+}
+
+func formatConsistencyReports(reports []*IndexConsistency, labels map[model.CollectionID]string, repaired bool) string {
+	var sb strings.Builder
+
+	for i, r := range reports {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+
+		if r.Consistent() {
+			fmt.Fprintf(&sb, "%s: consistent", r.Index)
+			continue
+		}
+
+		missing := 0
+		for _, sections := range r.Documents {
+			missing += len(sections)
+		}
+
+		fmt.Fprintf(&sb, "%s: %d orphaned sections, %d missing sections", r.Index, len(r.Orphaned), missing)
+
+		if len(r.Missing) > 0 {
+			collections := slices.Sorted(maps.Keys(r.Missing))
+
+			details := make([]string, 0, len(collections))
+			for _, collectionID := range collections {
+				name := "no collection"
+				if collectionID != "" {
+					name = fmt.Sprintf("collection '%s'", cmp.Or(labels[collectionID], string(collectionID)))
+				}
+
+				details = append(details, fmt.Sprintf("%s: %d", name, len(r.Missing[collectionID])))
+			}
+
+			fmt.Fprintf(&sb, " (%s)", strings.Join(details, ", "))
+		}
+
+		if repaired {
+			sb.WriteString(", repaired")
+		}
+	}
+
+	return sb.String()
+}
+
+var _ port.TaskHandler = &CheckConsistencyHandler{}
@@ -0,0 +1,184 @@
+package document
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestCheckConsistencyHandler(t *testing.T) {
+	type testCase struct {
+		Name            string
+		Repair          bool
+		ExpectedMessage string
+		// ExpectedRepaired is true if the index is expected to match the store
+		// after the check
+		ExpectedRepaired bool
+	}
+
+	testCases := []testCase{
+		{
+			Name:             "Check only",
+			Repair:           false,
+			ExpectedMessage:  "index: 1 orphaned sections, 1 missing sections (collection 'Foxes': 1)",
+			ExpectedRepaired: false,
+		},
+		{
+			Name:             "Check and repair",
+			Repair:           true,
+			ExpectedMessage:  "index: 1 orphaned sections, 1 missing sections (collection 'Foxes': 1), repaired",
+			ExpectedRepaired: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := newTestStore(t)
+
+			owner, err := store.FindOrCreateUser(ctx, "test", "owner")
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			collection, err := store.CreateCollection(ctx, owner.ID(), "Foxes")
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			indexed := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.", collection)
+			missing := saveTestDocument(t, store, owner, "https://example.net/dog", "# Dog\n\nThe lazy dog.", collection)
+
+			index := newMockIndex()
+
+			if err := index.Index(ctx, indexed); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			// A section of a document deleted from the store but not from the index
+			const orphaned model.SectionID = "orphaned"
+			index.sections[orphaned] = "https://example.net/deleted"
+
+			handler := NewCheckConsistencyHandler(index, store)
+
+			messages := runTestTask(t, handler, NewCheckConsistencyTask(owner, nil, tc.Repair))
+
+			if e, g := tc.ExpectedMessage, messages[len(messages)-1]; e != g {
+				t.Errorf("last message: expected %q, got %q", e, g)
+			}
+
+			if e, g := !tc.ExpectedRepaired, index.Has(orphaned); e != g {
+				t.Errorf("index.Has(orphaned): expected %v, got %v", e, g)
+			}
+
+			for _, id := range sectionIDs(t, missing) {
+				if e, g := tc.ExpectedRepaired, index.Has(id); e != g {
+					t.Errorf("index.Has(%s): expected %v, got %v", id, e, g)
+				}
+			}
+
+			// Sections already indexed are left untouched
+			for _, id := range sectionIDs(t, indexed) {
+				if !index.Has(id) {
+					t.Errorf("index.Has(%s): expected true, got false", id)
+				}
+			}
+
+			if !tc.ExpectedRepaired {
+				return
+			}
+
+			// A second check finds no difference anymore
+			messages = runTestTask(t, handler, NewCheckConsistencyTask(owner, nil, false))
+
+			if e, g := "index: consistent", messages[len(messages)-1]; e != g {
+				t.Errorf("last message: expected %q, got %q", e, g)
+			}
+		})
+	}
+}
+
+func TestCheckConsistencyHandlerCollections(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner, err := store.FindOrCreateUser(ctx, "test", "owner")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	foxes, err := store.CreateCollection(ctx, owner.ID(), "Foxes")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	dogs, err := store.CreateCollection(ctx, owner.ID(), "Dogs")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	fox := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.", foxes)
+	dog := saveTestDocument(t, store, owner, "https://example.net/dog", "# Dog\n\nThe lazy dog.", dogs)
+
+	index := newMockIndex()
+
+	// The indexed sections of the documents out of the checked collections
+	// are not orphaned
+	if err := index.Index(ctx, dog); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	handler := NewCheckConsistencyHandler(index, store)
+
+	messages := runTestTask(t, handler, NewCheckConsistencyTask(owner, []model.CollectionID{foxes.ID()}, true))
+
+	if e, g := "index: 0 orphaned sections, 1 missing sections (collection 'Foxes': 1), repaired", messages[len(messages)-1]; e != g {
+		t.Errorf("last message: expected %q, got %q", e, g)
+	}
+
+	for _, d := range []model.Document{fox, dog} {
+		for _, id := range sectionIDs(t, d) {
+			if !index.Has(id) {
+				t.Errorf("index.Has(%s): expected true, got false", id)
+			}
+		}
+	}
+}
+
+// runTestTask executes the given task with the given handler and returns the
+// messages of the emitted events
+func runTestTask(t *testing.T, handler port.TaskHandler, task model.Task) []string {
+	events := make(chan port.TaskEvent)
+	done := make(chan []string)
+
+	go func() {
+		messages := make([]string, 0)
+		for evt := range events {
+			if evt.Message != nil {
+				messages = append(messages, *evt.Message)
+			}
+		}
+		done <- messages
+	}()
+
+	err := handler.Handle(context.Background(), task, events)
+
+	close(events)
+
+	messages := <-done
+
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(messages) == 0 {
+		t.Fatalf("no message emitted")
+	}
+
+	return messages
+}
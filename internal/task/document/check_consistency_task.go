@@ -0,0 +1,90 @@
+package document
+
+import (
+	"encoding/json"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/pkg/errors"
+)
+
+const TaskTypeCheckConsistency model.TaskType = "check_consistency"
+
+type checkConsistencyTaskPayload struct {
+	Collections []model.CollectionID `json:"collections"`
+	Repair      bool                 `json:"repair"`
+}
+
+// CheckConsistencyTask compares the sections of the document store with the
+// ones of each index and, if requested, repairs the differences.
+type CheckConsistencyTask struct {
+	id          model.TaskID
+	owner       model.User
+	collections []model.CollectionID
+	repair      bool
+}
+
+// MarshalJSON implements [model.Task].
+func (t *CheckConsistencyTask) MarshalJSON() ([]byte, error) {
+	payload := checkConsistencyTaskPayload{
+		Collections: t.collections,
+		Repair:      t.repair,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return data, nil
+}
+
+// UnmarshalJSON implements [model.Task].
+func (t *CheckConsistencyTask) UnmarshalJSON(data []byte) error {
+	var payload checkConsistencyTaskPayload
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return errors.WithStack(err)
+	}
+
+	t.collections = payload.Collections
+	t.repair = payload.Repair
+
+	return nil
+}
+
+// Owner implements [model.Task].
+func (t *CheckConsistencyTask) Owner() model.User {
+	return t.owner
+}
+
+// ID implements port.Task.
+func (t *CheckConsistencyTask) ID() model.TaskID {
+	return t.id
+}
+
+// Type implements port.Task.
+func (t *CheckConsistencyTask) Type() model.TaskType {
+	return TaskTypeCheckConsistency
+}
+
+// Collections returns the collections restricting the check, empty meaning
+// all collections.
+func (t *CheckConsistencyTask) Collections() []model.CollectionID {
+	return t.collections
+}
+
+// Repair returns true if the inconsistencies have to be repaired.
+func (t *CheckConsistencyTask) Repair() bool {
+	return t.repair
+}
+
+func NewCheckConsistencyTask(owner model.User, collections []model.CollectionID, repair bool) *CheckConsistencyTask {
+	return &CheckConsistencyTask{
+		id:          model.NewTaskID(),
+		owner:       owner,
+		collections: collections,
+		repair:      repair,
+	}
+}
+
+var _ model.Task = &CheckConsistencyTask{}
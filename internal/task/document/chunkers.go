@@ -0,0 +1,61 @@
+package document
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+// Chunkers selects the chunker splitting an indexed file into sections,
+// according to its target collections or to its file type.
+type Chunkers struct {
+	defaultChunker markdown.Chunker
+	extensions     map[string]markdown.Chunker
+	collections    map[model.CollectionID]markdown.Chunker
+}
+
+// Select returns the chunker of the first target collection having one, or
+// the chunker of the file extension, or the default chunker.
+func (c *Chunkers) Select(filename string, collections []model.CollectionID) markdown.Chunker {
+	for _, collectionID := range collections {
+		if chunker, exists := c.collections[collectionID]; exists {
+			return chunker
+		}
+	}
+
+	if chunker, exists := c.extensions[normalizeExtension(filepath.Ext(filename))]; exists {
+		return chunker
+	}
+
+	return c.defaultChunker
+}
+
+// SetExtensionChunker sets the chunker of the files with the given extension
+func (c *Chunkers) SetExtensionChunker(ext string, chunker markdown.Chunker) {
+	c.extensions[normalizeExtension(ext)] = chunker
+}
+
+// SetCollectionChunker sets the chunker of the files indexed in the given
+// collection
+func (c *Chunkers) SetCollectionChunker(collectionID model.CollectionID, chunker markdown.Chunker) {
+	c.collections[collectionID] = chunker
+}
+
+func NewChunkers(defaultChunker markdown.Chunker) *Chunkers {
+	return &Chunkers{
+		defaultChunker: defaultChunker,
+		extensions:     map[string]markdown.Chunker{},
+		collections:    map[model.CollectionID]markdown.Chunker{},
+	}
+}
+
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	return ext
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
@@ -11,7 +12,6 @@ import (
 
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
-	"github.com/bornholm/corpus/internal/markdown"
 	"github.com/bornholm/corpus/internal/workflow"
 	"github.com/pkg/errors"
 )
@@ -19,20 +19,20 @@ import (
 const indexFileTaskTimeout = 2 * time.Hour
 
 type IndexFileHandler struct {
-	userStore         port.UserStore
-	documentStore     port.DocumentStore
-	fileConverter     port.FileConverter
-	index             port.Index
-	maxWordPerSection int
+	userStore     port.UserStore
+	documentStore port.DocumentStore
+	fileConverter port.FileConverter
+	index         port.Index
+	chunkers      *Chunkers
 }
 
-func NewIndexFileHandler(userStore port.UserStore, documentStore port.DocumentStore, fileConverter port.FileConverter, index port.Index, maxWordPerSection int) *IndexFileHandler {
+func NewIndexFileHandler(userStore port.UserStore, documentStore port.DocumentStore, fileConverter port.FileConverter, index port.Index, chunkers *Chunkers) *IndexFileHandler {
 	return &IndexFileHandler{
-		userStore:         userStore,
-		documentStore:     documentStore,
-		fileConverter:     fileConverter,
-		index:             index,
-		maxWordPerSection: maxWordPerSection,
+		userStore:     userStore,
+		documentStore: documentStore,
+		fileConverter: fileConverter,
+		index:         index,
+		chunkers:      chunkers,
 	}
 }
 
@@ -55,6 +55,11 @@ func (h *IndexFileHandler) Handle(ctx context.Context, task model.Task, events c
 
 	var (
 		document model.OwnedDocument
+		// Sections added, removed or left untouched since the previous
+		// version of the document, if any
+		diff *model.SectionsDiff
+		// Identifier of the persisted document
+		documentID model.DocumentID
 	)
 
 	var reader io.ReadCloser
@@ -106,10 +111,9 @@ func (h *IndexFileHandler) Handle(ctx context.Context, task model.Task, events c
 
 				events <- port.NewTaskEvent(port.WithTaskMessage("parsing document"))
 
-				doc, err := markdown.Parse(
-					data,
-					markdown.WithMaxWordPerSection(h.maxWordPerSection),
-				)
+				chunker := h.chunkers.Select(indexFileTask.originalName, indexFileTask.collections)
+
+				doc, err := chunker.Chunk(ctx, data)
 				if err != nil {
 					return errors.Wrap(err, "could not parse document")
 				}
@@ -128,6 +132,10 @@ func (h *IndexFileHandler) Handle(ctx context.Context, task model.Task, events c
 					doc.SetETag(indexFileTask.etag)
 				}
 
+				for key, values := range indexFileTask.metadata {
+					doc.SetMetadata(key, values...)
+				}
+
 				if len(indexFileTask.collections) == 0 {
 					return errors.New("no specified target collections")
 				}
@@ -155,7 +163,7 @@ func (h *IndexFileHandler) Handle(ctx context.Context, task model.Task, events c
 					return errors.Wrap(err, "could not retrieve task owner")
 				}
 
-				document = model.AsOwnedDocument(doc, user)
+				document = model.AsTaskDocument(model.AsOwnedDocument(doc, user), task.ID())
 
 				events <- port.NewTaskEvent(port.WithTaskProgress(0.1))
 
@@ -167,6 +175,24 @@ func (h *IndexFileHandler) Handle(ctx context.Context, task model.Task, events c
 			func(ctx context.Context) error {
 				events <- port.NewTaskEvent(port.WithTaskMessage("saving document"))
 
+				previous, err := h.previousVersion(ctx, document)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				d, err := diffPreviousVersion(previous, document)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				diff = d
+
+				// The persisted document keeps the identity of its previous version
+				documentID = document.ID()
+				if previous != nil {
+					documentID = previous.ID()
+				}
+
 				if err := h.documentStore.SaveDocuments(ctx, document); err != nil {
 					return errors.WithStack(err)
 				}
@@ -191,7 +217,32 @@ func (h *IndexFileHandler) Handle(ctx context.Context, task model.Task, events c
 
 				events <- port.NewTaskEvent(port.WithTaskMessage("indexing document"))
 
-				if err := h.index.Index(ctx, document, port.WithIndexOnProgress(onProgress)); err != nil {
+				indexOptions := []port.IndexOptionFunc{port.WithIndexOnProgress(onProgress)}
+
+				if diff != nil {
+					if diff.Empty() {
+						if err := h.markIndexed(ctx, documentID, document); err != nil {
+							return errors.WithStack(err)
+						}
+
+						events <- port.NewTaskEvent(port.WithTaskMessage("document unchanged"))
+						return nil
+					}
+
+					if len(diff.Removed) > 0 {
+						if err := h.index.DeleteByID(ctx, diff.Removed...); err != nil {
+							return errors.WithStack(err)
+						}
+					}
+
+					indexOptions = append(indexOptions, port.WithIndexSections(diff.Added...))
+				}
+
+				if err := h.index.Index(ctx, document, indexOptions...); err != nil {
+					return errors.WithStack(err)
+				}
+
+				if err := h.markIndexed(ctx, documentID, document); err != nil {
 					return errors.WithStack(err)
 				}
 
@@ -217,4 +268,89 @@ func (h *IndexFileHandler) Handle(ctx context.Context, task model.Task, events c
 	return nil
 }
 
+// markIndexed records the content the document was indexed with. It must only
+// be called once the index is up to date, the next indexation of the document
+// being incremental.
+func (h *IndexFileHandler) markIndexed(ctx context.Context, id model.DocumentID, document model.Document) error {
+	checksum, err := model.DocumentChecksum(document)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := h.documentStore.MarkDocumentIndexed(ctx, id, checksum); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// previousVersion returns the persisted version of the document, or nil if
+// it is not known yet
+func (h *IndexFileHandler) previousVersion(ctx context.Context, document model.OwnedDocument) (model.PersistedDocument, error) {
+	documents, _, err := h.documentStore.QueryDocuments(ctx, port.QueryDocumentsOptions{
+		MatchingSource: document.Source(),
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	return documents[0], nil
+}
+
+// diffPreviousVersion compares the sections of the document with the ones of
+// its persisted version. It returns nil if the document has to be fully
+// indexed, i.e. if it was not known yet, if its collections or its metadata
+// changed, or if its persisted version was not successfully indexed.
+func diffPreviousVersion(previous model.PersistedDocument, document model.OwnedDocument) (*model.SectionsDiff, error) {
+	if previous == nil {
+		return nil, nil
+	}
+
+	// The sections of the persisted version may not have been indexed if a
+	// previous indexation failed or was interrupted
+	previousChecksum, err := model.DocumentChecksum(previous)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if model.DocumentIndexedChecksum(previous) != previousChecksum {
+		return nil, nil
+	}
+
+	collectionIDs := func(collections []model.Collection) []model.CollectionID {
+		ids := make([]model.CollectionID, 0, len(collections))
+		for _, c := range collections {
+			ids = append(ids, c.ID())
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	if !slices.Equal(collectionIDs(previous.Collections()), collectionIDs(document.Collections())) {
+		return nil, nil
+	}
+
+	// The metadata are indexed with each section
+	if !maps.EqualFunc(model.DocumentMetadata(previous), model.DocumentMetadata(document), slices.Equal) {
+		return nil, nil
+	}
+
+	previousSections := make([]model.SectionID, 0)
+	err = model.WalkSections(previous, func(s model.Section) error {
+		previousSections = append(previousSections, s.ID())
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	diff := model.DiffSections(previousSections, document)
+
+	return &diff, nil
+}
+
 var _ port.TaskHandler = &IndexFileHandler{}
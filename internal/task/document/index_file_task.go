@@ -19,6 +19,8 @@ type IndexFileTask struct {
 	source       *url.URL
 	// Names of the collection to associate with the document
 	collections []model.CollectionID
+	// Metadata of the document, overriding the ones of its front matter
+	metadata map[string][]string
 }
 
 type indexTaskPayload struct {
@@ -27,6 +29,7 @@ type indexTaskPayload struct {
 	Etag         string               `json:"etag"`
 	Source       string               `json:"source"`
 	Collections  []model.CollectionID `json:"collections"`
+	Metadata     map[string][]string  `json:"metadata,omitempty"`
 }
 
 // MarshalJSON implements [model.Task].
@@ -42,6 +45,7 @@ func (i *IndexFileTask) MarshalJSON() ([]byte, error) {
 		Etag:         i.etag,
 		Source:       sourceStr,
 		Collections:  i.collections,
+		Metadata:     i.metadata,
 	}
 
 	data, err := json.Marshal(payload)
@@ -64,6 +68,7 @@ func (i *IndexFileTask) UnmarshalJSON(data []byte) error {
 	i.etag = payload.Etag
 	i.originalName = payload.OriginalName
 	i.path = payload.Path
+	i.metadata = payload.Metadata
 
 	source, err := url.Parse(payload.Source)
 	if err != nil {
@@ -75,7 +80,7 @@ func (i *IndexFileTask) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func NewIndexFileTask(owner model.User, path string, originalName string, etag string, source *url.URL, collections []model.CollectionID) *IndexFileTask {
+func NewIndexFileTask(owner model.User, path string, originalName string, etag string, source *url.URL, collections []model.CollectionID, metadata map[string][]string) *IndexFileTask {
 	return &IndexFileTask{
 		id:           model.NewTaskID(),
 		owner:        owner,
@@ -84,6 +89,7 @@ func NewIndexFileTask(owner model.User, path string, originalName string, etag s
 		etag:         etag,
 		source:       source,
 		collections:  collections,
+		metadata:     metadata,
 	}
 }
 
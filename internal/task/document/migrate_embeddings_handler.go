@@ -0,0 +1,150 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// EmbeddingsMigrator is an index able to build the vectors of a new
+// embeddings model alongside the vectors of the previous one, and to backfill
+// the document level attributes of the sources indexed before they were
+// recorded.
+type EmbeddingsMigrator interface {
+	port.Index
+	MigrationPending(ctx context.Context) (bool, error)
+	CompleteMigration(ctx context.Context) error
+	SourcesPending(ctx context.Context) (bool, error)
+	SaveSource(ctx context.Context, document model.Document) error
+}
+
+type MigrateEmbeddingsHandler struct {
+	documentStore port.DocumentStore
+	index         EmbeddingsMigrator
+}
+
+func NewMigrateEmbeddingsHandler(documentStore port.DocumentStore, index EmbeddingsMigrator) *MigrateEmbeddingsHandler {
+	return &MigrateEmbeddingsHandler{
+		documentStore: documentStore,
+		index:         index,
+	}
+}
+
+// Handle implements [port.TaskHandler].
+func (h *MigrateEmbeddingsHandler) Handle(ctx context.Context, task model.Task, events chan port.TaskEvent) error {
+	if _, ok := task.(*MigrateEmbeddingsTask); !ok {
+		return errors.Errorf("unexpected task type '%T'", task)
+	}
+
+	pending, err := h.index.MigrationPending(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	sourcesPending, err := h.index.SourcesPending(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if !pending && !sourcesPending {
+		events <- port.NewTaskEvent(port.WithTaskMessage("no embeddings migration pending"), port.WithTaskProgress(1))
+		return nil
+	}
+
+	events <- port.NewTaskEvent(port.WithTaskMessage("retrieving total documents"))
+
+	limit := 1
+	page := 1
+
+	_, totalDocuments, err := h.documentStore.QueryDocuments(ctx, port.QueryDocumentsOptions{
+		Limit:      &limit,
+		Page:       &page,
+		HeaderOnly: true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not query documents")
+	}
+
+	docPage := 1
+	docLimit := 50
+	failed := 0
+
+	for totalDocuments > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		default:
+		}
+
+		documents, _, err := h.documentStore.QueryDocuments(ctx, port.QueryDocumentsOptions{
+			Limit: &docLimit,
+			Page:  &docPage,
+		})
+		if err != nil {
+			return errors.Wrap(err, "could not query documents")
+		}
+
+		for i, doc := range documents {
+			select {
+			case <-ctx.Done():
+				return errors.WithStack(ctx.Err())
+			default:
+			}
+
+			if pending {
+				// Re-embed the document with the current embeddings model, the
+				// vectors of the previous model are kept until the migration completes
+				if err := h.index.Index(ctx, doc); err != nil {
+					slog.ErrorContext(ctx, "could not migrate document embeddings", slog.String("document_id", string(doc.ID())), slog.Any("error", errors.WithStack(err)))
+					failed++
+				}
+			} else if doc.Source() != nil {
+				// Only record the attributes used by the search filters
+				if err := h.index.SaveSource(ctx, doc); err != nil {
+					slog.ErrorContext(ctx, "could not backfill document attributes", slog.String("document_id", string(doc.ID())), slog.Any("error", errors.WithStack(err)))
+					failed++
+				}
+			}
+
+			progress := float32((docPage-1)*docLimit+i+1) / float32(totalDocuments)
+			if progress > 1 {
+				progress = 1
+			}
+
+			events <- port.NewTaskEvent(port.WithTaskProgress(0.95*progress), port.WithTaskMessage("migrating embeddings"))
+		}
+
+		if len(documents) < docLimit {
+			break
+		}
+
+		docPage++
+	}
+
+	if failed > 0 {
+		if !pending {
+			return errors.Errorf("could not backfill attributes of %d documents", failed)
+		}
+
+		return errors.Errorf("could not migrate embeddings of %d documents, previous embeddings are kept", failed)
+	}
+
+	if !pending {
+		events <- port.NewTaskEvent(port.WithTaskProgress(1), port.WithTaskMessage(fmt.Sprintf("documents attributes backfill finished (%d documents)", totalDocuments)))
+		return nil
+	}
+
+	if err := h.index.CompleteMigration(ctx); err != nil {
+		return errors.Wrap(err, "could not complete embeddings migration")
+	}
+
+	events <- port.NewTaskEvent(port.WithTaskProgress(1), port.WithTaskMessage(fmt.Sprintf("embeddings migration finished (%d documents)", totalDocuments)))
+
+	return nil
+}
+
+var _ port.TaskHandler = &MigrateEmbeddingsHandler{}
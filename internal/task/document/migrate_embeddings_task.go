@@ -0,0 +1,46 @@
+package document
+
+import (
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+const TaskTypeMigrateEmbeddings model.TaskType = "migrate_embeddings"
+
+type MigrateEmbeddingsTask struct {
+	id    model.TaskID
+	owner model.User
+}
+
+// MarshalJSON implements [model.Task].
+func (t *MigrateEmbeddingsTask) MarshalJSON() ([]byte, error) {
+	return []byte("{}"), nil
+}
+
+// UnmarshalJSON implements [model.Task].
+func (t *MigrateEmbeddingsTask) UnmarshalJSON(data []byte) error {
+	return nil
+}
+
+// Owner implements [model.Task].
+func (t *MigrateEmbeddingsTask) Owner() model.User {
+	return t.owner
+}
+
+// ID implements port.Task.
+func (t *MigrateEmbeddingsTask) ID() model.TaskID {
+	return t.id
+}
+
+// Type implements port.Task.
+func (t *MigrateEmbeddingsTask) Type() model.TaskType {
+	return TaskTypeMigrateEmbeddings
+}
+
+func NewMigrateEmbeddingsTask(owner model.User) *MigrateEmbeddingsTask {
+	return &MigrateEmbeddingsTask{
+		id:    model.NewTaskID(),
+		owner: owner,
+	}
+}
+
+var _ model.Task = &MigrateEmbeddingsTask{}
@@ -0,0 +1,205 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+const purgeTrashBatchSize = 50
+
+// PurgeTrashHandler permanently deletes the documents and collections which
+// have been in the trash for too long, along with their index entries.
+type PurgeTrashHandler struct {
+	index         port.Index
+	documentStore port.DocumentStore
+}
+
+func NewPurgeTrashHandler(index port.Index, documentStore port.DocumentStore) *PurgeTrashHandler {
+	return &PurgeTrashHandler{
+		index:         index,
+		documentStore: documentStore,
+	}
+}
+
+// Handle implements [port.TaskHandler].
+func (h *PurgeTrashHandler) Handle(ctx context.Context, task model.Task, events chan port.TaskEvent) error {
+	purgeTask, ok := task.(*PurgeTrashTask)
+	if !ok {
+		return errors.Errorf("unexpected task type '%T'", task)
+	}
+
+	trashedBefore := purgeTask.TrashedBefore()
+
+	events <- port.NewTaskEvent(port.WithTaskMessage("purging trashed documents"))
+
+	documents, err := h.purgeDocuments(ctx, trashedBefore)
+	if err != nil {
+		return errors.Wrap(err, "could not purge trashed documents")
+	}
+
+	events <- port.NewTaskEvent(port.WithTaskProgress(0.5), port.WithTaskMessage("purging trashed collections"))
+
+	collections, err := h.purgeCollections(ctx, trashedBefore)
+	if err != nil {
+		return errors.Wrap(err, "could not purge trashed collections")
+	}
+
+	slog.InfoContext(ctx, "trash purged", slog.Int("documents", documents), slog.Int("collections", collections))
+
+	events <- port.NewTaskEvent(port.WithTaskProgress(1), port.WithTaskMessage(fmt.Sprintf("trash purged (documents: %d, collections: %d)", documents, collections)))
+
+	return nil
+}
+
+func (h *PurgeTrashHandler) purgeDocuments(ctx context.Context, trashedBefore time.Time) (int, error) {
+	limit := purgeTrashBatchSize
+	count := 0
+
+	for {
+		documents, _, err := h.documentStore.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{
+			Limit:         &limit,
+			TrashedBefore: &trashedBefore,
+		})
+		if err != nil {
+			return count, errors.Wrap(err, "could not query trashed documents")
+		}
+
+		if len(documents) == 0 {
+			return count, nil
+		}
+
+		if err := h.deleteDocuments(ctx, documents); err != nil {
+			return count, errors.WithStack(err)
+		}
+
+		count += len(documents)
+	}
+}
+
+// purgeCollections deletes the trashed collections, then their documents left
+// without collection
+func (h *PurgeTrashHandler) purgeCollections(ctx context.Context, trashedBefore time.Time) (int, error) {
+	limit := purgeTrashBatchSize
+	count := 0
+
+	// Documents of the deleted collections, which may be left without
+	// collection
+	documentIDs := make([]model.DocumentID, 0)
+
+	for {
+		collections, _, err := h.documentStore.QueryTrashedCollections(ctx, port.QueryTrashedCollectionsOptions{
+			Limit:         &limit,
+			TrashedBefore: &trashedBefore,
+		})
+		if err != nil {
+			return count, errors.Wrap(err, "could not query trashed collections")
+		}
+
+		if len(collections) == 0 {
+			break
+		}
+
+		for _, c := range collections {
+			ids, err := h.collectionDocumentIDs(ctx, c.ID())
+			if err != nil {
+				return count, errors.Wrapf(err, "could not query documents of collection '%s'", c.ID())
+			}
+
+			documentIDs = append(documentIDs, ids...)
+
+			slog.InfoContext(ctx, "deleting trashed collection", slog.String("collection_id", string(c.ID())))
+
+			if err := h.documentStore.DeleteCollection(ctx, c.ID()); err != nil {
+				return count, errors.Wrapf(err, "could not delete collection '%s'", c.ID())
+			}
+		}
+
+		count += len(collections)
+	}
+
+	slices.Sort(documentIDs)
+	documentIDs = slices.Compact(documentIDs)
+
+	orphaned := true
+
+	// Only the documents whose collections have all been deleted are deleted,
+	// the other ones being left untouched
+	for batch := range slices.Chunk(documentIDs, limit) {
+		documents, _, err := h.documentStore.QueryDocuments(ctx, port.QueryDocumentsOptions{
+			Limit:      &limit,
+			HeaderOnly: true,
+			IDs:        batch,
+			Orphaned:   &orphaned,
+		})
+		if err != nil {
+			return count, errors.Wrap(err, "could not query orphaned documents")
+		}
+
+		if len(documents) == 0 {
+			continue
+		}
+
+		if err := h.deleteDocuments(ctx, documents); err != nil {
+			return count, errors.WithStack(err)
+		}
+	}
+
+	return count, nil
+}
+
+// collectionDocumentIDs returns the identifiers of the documents of the given
+// collection
+func (h *PurgeTrashHandler) collectionDocumentIDs(ctx context.Context, collectionID model.CollectionID) ([]model.DocumentID, error) {
+	limit := purgeTrashBatchSize
+	documentIDs := make([]model.DocumentID, 0)
+
+	for page := 0; ; page++ {
+		documents, _, err := h.documentStore.QueryDocumentsByCollectionID(ctx, collectionID, port.QueryDocumentsOptions{
+			Page:       &page,
+			Limit:      &limit,
+			HeaderOnly: true,
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, d := range documents {
+			documentIDs = append(documentIDs, d.ID())
+		}
+
+		if len(documents) < limit {
+			return documentIDs, nil
+		}
+	}
+}
+
+// deleteDocuments permanently deletes the documents and their index entries
+func (h *PurgeTrashHandler) deleteDocuments(ctx context.Context, documents []model.PersistedDocument) error {
+	documentIDs := make([]model.DocumentID, 0, len(documents))
+
+	for _, d := range documents {
+		// Remaining entries are removed by the cleanup of the index
+		if err := h.index.DeleteBySource(ctx, d.Source()); err != nil {
+			slog.ErrorContext(ctx, "could not delete index entries of document", slog.String("document_id", string(d.ID())), slog.Any("error", errors.WithStack(err)))
+		}
+
+		documentIDs = append(documentIDs, d.ID())
+	}
+
+	slog.InfoContext(ctx, "deleting documents", "document_ids", documentIDs)
+
+	if err := h.documentStore.DeleteDocumentByID(ctx, documentIDs...); err != nil {
+		return errors.Wrap(err, "could not delete documents")
+	}
+
+	return nil
+}
+
+var _ port.TaskHandler = &PurgeTrashHandler{}
@@ -0,0 +1,157 @@
+package document
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestPurgeTrashHandler(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner, err := store.FindOrCreateUser(ctx, "test", "owner")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	trashedCollection, err := store.CreateCollection(ctx, owner.ID(), "Trashed")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	collection, err := store.CreateCollection(ctx, owner.ID(), "Kept")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	trashedOnly := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.", trashedCollection)
+	shared := saveTestDocument(t, store, owner, "https://example.net/dog", "# Dog\n\nThe lazy dog.", trashedCollection, collection)
+	// Documents without collection, unrelated to the trashed collection
+	orphaned := saveTestDocument(t, store, owner, "https://example.net/cat", "# Cat\n\nThe sleepy cat.")
+	trashed := saveTestDocument(t, store, owner, "https://example.net/bird", "# Bird\n\nThe early bird.")
+
+	index := newMockIndex()
+
+	for _, d := range []model.Document{trashedOnly, shared, orphaned, trashed} {
+		if err := index.Index(ctx, d); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	if err := store.TrashCollection(ctx, trashedCollection.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.TrashDocumentByID(ctx, trashed.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	handler := NewPurgeTrashHandler(index, store)
+
+	messages := runTestTask(t, handler, NewPurgeTrashTask(owner, time.Now().Add(time.Minute)))
+
+	if e, g := "trash purged (documents: 1, collections: 1)", messages[len(messages)-1]; e != g {
+		t.Errorf("last message: expected %q, got %q", e, g)
+	}
+
+	// Only the documents left without collection by the purge are deleted
+	documents, _, err := store.QueryDocuments(ctx, port.QueryDocumentsOptions{HeaderOnly: true})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	expected := []model.DocumentID{shared.ID(), orphaned.ID()}
+	slices.Sort(expected)
+
+	remaining := make([]model.DocumentID, 0, len(documents))
+	for _, d := range documents {
+		remaining = append(remaining, d.ID())
+	}
+
+	slices.Sort(remaining)
+
+	if e, g := expected, remaining; !slices.Equal(e, g) {
+		t.Errorf("remaining documents: expected %v, got %v", e, g)
+	}
+
+	_, total, err := store.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(0), total; e != g {
+		t.Errorf("total trashed documents: expected %d, got %d", e, g)
+	}
+
+	_, total, err = store.QueryTrashedCollections(ctx, port.QueryTrashedCollectionsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(0), total; e != g {
+		t.Errorf("total trashed collections: expected %d, got %d", e, g)
+	}
+
+	// The index entries of the deleted documents are deleted too
+	for _, tc := range []struct {
+		Document model.Document
+		Indexed  bool
+	}{
+		{Document: trashedOnly, Indexed: false},
+		{Document: trashed, Indexed: false},
+		{Document: shared, Indexed: true},
+		{Document: orphaned, Indexed: true},
+	} {
+		for _, id := range sectionIDs(t, tc.Document) {
+			if e, g := tc.Indexed, index.Has(id); e != g {
+				t.Errorf("index.Has(%s) (%s): expected %v, got %v", id, tc.Document.Source(), e, g)
+			}
+		}
+	}
+}
+
+func TestPurgeTrashHandlerUnexpired(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner, err := store.FindOrCreateUser(ctx, "test", "owner")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	trashedCollection, err := store.CreateCollection(ctx, owner.ID(), "Trashed")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	document := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.", trashedCollection)
+
+	if err := store.TrashCollection(ctx, trashedCollection.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	handler := NewPurgeTrashHandler(newMockIndex(), store)
+
+	// Items trashed after the given time are kept
+	messages := runTestTask(t, handler, NewPurgeTrashTask(owner, time.Now().Add(-time.Hour)))
+
+	if e, g := "trash purged (documents: 0, collections: 0)", messages[len(messages)-1]; e != g {
+		t.Errorf("last message: expected %q, got %q", e, g)
+	}
+
+	if err := store.RestoreCollection(ctx, trashedCollection.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if _, err := store.GetDocumentByID(ctx, document.ID()); err != nil {
+		t.Errorf("store.GetDocumentByID(): expected no error, got %+v", err)
+	}
+}
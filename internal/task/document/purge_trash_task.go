@@ -0,0 +1,80 @@
+package document
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/pkg/errors"
+)
+
+const TaskTypePurgeTrash model.TaskType = "purge_trash"
+
+type purgeTrashTaskPayload struct {
+	TrashedBefore time.Time `json:"trashedBefore"`
+}
+
+// PurgeTrashTask permanently deletes the documents and collections moved to
+// the trash before a given time.
+type PurgeTrashTask struct {
+	id            model.TaskID
+	owner         model.User
+	trashedBefore time.Time
+}
+
+// MarshalJSON implements [model.Task].
+func (t *PurgeTrashTask) MarshalJSON() ([]byte, error) {
+	payload := purgeTrashTaskPayload{
+		TrashedBefore: t.trashedBefore,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return data, nil
+}
+
+// UnmarshalJSON implements [model.Task].
+func (t *PurgeTrashTask) UnmarshalJSON(data []byte) error {
+	var payload purgeTrashTaskPayload
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return errors.WithStack(err)
+	}
+
+	t.trashedBefore = payload.TrashedBefore
+
+	return nil
+}
+
+// Owner implements [model.Task].
+func (t *PurgeTrashTask) Owner() model.User {
+	return t.owner
+}
+
+// ID implements port.Task.
+func (t *PurgeTrashTask) ID() model.TaskID {
+	return t.id
+}
+
+// Type implements port.Task.
+func (t *PurgeTrashTask) Type() model.TaskType {
+	return TaskTypePurgeTrash
+}
+
+// TrashedBefore returns the time before which the trashed items are purged.
+func (t *PurgeTrashTask) TrashedBefore() time.Time {
+	return t.trashedBefore
+}
+
+func NewPurgeTrashTask(owner model.User, trashedBefore time.Time) *PurgeTrashTask {
+	return &PurgeTrashTask{
+		id:            model.NewTaskID(),
+		owner:         owner,
+		trashedBefore: trashedBefore,
+	}
+}
+
+var _ model.Task = &PurgeTrashTask{}
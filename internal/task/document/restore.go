@@ -83,3 +83,39 @@ func RestoreSyncFilesystemSourceTask(id model.TaskID, ownerID string, payload []
 	}
 	return t, nil
 }
+
+// RestoreMigrateEmbeddingsTask reconstruit un MigrateEmbeddingsTask depuis les données persistées.
+func RestoreMigrateEmbeddingsTask(id model.TaskID, ownerID string, payload []byte) (model.Task, error) {
+	t := &MigrateEmbeddingsTask{
+		id:    id,
+		owner: &stubUser{id: model.UserID(ownerID)},
+	}
+	if err := json.Unmarshal(payload, t); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return t, nil
+}
+
+// RestoreCheckConsistencyTask reconstruit un CheckConsistencyTask depuis les données persistées.
+func RestoreCheckConsistencyTask(id model.TaskID, ownerID string, payload []byte) (model.Task, error) {
+	t := &CheckConsistencyTask{
+		id:    id,
+		owner: &stubUser{id: model.UserID(ownerID)},
+	}
+	if err := json.Unmarshal(payload, t); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return t, nil
+}
+
+// RestorePurgeTrashTask reconstruit un PurgeTrashTask depuis les données persistées.
+func RestorePurgeTrashTask(id model.TaskID, ownerID string, payload []byte) (model.Task, error) {
+	t := &PurgeTrashTask{
+		id:    id,
+		owner: &stubUser{id: model.UserID(ownerID)},
+	}
+	if err := json.Unmarshal(payload, t); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return t, nil
+}
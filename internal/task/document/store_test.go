@@ -0,0 +1,210 @@
+package document
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/adapter/gorm"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3/gormlite"
+	"github.com/pkg/errors"
+	gormlib "gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	_ "github.com/asg017/sqlite-vec-go-bindings/ncruces"
+)
+
+// newTestStore returns a store backed by a temporary sqlite database
+func newTestStore(t *testing.T) *gorm.Store {
+	db, err := gormlib.Open(gormlite.Open(filepath.Join(t.TempDir(), "corpus.sqlite")), &gormlib.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	internalDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	t.Cleanup(func() {
+		internalDB.Close()
+	})
+
+	internalDB.SetMaxOpenConns(1)
+
+	if err := db.Exec("PRAGMA foreign_keys=on").Error; err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	return gorm.NewStore(db)
+}
+
+// saveTestDocument saves a document with the given content in the given
+// collections and returns its persisted version
+func saveTestDocument(t *testing.T, store port.DocumentStore, owner model.User, rawURL string, content string, collections ...model.Collection) model.PersistedDocument {
+	ctx := context.Background()
+
+	source, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	doc, err := markdown.Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	doc.SetSource(source)
+
+	for _, c := range collections {
+		collection, err := store.GetCollectionByID(ctx, c.ID(), false)
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		doc.AddCollection(collection)
+	}
+
+	if err := store.SaveDocuments(ctx, model.AsOwnedDocument(doc, owner)); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	documents, _, err := store.QueryDocuments(ctx, port.QueryDocumentsOptions{MatchingSource: source})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(documents); e != g {
+		t.Fatalf("len(documents): expected %d, got %d", e, g)
+	}
+
+	return documents[0]
+}
+
+// sectionIDs returns the identifiers of the non empty sections of the given
+// document, as indexed
+func sectionIDs(t *testing.T, document model.Document) []model.SectionID {
+	ids := make([]model.SectionID, 0)
+
+	err := model.WalkSections(document, func(s model.Section) error {
+		content, err := s.Content()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if len(content) > 0 {
+			ids = append(ids, s.ID())
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	return ids
+}
+
+// mockIndex keeps the identifiers of the indexed sections in memory
+type mockIndex struct {
+	mutex    sync.Mutex
+	sections map[model.SectionID]string
+}
+
+func newMockIndex() *mockIndex {
+	return &mockIndex{
+		sections: map[model.SectionID]string{},
+	}
+}
+
+// Has returns true if the given section is indexed
+func (m *mockIndex) Has(id model.SectionID) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, exists := m.sections[id]
+
+	return exists
+}
+
+// All implements port.Index.
+func (m *mockIndex) All(ctx context.Context, yield func(model.SectionID) bool) error {
+	m.mutex.Lock()
+	ids := make([]model.SectionID, 0, len(m.sections))
+	for id := range m.sections {
+		ids = append(ids, id)
+	}
+	m.mutex.Unlock()
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		if !yield(id) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// DeleteByID implements port.Index.
+func (m *mockIndex) DeleteByID(ctx context.Context, ids ...model.SectionID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, id := range ids {
+		delete(m.sections, id)
+	}
+
+	return nil
+}
+
+// DeleteBySource implements port.Index.
+func (m *mockIndex) DeleteBySource(ctx context.Context, source *url.URL) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for id, s := range m.sections {
+		if s == source.String() {
+			delete(m.sections, id)
+		}
+	}
+
+	return nil
+}
+
+// Index implements port.Index.
+func (m *mockIndex) Index(ctx context.Context, document model.Document, funcs ...port.IndexOptionFunc) error {
+	opts := port.NewIndexOptions(funcs...)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return model.WalkSections(document, func(s model.Section) error {
+		content, err := s.Content()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if len(content) > 0 && opts.Includes(s.ID()) {
+			m.sections[s.ID()] = document.Source().String()
+		}
+
+		return nil
+	})
+}
+
+// Search implements port.Index.
+func (m *mockIndex) Search(ctx context.Context, query string, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error) {
+	return []*port.IndexSearchResult{}, nil
+}
+
+var _ port.Index = &mockIndex{}
@@ -119,8 +119,10 @@ func (h *SyncFilesystemSourceHandler) Handle(ctx context.Context, task model.Tas
 		wg.Wait()
 
 		if opts.DeleteOrphans && len(toDelete) > 0 {
-			if err := h.documentStore.DeleteDocumentByID(ctx, toDelete...); err != nil {
-				slog.ErrorContext(ctx, "could not delete orphan documents", slog.Any("error", errors.WithStack(err)))
+			// Orphan documents go through the trash, a file removed by mistake
+			// can be restored until the trash is purged
+			if err := h.documentStore.TrashDocumentByID(ctx, toDelete...); err != nil {
+				slog.ErrorContext(ctx, "could not trash orphan documents", slog.Any("error", errors.WithStack(err)))
 			}
 			done += len(toDelete)
 			sendProgress()
@@ -168,7 +170,7 @@ func (h *SyncFilesystemSourceHandler) stageAndScheduleIndexFile(ctx context.Cont
 	}
 	dst.Close()
 
-	indexTask := NewIndexFileTask(owner, stagedPath, job.Filename, job.ETag, job.Source, collectionIDs)
+	indexTask := NewIndexFileTask(owner, stagedPath, job.Filename, job.ETag, job.Source, collectionIDs, nil)
 
 	if err := h.taskRunner.ScheduleTask(ctx, indexTask); err != nil {
 		os.Remove(stagedPath)
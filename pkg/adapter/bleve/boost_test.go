@@ -0,0 +1,67 @@
+package bleve
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestSearchBoost(t *testing.T) {
+	ctx := context.Background()
+
+	bleveIndex, err := bleve.NewMemOnly(IndexMapping())
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	index := NewIndex(bleveIndex)
+
+	documents := []string{
+		"---\nsource: https://example.net/foxes\ntags: [animals]\n---\n\n# Foxes\n\nThe fox is a small omnivorous mammal. The fox hunts at night.",
+		"---\nsource: https://example.net/tales\ntags: [tales]\n---\n\n# Tales\n\nThe fox and the crow is a fable.",
+		"---\nsource: https://example.net/dogs\ntags: [animals]\n---\n\n# Dogs\n\nThe dog is a domesticated mammal.",
+	}
+
+	for _, data := range documents {
+		doc, err := markdown.Parse([]byte(data))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		if err := index.Index(ctx, doc); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	results, err := index.Search(ctx, "fox", port.IndexSearchOptions{MaxResults: 5})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 2, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	if e, g := "https://example.net/foxes", results[0].Source.String(); e != g {
+		t.Fatalf("results[0].Source: expected '%s', got '%s'", e, g)
+	}
+
+	results, err = index.Search(ctx, "fox", port.IndexSearchOptions{MaxResults: 5, Boost: map[string]string{"tags": "tales"}})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	// The boost ranks the tales first without adding the documents not
+	// matching the query
+	if e, g := 2, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	if e, g := "https://example.net/tales", results[0].Source.String(); e != g {
+		t.Errorf("results[0].Source: expected '%s', got '%s'", e, g)
+	}
+}
@@ -0,0 +1,223 @@
+package bleve
+
+import (
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/bornholm/corpus/pkg/port"
+)
+
+const (
+	// highlightFragmentSize is the approximate size, in bytes, of an highlight fragment
+	highlightFragmentSize = 200
+	// highlightMaxFragments is the maximum number of fragments returned per section
+	highlightMaxFragments = 3
+)
+
+type termLocation struct {
+	Term  string
+	Start int
+	End   int
+}
+
+type fragment struct {
+	Start int
+	End   int
+	Terms []termLocation
+}
+
+// highlight extracts from the content the fragments surrounding the given
+// term locations. The fragments holding the most distinct terms are kept and
+// returned in order of appearance.
+func highlight(content string, locations search.TermLocationMap) []*port.IndexSearchHighlight {
+	terms := sortedTermLocations(content, locations)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	fragments := make([]*fragment, 0)
+
+	var current *fragment
+	for _, t := range terms {
+		if current != nil && t.Start < current.End {
+			if t.End > current.End {
+				current.End = wordBoundaryAfter(content, t.End)
+			}
+			current.Terms = append(current.Terms, t)
+			continue
+		}
+
+		start := wordBoundaryBefore(content, t.Start-highlightFragmentSize/4)
+		end := wordBoundaryAfter(content, max(start+highlightFragmentSize, t.End))
+
+		// Do not overlap the previous fragment
+		if current != nil && start < current.End {
+			start = current.End
+		}
+
+		current = &fragment{
+			Start: start,
+			End:   end,
+			Terms: []termLocation{t},
+		}
+
+		fragments = append(fragments, current)
+	}
+
+	slices.SortStableFunc(fragments, func(f1, f2 *fragment) int {
+		if d := distinctTerms(f2) - distinctTerms(f1); d != 0 {
+			return d
+		}
+		return len(f2.Terms) - len(f1.Terms)
+	})
+
+	if len(fragments) > highlightMaxFragments {
+		fragments = fragments[:highlightMaxFragments]
+	}
+
+	slices.SortFunc(fragments, func(f1, f2 *fragment) int {
+		return f1.Start - f2.Start
+	})
+
+	highlights := make([]*port.IndexSearchHighlight, 0, len(fragments))
+	for _, f := range fragments {
+		highlights = append(highlights, f.highlight(content))
+	}
+
+	return highlights
+}
+
+func (f *fragment) highlight(content string) *port.IndexSearchHighlight {
+	start, end := f.Start, f.End
+
+	// Trim the surrounding whitespaces without moving past the terms
+	for start < f.Terms[0].Start {
+		r, size := utf8.DecodeRuneInString(content[start:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+
+	last := f.Terms[len(f.Terms)-1].End
+	for end > last {
+		r, size := utf8.DecodeLastRuneInString(content[:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+
+	h := &port.IndexSearchHighlight{
+		Fragment: content[start:end],
+		Terms:    make([]port.IndexSearchHighlightTerm, 0, len(f.Terms)),
+	}
+
+	for _, t := range f.Terms {
+		h.Terms = append(h.Terms, port.IndexSearchHighlightTerm{
+			Term:  t.Term,
+			Start: t.Start - start,
+			End:   t.End - start,
+		})
+	}
+
+	return h
+}
+
+// sortedTermLocations flattens the term locations, ignoring the ones outside
+// of the content and the duplicates produced by the multiple language
+// analyzers.
+func sortedTermLocations(content string, locations search.TermLocationMap) []termLocation {
+	terms := make([]termLocation, 0)
+	seen := map[[2]int]struct{}{}
+
+	for term, locs := range locations {
+		for _, l := range locs {
+			start, end := int(l.Start), int(l.End)
+			if start >= end || end > len(content) {
+				continue
+			}
+
+			key := [2]int{start, end}
+			if _, exists := seen[key]; exists {
+				continue
+			}
+
+			seen[key] = struct{}{}
+
+			terms = append(terms, termLocation{Term: term, Start: start, End: end})
+		}
+	}
+
+	slices.SortFunc(terms, func(t1, t2 termLocation) int {
+		if t1.Start != t2.Start {
+			return t1.Start - t2.Start
+		}
+		return t1.End - t2.End
+	})
+
+	// Drop overlapping locations, keeping the first one
+	filtered := terms[:0]
+	for _, t := range terms {
+		if len(filtered) > 0 && t.Start < filtered[len(filtered)-1].End {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+
+	return filtered
+}
+
+func distinctTerms(f *fragment) int {
+	terms := map[string]struct{}{}
+	for _, t := range f.Terms {
+		terms[t.Term] = struct{}{}
+	}
+	return len(terms)
+}
+
+// wordBoundaryBefore returns the offset of the start of the word containing
+// the given offset.
+func wordBoundaryBefore(content string, offset int) int {
+	if offset <= 0 {
+		return 0
+	}
+
+	for offset < len(content) && !utf8.RuneStart(content[offset]) {
+		offset++
+	}
+
+	for offset > 0 {
+		r, size := utf8.DecodeLastRuneInString(content[:offset])
+		if unicode.IsSpace(r) {
+			break
+		}
+		offset -= size
+	}
+
+	return offset
+}
+
+// wordBoundaryAfter returns the offset of the end of the word at the given
+// offset.
+func wordBoundaryAfter(content string, offset int) int {
+	if offset >= len(content) {
+		return len(content)
+	}
+
+	for offset < len(content) && !utf8.RuneStart(content[offset]) {
+		offset++
+	}
+
+	for offset < len(content) {
+		r, size := utf8.DecodeRuneInString(content[offset:])
+		if unicode.IsSpace(r) {
+			break
+		}
+		offset += size
+	}
+
+	return offset
+}
@@ -0,0 +1,85 @@
+package bleve
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestSearchHighlight(t *testing.T) {
+	ctx := context.Background()
+
+	bleveIndex, err := bleve.NewMemOnly(IndexMapping())
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	index := NewIndex(bleveIndex)
+
+	filler := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 20)
+	content := "# Foxes\n\n" + filler + "The quick brown fox jumps over the lazy dog. " + filler + "A fox is a small omnivorous mammal."
+
+	doc, err := markdown.Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/foxes")
+	doc.SetSource(source)
+
+	if err := index.Index(ctx, doc); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	results, err := index.Search(ctx, "fox", port.IndexSearchOptions{MaxResults: 5})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	if results[0].Highlights != nil {
+		t.Errorf("results[0].Highlights: expected nil when not requested, got %v", results[0].Highlights)
+	}
+
+	results, err = index.Search(ctx, "fox", port.IndexSearchOptions{MaxResults: 5, Highlight: true})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	total := 0
+
+	for _, sectionID := range results[0].Sections {
+		for _, h := range results[0].Highlights[sectionID] {
+			if len(h.Fragment) > 2*highlightFragmentSize {
+				t.Errorf("len(h.Fragment): expected at most %d, got %d", 2*highlightFragmentSize, len(h.Fragment))
+			}
+
+			for _, term := range h.Terms {
+				// Terms are stemmed, "Foxes" matches too
+				if g := strings.ToLower(h.Fragment[term.Start:term.End]); !strings.HasPrefix(g, "fox") {
+					t.Errorf("h.Fragment[term.Start:term.End]: expected a 'fox' prefix, got '%s'", g)
+				}
+
+				total++
+			}
+		}
+	}
+
+	// The title and the two occurrences in the body
+	if e, g := 3, total; e != g {
+		t.Errorf("total highlighted terms: expected %d, got %d", e, g)
+	}
+}
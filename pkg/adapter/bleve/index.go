@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"net/url"
 	"slices"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
 	bleveQuery "github.com/blevesearch/bleve/v2/search/query"
@@ -70,11 +71,18 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 		return errors.New("source missing")
 	}
 
-	if err := i.DeleteBySource(ctx, source); err != nil {
-		return errors.WithStack(err)
+	// When restricted to some sections, the other indexed sections of the
+	// document are kept
+	if opts.Sections == nil {
+		if err := i.DeleteBySource(ctx, source); err != nil {
+			return errors.WithStack(err)
+		}
 	}
 
 	totalSections := model.CountSections(document)
+	if opts.Sections != nil {
+		totalSections = len(opts.Sections)
+	}
 
 	totalIndexed := 0
 	onSectionIndexed := func() {
@@ -87,8 +95,13 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 		opts.OnProgress(progress)
 	}
 
+	attrs := documentAttributes{
+		UpdatedAt: model.DocumentUpdatedAt(document),
+		Metadata:  model.DocumentMetadata(document),
+	}
+
 	for _, s := range document.Sections() {
-		if err := i.indexSection(ctx, s, onSectionIndexed); err != nil {
+		if err := i.indexSection(ctx, s, attrs, opts, onSectionIndexed); err != nil {
 			return errors.WithStack(err)
 		}
 	}
@@ -96,14 +109,25 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 	return nil
 }
 
-func (i *Index) indexSection(ctx context.Context, section model.Section, onSectionIndexed func()) error {
+// documentAttributes holds the document level attributes indexed with each
+// section, used by the search filters
+type documentAttributes struct {
+	UpdatedAt time.Time
+	Metadata  map[string][]string
+}
+
+func (i *Index) indexSection(ctx context.Context, section model.Section, attrs documentAttributes, opts *port.IndexOptions, onSectionIndexed func()) error {
 	for _, s := range section.Sections() {
-		if err := i.indexSection(ctx, s, onSectionIndexed); err != nil {
+		if err := i.indexSection(ctx, s, attrs, opts, onSectionIndexed); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 
-	id, resource, err := i.getIndexableResource(ctx, section)
+	if !opts.Includes(section.ID()) {
+		return nil
+	}
+
+	id, resource, err := i.getIndexableResource(ctx, section, attrs, opts)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -124,7 +148,7 @@ func (i *Index) indexSection(ctx context.Context, section model.Section, onSecti
 	return nil
 }
 
-func (i *Index) getIndexableResource(ctx context.Context, section model.Section) (string, map[string]any, error) {
+func (i *Index) getIndexableResource(ctx context.Context, section model.Section, attrs documentAttributes, opts *port.IndexOptions) (string, map[string]any, error) {
 	source := section.Document().Source()
 
 	collections := slices.Collect(func(yield func(s string) bool) {
@@ -144,12 +168,26 @@ func (i *Index) getIndexableResource(ctx context.Context, section model.Section)
 		return "", nil, nil
 	}
 
-	return string(section.ID()), map[string]any{
+	resource := map[string]any{
 		"_type":       "resource",
 		"content":     string(content),
 		"source":      source.String(),
+		"source_url":  source.String(),
 		"collections": collections,
-	}, nil
+		"updated_at":  attrs.UpdatedAt,
+	}
+
+	if len(attrs.Metadata) > 0 {
+		resource["metadata"] = attrs.Metadata
+	}
+
+	// The context is searched along with the content but kept apart from it,
+	// the stored content being used for the highlights
+	if sectionContext := opts.Contexts[section.ID()]; sectionContext != "" {
+		resource["context"] = sectionContext
+	}
+
+	return string(section.ID()), resource, nil
 }
 
 // Search implements port.Index.
@@ -169,11 +207,29 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 		queries = append(queries, bleve.NewDisjunctionQuery(collectionQueries...))
 	}
 
-	req := bleve.NewSearchRequest(bleve.NewConjunctionQuery(queries...))
+	queries = append(queries, filterQueries(opts.Filter)...)
+
+	var searchQuery bleveQuery.Query = bleve.NewConjunctionQuery(queries...)
+
+	if boosts := boostQueries(opts.Boost); len(boosts) > 0 {
+		// The boosts are optional clauses, only adding to the score of the
+		// matching sections
+		booleanQuery := bleve.NewBooleanQuery()
+		booleanQuery.AddMust(queries...)
+		booleanQuery.AddShould(boosts...)
+		searchQuery = booleanQuery
+	}
+
+	req := bleve.NewSearchRequest(searchQuery)
 
 	req.From = 0
 	req.Fields = []string{"source"}
 
+	if opts.Highlight {
+		req.Fields = append(req.Fields, "content")
+		req.IncludeLocations = true
+	}
+
 	if opts.MaxResults > 0 {
 		req.Size = opts.MaxResults
 	}
@@ -185,6 +241,8 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 
 	mappedScores := map[string]float64{}
 	mappedSections := map[string][]model.SectionID{}
+	sectionScores := map[model.SectionID]float64{}
+	sectionHighlights := map[model.SectionID][]*port.IndexSearchHighlight{}
 
 	for _, r := range result.Hits {
 		rawSource, ok := r.Fields["source"].(string)
@@ -210,6 +268,18 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 
 		mappedSections[source.String()] = sectionIDs
 		mappedScores[source.String()] += r.Score
+
+		// Bleve scores are unbounded, normalize them against the best hit
+		if result.MaxScore > 0 {
+			sectionScores[sectionID] = r.Score / result.MaxScore
+		}
+
+		if opts.Highlight {
+			content, _ := r.Fields["content"].(string)
+			if highlights := highlight(content, r.Locations["content"]); len(highlights) > 0 {
+				sectionHighlights[sectionID] = highlights
+			}
+		}
 	}
 
 	searchResults := make([]*port.IndexSearchResult, 0)
@@ -220,9 +290,24 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 			return nil, errors.WithStack(err)
 		}
 
+		scores := make(map[model.SectionID]float64, len(sectionIDs))
+		var highlights map[model.SectionID][]*port.IndexSearchHighlight
+		for _, id := range sectionIDs {
+			scores[id] = sectionScores[id]
+
+			if h, exists := sectionHighlights[id]; exists {
+				if highlights == nil {
+					highlights = map[model.SectionID][]*port.IndexSearchHighlight{}
+				}
+				highlights[id] = h
+			}
+		}
+
 		searchResults = append(searchResults, &port.IndexSearchResult{
-			Source:   source,
-			Sections: sectionIDs,
+			Source:     source,
+			Sections:   sectionIDs,
+			Scores:     scores,
+			Highlights: highlights,
 		})
 	}
 
@@ -241,6 +326,58 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 	return searchResults, nil
 }
 
+// filterQueries translates the search filter into query clauses
+func filterQueries(filter *port.IndexSearchFilter) []bleveQuery.Query {
+	if filter.IsZero() {
+		return nil
+	}
+
+	queries := []bleveQuery.Query{}
+
+	if filter.SourcePrefix != "" {
+		prefixQuery := bleve.NewPrefixQuery(filter.SourcePrefix)
+		prefixQuery.SetField("source_url")
+		queries = append(queries, prefixQuery)
+	}
+
+	if filter.SourceGlob != "" {
+		wildcardQuery := bleve.NewWildcardQuery(filter.SourceGlob)
+		wildcardQuery.SetField("source_url")
+		queries = append(queries, wildcardQuery)
+	}
+
+	if !filter.UpdatedAfter.IsZero() || !filter.UpdatedBefore.IsZero() {
+		dateQuery := bleve.NewDateRangeQuery(filter.UpdatedAfter, filter.UpdatedBefore)
+		dateQuery.SetField("updated_at")
+		queries = append(queries, dateQuery)
+	}
+
+	for key, value := range filter.Metadata {
+		termQuery := bleve.NewTermQuery(value)
+		termQuery.SetField("metadata." + key)
+		queries = append(queries, termQuery)
+	}
+
+	return queries
+}
+
+// metadataBoost is the boost of the clauses matching the boosted metadata
+const metadataBoost = 2.0
+
+// boostQueries translates the boosted metadata into query clauses
+func boostQueries(boost map[string]string) []bleveQuery.Query {
+	queries := make([]bleveQuery.Query, 0, len(boost))
+
+	for key, value := range boost {
+		termQuery := bleve.NewTermQuery(value)
+		termQuery.SetField("metadata." + key)
+		termQuery.SetBoost(metadataBoost)
+		queries = append(queries, termQuery)
+	}
+
+	return queries
+}
+
 func NewIndex(index bleve.Index) *Index {
 	return &Index{
 		index: index,
@@ -0,0 +1,133 @@
+package bleve
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestIndexSections(t *testing.T) {
+	ctx := context.Background()
+
+	bleveIndex, err := bleve.NewMemOnly(IndexMapping())
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	index := NewIndex(bleveIndex)
+
+	parse := func(content string) *markdown.Document {
+		doc, err := markdown.Parse([]byte(content))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		source, _ := url.Parse("https://example.net/animals")
+		doc.SetSource(source)
+
+		return doc
+	}
+
+	previous := parse("Animals\n\n# Foxes\n\nThe quick brown fox.\n\n# Dogs\n\nThe obsolete dog.\n")
+
+	if err := index.Index(ctx, previous); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	previousSections := make([]model.SectionID, 0)
+	model.WalkSections(previous, func(s model.Section) error {
+		previousSections = append(previousSections, s.ID())
+		return nil
+	})
+
+	next := parse("Animals\n\n# Foxes\n\nThe quick brown fox.\n\n# Dogs\n\nThe lazy dog.\n")
+
+	diff := model.DiffSections(previousSections, next)
+
+	if err := index.DeleteByID(ctx, diff.Removed...); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := index.Index(ctx, next, port.WithIndexSections(diff.Added...)); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	search := func(query string) []model.SectionID {
+		results, err := index.Search(ctx, query, port.IndexSearchOptions{MaxResults: 10})
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		sections := make([]model.SectionID, 0)
+		for _, r := range results {
+			sections = append(sections, r.Sections...)
+		}
+
+		return sections
+	}
+
+	if g := search("obsolete"); len(g) != 0 {
+		t.Errorf("search('obsolete'): expected no section, got %v", g)
+	}
+
+	if g := search("lazy"); len(g) == 0 {
+		t.Errorf("search('lazy'): expected at least one section")
+	}
+
+	// The unchanged section was kept with its identity
+	if g := search("quick"); !slices.ContainsFunc(g, func(id model.SectionID) bool { return slices.Contains(diff.Unchanged, id) }) {
+		t.Errorf("search('quick'): expected an unchanged section, got %v", g)
+	}
+}
+
+func TestIndexSectionsContexts(t *testing.T) {
+	ctx := context.Background()
+
+	bleveIndex, err := bleve.NewMemOnly(IndexMapping())
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	index := NewIndex(bleveIndex)
+
+	doc, err := markdown.Parse([]byte("Run the installer.\n"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/install")
+	doc.SetSource(source)
+
+	contexts := map[model.SectionID]string{}
+	model.WalkSections(doc, func(s model.Section) error {
+		contexts[s.ID()] = "Corpus\nInstallation > Windows"
+		return nil
+	})
+
+	if err := index.Index(ctx, doc, port.WithIndexContexts(contexts)); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	// The context is searched but not highlighted
+	results, err := index.Search(ctx, "windows", port.IndexSearchOptions{MaxResults: 10, Highlight: true})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	for _, highlights := range results[0].Highlights {
+		if len(highlights) > 0 {
+			t.Errorf("results[0].Highlights: expected no highlight, got %v", highlights)
+		}
+	}
+}
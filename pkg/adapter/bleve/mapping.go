@@ -2,6 +2,7 @@ package bleve
 
 import (
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
 	"github.com/blevesearch/bleve/v2/mapping"
 )
 
@@ -15,10 +16,17 @@ func IndexMapping() *mapping.IndexMappingImpl {
 
 	contentFieldMapping := bleve.NewTextFieldMapping()
 	contentFieldMapping.Analyzer = AnalyzerDynamicLang
-	contentFieldMapping.Store = false
+	// Stored to extract the highlight fragments at search time
+	contentFieldMapping.Store = true
 	contentFieldMapping.IncludeTermVectors = true
 	resourceMapping.AddFieldMappingsAt("content", contentFieldMapping)
 
+	// Text situating the section in its document, see port.IndexOptions.Contexts
+	contextFieldMapping := bleve.NewTextFieldMapping()
+	contextFieldMapping.Analyzer = AnalyzerDynamicLang
+	contextFieldMapping.Store = false
+	resourceMapping.AddFieldMappingsAt("context", contextFieldMapping)
+
 	sourceFieldMapping := bleve.NewTextFieldMapping()
 	sourceFieldMapping.Analyzer = AnalyzerDynamicLang
 	sourceFieldMapping.Store = true
@@ -31,6 +39,21 @@ func IndexMapping() *mapping.IndexMappingImpl {
 	collectionsFieldMapping.IncludeTermVectors = true
 	resourceMapping.AddFieldMappingsAt("collections", collectionsFieldMapping)
 
+	// Untokenized source, used by the prefix and glob filters
+	sourceURLFieldMapping := bleve.NewKeywordFieldMapping()
+	sourceURLFieldMapping.Store = false
+	resourceMapping.AddFieldMappingsAt("source_url", sourceURLFieldMapping)
+
+	updatedAtFieldMapping := bleve.NewDateTimeFieldMapping()
+	updatedAtFieldMapping.Store = false
+	resourceMapping.AddFieldMappingsAt("updated_at", updatedAtFieldMapping)
+
+	// Document metadata, indexed as keywords under "metadata.<key>"
+	metadataMapping := bleve.NewDocumentMapping()
+	metadataMapping.Dynamic = true
+	metadataMapping.DefaultAnalyzer = keyword.Name
+	resourceMapping.AddSubDocumentMapping("metadata", metadataMapping)
+
 	mapping.AddDocumentMapping("resource", resourceMapping)
 
 	return mapping
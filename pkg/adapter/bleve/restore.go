@@ -5,6 +5,7 @@ import (
 
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/internal/core/service/backup"
+	"github.com/bornholm/corpus/pkg/port"
 	"github.com/pkg/errors"
 )
 
@@ -12,9 +13,17 @@ import (
 func (i *Index) RestoreDocuments(ctx context.Context, documents []model.Document) error {
 	batch := i.index.NewBatch()
 
+	// Restored sections are indexed without context
+	opts := port.NewIndexOptions()
+
 	for _, d := range documents {
+		attrs := documentAttributes{
+			UpdatedAt: model.DocumentUpdatedAt(d),
+			Metadata:  model.DocumentMetadata(d),
+		}
+
 		err := model.WalkSections(d, func(s model.Section) error {
-			id, resource, err := i.getIndexableResource(ctx, s)
+			id, resource, err := i.getIndexableResource(ctx, s, attrs, opts)
 			if err != nil {
 				return errors.WithStack(err)
 			}
@@ -15,11 +15,17 @@ func (c *CacheableCollection) CacheKeys() []string {
 	}
 }
 
+// VersionRetention implements [model.WithVersionRetention].
+func (c *CacheableCollection) VersionRetention() int {
+	return model.CollectionVersionRetention(c.PersistedCollection)
+}
+
 func NewCacheableCollection(collection model.PersistedCollection) *CacheableCollection {
 	return &CacheableCollection{collection}
 }
 
 var (
-	_ model.PersistedCollection = &CacheableCollection{}
-	_ Cacheable                 = &CacheableCollection{}
+	_ model.PersistedCollection  = &CacheableCollection{}
+	_ model.WithVersionRetention = &CacheableCollection{}
+	_ Cacheable                  = &CacheableCollection{}
 )
@@ -16,11 +16,29 @@ func (d *CacheableDocument) CacheKeys() []string {
 	}
 }
 
+// Metadata implements [model.WithMetadata].
+func (d *CacheableDocument) Metadata() map[string][]string {
+	return model.DocumentMetadata(d.PersistedDocument)
+}
+
+// TaskID implements [model.WithTaskID].
+func (d *CacheableDocument) TaskID() model.TaskID {
+	return model.DocumentTaskID(d.PersistedDocument)
+}
+
+// IndexedChecksum implements [model.WithIndexedChecksum].
+func (d *CacheableDocument) IndexedChecksum() string {
+	return model.DocumentIndexedChecksum(d.PersistedDocument)
+}
+
 func NewCacheableDocument(document model.PersistedDocument) *CacheableDocument {
 	return &CacheableDocument{document}
 }
 
 var (
 	_ model.PersistedDocument = &CacheableDocument{}
-	_ Cacheable               = &CacheableDocument{}
+	_ model.WithMetadata      = &CacheableDocument{}
+	_ model.WithTaskID          = &CacheableDocument{}
+	_ model.WithIndexedChecksum = &CacheableDocument{}
+	_ Cacheable                 = &CacheableDocument{}
 )
@@ -13,11 +13,17 @@ func (d *CacheableSection) CacheKeys() []string {
 	}
 }
 
+// Frame implements [model.FramedSection].
+func (d *CacheableSection) Frame() (model.Range, model.Range) {
+	return model.SectionFrame(d.Section)
+}
+
 func NewCacheableSection(section model.Section) *CacheableSection {
 	return &CacheableSection{section}
 }
 
 var (
-	_ model.Section = &CacheableSection{}
-	_ Cacheable     = &CacheableSection{}
+	_ model.Section       = &CacheableSection{}
+	_ model.FramedSection = &CacheableSection{}
+	_ Cacheable           = &CacheableSection{}
 )
@@ -146,6 +146,15 @@ func (s *DocumentStore) CreateCollectionShare(ctx context.Context, collectionID
 	return share, nil
 }
 
+// CreateCollectionGroupShare implements [port.DocumentStore].
+func (s *DocumentStore) CreateCollectionGroupShare(ctx context.Context, collectionID model.CollectionID, groupID model.GroupID, level model.CollectionShareLevel) (model.PersistedCollectionShare, error) {
+	// The share grants access to all the members of the group, so purge the
+	// entire authorization and stat caches to ensure consistency.
+	defer s.purgeAuthorizations()
+
+	return s.backend.CreateCollectionGroupShare(ctx, collectionID, groupID, level)
+}
+
 // DeleteCollectionShare implements [port.DocumentStore].
 func (s *DocumentStore) DeleteCollectionShare(ctx context.Context, shareID model.CollectionShareID) error {
 	// We can't know which user/collection this was for without fetching first,
@@ -183,6 +192,13 @@ func (s *DocumentStore) DeleteDocumentByID(ctx context.Context, ids ...model.Doc
 	return s.backend.DeleteDocumentByID(ctx, ids...)
 }
 
+// MarkDocumentIndexed implements [port.DocumentStore].
+func (s *DocumentStore) MarkDocumentIndexed(ctx context.Context, id model.DocumentID, checksum string) error {
+	defer s.documentCache.Remove(string(id))
+
+	return s.backend.MarkDocumentIndexed(ctx, id, checksum)
+}
+
 // DeleteDocumentBySource implements [port.DocumentStore].
 func (s *DocumentStore) DeleteDocumentBySource(ctx context.Context, ownerID model.UserID, source *url.URL) error {
 	defer func() {
@@ -193,6 +209,62 @@ func (s *DocumentStore) DeleteDocumentBySource(ctx context.Context, ownerID mode
 	return s.backend.DeleteDocumentBySource(ctx, ownerID, source)
 }
 
+// TrashDocumentByID implements [port.DocumentStore].
+func (s *DocumentStore) TrashDocumentByID(ctx context.Context, ids ...model.DocumentID) error {
+	defer s.purgeTrashed(ids...)
+
+	return s.backend.TrashDocumentByID(ctx, ids...)
+}
+
+// RestoreDocumentByID implements [port.DocumentStore].
+func (s *DocumentStore) RestoreDocumentByID(ctx context.Context, ids ...model.DocumentID) error {
+	defer s.purgeTrashed(ids...)
+
+	return s.backend.RestoreDocumentByID(ctx, ids...)
+}
+
+// QueryTrashedDocuments implements [port.DocumentStore].
+func (s *DocumentStore) QueryTrashedDocuments(ctx context.Context, opts port.QueryTrashedDocumentsOptions) ([]model.PersistedDocument, int64, error) {
+	return s.backend.QueryTrashedDocuments(ctx, opts)
+}
+
+// TrashCollection implements [port.DocumentStore].
+func (s *DocumentStore) TrashCollection(ctx context.Context, id model.CollectionID) error {
+	defer func() {
+		s.purgeTrashed()
+		s.collectionCache.Remove(string(id))
+	}()
+
+	return s.backend.TrashCollection(ctx, id)
+}
+
+// RestoreCollection implements [port.DocumentStore].
+func (s *DocumentStore) RestoreCollection(ctx context.Context, id model.CollectionID) error {
+	defer func() {
+		s.purgeTrashed()
+		s.collectionCache.Remove(string(id))
+	}()
+
+	return s.backend.RestoreCollection(ctx, id)
+}
+
+// QueryTrashedCollections implements [port.DocumentStore].
+func (s *DocumentStore) QueryTrashedCollections(ctx context.Context, opts port.QueryTrashedCollectionsOptions) ([]model.PersistedCollection, int64, error) {
+	return s.backend.QueryTrashedCollections(ctx, opts)
+}
+
+// purgeTrashed removes the cached entries which may be affected by moving
+// the given documents to or out of the trash. The sections and authorizations
+// are not indexed by document and are purged entirely.
+func (s *DocumentStore) purgeTrashed(ids ...model.DocumentID) {
+	s.statCache.Purge()
+	s.sectionCache.Purge()
+	s.authorizationCache.Purge()
+	for _, id := range ids {
+		s.documentCache.Remove(string(id))
+	}
+}
+
 // GetCollectionByID implements [port.DocumentStore].
 func (s *DocumentStore) GetCollectionByID(ctx context.Context, id model.CollectionID, full bool) (model.PersistedCollection, error) {
 	cachedCollection, exists := s.collectionCache.Get(getCompositeCacheKey(id, full))
@@ -232,6 +304,16 @@ func (s *DocumentStore) GetDocumentByID(ctx context.Context, id model.DocumentID
 	return document, nil
 }
 
+// QueryDocumentVersions implements [port.DocumentStore].
+func (s *DocumentStore) QueryDocumentVersions(ctx context.Context, documentID model.DocumentID, opts port.QueryDocumentVersionsOptions) ([]model.DocumentVersion, int64, error) {
+	return s.backend.QueryDocumentVersions(ctx, documentID, opts)
+}
+
+// GetDocumentVersionByID implements [port.DocumentStore].
+func (s *DocumentStore) GetDocumentVersionByID(ctx context.Context, id model.DocumentVersionID) (model.DocumentVersion, error) {
+	return s.backend.GetDocumentVersionByID(ctx, id)
+}
+
 // GetSectionByID implements [port.DocumentStore].
 func (s *DocumentStore) GetSectionByID(ctx context.Context, id model.SectionID) (model.Section, error) {
 	cachedSection, exists := s.sectionCache.Get(string(id))
@@ -324,6 +406,8 @@ func (s *DocumentStore) SaveDocuments(ctx context.Context, documents ...model.Ow
 		for _, d := range documents {
 			s.statCache.Remove(getReadableDocumentsCountCacheKey(d.Owner().ID()))
 			s.documentCache.Remove(string(d.ID()))
+			// The persisted document may keep the identity of its previous version
+			s.documentCache.Remove(getCompositeCacheKey(d.Owner().ID(), d.Source().String()))
 		}
 	}()
 
@@ -342,6 +426,13 @@ func (s *DocumentStore) UpdateCollection(ctx context.Context, id model.Collectio
 	return s.backend.UpdateCollection(ctx, id, updates)
 }
 
+// purgeAuthorizations purges the cached authorizations and the statistics
+// depending on them
+func (s *DocumentStore) purgeAuthorizations() {
+	s.authorizationCache.Purge()
+	s.statCache.Purge()
+}
+
 func NewDocumentStore(backend port.DocumentStore, size int, ttl time.Duration) *DocumentStore {
 	return &DocumentStore{
 		backend:            backend,
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+)
+
+// GroupStore purges the authorizations cached by a DocumentStore when the
+// groups or their members change, as they grant access to the collections
+// shared with the groups.
+type GroupStore struct {
+	backend   port.GroupStore
+	documents *DocumentStore
+}
+
+// CreateGroup implements [port.GroupStore].
+func (s *GroupStore) CreateGroup(ctx context.Context, ownerID model.UserID, name string, description string) (model.PersistedGroup, error) {
+	return s.backend.CreateGroup(ctx, ownerID, name, description)
+}
+
+// GetGroupByID implements [port.GroupStore].
+func (s *GroupStore) GetGroupByID(ctx context.Context, id model.GroupID) (model.PersistedGroup, error) {
+	return s.backend.GetGroupByID(ctx, id)
+}
+
+// QueryGroups implements [port.GroupStore].
+func (s *GroupStore) QueryGroups(ctx context.Context, opts port.QueryGroupsOptions) ([]model.PersistedGroup, int64, error) {
+	return s.backend.QueryGroups(ctx, opts)
+}
+
+// UpdateGroup implements [port.GroupStore].
+func (s *GroupStore) UpdateGroup(ctx context.Context, id model.GroupID, updates port.GroupUpdates) (model.PersistedGroup, error) {
+	return s.backend.UpdateGroup(ctx, id, updates)
+}
+
+// DeleteGroup implements [port.GroupStore].
+func (s *GroupStore) DeleteGroup(ctx context.Context, id model.GroupID) error {
+	defer s.documents.purgeAuthorizations()
+
+	return s.backend.DeleteGroup(ctx, id)
+}
+
+// AddGroupMembers implements [port.GroupStore].
+func (s *GroupStore) AddGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error {
+	defer s.documents.purgeAuthorizations()
+
+	return s.backend.AddGroupMembers(ctx, id, userIDs...)
+}
+
+// RemoveGroupMembers implements [port.GroupStore].
+func (s *GroupStore) RemoveGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error {
+	defer s.documents.purgeAuthorizations()
+
+	return s.backend.RemoveGroupMembers(ctx, id, userIDs...)
+}
+
+// SyncUserGroups implements [port.GroupStore].
+func (s *GroupStore) SyncUserGroups(ctx context.Context, userID model.UserID, provider string, names []string) error {
+	defer s.documents.purgeAuthorizations()
+
+	return s.backend.SyncUserGroups(ctx, userID, provider, names)
+}
+
+func NewGroupStore(backend port.GroupStore, documents *DocumentStore) *GroupStore {
+	return &GroupStore{
+		backend:   backend,
+		documents: documents,
+	}
+}
+
+var _ port.GroupStore = &GroupStore{}
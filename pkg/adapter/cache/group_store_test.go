@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// authorizationsBackend counts the authorization checks reaching the backend
+type authorizationsBackend struct {
+	port.DocumentStore
+	checks int
+}
+
+func (b *authorizationsBackend) CanReadCollection(ctx context.Context, userID model.UserID, collectionID model.CollectionID) (bool, error) {
+	b.checks++
+	return true, nil
+}
+
+func (b *authorizationsBackend) CountReadableDocuments(ctx context.Context, userID model.UserID) (int64, error) {
+	b.checks++
+	return 1, nil
+}
+
+type groupsBackend struct {
+	port.GroupStore
+}
+
+func (b *groupsBackend) DeleteGroup(ctx context.Context, id model.GroupID) error {
+	return nil
+}
+
+func (b *groupsBackend) AddGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error {
+	return nil
+}
+
+func (b *groupsBackend) RemoveGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error {
+	return nil
+}
+
+func (b *groupsBackend) SyncUserGroups(ctx context.Context, userID model.UserID, provider string, names []string) error {
+	return nil
+}
+
+func (b *groupsBackend) UpdateGroup(ctx context.Context, id model.GroupID, updates port.GroupUpdates) (model.PersistedGroup, error) {
+	return nil, nil
+}
+
+func TestGroupStorePurge(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		userID       model.UserID       = "user"
+		groupID      model.GroupID      = "group"
+		collectionID model.CollectionID = "collection"
+	)
+
+	type testCase struct {
+		Name          string
+		Mutate        func(store *GroupStore) error
+		ExpectedPurge bool
+	}
+
+	testCases := []testCase{
+		{
+			Name: "DeleteGroup",
+			Mutate: func(store *GroupStore) error {
+				return store.DeleteGroup(ctx, groupID)
+			},
+			ExpectedPurge: true,
+		},
+		{
+			Name: "AddGroupMembers",
+			Mutate: func(store *GroupStore) error {
+				return store.AddGroupMembers(ctx, groupID, userID)
+			},
+			ExpectedPurge: true,
+		},
+		{
+			Name: "RemoveGroupMembers",
+			Mutate: func(store *GroupStore) error {
+				return store.RemoveGroupMembers(ctx, groupID, userID)
+			},
+			ExpectedPurge: true,
+		},
+		{
+			Name: "SyncUserGroups",
+			Mutate: func(store *GroupStore) error {
+				return store.SyncUserGroups(ctx, userID, "oidc", []string{"engineering"})
+			},
+			ExpectedPurge: true,
+		},
+		{
+			Name: "UpdateGroup",
+			Mutate: func(store *GroupStore) error {
+				name := "renamed"
+				_, err := store.UpdateGroup(ctx, groupID, port.GroupUpdates{Name: &name})
+				return err
+			},
+			ExpectedPurge: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			backend := &authorizationsBackend{}
+			documents := NewDocumentStore(backend, 10, time.Minute)
+			store := NewGroupStore(&groupsBackend{}, documents)
+
+			check := func() {
+				if _, err := documents.CanReadCollection(ctx, userID, collectionID); err != nil {
+					t.Fatalf("%+v", errors.WithStack(err))
+				}
+
+				if _, err := documents.CountReadableDocuments(ctx, userID); err != nil {
+					t.Fatalf("%+v", errors.WithStack(err))
+				}
+			}
+
+			// The second check is answered by the cache
+			check()
+			check()
+
+			if e, g := 2, backend.checks; e != g {
+				t.Fatalf("backend.checks: expected %d, got %d", e, g)
+			}
+
+			if err := tc.Mutate(store); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			check()
+
+			expected := 2
+			if tc.ExpectedPurge {
+				expected = 4
+			}
+
+			if e, g := expected, backend.checks; e != g {
+				t.Errorf("backend.checks: expected %d, got %d", e, g)
+			}
+		})
+	}
+}
@@ -59,3 +59,10 @@ func (c *MultiIndexCache[V]) Remove(key string) {
 func (c *MultiIndexCache[V]) Len() int {
 	return c.cache.Len()
 }
+
+func (c *MultiIndexCache[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cache.Purge()
+}
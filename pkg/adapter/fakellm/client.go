@@ -0,0 +1,375 @@
+// Package fakellm is a deterministic llm.Client requiring no network, for the
+// development and the tests of corpus. Its embeddings are hashed bags of words
+// and its chat completions are scripted, extracted from the passages of the
+// prompt or echoed. When tools are offered, it first searches with the
+// question before answering.
+package fakellm
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+type Mode string
+
+const (
+	// ModeExtractive answers with the sentences of the numbered passages of
+	// the prompt sharing the most words with the question
+	ModeExtractive Mode = "extractive"
+	// ModeEcho answers with the question
+	ModeEcho Mode = "echo"
+)
+
+const (
+	DefaultDimensions = 384
+
+	// NoAnswerMessage is the answer of the extractive mode when no passage
+	// shares a word with the question
+	NoAnswerMessage = "The provided context does not contain the answer."
+
+	maxExtractedSentences = 3
+)
+
+type Client struct {
+	mode       Mode
+	rules      []Rule
+	dimensions int
+}
+
+func NewClient(mode Mode, rules []Rule, dimensions int) *Client {
+	if mode == "" {
+		mode = ModeExtractive
+	}
+
+	if dimensions <= 0 {
+		dimensions = DefaultDimensions
+	}
+
+	return &Client{
+		mode:       mode,
+		rules:      rules,
+		dimensions: dimensions,
+	}
+}
+
+// ChatCompletion implements llm.ChatCompletionClient.
+func (c *Client) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	call, err := toolCall(opts, parsePrompt(opts.Messages))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if call != nil {
+		return llm.NewChatCompletionResponse(
+			llm.NewMessage(llm.RoleAssistant, ""),
+			usage(opts, ""),
+			call,
+		), nil
+	}
+
+	content, err := c.complete(opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return llm.NewChatCompletionResponse(
+		llm.NewMessage(llm.RoleAssistant, content),
+		usage(opts, content),
+	), nil
+}
+
+// ChatCompletionStream implements llm.ChatCompletionStreamingClient.
+func (c *Client) ChatCompletionStream(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (<-chan llm.StreamChunk, error) {
+	opts := llm.NewChatCompletionOptions(funcs...)
+
+	content, err := c.complete(opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	chunks := make(chan llm.StreamChunk)
+
+	go func() {
+		defer close(chunks)
+
+		for _, word := range strings.SplitAfter(content, " ") {
+			select {
+			case <-ctx.Done():
+				return
+			case chunks <- llm.NewStreamChunk(llm.NewStreamDelta(llm.RoleAssistant, word)):
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case chunks <- llm.NewCompleteStreamChunk(usage(opts, content)):
+		}
+	}()
+
+	return chunks, nil
+}
+
+// Embeddings implements llm.EmbeddingsClient.
+func (c *Client) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	opts := llm.NewEmbeddingsOptions(funcs...)
+
+	dimensions := c.dimensions
+	if opts.Dimensions != nil && *opts.Dimensions > 0 {
+		dimensions = *opts.Dimensions
+	}
+
+	res := &embeddingsResponse{
+		embeddings: make([][]float64, 0, len(inputs)),
+	}
+
+	var tokens int64
+	for _, input := range inputs {
+		words := tokenize(input)
+		tokens += int64(len(words))
+		res.embeddings = append(res.embeddings, embed(words, dimensions))
+	}
+
+	res.usage = llm.NewEmbeddingsUsage(tokens, tokens)
+
+	return res, nil
+}
+
+func (c *Client) complete(opts *llm.ChatCompletionOptions) (string, error) {
+	p := parsePrompt(opts.Messages)
+
+	for _, r := range c.rules {
+		if r.Pattern.MatchString(p.text) {
+			return r.Response, nil
+		}
+	}
+
+	if opts.ResponseFormat != llm.ResponseFormatJSON {
+		return c.answer(p), nil
+	}
+
+	var value any
+	if opts.ResponseSchema != nil {
+		value = generate(opts.ResponseSchema.Name(), toMap(opts.ResponseSchema.Schema()), p)
+	} else {
+		value = map[string]any{"response": c.answer(p)}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return string(data), nil
+}
+
+func (c *Client) answer(p prompt) string {
+	if c.mode == ModeEcho {
+		return p.question
+	}
+
+	numbered := slices.ContainsFunc(p.passages, func(p passage) bool { return p.number > 0 })
+	if !numbered {
+		if p.question == strings.TrimSpace(p.text) && p.lastSection != "" {
+			// A standalone instruction, e.g. a summary of the last section
+			return firstSentence(p.lastSection)
+		}
+
+		return p.question
+	}
+
+	if extract := extractSentences(p); extract != "" {
+		return extract
+	}
+
+	return NoAnswerMessage
+}
+
+type scoredSentence struct {
+	text   string
+	number int
+	score  int
+	index  int
+}
+
+var sentenceEndRegExp = regexp.MustCompile(`([.!?])\s+`)
+
+// extractSentences returns the sentences of the numbered passages sharing the
+// most keywords with the question, in the order of the prompt, each followed
+// by the citation marker of its passage
+func extractSentences(p prompt) string {
+	keywords := keywords(p.question)
+
+	var sentences []scoredSentence
+	for _, passage := range p.passages {
+		if passage.number == 0 {
+			continue
+		}
+
+		for _, s := range splitSentences(passage.content) {
+			score := 0
+			seen := map[string]struct{}{}
+			for _, t := range tokenize(s) {
+				if _, exists := keywords[t]; !exists {
+					continue
+				}
+
+				if _, exists := seen[t]; exists {
+					continue
+				}
+
+				seen[t] = struct{}{}
+				score++
+			}
+
+			if score == 0 {
+				continue
+			}
+
+			sentences = append(sentences, scoredSentence{
+				text:   s,
+				number: passage.number,
+				score:  score,
+				index:  len(sentences),
+			})
+		}
+	}
+
+	sort.SliceStable(sentences, func(i, j int) bool {
+		return sentences[i].score > sentences[j].score
+	})
+
+	if len(sentences) > maxExtractedSentences {
+		sentences = sentences[:maxExtractedSentences]
+	}
+
+	sort.Slice(sentences, func(i, j int) bool {
+		return sentences[i].index < sentences[j].index
+	})
+
+	parts := make([]string, 0, len(sentences))
+	for _, s := range sentences {
+		parts = append(parts, cite(s.text, s.number))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// cite inserts the citation marker of the passage before the final
+// punctuation of the sentence
+func cite(sentence string, number int) string {
+	marker := " [" + strconv.Itoa(number) + "]"
+
+	if end := len(sentence) - 1; end >= 0 && strings.ContainsRune(".!?", rune(sentence[end])) {
+		return sentence[:end] + marker + sentence[end:]
+	}
+
+	return sentence + marker
+}
+
+func splitSentences(text string) []string {
+	var sentences []string
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = sentenceEndRegExp.ReplaceAllString(line, "$1\n")
+		for _, s := range strings.Split(line, "\n") {
+			if s = strings.TrimSpace(s); s != "" {
+				sentences = append(sentences, s)
+			}
+		}
+	}
+
+	return sentences
+}
+
+func firstSentence(text string) string {
+	sentences := splitSentences(text)
+	if len(sentences) == 0 {
+		return strings.TrimSpace(text)
+	}
+
+	return sentences[0]
+}
+
+// embed returns the normalized hashed bag of the words, each word adding or
+// removing one to the dimension given by its hash
+func embed(words []string, dimensions int) []float64 {
+	vector := make([]float64, dimensions)
+
+	for _, w := range words {
+		hash := fnv.New64a()
+		hash.Write([]byte(w))
+		sum := hash.Sum64()
+
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+
+		vector[sum%uint64(dimensions)] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+
+	if norm == 0 {
+		vector[0] = 1
+		return vector
+	}
+
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+
+	return vector
+}
+
+func usage(opts *llm.ChatCompletionOptions, content string) llm.ChatCompletionUsage {
+	var promptTokens int64
+	for _, m := range opts.Messages {
+		promptTokens += int64(len(tokenize(m.Content())))
+	}
+
+	completionTokens := int64(len(tokenize(content)))
+
+	return llm.NewChatCompletionUsage(promptTokens, completionTokens, promptTokens+completionTokens)
+}
+
+type embeddingsResponse struct {
+	embeddings [][]float64
+	usage      llm.EmbeddingsUsage
+}
+
+// Embeddings implements llm.EmbeddingsResponse.
+func (r *embeddingsResponse) Embeddings() [][]float64 {
+	return r.embeddings
+}
+
+// Usage implements llm.EmbeddingsResponse.
+func (r *embeddingsResponse) Usage() llm.EmbeddingsUsage {
+	return r.usage
+}
+
+var (
+	_ llm.Client             = &Client{}
+	_ llm.EmbeddingsResponse = &embeddingsResponse{}
+)
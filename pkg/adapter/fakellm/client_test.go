@@ -0,0 +1,288 @@
+package fakellm
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+const answerPrompt = `
+Answer the question using only the following context.
+
+## Context
+
+### [1] example://eiffel.md
+
+# The Eiffel Tower
+
+The Eiffel Tower is a wrought-iron lattice tower located in Paris. It was completed in 1889.
+
+### [2] example://paris.md
+
+Paris is the capital of France. It sits on the river Seine.
+`
+
+func TestClientChatCompletion(t *testing.T) {
+	ctx := context.Background()
+
+	type testCase struct {
+		Name     string
+		Client   *Client
+		Messages []llm.Message
+		Expected string
+	}
+
+	testCases := []testCase{
+		{
+			Name:   "extractive answer with citations",
+			Client: NewClient(ModeExtractive, nil, 0),
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleSystem, answerPrompt),
+				llm.NewMessage(llm.RoleUser, "When was the Eiffel Tower completed?"),
+			},
+			Expected: "The Eiffel Tower is a wrought-iron lattice tower located in Paris [1]. It was completed in 1889 [1].",
+		},
+		{
+			Name:   "extractive answer without matching context",
+			Client: NewClient(ModeExtractive, nil, 0),
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleSystem, answerPrompt),
+				llm.NewMessage(llm.RoleUser, "Who won the football world cup?"),
+			},
+			Expected: NoAnswerMessage,
+		},
+		{
+			Name:   "query section",
+			Client: NewClient(ModeExtractive, nil, 0),
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleUser, "Rewrite the query.\n\n## Original query\n\nEiffel tower height\n\n## What was missing\n\nThe height."),
+			},
+			Expected: "Eiffel tower height",
+		},
+		{
+			Name:   "summary of the last section",
+			Client: NewClient(ModeExtractive, nil, 0),
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleUser, "Summarize the following document.\n\n## Document\n\nParis is the capital of France. It sits on the river Seine."),
+			},
+			Expected: "Paris is the capital of France.",
+		},
+		{
+			Name:   "echo",
+			Client: NewClient(ModeEcho, nil, 0),
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleSystem, answerPrompt),
+				llm.NewMessage(llm.RoleUser, "When was the Eiffel Tower completed?"),
+			},
+			Expected: "When was the Eiffel Tower completed?",
+		},
+		{
+			Name: "scripted",
+			Client: NewClient(ModeEcho, []Rule{
+				{Pattern: regexp.MustCompile(`(?i)eiffel`), Response: "In 1889 [1]."},
+			}, 0),
+			Messages: []llm.Message{
+				llm.NewMessage(llm.RoleSystem, answerPrompt),
+				llm.NewMessage(llm.RoleUser, "When was the Eiffel Tower completed?"),
+			},
+			Expected: "In 1889 [1].",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			res, err := tc.Client.ChatCompletion(ctx, llm.WithMessages(tc.Messages...))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if e, g := tc.Expected, res.Message().Content(); e != g {
+				t.Errorf("res.Message().Content(): expected '%s', got '%s'", e, g)
+			}
+		})
+	}
+}
+
+func TestClientChatCompletionTools(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(ModeExtractive, nil, 0)
+
+	search := llm.NewFuncTool("search", "Search the documents",
+		llm.NewJSONSchema().RequiredProperty("query", "The search query", "string"),
+		func(ctx context.Context, params map[string]any) (llm.ToolResult, error) {
+			return llm.NewToolResult(""), nil
+		},
+	)
+
+	messages := []llm.Message{
+		llm.NewMessage(llm.RoleSystem, "Answer with the tools."),
+		llm.NewMessage(llm.RoleUser, "When was the Eiffel Tower completed?"),
+	}
+
+	res, err := client.ChatCompletion(ctx, llm.WithMessages(messages...), llm.WithTools(search), llm.WithToolChoice(llm.ToolChoiceAuto))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	toolCalls := res.ToolCalls()
+	if len(toolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(toolCalls))
+	}
+
+	if e, g := `{"query":"When was the Eiffel Tower completed?"}`, toolCalls[0].Parameters(); e != g {
+		t.Errorf("toolCalls[0].Parameters(): expected '%s', got '%v'", e, g)
+	}
+
+	messages = append(messages,
+		llm.NewToolCallsMessage(toolCalls...),
+		llm.NewToolMessage(toolCalls[0].ID(), llm.NewToolResult("### [1] example://eiffel.md\n\nThe Eiffel Tower was completed in 1889.")),
+	)
+
+	res, err = client.ChatCompletion(ctx, llm.WithMessages(messages...), llm.WithTools(search), llm.WithToolChoice(llm.ToolChoiceAuto))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(res.ToolCalls()) != 0 {
+		t.Fatalf("expected no tool call once the tool has answered")
+	}
+
+	if e, g := "The Eiffel Tower was completed in 1889 [1].", res.Message().Content(); e != g {
+		t.Errorf("res.Message().Content(): expected '%s', got '%s'", e, g)
+	}
+}
+
+func TestClientChatCompletionJSON(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(ModeExtractive, nil, 0)
+
+	userPrompt := "## Query\n\nWhen was the Eiffel Tower completed?\n\n## Documents\n\n" +
+		"### Document s1\n\n**Identifier:**s1\n\nThe Eiffel Tower was completed in 1889.\n\n" +
+		"### Document s2\n\n**Identifier:**s2\n\nParis is the capital of France.\n\n"
+
+	type judgeResponse struct {
+		Identifiers []string `json:"identifiers"`
+		Explanation string   `json:"explanation"`
+	}
+
+	res, err := client.ChatCompletion(ctx,
+		llm.WithJSONResponse(llm.NewResponseSchema("FilteredResults", "", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"identifiers": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"type": "string"},
+				},
+				"explanation": map[string]any{"type": "string"},
+			},
+		})),
+		llm.WithMessages(llm.NewMessage(llm.RoleUser, userPrompt)),
+	)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	judges, err := llm.ParseJSON[judgeResponse](res.Message())
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := "s1,s2", strings.Join(judges[0].Identifiers, ","); e != g {
+		t.Errorf("judges[0].Identifiers: expected '%s', got '%s'", e, g)
+	}
+
+	type groundingResponse struct {
+		Status string  `json:"status"`
+		Score  float64 `json:"score"`
+	}
+
+	groundingSchema := llm.NewResponseSchema("Grounding", "", map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"status": map[string]any{"type": "string", "enum": []string{"valid", "partial", "invalid"}},
+			"score":  map[string]any{"type": "number"},
+		},
+	})
+
+	for question, expected := range map[string]string{
+		"When was the Eiffel Tower completed?": "valid",
+		"Who won the football world cup?":      "invalid",
+	} {
+		prompt := strings.Replace(userPrompt, "When was the Eiffel Tower completed?", question, 1)
+
+		res, err := client.ChatCompletion(ctx,
+			llm.WithJSONResponse(groundingSchema),
+			llm.WithMessages(llm.NewMessage(llm.RoleUser, prompt)),
+		)
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		groundings, err := llm.ParseJSON[groundingResponse](res.Message())
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		if e, g := expected, groundings[0].Status; e != g {
+			t.Errorf("groundings[0].Status for '%s': expected '%s', got '%s'", question, e, g)
+		}
+	}
+}
+
+func TestClientEmbeddings(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient(ModeExtractive, nil, 64)
+
+	res, err := client.Embeddings(ctx, []string{
+		"The Eiffel Tower is located in Paris",
+		"Where is the Eiffel Tower located?",
+		"Photosynthesis converts light into chemical energy",
+		"The Eiffel Tower is located in Paris",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	embeddings := res.Embeddings()
+
+	for i, e := range embeddings {
+		if g := len(e); g != 64 {
+			t.Errorf("len(embeddings[%d]): expected 64, got %d", i, g)
+		}
+
+		if norm := math.Sqrt(dot(e, e)); math.Abs(norm-1) > 1e-9 {
+			t.Errorf("embeddings[%d]: expected a unit vector, got a norm of %f", i, norm)
+		}
+	}
+
+	if dot(embeddings[0], embeddings[3]) < 1-1e-9 {
+		t.Errorf("expected identical inputs to have identical embeddings")
+	}
+
+	if related, unrelated := dot(embeddings[0], embeddings[1]), dot(embeddings[0], embeddings[2]); related <= unrelated {
+		t.Errorf("expected related inputs to be closer (%f) than unrelated ones (%f)", related, unrelated)
+	}
+
+	res, err = client.Embeddings(ctx, []string{"Paris"}, llm.WithDimensions(16))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if g := len(res.Embeddings()[0]); g != 16 {
+		t.Errorf("len(res.Embeddings()[0]): expected 16, got %d", g)
+	}
+}
+
+func dot(v1, v2 []float64) float64 {
+	var sum float64
+	for i := range v1 {
+		sum += v1[i] * v2[i]
+	}
+	return sum
+}
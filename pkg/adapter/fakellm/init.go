@@ -0,0 +1,98 @@
+package fakellm
+
+import (
+	"context"
+	"os"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/provider"
+	"github.com/pkg/errors"
+)
+
+const Name provider.Name = "fake"
+
+// Options configures the fake provider. The model, base URL and API key of
+// the common options are ignored.
+type Options struct {
+	provider.CommonOptions
+
+	// Mode is the strategy of the chat completions not matched by a rule of the
+	// script, "extractive" by default
+	Mode Mode `env:"MODE"`
+	// Script is the path to a YAML file of rules, matched against the prompts
+	// before falling back to the mode
+	Script string `env:"SCRIPT"`
+	// Dimensions is the size of the embeddings vectors, DefaultDimensions
+	// when zero
+	Dimensions int `env:"DIMENSIONS"`
+}
+
+// Validate implements provider.Validator.
+func (o *Options) Validate() error {
+	switch o.Mode {
+	case "", ModeExtractive, ModeEcho:
+	default:
+		return errors.Errorf("unknown fake llm mode '%s'", o.Mode)
+	}
+
+	if o.Dimensions < 0 {
+		return errors.Errorf("invalid fake llm embeddings dimensions '%d'", o.Dimensions)
+	}
+
+	return nil
+}
+
+func defaultOptions() *Options {
+	return &Options{
+		Mode:       ModeExtractive,
+		Dimensions: DefaultDimensions,
+	}
+}
+
+func newClient(opts *Options) (*Client, error) {
+	var rules []Rule
+
+	if opts.Script != "" {
+		file, err := os.Open(opts.Script)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		defer file.Close()
+
+		rules, err = ReadScript(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read script '%s'", opts.Script)
+		}
+	}
+
+	return NewClient(opts.Mode, rules, opts.Dimensions), nil
+}
+
+func init() {
+	provider.RegisterChatCompletion(
+		Name,
+		defaultOptions,
+		func(ctx context.Context, opts *Options) (llm.ChatCompletionClient, error) {
+			client, err := newClient(opts)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			return client, nil
+		},
+	)
+
+	provider.RegisterEmbeddings(
+		Name,
+		defaultOptions,
+		func(ctx context.Context, opts *Options) (llm.EmbeddingsClient, error) {
+			client, err := newClient(opts)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			return client, nil
+		},
+	)
+}
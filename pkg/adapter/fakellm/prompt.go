@@ -0,0 +1,193 @@
+package fakellm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bornholm/genai/llm"
+)
+
+// prompt is the structure of the messages of a chat completion, as written by
+// the prompts of corpus: markdown "##" sections, "###" passages and
+// "**Identifier:**" lines.
+type prompt struct {
+	// text is the content of the messages joined by blank lines
+	text string
+	// question is the content of the last section titled after a query or a
+	// question, or else the last user message
+	question string
+	// lastSection is the content of the last "##" section
+	lastSection string
+	passages    []passage
+	identifiers []string
+}
+
+type passage struct {
+	// number is the number of the passage when its heading starts with
+	// "[n]", 0 otherwise
+	number  int
+	content string
+}
+
+var (
+	passageNumberRegExp = regexp.MustCompile(`^\[(\d+)\]`)
+	labelLineRegExp     = regexp.MustCompile(`^\*\*[^*]+:\*\*`)
+)
+
+const identifierLabel = "**Identifier:**"
+
+func parsePrompt(messages []llm.Message) prompt {
+	var (
+		p           prompt
+		contents    []string
+		lastUser    string
+		hasQuestion bool
+	)
+
+	for _, m := range messages {
+		contents = append(contents, m.Content())
+
+		if m.Role() == llm.RoleUser {
+			lastUser = m.Content()
+		}
+
+		var (
+			heading   string
+			section   strings.Builder
+			current   *passage
+			inSection bool
+		)
+
+		closeSection := func() {
+			if !inSection {
+				return
+			}
+
+			content := strings.TrimSpace(section.String())
+			p.lastSection = content
+
+			lower := strings.ToLower(heading)
+			if strings.Contains(lower, "query") || strings.Contains(lower, "question") {
+				p.question = content
+				hasQuestion = true
+			}
+
+			section.Reset()
+			inSection = false
+		}
+
+		closePassage := func() {
+			if current == nil {
+				return
+			}
+
+			current.content = strings.TrimSpace(current.content)
+			p.passages = append(p.passages, *current)
+			current = nil
+		}
+
+		for _, line := range strings.Split(m.Content(), "\n") {
+			switch {
+			case strings.HasPrefix(line, "## "):
+				closePassage()
+				closeSection()
+				heading = strings.TrimPrefix(line, "## ")
+				inSection = true
+				continue
+
+			case strings.HasPrefix(line, "### "):
+				closePassage()
+				current = &passage{}
+				if match := passageNumberRegExp.FindStringSubmatch(strings.TrimPrefix(line, "### ")); match != nil {
+					current.number, _ = strconv.Atoi(match[1])
+				}
+				continue
+
+			case strings.HasPrefix(line, identifierLabel):
+				p.identifiers = append(p.identifiers, strings.TrimSpace(strings.TrimPrefix(line, identifierLabel)))
+				continue
+			}
+
+			if current != nil {
+				if !labelLineRegExp.MatchString(line) {
+					current.content += line + "\n"
+				}
+				continue
+			}
+
+			if inSection {
+				section.WriteString(line)
+				section.WriteString("\n")
+			}
+		}
+
+		closePassage()
+		closeSection()
+	}
+
+	if !hasQuestion {
+		p.question = strings.TrimSpace(lastUser)
+	}
+
+	p.text = strings.Join(contents, "\n\n")
+
+	return p
+}
+
+// support returns the share of the question's keywords found in the passages,
+// 1 when the prompt has no passage
+func (p prompt) support() float64 {
+	if len(p.passages) == 0 {
+		return 1
+	}
+
+	keywords := keywords(p.question)
+	if len(keywords) == 0 {
+		return 1
+	}
+
+	found := map[string]struct{}{}
+	for _, passage := range p.passages {
+		for _, t := range tokenize(passage.content) {
+			if _, exists := keywords[t]; exists {
+				found[t] = struct{}{}
+			}
+		}
+	}
+
+	return float64(len(found)) / float64(len(keywords))
+}
+
+var stopWords = map[string]struct{}{
+	"the": {}, "and": {}, "for": {}, "are": {}, "was": {}, "what": {}, "which": {},
+	"who": {}, "how": {}, "why": {}, "when": {}, "where": {}, "with": {}, "does": {},
+	"les": {}, "des": {}, "une": {}, "est": {}, "que": {}, "qui": {}, "quoi": {},
+	"dans": {}, "pour": {}, "avec": {}, "quel": {}, "quelle": {},
+}
+
+// keywords returns the distinct words of the text, without the short and the
+// most common ones
+func keywords(text string) map[string]struct{} {
+	keywords := map[string]struct{}{}
+	for _, t := range tokenize(text) {
+		if len([]rune(t)) < 3 {
+			continue
+		}
+
+		if _, exists := stopWords[t]; exists {
+			continue
+		}
+
+		keywords[t] = struct{}{}
+	}
+
+	return keywords
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
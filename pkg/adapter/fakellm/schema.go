@@ -0,0 +1,109 @@
+package fakellm
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+const fakeExplanation = "Generated by the fake provider from the words shared by the query and the documents."
+
+// generate returns a value valid against the JSON schema, derived from the
+// prompt where the property allows it:
+//   - the enums are assumed ordered from the most to the least favorable
+//     value, one being chosen according to the support of the question by the
+//     passages of the prompt, which also gives the numbers;
+//   - the arrays of identifiers hold the identifiers of the prompt;
+//   - the other arrays of strings hold the question.
+func generate(name string, schema map[string]any, p prompt) any {
+	support := p.support()
+
+	if enum := toSlice(schema["enum"]); len(enum) > 0 {
+		switch {
+		case support >= 2.0/3:
+			return enum[0]
+		case support >= 1.0/3:
+			return enum[len(enum)/2]
+		default:
+			return enum[len(enum)-1]
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		properties := toMap(schema["properties"])
+
+		object := make(map[string]any, len(properties))
+		for key, property := range properties {
+			object[key] = generate(key, toMap(property), p)
+		}
+
+		return object
+
+	case "array":
+		items := toMap(schema["items"])
+		if items["type"] != "string" {
+			return []any{}
+		}
+
+		if strings.Contains(strings.ToLower(name), "identifier") {
+			return append([]string{}, p.identifiers...)
+		}
+
+		if p.question == "" {
+			return []string{}
+		}
+
+		return []string{p.question}
+
+	case "number":
+		return math.Round(support*100) / 100
+
+	case "integer":
+		return 1
+
+	case "boolean":
+		return true
+
+	case "string":
+		return fakeExplanation
+
+	default:
+		return nil
+	}
+}
+
+// toMap returns the schema as a map, converting it through JSON when it is
+// not already one
+func toMap(schema any) map[string]any {
+	if m, ok := schema.(map[string]any); ok {
+		return m
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return map[string]any{}
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return map[string]any{}
+	}
+
+	return m
+}
+
+func toSlice(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case []string:
+		values := make([]any, 0, len(v))
+		for _, s := range v {
+			values = append(values, s)
+		}
+		return values
+	default:
+		return nil
+	}
+}
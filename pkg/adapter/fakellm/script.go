@@ -0,0 +1,49 @@
+package fakellm
+
+import (
+	"io"
+	"regexp"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a scripted response, returned when its pattern matches the prompt.
+// The prompt is the content of the messages joined by blank lines.
+type Rule struct {
+	Pattern  *regexp.Regexp
+	Response string
+}
+
+type scriptRule struct {
+	Match    string `yaml:"match"`
+	Response string `yaml:"response"`
+}
+
+// ReadScript reads a YAML list of rules, each with a "match" regular
+// expression and the "response" returned when it matches, e.g.:
+//
+//	- match: (?i)capital of france
+//	  response: Paris is the capital of France [1].
+func ReadScript(r io.Reader) ([]Rule, error) {
+	var script []scriptRule
+
+	if err := yaml.NewDecoder(r).Decode(&script); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.WithStack(err)
+	}
+
+	rules := make([]Rule, 0, len(script))
+	for i, r := range script {
+		pattern, err := regexp.Compile(r.Match)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern of rule #%d", i+1)
+		}
+
+		rules = append(rules, Rule{
+			Pattern:  pattern,
+			Response: r.Response,
+		})
+	}
+
+	return rules, nil
+}
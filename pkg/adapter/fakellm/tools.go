@@ -0,0 +1,43 @@
+package fakellm
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+const toolCallID = "fake-call-1"
+
+// toolCall returns a call of the first offered tool taking a "query"
+// parameter, given the question, when no tool has been called yet. The
+// extractive answer is then given from the result of the call.
+func toolCall(opts *llm.ChatCompletionOptions, p prompt) (llm.ToolCall, error) {
+	if opts.ToolChoice == llm.ToolChoiceNone || len(opts.Tools) == 0 || p.question == "" {
+		return nil, nil
+	}
+
+	called := slices.ContainsFunc(opts.Messages, func(m llm.Message) bool {
+		return m.Role() == llm.RoleTool
+	})
+	if called {
+		return nil, nil
+	}
+
+	for _, t := range opts.Tools {
+		properties := toMap(t.Parameters()["properties"])
+		if _, exists := properties["query"]; !exists {
+			continue
+		}
+
+		params, err := json.Marshal(map[string]any{"query": p.question})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return llm.NewToolCall(toolCallID, t.Name(), string(params)), nil
+	}
+
+	return nil, nil
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/bornholm/corpus/pkg/model"
+	"gorm.io/gorm"
 )
 
 type Collection struct {
@@ -11,6 +12,8 @@ type Collection struct {
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is set when the collection is moved to the trash
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	Owner   *User
 	OwnerID string
@@ -18,6 +21,10 @@ type Collection struct {
 	Label       string
 	Description string
 
+	// VersionRetention is the number of previous versions kept for each
+	// document of the collection
+	VersionRetention int `gorm:"not null;default:10"`
+
 	Documents []*Document `gorm:"many2many:documents_collections;constraint:OnDelete:CASCADE"`
 
 	PublicShares []*PublicShare `gorm:"many2many:public_shares_collections;"`
@@ -59,7 +66,21 @@ func (w *wrappedCollection) Owner() model.User {
 	return &wrappedUser{w.c.Owner}
 }
 
-var _ model.PersistedCollection = &wrappedCollection{}
+// VersionRetention implements model.WithVersionRetention.
+func (w *wrappedCollection) VersionRetention() int {
+	return w.c.VersionRetention
+}
+
+// TrashedAt implements model.WithTrashedAt.
+func (w *wrappedCollection) TrashedAt() *time.Time {
+	return trashedAt(w.c.DeletedAt)
+}
+
+var (
+	_ model.PersistedCollection  = &wrappedCollection{}
+	_ model.WithVersionRetention = &wrappedCollection{}
+	_ model.WithTrashedAt        = &wrappedCollection{}
+)
 
 func fromCollection(c model.OwnedCollection) *Collection {
 	collection := &Collection{
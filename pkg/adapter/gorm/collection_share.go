@@ -16,9 +16,13 @@ type CollectionShare struct {
 	CollectionID string
 	Collection   *Collection
 
-	UserID string
+	// Either UserID or GroupID is set, depending on the target of the share
+	UserID *string
 	User   *User
 
+	GroupID *string
+	Group   *Group
+
 	Level string
 }
 
@@ -48,9 +52,22 @@ func (w *wrappedCollectionShare) CollectionID() model.CollectionID {
 
 // SharedWith implements [model.CollectionShare].
 func (w *wrappedCollectionShare) SharedWith() model.User {
+	if w.cs.User == nil {
+		return nil
+	}
+
 	return &wrappedUser{w.cs.User}
 }
 
+// SharedWithGroup implements [model.CollectionShare].
+func (w *wrappedCollectionShare) SharedWithGroup() model.Group {
+	if w.cs.Group == nil {
+		return nil
+	}
+
+	return &wrappedGroup{w.cs.Group}
+}
+
 // Level implements [model.CollectionShare].
 func (w *wrappedCollectionShare) Level() model.CollectionShareLevel {
 	return model.CollectionShareLevel(w.cs.Level)
@@ -0,0 +1,35 @@
+package gorm
+
+import "time"
+
+type Conversation struct {
+	ID string `gorm:"primaryKey;autoIncrement:false"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Title string
+
+	Owner   *User
+	OwnerID string `gorm:"index"`
+
+	CollectionIDs string `gorm:"column:collection_ids"`
+
+	Turns []*ConversationTurn `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+type ConversationTurn struct {
+	ID string `gorm:"primaryKey;autoIncrement:false"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Conversation   *Conversation
+	ConversationID string `gorm:"index"`
+
+	Question           string
+	StandaloneQuestion string
+	Answer             string
+
+	SectionIDs string `gorm:"column:section_ids"`
+}
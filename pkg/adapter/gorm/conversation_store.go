@@ -0,0 +1,338 @@
+package gorm
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type wrappedConversation struct {
+	c           *Conversation
+	collections []model.CollectionID
+}
+
+// CreatedAt implements [model.PersistedConversation].
+func (w *wrappedConversation) CreatedAt() time.Time {
+	return w.c.CreatedAt
+}
+
+// UpdatedAt implements [model.PersistedConversation].
+func (w *wrappedConversation) UpdatedAt() time.Time {
+	return w.c.UpdatedAt
+}
+
+// ID implements [model.Conversation].
+func (w *wrappedConversation) ID() model.ConversationID {
+	return model.ConversationID(w.c.ID)
+}
+
+// Title implements [model.Conversation].
+func (w *wrappedConversation) Title() string {
+	return w.c.Title
+}
+
+// Owner implements [model.OwnedConversation].
+func (w *wrappedConversation) Owner() model.User {
+	return &wrappedUser{w.c.Owner}
+}
+
+// Collections implements [model.Conversation].
+func (w *wrappedConversation) Collections() []model.CollectionID {
+	return w.collections
+}
+
+var _ model.PersistedConversation = &wrappedConversation{}
+
+func wrapConversation(c *Conversation) (*wrappedConversation, error) {
+	var collections []model.CollectionID
+	if c.CollectionIDs != "" {
+		if err := json.Unmarshal([]byte(c.CollectionIDs), &collections); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return &wrappedConversation{c: c, collections: collections}, nil
+}
+
+type wrappedConversationTurn struct {
+	t        *ConversationTurn
+	sections []model.SectionID
+}
+
+// CreatedAt implements [model.PersistedConversationTurn].
+func (w *wrappedConversationTurn) CreatedAt() time.Time {
+	return w.t.CreatedAt
+}
+
+// UpdatedAt implements [model.PersistedConversationTurn].
+func (w *wrappedConversationTurn) UpdatedAt() time.Time {
+	return w.t.UpdatedAt
+}
+
+// ID implements [model.ConversationTurn].
+func (w *wrappedConversationTurn) ID() model.ConversationTurnID {
+	return model.ConversationTurnID(w.t.ID)
+}
+
+// Question implements [model.ConversationTurn].
+func (w *wrappedConversationTurn) Question() string {
+	return w.t.Question
+}
+
+// StandaloneQuestion implements [model.ConversationTurn].
+func (w *wrappedConversationTurn) StandaloneQuestion() string {
+	return w.t.StandaloneQuestion
+}
+
+// Answer implements [model.ConversationTurn].
+func (w *wrappedConversationTurn) Answer() string {
+	return w.t.Answer
+}
+
+// Sections implements [model.ConversationTurn].
+func (w *wrappedConversationTurn) Sections() []model.SectionID {
+	return w.sections
+}
+
+var _ model.PersistedConversationTurn = &wrappedConversationTurn{}
+
+func wrapConversationTurn(t *ConversationTurn) (*wrappedConversationTurn, error) {
+	var sections []model.SectionID
+	if t.SectionIDs != "" {
+		if err := json.Unmarshal([]byte(t.SectionIDs), &sections); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return &wrappedConversationTurn{t: t, sections: sections}, nil
+}
+
+// SaveConversation implements [port.ConversationStore].
+func (s *Store) SaveConversation(ctx context.Context, conversation model.OwnedConversation) (model.PersistedConversation, error) {
+	collectionIDs, err := json.Marshal(conversation.Collections())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var saved Conversation
+
+	err = s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		c := &Conversation{
+			ID:            string(conversation.ID()),
+			Title:         conversation.Title(),
+			OwnerID:       string(conversation.Owner().ID()),
+			CollectionIDs: string(collectionIDs),
+		}
+
+		err := db.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"title", "collection_ids", "updated_at"}),
+		}).Create(c).Error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := db.Preload("Owner").First(&saved, "id = ?", c.ID).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	wrapped, err := wrapConversation(&saved)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return wrapped, nil
+}
+
+// GetConversationByID implements [port.ConversationStore].
+func (s *Store) GetConversationByID(ctx context.Context, id model.ConversationID) (model.PersistedConversation, error) {
+	var conversation Conversation
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		if err := db.Preload("Owner").First(&conversation, "id = ?", string(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.WithStack(port.ErrNotFound)
+			}
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	wrapped, err := wrapConversation(&conversation)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return wrapped, nil
+}
+
+// QueryUserConversations implements [port.ConversationStore].
+func (s *Store) QueryUserConversations(ctx context.Context, userID model.UserID, opts port.QueryConversationsOptions) ([]model.PersistedConversation, int64, error) {
+	var (
+		conversations []*Conversation
+		total         int64
+	)
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		query := db.Model(&Conversation{}).Where("owner_id = ?", string(userID))
+
+		if err := query.Count(&total).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Apply pagination
+		if opts.Page != nil {
+			limit := 10
+			if opts.Limit != nil {
+				limit = *opts.Limit
+			}
+			query = query.Offset(*opts.Page * limit)
+		}
+
+		if opts.Limit != nil {
+			query = query.Limit(*opts.Limit)
+		}
+
+		if err := query.Preload("Owner").Order("updated_at DESC").Find(&conversations).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	wrappedConversations := make([]model.PersistedConversation, 0, len(conversations))
+	for _, c := range conversations {
+		wrapped, err := wrapConversation(c)
+		if err != nil {
+			return nil, 0, errors.WithStack(err)
+		}
+
+		wrappedConversations = append(wrappedConversations, wrapped)
+	}
+
+	return wrappedConversations, total, nil
+}
+
+// DeleteConversation implements [port.ConversationStore].
+func (s *Store) DeleteConversation(ctx context.Context, id model.ConversationID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		var conversation Conversation
+		if err := db.First(&conversation, "id = ?", string(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.WithStack(port.ErrNotFound)
+			}
+			return errors.WithStack(err)
+		}
+
+		if err := db.Where("conversation_id = ?", conversation.ID).Delete(&ConversationTurn{}).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := db.Delete(&conversation).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// AppendConversationTurn implements [port.ConversationStore].
+func (s *Store) AppendConversationTurn(ctx context.Context, id model.ConversationID, turn model.ConversationTurn) (model.PersistedConversationTurn, error) {
+	sectionIDs, err := json.Marshal(turn.Sections())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	t := &ConversationTurn{
+		ID:                 string(turn.ID()),
+		ConversationID:     string(id),
+		Question:           turn.Question(),
+		StandaloneQuestion: turn.StandaloneQuestion(),
+		Answer:             turn.Answer(),
+		SectionIDs:         string(sectionIDs),
+	}
+
+	err = s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		var conversation Conversation
+		if err := db.First(&conversation, "id = ?", string(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.WithStack(port.ErrNotFound)
+			}
+			return errors.WithStack(err)
+		}
+
+		if err := db.Omit(clause.Associations).Create(t).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Move the conversation to the top of the most recent ones
+		if err := db.Model(&conversation).Update("updated_at", t.CreatedAt).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	wrapped, err := wrapConversationTurn(t)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return wrapped, nil
+}
+
+// GetConversationTurns implements [port.ConversationStore].
+func (s *Store) GetConversationTurns(ctx context.Context, id model.ConversationID) ([]model.PersistedConversationTurn, error) {
+	var turns []*ConversationTurn
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		if err := db.Where("conversation_id = ?", string(id)).Order("created_at ASC").Find(&turns).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	wrappedTurns := make([]model.PersistedConversationTurn, 0, len(turns))
+	for _, t := range turns {
+		wrapped, err := wrapConversationTurn(t)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		wrappedTurns = append(wrappedTurns, wrapped)
+	}
+
+	return wrappedTurns, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 type Document struct {
@@ -13,6 +14,8 @@ type Document struct {
 	ETag      string `gorm:"index"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is set when the document is moved to the trash
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	Owner   *User
 	OwnerID string
@@ -21,6 +24,15 @@ type Document struct {
 	Sections    []*Section    `gorm:"constraint:OnDelete:CASCADE"`
 	Collections []*Collection `gorm:"many2many:documents_collections;"`
 	Content     []byte
+	// Metadata holds the metadata key/values of the document, stored as a
+	// JSON object
+	Metadata map[string][]string `gorm:"serializer:json"`
+	// TaskID is the identifier of the task which produced the current
+	// version of the document
+	TaskID string
+	// IndexedChecksum is the checksum of the content the document was last
+	// successfully indexed with
+	IndexedChecksum string
 }
 
 type wrappedDocument struct {
@@ -89,6 +101,26 @@ func (w *wrappedDocument) Sections() []model.Section {
 	return sections
 }
 
+// Metadata implements model.WithMetadata.
+func (w *wrappedDocument) Metadata() map[string][]string {
+	return w.d.Metadata
+}
+
+// TaskID implements model.WithTaskID.
+func (w *wrappedDocument) TaskID() model.TaskID {
+	return model.TaskID(w.d.TaskID)
+}
+
+// IndexedChecksum implements model.WithIndexedChecksum.
+func (w *wrappedDocument) IndexedChecksum() string {
+	return w.d.IndexedChecksum
+}
+
+// TrashedAt implements model.WithTrashedAt.
+func (w *wrappedDocument) TrashedAt() *time.Time {
+	return trashedAt(w.d.DeletedAt)
+}
+
 // Source implements model.Document.
 func (w *wrappedDocument) Source() *url.URL {
 	url, err := url.Parse(w.d.Source)
@@ -99,7 +131,13 @@ func (w *wrappedDocument) Source() *url.URL {
 	return url
 }
 
-var _ model.PersistedDocument = &wrappedDocument{}
+var (
+	_ model.PersistedDocument = &wrappedDocument{}
+	_ model.WithMetadata      = &wrappedDocument{}
+	_ model.WithTaskID          = &wrappedDocument{}
+	_ model.WithIndexedChecksum = &wrappedDocument{}
+	_ model.WithTrashedAt       = &wrappedDocument{}
+)
 
 func fromDocument(d model.OwnedDocument) (*Document, error) {
 	content, err := d.Content()
@@ -115,6 +153,8 @@ func fromDocument(d model.OwnedDocument) (*Document, error) {
 		Collections: make([]*Collection, 0, len(d.Collections())),
 		Sections:    make([]*Section, 0, len(d.Sections())),
 		Content:     content,
+		Metadata:    model.DocumentMetadata(d),
+		TaskID:      string(model.DocumentTaskID(d)),
 	}
 
 	for _, s := range d.Sections() {
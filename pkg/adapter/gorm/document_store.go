@@ -1,8 +1,10 @@
 package gorm
 
 import (
+	"bytes"
 	"context"
 	"log/slog"
+	"maps"
 	"net/url"
 	"slices"
 	"strings"
@@ -59,7 +61,11 @@ func (s *Store) DeleteDocumentByID(ctx context.Context, ids ...model.DocumentID)
 			return errors.WithStack(err)
 		}
 
-		if err := db.Model(&Document{}).Delete("id in ?", ids).Error; err != nil {
+		if err := db.Where("document_id IN ?", ids).Delete(&DocumentVersion{}).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := db.Unscoped().Delete(&Document{}, "id in ?", ids).Error; err != nil {
 			return errors.WithStack(err)
 		}
 
@@ -241,6 +247,10 @@ func (s *Store) UpdateCollection(ctx context.Context, id model.CollectionID, upd
 			updateFields["description"] = *updates.Description
 		}
 
+		if updates.VersionRetention != nil {
+			updateFields["version_retention"] = max(*updates.VersionRetention, 0)
+		}
+
 		// Only perform update if there are fields to update
 		if len(updateFields) > 0 {
 			if err := db.Model(&collection).Updates(updateFields).Error; err != nil {
@@ -248,6 +258,20 @@ func (s *Store) UpdateCollection(ctx context.Context, id model.CollectionID, upd
 			}
 		}
 
+		// Apply the new retention to the versions of the documents
+		if updates.VersionRetention != nil {
+			var documentIDs []string
+			if err := db.Table("documents_collections").Where("collection_id = ?", string(id)).Pluck("document_id", &documentIDs).Error; err != nil {
+				return errors.WithStack(err)
+			}
+
+			for _, documentID := range documentIDs {
+				if err := pruneDocumentVersions(db, documentID); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+
 		// Reload the collection to get the updated values
 		if err := db.Preload(clause.Associations).First(&collection, "id = ?", id).Error; err != nil {
 			return errors.WithStack(err)
@@ -277,7 +301,7 @@ func (s *Store) DeleteCollection(ctx context.Context, id model.CollectionID) err
 			return errors.WithStack(err)
 		}
 
-		if err := db.Model(&Collection{}).Delete("id = ?", id).Error; err != nil {
+		if err := db.Unscoped().Delete(&Collection{}, "id = ?", string(id)).Error; err != nil {
 			return errors.WithStack(err)
 		}
 
@@ -354,8 +378,8 @@ func (s *Store) CountReadableDocuments(ctx context.Context, userID model.UserID)
 
 	// Count documents the user owns OR documents in collections shared with the user
 	if err := db.Model(&Document{}).Where(
-		"owner_id = ? OR id IN (SELECT document_id FROM documents_collections WHERE collection_id IN (SELECT collection_id FROM collection_shares WHERE user_id = ?))",
-		string(userID), string(userID),
+		"owner_id = ? OR id IN (SELECT document_id FROM documents_collections WHERE collection_id IN (?))",
+		string(userID), sharedCollectionIDs(db, userID),
 	).Count(&total).Error; err != nil {
 		return 0, errors.WithStack(err)
 	}
@@ -368,7 +392,7 @@ func (s *Store) GetSectionByID(ctx context.Context, id model.SectionID) (model.S
 	var section Section
 
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
-		if err := db.Model(&section).Preload(clause.Associations).First(&section, "id = ?", id).Error; err != nil {
+		if err := db.Model(&section).Preload(clause.Associations).Scopes(withoutTrashedDocuments).First(&section, "id = ?", id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return errors.WithStack(port.ErrNotFound)
 			}
@@ -399,7 +423,7 @@ func (s *Store) GetSectionsByIDs(ctx context.Context, ids []model.SectionID) (ma
 	}
 
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
-		if err := db.Preload(clause.Associations).Find(&sections, "id in ?", rawIDs).Error; err != nil {
+		if err := db.Preload(clause.Associations).Scopes(withoutTrashedDocuments).Find(&sections, "id in ?", rawIDs).Error; err != nil {
 			return errors.WithStack(err)
 		}
 
@@ -417,6 +441,19 @@ func (s *Store) GetSectionsByIDs(ctx context.Context, ids []model.SectionID) (ma
 	return result, nil
 }
 
+// withoutTrashedDocuments excludes the sections of the documents in the trash
+func withoutTrashedDocuments(db *gorm.DB) *gorm.DB {
+	return db.Where("document_id IN (SELECT id FROM documents WHERE deleted_at IS NULL)")
+}
+
+// withoutTrashedCollectionsDocuments excludes the documents whose collections
+// are all in the trash
+func withoutTrashedCollectionsDocuments(db *gorm.DB) *gorm.DB {
+	return db.Where(
+		"(id NOT IN (SELECT document_id FROM documents_collections) OR id IN (SELECT dc.document_id FROM documents_collections dc JOIN collections c ON c.id = dc.collection_id WHERE c.deleted_at IS NULL))",
+	)
+}
+
 // DeleteDocumentBySource implements port.DocumentStore.
 func (s *Store) DeleteDocumentBySource(ctx context.Context, ownerID model.UserID, source *url.URL) error {
 	if source == nil {
@@ -425,7 +462,7 @@ func (s *Store) DeleteDocumentBySource(ctx context.Context, ownerID model.UserID
 
 	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
 		var doc Document
-		if err := db.First(&doc, "source = ? and owner_id = ?", source.String(), ownerID).Error; err != nil {
+		if err := db.Unscoped().First(&doc, "source = ? and owner_id = ?", source.String(), ownerID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil
 			}
@@ -433,7 +470,11 @@ func (s *Store) DeleteDocumentBySource(ctx context.Context, ownerID model.UserID
 			return errors.WithStack(err)
 		}
 
-		if err := db.Select(clause.Associations).Delete(&doc).Error; err != nil {
+		if err := db.Delete(&DocumentVersion{}, "document_id = ?", doc.ID).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := db.Unscoped().Select(clause.Associations).Delete(&doc).Error; err != nil {
 			return errors.WithStack(err)
 		}
 
@@ -464,16 +505,20 @@ func (s *Store) QueryDocuments(ctx context.Context, opts port.QueryDocumentsOpti
 			page = *opts.Page
 		}
 
-		if err := db.Model(&Document{}).Count(&total).Error; err != nil {
+		if err := db.Model(&Document{}).Scopes(withoutTrashedCollectionsDocuments).Count(&total).Error; err != nil {
 			return errors.WithStack(err)
 		}
 
-		query := db.Limit(limit).Offset(page * limit)
+		query := db.Limit(limit).Offset(page * limit).Scopes(withoutTrashedCollectionsDocuments)
+
+		if opts.IDs != nil {
+			query = query.Where("id IN ?", opts.IDs)
+		}
 
 		if !opts.HeaderOnly {
 			query = query.Preload(clause.Associations).Preload("Sections")
 		} else {
-			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag")
+			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag", "Metadata")
 		}
 
 		if opts.MatchingSource != nil {
@@ -490,6 +535,8 @@ func (s *Store) QueryDocuments(ctx context.Context, opts port.QueryDocumentsOpti
 			}
 		}
 
+		query = whereMetadata(query, opts.Metadata)
+
 		if err := query.Find(&documents).Error; err != nil {
 			return errors.WithStack(err)
 		}
@@ -508,6 +555,19 @@ func (s *Store) QueryDocuments(ctx context.Context, opts port.QueryDocumentsOpti
 	return wrappedDocuments, total, nil
 }
 
+// whereMetadata restricts the query to the documents having all the given
+// metadata key/values
+func whereMetadata(query *gorm.DB, metadata map[string]string) *gorm.DB {
+	for key, value := range metadata {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM json_each(documents.metadata) m, json_each(m.value) mv WHERE m.key = ? AND mv.value = ?)",
+			key, value,
+		)
+	}
+
+	return query
+}
+
 // QueryUserReadableDocuments implements port.DocumentStore.
 func (s *Store) QueryUserReadableDocuments(ctx context.Context, userID model.UserID, opts port.QueryDocumentsOptions) ([]model.PersistedDocument, int64, error) {
 	var (
@@ -516,7 +576,7 @@ func (s *Store) QueryUserReadableDocuments(ctx context.Context, userID model.Use
 	)
 
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
-		query := db.Model(&Document{})
+		query := db.Model(&Document{}).Scopes(withoutTrashedCollectionsDocuments)
 
 		query = query.Where("owner_id = ?", userID)
 
@@ -534,6 +594,8 @@ func (s *Store) QueryUserReadableDocuments(ctx context.Context, userID model.Use
 			}
 		}
 
+		query = whereMetadata(query, opts.Metadata)
+
 		if err := query.Count(&total).Error; err != nil {
 			return errors.WithStack(err)
 		}
@@ -553,7 +615,7 @@ func (s *Store) QueryUserReadableDocuments(ctx context.Context, userID model.Use
 		if !opts.HeaderOnly {
 			query = query.Preload(clause.Associations).Preload("Sections")
 		} else {
-			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag")
+			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag", "Metadata")
 		}
 
 		if err := query.Find(&documents).Error; err != nil {
@@ -582,7 +644,7 @@ func (s *Store) QueryUserWritableDocuments(ctx context.Context, userID model.Use
 	)
 
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
-		query := db.Model(&Document{})
+		query := db.Model(&Document{}).Scopes(withoutTrashedCollectionsDocuments)
 
 		query = query.Where("owner_id = ?", userID)
 
@@ -600,6 +662,8 @@ func (s *Store) QueryUserWritableDocuments(ctx context.Context, userID model.Use
 			}
 		}
 
+		query = whereMetadata(query, opts.Metadata)
+
 		if err := query.Count(&total).Error; err != nil {
 			return errors.WithStack(err)
 		}
@@ -619,7 +683,7 @@ func (s *Store) QueryUserWritableDocuments(ctx context.Context, userID model.Use
 		if !opts.HeaderOnly {
 			query = query.Preload(clause.Associations).Preload("Sections")
 		} else {
-			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag")
+			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag", "Metadata")
 		}
 
 		if err := query.Find(&documents).Error; err != nil {
@@ -640,6 +704,28 @@ func (s *Store) QueryUserWritableDocuments(ctx context.Context, userID model.Use
 	return wrappedDocuments, total, nil
 }
 
+// MarkDocumentIndexed implements port.DocumentStore.
+func (s *Store) MarkDocumentIndexed(ctx context.Context, id model.DocumentID, checksum string) error {
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		// The update time of the document is left untouched
+		result := db.Model(&Document{}).Where("id = ?", string(id)).UpdateColumn("indexed_checksum", checksum)
+		if result.Error != nil {
+			return errors.WithStack(result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
 // SaveDocuments implements port.DocumentStore.
 func (s *Store) SaveDocuments(ctx context.Context, documents ...model.OwnedDocument) error {
 	for _, doc := range documents {
@@ -650,12 +736,13 @@ func (s *Store) SaveDocuments(ctx context.Context, documents ...model.OwnedDocum
 			}
 
 			var existing Document
-			if err := db.First(&existing, "source = ?", source.String()).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := db.Unscoped().First(&existing, "source = ?", source.String()).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 				return errors.WithStack(err)
 			}
 
-			if existing.ID != "" {
-				if err := db.Delete(&existing).Error; err != nil {
+			// Saving a document from the trash restores it
+			if existing.DeletedAt.Valid {
+				if err := db.Unscoped().Model(&existing).Update("deleted_at", nil).Error; err != nil {
 					return errors.WithStack(err)
 				}
 			}
@@ -665,44 +752,16 @@ func (s *Store) SaveDocuments(ctx context.Context, documents ...model.OwnedDocum
 				return errors.WithStack(err)
 			}
 
-			if res := db.Omit("Sections", "Owner.Roles", "Owner.Preferences").Create(document); res.Error != nil {
-				return errors.WithStack(res.Error)
+			if existing.ID != "" {
+				return errors.WithStack(updateDocument(db, &existing, document))
 			}
 
-			var createSection func(s *Section) error
-			createSection = func(s *Section) error {
-				err := db.
-					Clauses(clause.OnConflict{
-						Columns:   []clause.Column{{Name: "id"}},
-						UpdateAll: true,
-					}).
-					Omit("Sections", "Parent", "Document").Create(s).Error
-				if err != nil {
-					return errors.WithStack(err)
-				}
-
-				for _, ss := range s.Sections {
-					if err := createSection(ss); err != nil {
-						return errors.WithStack(err)
-					}
-
-					err := db.Model(&Section{}).
-						Where("id = ?", ss.ID).
-						Updates(map[string]any{
-							"parent_id":   s.ID,
-							"document_id": s.DocumentID,
-						}).
-						Error
-					if err != nil {
-						return errors.WithStack(err)
-					}
-				}
-
-				return nil
+			if res := db.Omit("Sections", "Owner.Roles", "Owner.Preferences").Create(document); res.Error != nil {
+				return errors.WithStack(res.Error)
 			}
 
 			for _, s := range document.Sections {
-				if err := createSection(s); err != nil {
+				if err := saveSection(db, s, nil, nil); err != nil {
 					return errors.WithStack(err)
 				}
 			}
@@ -717,6 +776,114 @@ func (s *Store) SaveDocuments(ctx context.Context, documents ...model.OwnedDocum
 	return nil
 }
 
+// updateDocument updates the existing document in place: its identity is
+// kept and only its added or modified sections are written, the sections
+// absent from the new version being deleted. The replaced version is archived
+// if the content, the ETag or the metadata of the document changed.
+func updateDocument(db *gorm.DB, existing *Document, document *Document) error {
+	document.ID = existing.ID
+	document.CreatedAt = existing.CreatedAt
+
+	changed := existing.ETag != document.ETag ||
+		!bytes.Equal(existing.Content, document.Content) ||
+		!maps.EqualFunc(existing.Metadata, document.Metadata, slices.Equal)
+
+	if changed {
+		if err := db.Create(versionOf(existing)).Error; err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		// The version is still the one produced by the previous task
+		document.TaskID = existing.TaskID
+	}
+
+	err := db.Model(existing).Select("ETag", "OwnerID", "Content", "Metadata", "TaskID").Updates(document).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := db.Model(existing).Association("Collections").Clear(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(document.Collections) > 0 {
+		if err := db.Model(existing).Omit("Collections.*").Association("Collections").Append(document.Collections); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	var sections []*Section
+	if err := db.Where("document_id = ?", existing.ID).Find(&sections).Error; err != nil {
+		return errors.WithStack(err)
+	}
+
+	previous := make(map[string]*Section, len(sections))
+	for _, s := range sections {
+		previous[s.ID] = s
+	}
+
+	kept := make(map[string]struct{}, len(sections))
+	model.WalkSections(&wrappedDocument{document}, func(s model.Section) error {
+		kept[string(s.ID())] = struct{}{}
+		return nil
+	})
+
+	for _, s := range document.Sections {
+		s.DocumentID = document.ID
+		if err := saveSection(db, s, nil, previous); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	removed := make([]string, 0)
+	for id := range previous {
+		if _, exists := kept[id]; !exists {
+			removed = append(removed, id)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := db.Delete(&Section{}, "id IN ?", removed).Error; err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := pruneDocumentVersions(db, existing.ID); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// saveSection writes the section and its subsections, skipping the ones
+// identical to their previous version.
+func saveSection(db *gorm.DB, s *Section, parent *Section, previous map[string]*Section) error {
+	if parent != nil {
+		s.ParentID = &parent.ID
+		s.DocumentID = parent.DocumentID
+	}
+
+	if p, exists := previous[s.ID]; !exists || !p.sameAs(s) {
+		err := db.
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				UpdateAll: true,
+			}).
+			Omit("Sections", "Parent", "Document").Create(s).Error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	for _, ss := range s.Sections {
+		if err := saveSection(db, ss, s, previous); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
 // QueryUserWritableCollections implements [port.DocumentStore].
 func (s *Store) QueryUserWritableCollections(ctx context.Context, userID model.UserID, opts port.QueryCollectionsOptions) ([]model.PersistedCollection, int64, error) {
 	var (
@@ -725,10 +892,10 @@ func (s *Store) QueryUserWritableCollections(ctx context.Context, userID model.U
 	)
 
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
-		// User can write to collections they own OR collections shared with them at write level
+		// User can write to collections they own OR collections shared with them (or their groups) at write level
 		query := db.Model(&Collection{}).Where(
-			"owner_id = ? OR id IN (SELECT collection_id FROM collection_shares WHERE user_id = ? AND level = ?)",
-			string(userID), string(userID), string(model.CollectionShareLevelWrite),
+			"owner_id = ? OR id IN (?)",
+			string(userID), sharedCollectionIDs(db, userID, model.CollectionShareLevelWrite),
 		)
 
 		// Apply ID filtering if specified
@@ -788,10 +955,10 @@ func (s *Store) QueryUserReadableCollections(ctx context.Context, userID model.U
 	)
 
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
-		// Users can read collections they own OR collections shared with them at any level
+		// Users can read collections they own OR collections shared with them (or their groups) at any level
 		query := db.Model(&Collection{}).Where(
-			"owner_id = ? OR id IN (SELECT collection_id FROM collection_shares WHERE user_id = ?)",
-			string(userID), string(userID),
+			"owner_id = ? OR id IN (?)",
+			string(userID), sharedCollectionIDs(db, userID),
 		)
 
 		// Apply ID filtering if specified
@@ -852,11 +1019,11 @@ func (s *Store) CanReadCollection(ctx context.Context, userID model.UserID, coll
 	var canRead bool
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
 		var count int64
-		// User can read if they own the collection OR have any share level
+		// User can read if they own the collection OR have any share level, directly or through a group
 		err := db.Model(&Collection{}).Where(
-			"(id = ? AND owner_id = ?) OR (id = ? AND id IN (SELECT collection_id FROM collection_shares WHERE user_id = ?))",
-			string(collectionID), string(userID),
+			"(id = ? AND owner_id = ?) OR (id = ? AND id IN (?))",
 			string(collectionID), string(userID),
+			string(collectionID), sharedCollectionIDs(db, userID),
 		).Count(&count).Error
 		if err != nil {
 			return errors.WithStack(err)
@@ -918,11 +1085,11 @@ func (s *Store) CanWriteCollection(ctx context.Context, userID model.UserID, col
 	var canWrite bool
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
 		var count int64
-		// User can write if they own the collection OR have a write-level share
+		// User can write if they own the collection OR have a write-level share, directly or through a group
 		err := db.Model(&Collection{}).Where(
-			"(id = ? AND owner_id = ?) OR (id = ? AND id IN (SELECT collection_id FROM collection_shares WHERE user_id = ? AND level = ?))",
+			"(id = ? AND owner_id = ?) OR (id = ? AND id IN (?))",
 			string(collectionID), string(userID),
-			string(collectionID), string(userID), string(model.CollectionShareLevelWrite),
+			string(collectionID), sharedCollectionIDs(db, userID, model.CollectionShareLevelWrite),
 		).Count(&count).Error
 		if err != nil {
 			return errors.WithStack(err)
@@ -968,10 +1135,11 @@ func (s *Store) CreateCollectionShare(ctx context.Context, collectionID model.Co
 			share = existing
 		} else {
 			// Create new share
+			rawUserID := string(userID)
 			newShare := CollectionShare{
 				ID:           string(model.NewCollectionShareID()),
 				CollectionID: string(collectionID),
-				UserID:       string(userID),
+				UserID:       &rawUserID,
 				Level:        string(level),
 			}
 			if err := db.Create(&newShare).Error; err != nil {
@@ -994,6 +1162,64 @@ func (s *Store) CreateCollectionShare(ctx context.Context, collectionID model.Co
 	return &wrappedCollectionShare{&share}, nil
 }
 
+// CreateCollectionGroupShare implements [port.DocumentStore].
+func (s *Store) CreateCollectionGroupShare(ctx context.Context, collectionID model.CollectionID, groupID model.GroupID, level model.CollectionShareLevel) (model.PersistedCollectionShare, error) {
+	var share CollectionShare
+
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		var count int64
+		if err := db.Model(&Group{}).Where("id = ?", string(groupID)).Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if count == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		// Check if a share already exists for this collection+group pair
+		var existing CollectionShare
+		err := db.Where("collection_id = ? AND group_id = ?", string(collectionID), string(groupID)).
+			First(&existing).Error
+
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.WithStack(err)
+		}
+
+		if existing.ID != "" {
+			// Update existing share level
+			if err := db.Model(&existing).Update("level", string(level)).Error; err != nil {
+				return errors.WithStack(err)
+			}
+			share = existing
+		} else {
+			// Create new share
+			rawGroupID := string(groupID)
+			newShare := CollectionShare{
+				ID:           string(model.NewCollectionShareID()),
+				CollectionID: string(collectionID),
+				GroupID:      &rawGroupID,
+				Level:        string(level),
+			}
+			if err := db.Create(&newShare).Error; err != nil {
+				return errors.WithStack(err)
+			}
+			share = newShare
+		}
+
+		// Reload with group association
+		if err := db.Preload("Group").First(&share, "id = ?", share.ID).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &wrappedCollectionShare{&share}, nil
+}
+
 // DeleteCollectionShare implements [port.DocumentStore].
 func (s *Store) DeleteCollectionShare(ctx context.Context, shareID model.CollectionShareID) error {
 	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
@@ -1017,7 +1243,7 @@ func (s *Store) GetCollectionShares(ctx context.Context, collectionID model.Coll
 	var shares []*CollectionShare
 
 	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
-		if err := db.Preload("User").Where("collection_id = ?", string(collectionID)).Find(&shares).Error; err != nil {
+		if err := db.Preload("User").Preload("Group").Where("collection_id = ?", string(collectionID)).Find(&shares).Error; err != nil {
 			return errors.WithStack(err)
 		}
 		return nil
@@ -1092,6 +1318,8 @@ func (s *Store) QueryDocumentsByCollectionID(ctx context.Context, collectionID m
 			query = query.Where("source LIKE ?", "%"+*opts.SourcePattern+"%")
 		}
 
+		query = whereMetadata(query, opts.Metadata)
+
 		if err := query.Count(&total).Error; err != nil {
 			return errors.WithStack(err)
 		}
@@ -1111,7 +1339,7 @@ func (s *Store) QueryDocumentsByCollectionID(ctx context.Context, collectionID m
 		if !opts.HeaderOnly {
 			query = query.Preload(clause.Associations).Preload("Sections")
 		} else {
-			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag")
+			query = query.Omit(clause.Associations).Select("ID", "CreatedAt", "UpdatedAt", "Source", "ETag", "Metadata")
 		}
 
 		if err := query.Find(&documents).Error; err != nil {
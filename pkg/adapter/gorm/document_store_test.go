@@ -0,0 +1,202 @@
+package gorm
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestDeleteDocumentByIDVersions(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner, err := store.FindOrCreateUser(ctx, "test", "owner")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/fox")
+
+	// Saving a modified document archives its previous version
+	for _, content := range []string{"The quick brown fox.", "The quick brown fox jumps over the lazy dog."} {
+		doc, err := markdown.Parse([]byte("# Fox\n\n" + content))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		doc.SetSource(source)
+
+		if err := store.SaveDocuments(ctx, model.AsOwnedDocument(doc, owner)); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	documents, _, err := store.QueryDocuments(ctx, port.QueryDocumentsOptions{HeaderOnly: true})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(documents); e != g {
+		t.Fatalf("len(documents): expected %d, got %d", e, g)
+	}
+
+	documentID := documents[0].ID()
+
+	_, total, err := store.QueryDocumentVersions(ctx, documentID, port.QueryDocumentVersionsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(1), total; e != g {
+		t.Fatalf("total versions: expected %d, got %d", e, g)
+	}
+
+	// Versions must be deleted explicitly, without relying on the cascade
+	db, err := store.getDatabase(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := db.Exec("PRAGMA foreign_keys=off").Error; err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.DeleteDocumentByID(ctx, documentID); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	_, total, err = store.QueryDocumentVersions(ctx, documentID, port.QueryDocumentVersionsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(0), total; e != g {
+		t.Errorf("total versions: expected %d, got %d", e, g)
+	}
+}
+
+func TestMarkDocumentIndexed(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner, err := store.FindOrCreateUser(ctx, "test", "owner")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/fox")
+
+	saveDocument := func(content string) model.PersistedDocument {
+		doc, err := markdown.Parse([]byte("# Fox\n\n" + content))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		doc.SetSource(source)
+
+		if err := store.SaveDocuments(ctx, model.AsOwnedDocument(doc, owner)); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		documents, _, err := store.QueryDocuments(ctx, port.QueryDocumentsOptions{MatchingSource: source})
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		if e, g := 1, len(documents); e != g {
+			t.Fatalf("len(documents): expected %d, got %d", e, g)
+		}
+
+		return documents[0]
+	}
+
+	document := saveDocument("The quick brown fox.")
+
+	if e, g := "", model.DocumentIndexedChecksum(document); e != g {
+		t.Errorf("model.DocumentIndexedChecksum(document): expected %q, got %q", e, g)
+	}
+
+	checksum, err := model.DocumentChecksum(document)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.MarkDocumentIndexed(ctx, document.ID(), checksum); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	document, err = store.GetDocumentByID(ctx, document.ID())
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := checksum, model.DocumentIndexedChecksum(document); e != g {
+		t.Errorf("model.DocumentIndexedChecksum(document): expected %q, got %q", e, g)
+	}
+
+	// A modified document no longer matches the indexed checksum until it is
+	// indexed again
+	document = saveDocument("The quick brown fox jumps over the lazy dog.")
+
+	newChecksum, err := model.DocumentChecksum(document)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if newChecksum == model.DocumentIndexedChecksum(document) {
+		t.Errorf("model.DocumentIndexedChecksum(document): expected a checksum different from %q", newChecksum)
+	}
+
+	if err := store.MarkDocumentIndexed(ctx, "unknown", checksum); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("store.MarkDocumentIndexed(unknown): expected port.ErrNotFound, got %v", err)
+	}
+}
+
+// saveTestDocument saves a document with the given content in the given
+// collections and returns its persisted version
+func saveTestDocument(t *testing.T, store *Store, owner model.User, rawURL string, content string, collections ...model.CollectionID) model.PersistedDocument {
+	ctx := context.Background()
+
+	source, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	doc, err := markdown.Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	doc.SetSource(source)
+
+	for _, id := range collections {
+		collection, err := store.GetCollectionByID(ctx, id, false)
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		doc.AddCollection(collection)
+	}
+
+	if err := store.SaveDocuments(ctx, model.AsOwnedDocument(doc, owner)); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	documents, _, err := store.QueryDocuments(ctx, port.QueryDocumentsOptions{MatchingSource: source})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(documents); e != g {
+		t.Fatalf("len(documents): expected %d, got %d", e, g)
+	}
+
+	return documents[0]
+}
@@ -0,0 +1,77 @@
+package gorm
+
+import (
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+type DocumentVersion struct {
+	ID string `gorm:"primaryKey;autoIncrement:false"`
+
+	// CreatedAt is the time the version was produced, i.e. the last update
+	// of the document before it was replaced
+	CreatedAt time.Time `gorm:"index"`
+
+	Document   *Document `gorm:"constraint:OnDelete:CASCADE;"`
+	DocumentID string    `gorm:"index"`
+
+	ETag     string
+	Content  []byte
+	Metadata map[string][]string `gorm:"serializer:json"`
+	TaskID   string
+}
+
+type wrappedDocumentVersion struct {
+	v *DocumentVersion
+}
+
+// ID implements model.DocumentVersion.
+func (w *wrappedDocumentVersion) ID() model.DocumentVersionID {
+	return model.DocumentVersionID(w.v.ID)
+}
+
+// DocumentID implements model.DocumentVersion.
+func (w *wrappedDocumentVersion) DocumentID() model.DocumentID {
+	return model.DocumentID(w.v.DocumentID)
+}
+
+// ETag implements model.DocumentVersion.
+func (w *wrappedDocumentVersion) ETag() string {
+	return w.v.ETag
+}
+
+// Metadata implements model.DocumentVersion.
+func (w *wrappedDocumentVersion) Metadata() map[string][]string {
+	return w.v.Metadata
+}
+
+// Content implements model.DocumentVersion.
+func (w *wrappedDocumentVersion) Content() ([]byte, error) {
+	return w.v.Content, nil
+}
+
+// TaskID implements model.DocumentVersion.
+func (w *wrappedDocumentVersion) TaskID() model.TaskID {
+	return model.TaskID(w.v.TaskID)
+}
+
+// CreatedAt implements model.DocumentVersion.
+func (w *wrappedDocumentVersion) CreatedAt() time.Time {
+	return w.v.CreatedAt
+}
+
+var _ model.DocumentVersion = &wrappedDocumentVersion{}
+
+// versionOf returns the version archiving the current state of the document
+func versionOf(d *Document) *DocumentVersion {
+	return &DocumentVersion{
+		ID:         string(model.NewDocumentVersionID()),
+		CreatedAt:  d.UpdatedAt,
+		DocumentID: d.ID,
+		ETag:       d.ETag,
+		Content:    d.Content,
+		Metadata:   d.Metadata,
+		TaskID:     d.TaskID,
+	}
+}
@@ -0,0 +1,118 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// QueryDocumentVersions implements [port.DocumentStore].
+func (s *Store) QueryDocumentVersions(ctx context.Context, documentID model.DocumentID, opts port.QueryDocumentVersionsOptions) ([]model.DocumentVersion, int64, error) {
+	var (
+		versions []*DocumentVersion
+		total    int64
+	)
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		query := db.Model(&DocumentVersion{}).Where("document_id = ?", string(documentID))
+
+		if err := query.Count(&total).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Apply pagination
+		if opts.Page != nil {
+			limit := 10
+			if opts.Limit != nil {
+				limit = *opts.Limit
+			}
+			query = query.Offset(*opts.Page * limit)
+		}
+
+		if opts.Limit != nil {
+			query = query.Limit(*opts.Limit)
+		}
+
+		if err := query.Omit("Content").Order("created_at DESC").Find(&versions).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	wrappedVersions := make([]model.DocumentVersion, 0, len(versions))
+	for _, v := range versions {
+		wrappedVersions = append(wrappedVersions, &wrappedDocumentVersion{v})
+	}
+
+	return wrappedVersions, total, nil
+}
+
+// GetDocumentVersionByID implements [port.DocumentStore].
+func (s *Store) GetDocumentVersionByID(ctx context.Context, id model.DocumentVersionID) (model.DocumentVersion, error) {
+	var version DocumentVersion
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		if err := db.First(&version, "id = ?", string(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.WithStack(port.ErrNotFound)
+			}
+
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &wrappedDocumentVersion{&version}, nil
+}
+
+// pruneDocumentVersions deletes the oldest versions of the document exceeding
+// the largest retention of its collections, the default one if it has none.
+func pruneDocumentVersions(db *gorm.DB, documentID string) error {
+	var retention sql.NullInt64
+
+	err := db.Raw(
+		"SELECT MAX(version_retention) FROM collections WHERE id IN (SELECT collection_id FROM documents_collections WHERE document_id = ?)",
+		documentID,
+	).Scan(&retention).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	keep := model.DefaultVersionRetention
+	if retention.Valid {
+		keep = int(retention.Int64)
+	}
+
+	var expired []string
+	err = db.Model(&DocumentVersion{}).
+		Where("document_id = ?", documentID).
+		Order("created_at DESC").
+		Offset(keep).Limit(-1).
+		Pluck("id", &expired).Error
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(expired) == 0 {
+		return nil
+	}
+
+	if err := db.Delete(&DocumentVersion{}, "id IN ?", expired).Error; err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
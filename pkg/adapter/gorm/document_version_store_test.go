@@ -0,0 +1,170 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestPruneDocumentVersions(t *testing.T) {
+	type testCase struct {
+		Name string
+		// Retentions of the collections of the document
+		Retentions []int
+		Versions   int
+		Expected   int
+	}
+
+	testCases := []testCase{
+		{
+			Name:       "Default retention without collection",
+			Retentions: nil,
+			Versions:   model.DefaultVersionRetention + 3,
+			Expected:   model.DefaultVersionRetention,
+		},
+		{
+			Name:       "Default retention of a collection",
+			Retentions: []int{-1},
+			Versions:   model.DefaultVersionRetention + 3,
+			Expected:   model.DefaultVersionRetention,
+		},
+		{
+			Name:       "Retention of the collection",
+			Retentions: []int{3},
+			Versions:   5,
+			Expected:   3,
+		},
+		{
+			Name:       "Largest retention of the collections",
+			Retentions: []int{2, 4, 1},
+			Versions:   6,
+			Expected:   4,
+		},
+		{
+			Name:       "Versions within the retention",
+			Retentions: []int{5},
+			Versions:   3,
+			Expected:   3,
+		},
+		{
+			Name:       "No retention",
+			Retentions: []int{0},
+			Versions:   3,
+			Expected:   0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := newTestStore(t)
+
+			owner := createTestUser(t, store, "owner")
+
+			collections := make([]model.CollectionID, 0, len(tc.Retentions))
+			for i, retention := range tc.Retentions {
+				collection, err := store.CreateCollection(ctx, owner.ID(), fmt.Sprintf("Collection %d", i))
+				if err != nil {
+					t.Fatalf("%+v", errors.WithStack(err))
+				}
+
+				// A negative retention keeps the default one
+				if retention >= 0 {
+					if _, err := store.UpdateCollection(ctx, collection.ID(), port.CollectionUpdates{VersionRetention: &retention}); err != nil {
+						t.Fatalf("%+v", errors.WithStack(err))
+					}
+				}
+
+				collections = append(collections, collection.ID())
+			}
+
+			document := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.", collections...)
+
+			db, err := store.getDatabase(ctx)
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			// The versions are created from the oldest to the most recent
+			createdAt := time.Now().Add(-time.Duration(tc.Versions) * time.Hour)
+
+			versions := make([]string, 0, tc.Versions)
+			for i := range tc.Versions {
+				version := &DocumentVersion{
+					ID:         fmt.Sprintf("version-%02d", i),
+					CreatedAt:  createdAt.Add(time.Duration(i) * time.Hour),
+					DocumentID: string(document.ID()),
+					Content:    []byte(fmt.Sprintf("# Fox\n\nVersion %d", i)),
+				}
+
+				if err := db.Create(version).Error; err != nil {
+					t.Fatalf("%+v", errors.WithStack(err))
+				}
+
+				versions = append(versions, version.ID)
+			}
+
+			if err := pruneDocumentVersions(db, string(document.ID())); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			remaining, total, err := store.QueryDocumentVersions(ctx, document.ID(), port.QueryDocumentVersionsOptions{})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if e, g := int64(tc.Expected), total; e != g {
+				t.Fatalf("total: expected %d, got %d", e, g)
+			}
+
+			// Only the most recent versions are kept
+			expected := slices.Clone(versions[len(versions)-tc.Expected:])
+			slices.Reverse(expected)
+
+			for i, v := range remaining {
+				if e, g := expected[i], string(v.ID()); e != g {
+					t.Errorf("remaining[%d]: expected %s, got %s", i, e, g)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveDocumentsPrunesVersions(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+
+	collection, err := store.CreateCollection(ctx, owner.ID(), "Foxes")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	retention := 2
+	if _, err := store.UpdateCollection(ctx, collection.ID(), port.CollectionUpdates{VersionRetention: &retention}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	var document model.PersistedDocument
+	for i := range 5 {
+		document = saveTestDocument(t, store, owner, "https://example.net/fox", fmt.Sprintf("# Fox\n\nVersion %d", i), collection.ID())
+	}
+
+	_, total, err := store.QueryDocumentVersions(ctx, document.ID(), port.QueryDocumentVersionsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(retention), total; e != g {
+		t.Errorf("total: expected %d, got %d", e, g)
+	}
+}
@@ -0,0 +1,79 @@
+package gorm
+
+import (
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+// Group is the GORM model for user groups.
+type Group struct {
+	ID string `gorm:"primaryKey;autoIncrement:false"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Name        string `gorm:"index:group_name_index,unique"`
+	Provider    string `gorm:"index:group_name_index,unique"`
+	Description string
+
+	// Owner is nil for the groups synchronized from an identity provider
+	Owner   *User
+	OwnerID *string
+
+	Members []*User `gorm:"many2many:groups_members;constraint:OnDelete:CASCADE"`
+}
+
+type wrappedGroup struct {
+	g *Group
+}
+
+// CreatedAt implements [model.PersistedGroup].
+func (w *wrappedGroup) CreatedAt() time.Time {
+	return w.g.CreatedAt
+}
+
+// UpdatedAt implements [model.PersistedGroup].
+func (w *wrappedGroup) UpdatedAt() time.Time {
+	return w.g.UpdatedAt
+}
+
+// ID implements [model.Group].
+func (w *wrappedGroup) ID() model.GroupID {
+	return model.GroupID(w.g.ID)
+}
+
+// Name implements [model.Group].
+func (w *wrappedGroup) Name() string {
+	return w.g.Name
+}
+
+// Description implements [model.Group].
+func (w *wrappedGroup) Description() string {
+	return w.g.Description
+}
+
+// Provider implements [model.Group].
+func (w *wrappedGroup) Provider() string {
+	return w.g.Provider
+}
+
+// Owner implements [model.Group].
+func (w *wrappedGroup) Owner() model.User {
+	if w.g.Owner == nil {
+		return nil
+	}
+
+	return &wrappedUser{w.g.Owner}
+}
+
+// Members implements [model.Group].
+func (w *wrappedGroup) Members() []model.User {
+	members := make([]model.User, 0, len(w.g.Members))
+	for _, u := range w.g.Members {
+		members = append(members, &wrappedUser{u})
+	}
+	return members
+}
+
+var _ model.PersistedGroup = &wrappedGroup{}
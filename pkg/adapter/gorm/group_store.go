@@ -0,0 +1,378 @@
+package gorm
+
+import (
+	"context"
+	"slices"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// CreateGroup implements [port.GroupStore].
+func (s *Store) CreateGroup(ctx context.Context, ownerID model.UserID, name string, description string) (model.PersistedGroup, error) {
+	var group Group
+
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		var count int64
+		if err := db.Model(&Group{}).Where("name = ? AND provider = ?", name, "").Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if count > 0 {
+			return errors.Wrapf(port.ErrAlreadyExists, "group '%s' already exists", name)
+		}
+
+		rawOwnerID := string(ownerID)
+
+		group = Group{
+			ID:          string(model.NewGroupID()),
+			Name:        name,
+			Description: description,
+			OwnerID:     &rawOwnerID,
+		}
+
+		if err := db.Create(&group).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := db.Preload("Owner").Preload("Members").First(&group, "id = ?", group.ID).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &wrappedGroup{&group}, nil
+}
+
+// GetGroupByID implements [port.GroupStore].
+func (s *Store) GetGroupByID(ctx context.Context, id model.GroupID) (model.PersistedGroup, error) {
+	var group Group
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		if err := db.Preload("Owner").Preload("Members").First(&group, "id = ?", string(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.WithStack(port.ErrNotFound)
+			}
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &wrappedGroup{&group}, nil
+}
+
+// QueryGroups implements [port.GroupStore].
+func (s *Store) QueryGroups(ctx context.Context, opts port.QueryGroupsOptions) ([]model.PersistedGroup, int64, error) {
+	var (
+		groups []*Group
+		total  int64
+	)
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		query := db.Model(&Group{})
+
+		if opts.OwnerID != nil {
+			query = query.Where("owner_id = ?", string(*opts.OwnerID))
+		}
+
+		if opts.MemberID != nil {
+			query = query.Where("id IN (SELECT group_id FROM groups_members WHERE user_id = ?)", string(*opts.MemberID))
+		}
+
+		if err := query.Count(&total).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Apply pagination
+		if opts.Page != nil {
+			limit := 10
+			if opts.Limit != nil {
+				limit = *opts.Limit
+			}
+			query = query.Offset(*opts.Page * limit)
+		}
+
+		if opts.Limit != nil {
+			query = query.Limit(*opts.Limit)
+		}
+
+		query = query.Preload("Owner").Order("provider ASC, name ASC")
+
+		if !opts.HeaderOnly {
+			query = query.Preload("Members")
+		}
+
+		if err := query.Find(&groups).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	wrappedGroups := make([]model.PersistedGroup, 0, len(groups))
+	for _, g := range groups {
+		wrappedGroups = append(wrappedGroups, &wrappedGroup{g})
+	}
+
+	return wrappedGroups, total, nil
+}
+
+// UpdateGroup implements [port.GroupStore].
+func (s *Store) UpdateGroup(ctx context.Context, id model.GroupID, updates port.GroupUpdates) (model.PersistedGroup, error) {
+	var group Group
+
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		if err := db.First(&group, "id = ?", string(id)).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.WithStack(port.ErrNotFound)
+			}
+			return errors.WithStack(err)
+		}
+
+		updateFields := make(map[string]any)
+
+		if updates.Name != nil && *updates.Name != group.Name {
+			var count int64
+			if err := db.Model(&Group{}).Where("name = ? AND provider = ?", *updates.Name, group.Provider).Count(&count).Error; err != nil {
+				return errors.WithStack(err)
+			}
+
+			if count > 0 {
+				return errors.Wrapf(port.ErrAlreadyExists, "group '%s' already exists", *updates.Name)
+			}
+
+			updateFields["name"] = *updates.Name
+		}
+
+		if updates.Description != nil {
+			updateFields["description"] = *updates.Description
+		}
+
+		if len(updateFields) > 0 {
+			if err := db.Model(&group).Updates(updateFields).Error; err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if err := db.Preload("Owner").Preload("Members").First(&group, "id = ?", group.ID).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &wrappedGroup{&group}, nil
+}
+
+// DeleteGroup implements [port.GroupStore].
+func (s *Store) DeleteGroup(ctx context.Context, id model.GroupID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		group := Group{ID: string(id)}
+
+		if err := db.Where("group_id = ?", string(id)).Delete(&CollectionShare{}).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := db.Model(&group).Association("Members").Clear(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		result := db.Delete(&group)
+		if result.Error != nil {
+			return errors.WithStack(result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// AddGroupMembers implements [port.GroupStore].
+func (s *Store) AddGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		var count int64
+		if err := db.Model(&Group{}).Where("id = ?", string(id)).Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if count == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		rawUserIDs := slices.Compact(slices.Sorted(slices.Values(toRawUserIDs(userIDs))))
+
+		if err := db.Model(&User{}).Where("id IN ?", rawUserIDs).Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if count != int64(len(rawUserIDs)) {
+			return errors.Wrap(port.ErrNotFound, "could not find all the users")
+		}
+
+		if err := addGroupMembers(db, string(id), rawUserIDs...); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// RemoveGroupMembers implements [port.GroupStore].
+func (s *Store) RemoveGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		var count int64
+		if err := db.Model(&Group{}).Where("id = ?", string(id)).Count(&count).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		if count == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		users := make([]*User, 0, len(userIDs))
+		for _, id := range userIDs {
+			users = append(users, &User{ID: string(id)})
+		}
+
+		if err := db.Model(&Group{ID: string(id)}).Association("Members").Delete(users); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// SyncUserGroups implements [port.GroupStore].
+func (s *Store) SyncUserGroups(ctx context.Context, userID model.UserID, provider string, names []string) error {
+	if provider == "" {
+		return errors.New("provider is required")
+	}
+
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		// Find or create the groups of the provider
+		groups := make([]*Group, 0, len(names))
+		for _, name := range names {
+			var group Group
+
+			err := db.Where("name = ? AND provider = ?", name, provider).
+				Attrs(&Group{
+					ID:       string(model.NewGroupID()),
+					Name:     name,
+					Provider: provider,
+				}).
+				FirstOrCreate(&group).Error
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			groups = append(groups, &group)
+		}
+
+		groupIDs := make([]string, 0, len(groups))
+		for _, g := range groups {
+			groupIDs = append(groupIDs, g.ID)
+		}
+
+		// Remove the user from the other groups of the provider
+		staleGroups := db.Model(&Group{}).Select("id").Where("provider = ?", provider)
+		if len(groupIDs) > 0 {
+			staleGroups = staleGroups.Where("id NOT IN ?", groupIDs)
+		}
+
+		if err := db.Exec("DELETE FROM groups_members WHERE user_id = ? AND group_id IN (?)", string(userID), staleGroups).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		for _, groupID := range groupIDs {
+			if err := addGroupMembers(db, groupID, string(userID)); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// sharedCollectionIDs returns a sub-query selecting the identifiers of the
+// collections shared with the user, directly or through one of their groups,
+// at one of the given levels or at any level if none is given
+func sharedCollectionIDs(db *gorm.DB, userID model.UserID, levels ...model.CollectionShareLevel) *gorm.DB {
+	query := db.Session(&gorm.Session{NewDB: true}).Model(&CollectionShare{}).Select("collection_id").Where(
+		"(user_id = ? OR group_id IN (SELECT group_id FROM groups_members WHERE user_id = ?))",
+		string(userID), string(userID),
+	)
+
+	if len(levels) > 0 {
+		rawLevels := make([]string, 0, len(levels))
+		for _, l := range levels {
+			rawLevels = append(rawLevels, string(l))
+		}
+
+		query = query.Where("level IN ?", rawLevels)
+	}
+
+	return query
+}
+
+// addGroupMembers inserts the missing memberships of the users to the group
+func addGroupMembers(db *gorm.DB, groupID string, userIDs ...string) error {
+	for _, userID := range userIDs {
+		err := db.Exec(
+			"INSERT INTO groups_members (group_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+			groupID, userID,
+		).Error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func toRawUserIDs(userIDs []model.UserID) []string {
+	rawUserIDs := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		rawUserIDs = append(rawUserIDs, string(id))
+	}
+	return rawUserIDs
+}
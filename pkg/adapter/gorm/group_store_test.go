@@ -0,0 +1,301 @@
+package gorm
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestGroupShares(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+	member := createTestUser(t, store, "member")
+	outsider := createTestUser(t, store, "outsider")
+
+	collection, err := store.CreateCollection(ctx, owner.ID(), "Shared")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	group, err := store.CreateGroup(ctx, owner.ID(), "Team", "")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.AddGroupMembers(ctx, group.ID(), member.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	type expectation struct {
+		User     model.User
+		CanRead  bool
+		CanWrite bool
+	}
+
+	assertAccess := func(t *testing.T, expectations ...expectation) {
+		for _, e := range expectations {
+			canRead, err := store.CanReadCollection(ctx, e.User.ID(), collection.ID())
+			if err != nil && !errors.Is(err, port.ErrNotFound) {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if canRead != e.CanRead {
+				t.Errorf("CanReadCollection(%s): expected %v, got %v", e.User.Subject(), e.CanRead, canRead)
+			}
+
+			canWrite, err := store.CanWriteCollection(ctx, e.User.ID(), collection.ID())
+			if err != nil && !errors.Is(err, port.ErrNotFound) {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if canWrite != e.CanWrite {
+				t.Errorf("CanWriteCollection(%s): expected %v, got %v", e.User.Subject(), e.CanWrite, canWrite)
+			}
+
+			readable, _, err := store.QueryUserReadableCollections(ctx, e.User.ID(), port.QueryCollectionsOptions{})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if g := containsCollection(readable, collection.ID()); g != e.CanRead {
+				t.Errorf("QueryUserReadableCollections(%s): expected %v, got %v", e.User.Subject(), e.CanRead, g)
+			}
+
+			writable, _, err := store.QueryUserWritableCollections(ctx, e.User.ID(), port.QueryCollectionsOptions{})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if g := containsCollection(writable, collection.ID()); g != e.CanWrite {
+				t.Errorf("QueryUserWritableCollections(%s): expected %v, got %v", e.User.Subject(), e.CanWrite, g)
+			}
+		}
+	}
+
+	t.Run("NotShared", func(t *testing.T) {
+		assertAccess(t,
+			expectation{User: owner, CanRead: true, CanWrite: true},
+			expectation{User: member, CanRead: false, CanWrite: false},
+			expectation{User: outsider, CanRead: false, CanWrite: false},
+		)
+	})
+
+	t.Run("ReadShare", func(t *testing.T) {
+		if _, err := store.CreateCollectionGroupShare(ctx, collection.ID(), group.ID(), model.CollectionShareLevelRead); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		assertAccess(t,
+			expectation{User: member, CanRead: true, CanWrite: false},
+			expectation{User: outsider, CanRead: false, CanWrite: false},
+		)
+	})
+
+	t.Run("WriteShare", func(t *testing.T) {
+		if _, err := store.CreateCollectionGroupShare(ctx, collection.ID(), group.ID(), model.CollectionShareLevelWrite); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		assertAccess(t,
+			expectation{User: member, CanRead: true, CanWrite: true},
+			expectation{User: outsider, CanRead: false, CanWrite: false},
+		)
+	})
+
+	t.Run("RemovedMember", func(t *testing.T) {
+		if err := store.RemoveGroupMembers(ctx, group.ID(), member.ID()); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		assertAccess(t,
+			expectation{User: member, CanRead: false, CanWrite: false},
+		)
+	})
+}
+
+func TestDeleteGroup(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+	member := createTestUser(t, store, "member")
+
+	collection, err := store.CreateCollection(ctx, owner.ID(), "Shared")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	group, err := store.CreateGroup(ctx, owner.ID(), "Team", "")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.AddGroupMembers(ctx, group.ID(), member.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if _, err := store.CreateCollectionGroupShare(ctx, collection.ID(), group.ID(), model.CollectionShareLevelRead); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.DeleteGroup(ctx, group.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	shares, err := store.GetCollectionShares(ctx, collection.ID())
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 0, len(shares); e != g {
+		t.Errorf("len(shares): expected %d, got %d", e, g)
+	}
+
+	// Collections the user can not access are reported as not found
+	canRead, err := store.CanReadCollection(ctx, member.ID(), collection.ID())
+	if err != nil && !errors.Is(err, port.ErrNotFound) {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if canRead {
+		t.Errorf("expected the member to lose access to the collection")
+	}
+
+	if _, err := store.GetGroupByID(ctx, group.ID()); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("GetGroupByID: expected port.ErrNotFound, got %v", err)
+	}
+
+	if err := store.DeleteGroup(ctx, group.ID()); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("DeleteGroup: expected port.ErrNotFound, got %v", err)
+	}
+}
+
+func TestSyncUserGroups(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+	user := createTestUser(t, store, "user")
+
+	// Groups managed in Corpus or synchronized from another provider are
+	// never touched by the synchronization
+	local, err := store.CreateGroup(ctx, owner.ID(), "local", "")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.AddGroupMembers(ctx, local.ID(), user.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.SyncUserGroups(ctx, user.ID(), "other", []string{"engineering"}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	type testCase struct {
+		Name     string
+		Groups   []string
+		Expected []string
+	}
+
+	testCases := []testCase{
+		{
+			Name:     "Join",
+			Groups:   []string{"engineering", "support"},
+			Expected: []string{"local", "oidc/engineering", "oidc/support", "other/engineering"},
+		},
+		{
+			Name:     "Idempotent",
+			Groups:   []string{"engineering", "support"},
+			Expected: []string{"local", "oidc/engineering", "oidc/support", "other/engineering"},
+		},
+		{
+			Name:     "Leave",
+			Groups:   []string{"support"},
+			Expected: []string{"local", "oidc/support", "other/engineering"},
+		},
+		{
+			Name:     "LeaveAll",
+			Groups:   []string{},
+			Expected: []string{"local", "other/engineering"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := store.SyncUserGroups(ctx, user.ID(), "oidc", tc.Groups); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			memberID := user.ID()
+
+			groups, _, err := store.QueryGroups(ctx, port.QueryGroupsOptions{MemberID: &memberID, HeaderOnly: true})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			names := make([]string, 0, len(groups))
+			for _, g := range groups {
+				if g.Provider() == "" {
+					names = append(names, g.Name())
+				} else {
+					names = append(names, g.Provider()+"/"+g.Name())
+				}
+			}
+
+			slices.Sort(names)
+
+			if e, g := tc.Expected, names; !slices.Equal(e, g) {
+				t.Errorf("groups: expected %v, got %v", e, g)
+			}
+		})
+	}
+
+	// Synchronized groups are kept when their last member leaves
+	_, total, err := store.QueryGroups(ctx, port.QueryGroupsOptions{HeaderOnly: true})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(4), total; e != g {
+		t.Errorf("total groups: expected %d, got %d", e, g)
+	}
+
+	if err := store.SyncUserGroups(ctx, user.ID(), "", []string{"engineering"}); err == nil {
+		t.Errorf("expected an error without provider")
+	}
+}
+
+func createTestUser(t *testing.T, store *Store, subject string) model.User {
+	ctx := context.Background()
+
+	created, err := store.FindOrCreateUser(ctx, "test", subject)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	// Emails are unique
+	user := model.CopyUser(created)
+	user.SetEmail(subject + "@example.net")
+
+	if err := store.SaveUser(ctx, user); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	return user
+}
+
+func containsCollection(collections []model.PersistedCollection, id model.CollectionID) bool {
+	return slices.ContainsFunc(collections, func(c model.PersistedCollection) bool {
+		return c.ID() == id
+	})
+}
@@ -2,6 +2,7 @@ package gorm
 
 import (
 	"database/sql/driver"
+	"slices"
 	"strings"
 	"time"
 
@@ -30,6 +31,12 @@ type Section struct {
 
 	Start int
 	End   int
+
+	// Ranges framing the section content, see model.FramedSection
+	PrefixStart int
+	PrefixEnd   int
+	SuffixStart int
+	SuffixEnd   int
 }
 
 type wrappedSection struct {
@@ -58,7 +65,13 @@ func (w *wrappedSection) Level() uint {
 
 // Content implements model.Section.
 func (w *wrappedSection) Content() ([]byte, error) {
-	return w.Document().Chunk(w.s.Start, w.s.End)
+	prefix, suffix := w.Frame()
+	return model.FramedChunk(w.Document(), w.s.Start, w.s.End, prefix, suffix)
+}
+
+// Frame implements model.FramedSection.
+func (w *wrappedSection) Frame() (model.Range, model.Range) {
+	return model.Range{Start: w.s.PrefixStart, End: w.s.PrefixEnd}, model.Range{Start: w.s.SuffixStart, End: w.s.SuffixEnd}
 }
 
 // Document implements model.Section.
@@ -90,18 +103,23 @@ func (w *wrappedSection) Sections() []model.Section {
 	return sections
 }
 
-var _ model.Section = &wrappedSection{}
+var _ model.FramedSection = &wrappedSection{}
 
 func fromSection(d *Document, s model.Section) *Section {
 	branch := Branch(s.Branch())
+	prefix, suffix := model.SectionFrame(s)
 	section := &Section{
-		ID:         string(s.ID()),
-		Document:   d,
-		DocumentID: d.ID,
-		Branch:     &branch,
-		Sections:   make([]*Section, 0, len(s.Sections())),
-		Start:      s.Start(),
-		End:        s.End(),
+		ID:          string(s.ID()),
+		Document:    d,
+		DocumentID:  d.ID,
+		Branch:      &branch,
+		Sections:    make([]*Section, 0, len(s.Sections())),
+		Start:       s.Start(),
+		End:         s.End(),
+		PrefixStart: prefix.Start,
+		PrefixEnd:   prefix.End,
+		SuffixStart: suffix.Start,
+		SuffixEnd:   suffix.End,
 	}
 
 	for _, s := range s.Sections() {
@@ -142,3 +160,25 @@ func (b *Branch) Value() (driver.Value, error) {
 
 	return strings.Join(parts, "."), nil
 }
+
+// sameAs returns true if the given section has the same position in the
+// document tree than this one
+func (s *Section) sameAs(other *Section) bool {
+	if s.Start != other.Start || s.End != other.End || s.Level != other.Level || s.DocumentID != other.DocumentID {
+		return false
+	}
+
+	if s.PrefixStart != other.PrefixStart || s.PrefixEnd != other.PrefixEnd || s.SuffixStart != other.SuffixStart || s.SuffixEnd != other.SuffixEnd {
+		return false
+	}
+
+	if (s.ParentID == nil) != (other.ParentID == nil) || (s.ParentID != nil && *s.ParentID != *other.ParentID) {
+		return false
+	}
+
+	if (s.Branch == nil) != (other.Branch == nil) || (s.Branch != nil && !slices.Equal(*s.Branch, *other.Branch)) {
+		return false
+	}
+
+	return true
+}
@@ -85,6 +85,7 @@ func (s *Store) GenerateSnapshot(ctx context.Context) (io.ReadCloser, error) {
 					Content:     content,
 					Collections: toSnapshottedCollections(ownedCollections),
 					Sections:    toSnapshottedSections(d.Sections()),
+					Metadata:    model.DocumentMetadata(d),
 				})
 				if err != nil {
 					w.CloseWithError(errors.WithStack(err))
@@ -162,6 +163,7 @@ type SnapshottedDocument struct {
 	Content     []byte
 	Collections []SnapshottedCollection
 	Sections    []SnapshottedSection
+	Metadata    map[string][]string
 }
 
 type SnapshottedUser struct {
@@ -292,7 +294,15 @@ func (w *snapshottedDocumentWrapper) Source() *url.URL {
 	return source
 }
 
-var _ model.Document = &snapshottedDocumentWrapper{}
+// Metadata implements model.WithMetadata.
+func (w *snapshottedDocumentWrapper) Metadata() map[string][]string {
+	return w.snapshot.Metadata
+}
+
+var (
+	_ model.Document     = &snapshottedDocumentWrapper{}
+	_ model.WithMetadata = &snapshottedDocumentWrapper{}
+)
 
 type SnapshottedCollection struct {
 	ID          string
@@ -345,6 +355,8 @@ type SnapshottedSection struct {
 	Branch   []string
 	Start    int
 	End      int
+	Prefix   model.Range
+	Suffix   model.Range
 	Level    int
 	Sections []SnapshottedSection
 }
@@ -368,7 +380,12 @@ func (w *snapshottedSectionWrapper) Branch() []model.SectionID {
 
 // Content implements model.Section.
 func (w *snapshottedSectionWrapper) Content() ([]byte, error) {
-	return w.Document().Chunk(w.snapshot.Start, w.snapshot.End)
+	return model.FramedChunk(w.Document(), w.snapshot.Start, w.snapshot.End, w.snapshot.Prefix, w.snapshot.Suffix)
+}
+
+// Frame implements model.FramedSection.
+func (w *snapshottedSectionWrapper) Frame() (model.Range, model.Range) {
+	return w.snapshot.Prefix, w.snapshot.Suffix
 }
 
 // Document implements model.Section.
@@ -414,11 +431,12 @@ func (w *snapshottedSectionWrapper) Start() int {
 	return w.snapshot.Start
 }
 
-var _ model.Section = &snapshottedSectionWrapper{}
+var _ model.FramedSection = &snapshottedSectionWrapper{}
 
 func toSnapshottedSections(sections []model.Section) []SnapshottedSection {
 	snapshots := make([]SnapshottedSection, 0, len(sections))
 	for _, s := range sections {
+		prefix, suffix := model.SectionFrame(s)
 		snapshots = append(snapshots, SnapshottedSection{
 			ID: string(s.ID()),
 			Branch: slices.Collect(func(yield func(string) bool) {
@@ -430,6 +448,8 @@ func toSnapshottedSections(sections []model.Section) []SnapshottedSection {
 			}),
 			Start:    s.Start(),
 			End:      s.End(),
+			Prefix:   prefix,
+			Suffix:   suffix,
 			Level:    int(s.Level()),
 			Sections: toSnapshottedSections(s.Sections()),
 		})
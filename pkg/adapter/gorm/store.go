@@ -70,15 +70,19 @@ func NewStore(db *gorm.DB) *Store {
 	return &Store{
 		getDatabase: createGetDatabase(db,
 			// Document store
-			&Document{}, &Section{}, &Collection{},
+			&Document{}, &Section{}, &Collection{}, &DocumentVersion{},
 			// Collection shares
 			&CollectionShare{},
 			// User store
 			&User{}, &AuthToken{}, &UserRole{}, &UserPreferences{},
+			// Group store
+			&Group{},
 			// Public share store
 			&PublicShare{},
 			// Filesystem source store
 			&FilesystemSource{},
+			// Conversation store
+			&Conversation{}, &ConversationTurn{},
 		),
 	}
 }
@@ -88,4 +92,6 @@ var (
 	_ port.UserStore              = &Store{}
 	_ port.PublicShareStore       = &Store{}
 	_ port.FilesystemSourceStore  = &Store{}
+	_ port.ConversationStore      = &Store{}
+	_ port.GroupStore             = &Store{}
 )
@@ -0,0 +1,40 @@
+package gorm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ncruces/go-sqlite3/gormlite"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	_ "github.com/asg017/sqlite-vec-go-bindings/ncruces"
+)
+
+// newTestStore returns a store backed by a temporary sqlite database
+func newTestStore(t *testing.T) *Store {
+	db, err := gorm.Open(gormlite.Open(filepath.Join(t.TempDir(), "corpus.sqlite")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	internalDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	t.Cleanup(func() {
+		internalDB.Close()
+	})
+
+	internalDB.SetMaxOpenConns(1)
+
+	if err := db.Exec("PRAGMA foreign_keys=on").Error; err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	return NewStore(db)
+}
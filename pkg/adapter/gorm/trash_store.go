@@ -0,0 +1,227 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// TrashDocumentByID implements port.DocumentStore.
+func (s *Store) TrashDocumentByID(ctx context.Context, ids ...model.DocumentID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		result := db.Delete(&Document{}, "id IN ?", ids)
+		if result.Error != nil {
+			return errors.WithStack(result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// RestoreDocumentByID implements port.DocumentStore.
+func (s *Store) RestoreDocumentByID(ctx context.Context, ids ...model.DocumentID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		result := db.Unscoped().Model(&Document{}).
+			Where("id IN ? AND deleted_at IS NOT NULL", ids).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return errors.WithStack(result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// QueryTrashedDocuments implements port.DocumentStore.
+func (s *Store) QueryTrashedDocuments(ctx context.Context, opts port.QueryTrashedDocumentsOptions) ([]model.PersistedDocument, int64, error) {
+	var (
+		documents []*Document
+		total     int64
+	)
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		query := db.Unscoped().Model(&Document{}).Where("deleted_at IS NOT NULL")
+
+		if opts.IDs != nil {
+			query = query.Where("id IN ?", opts.IDs)
+		}
+
+		if opts.OwnerID != nil {
+			query = query.Where("owner_id = ?", string(*opts.OwnerID))
+		}
+
+		if opts.TrashedBefore != nil {
+			query = query.Where("deleted_at < ?", *opts.TrashedBefore)
+		}
+
+		if err := query.Count(&total).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		limit := 10
+		if opts.Limit != nil {
+			limit = *opts.Limit
+		}
+
+		page := 0
+		if opts.Page != nil {
+			page = *opts.Page
+		}
+
+		query = query.Limit(limit).Offset(page * limit).Order("deleted_at DESC")
+
+		err := query.
+			Preload("Owner").
+			Preload("Collections").
+			Select("ID", "CreatedAt", "UpdatedAt", "DeletedAt", "OwnerID", "Source", "ETag", "Metadata").
+			Find(&documents).Error
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.BUSY, sqlite3.LOCKED)
+	if err != nil {
+		return nil, total, errors.WithStack(err)
+	}
+
+	wrappedDocuments := make([]model.PersistedDocument, 0, len(documents))
+	for _, d := range documents {
+		wrappedDocuments = append(wrappedDocuments, &wrappedDocument{d})
+	}
+
+	return wrappedDocuments, total, nil
+}
+
+// TrashCollection implements port.DocumentStore.
+func (s *Store) TrashCollection(ctx context.Context, id model.CollectionID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		result := db.Delete(&Collection{}, "id = ?", string(id))
+		if result.Error != nil {
+			return errors.WithStack(result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// RestoreCollection implements port.DocumentStore.
+func (s *Store) RestoreCollection(ctx context.Context, id model.CollectionID) error {
+	err := s.withRetry(ctx, true, func(ctx context.Context, db *gorm.DB) error {
+		result := db.Unscoped().Model(&Collection{}).
+			Where("id = ? AND deleted_at IS NOT NULL", string(id)).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return errors.WithStack(result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.WithStack(port.ErrNotFound)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// QueryTrashedCollections implements port.DocumentStore.
+func (s *Store) QueryTrashedCollections(ctx context.Context, opts port.QueryTrashedCollectionsOptions) ([]model.PersistedCollection, int64, error) {
+	var (
+		collections []*Collection
+		total       int64
+	)
+
+	err := s.withRetry(ctx, false, func(ctx context.Context, db *gorm.DB) error {
+		query := db.Unscoped().Model(&Collection{}).Where("deleted_at IS NOT NULL")
+
+		if opts.IDs != nil {
+			query = query.Where("id IN ?", opts.IDs)
+		}
+
+		if opts.OwnerID != nil {
+			query = query.Where("owner_id = ?", string(*opts.OwnerID))
+		}
+
+		if opts.TrashedBefore != nil {
+			query = query.Where("deleted_at < ?", *opts.TrashedBefore)
+		}
+
+		if err := query.Count(&total).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		limit := 10
+		if opts.Limit != nil {
+			limit = *opts.Limit
+		}
+
+		page := 0
+		if opts.Page != nil {
+			page = *opts.Page
+		}
+
+		query = query.Limit(limit).Offset(page * limit).Order("deleted_at DESC")
+
+		if err := query.Preload("Owner").Find(&collections).Error; err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, total, errors.WithStack(err)
+	}
+
+	wrappedCollections := make([]model.PersistedCollection, 0, len(collections))
+	for _, c := range collections {
+		wrappedCollections = append(wrappedCollections, &wrappedCollection{c})
+	}
+
+	return wrappedCollections, total, nil
+}
+
+// trashedAt returns the time the record was moved to the trash, or nil if it
+// is not in the trash
+func trashedAt(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+
+	return &deletedAt.Time
+}
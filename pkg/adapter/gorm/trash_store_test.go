@@ -0,0 +1,289 @@
+package gorm
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestTrashDocument(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+	other := createTestUser(t, store, "other")
+
+	document := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.")
+	otherDocument := saveTestDocument(t, store, other, "https://example.net/dog", "# Dog\n\nThe lazy dog.")
+
+	if err := store.TrashDocumentByID(ctx, document.ID(), otherDocument.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	// Trashed documents are hidden
+	if _, err := store.GetDocumentByID(ctx, document.ID()); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("store.GetDocumentByID(): expected port.ErrNotFound, got %v", err)
+	}
+
+	if e, g := []model.DocumentID{}, queryDocumentIDs(t, store); !slices.Equal(e, g) {
+		t.Errorf("documents: expected %v, got %v", e, g)
+	}
+
+	// Trashed documents are listed with their trash time
+	trashed, total, err := store.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(2), total; e != g {
+		t.Errorf("total trashed documents: expected %d, got %d", e, g)
+	}
+
+	for _, d := range trashed {
+		if model.TrashedAt(d) == nil {
+			t.Errorf("model.TrashedAt(%s): expected a time, got nil", d.ID())
+		}
+	}
+
+	// Only the documents of the given owner are listed
+	ownerID := owner.ID()
+
+	trashed, _, err = store.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{OwnerID: &ownerID})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := []model.DocumentID{document.ID()}, documentIDs(trashed); !slices.Equal(e, g) {
+		t.Errorf("owner trashed documents: expected %v, got %v", e, g)
+	}
+
+	// Only the documents trashed before the given time are listed
+	past := time.Now().Add(-time.Hour)
+
+	trashed, _, err = store.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{TrashedBefore: &past})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 0, len(trashed); e != g {
+		t.Errorf("len(trashed): expected %d, got %d", e, g)
+	}
+
+	// Restored documents are visible again
+	if err := store.RestoreDocumentByID(ctx, document.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := []model.DocumentID{document.ID()}, queryDocumentIDs(t, store); !slices.Equal(e, g) {
+		t.Errorf("documents: expected %v, got %v", e, g)
+	}
+
+	// Documents absent from the trash can not be restored
+	if err := store.RestoreDocumentByID(ctx, document.ID()); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("store.RestoreDocumentByID(): expected port.ErrNotFound, got %v", err)
+	}
+
+	if err := store.TrashDocumentByID(ctx, "unknown"); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("store.TrashDocumentByID(unknown): expected port.ErrNotFound, got %v", err)
+	}
+}
+
+func TestSaveTrashedDocument(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+
+	document := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.")
+
+	if err := store.TrashDocumentByID(ctx, document.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	// Saving a document from the trash restores it, keeping its identity
+	saved := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox jumps over the lazy dog.")
+
+	if e, g := document.ID(), saved.ID(); e != g {
+		t.Errorf("saved.ID(): expected %s, got %s", e, g)
+	}
+
+	if g := model.TrashedAt(saved); g != nil {
+		t.Errorf("model.TrashedAt(saved): expected nil, got %v", g)
+	}
+
+	_, total, err := store.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(0), total; e != g {
+		t.Errorf("total trashed documents: expected %d, got %d", e, g)
+	}
+}
+
+func TestDeleteTrashedDocumentBySource(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+
+	document := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.")
+
+	if err := store.TrashDocumentByID(ctx, document.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	// Trashed documents are permanently deleted by source
+	if err := store.DeleteDocumentBySource(ctx, owner.ID(), document.Source()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	_, total, err := store.QueryTrashedDocuments(ctx, port.QueryTrashedDocumentsOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := int64(0), total; e != g {
+		t.Errorf("total trashed documents: expected %d, got %d", e, g)
+	}
+
+	// A new document can then be saved with the same source
+	saved := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.")
+
+	if saved.ID() == document.ID() {
+		t.Errorf("saved.ID(): expected an identifier different from %s", document.ID())
+	}
+}
+
+func TestTrashCollection(t *testing.T) {
+	ctx := context.Background()
+
+	store := newTestStore(t)
+
+	owner := createTestUser(t, store, "owner")
+	other := createTestUser(t, store, "other")
+
+	trashedCollection, err := store.CreateCollection(ctx, owner.ID(), "Trashed")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	collection, err := store.CreateCollection(ctx, owner.ID(), "Kept")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	otherCollection, err := store.CreateCollection(ctx, other.ID(), "Other")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	trashedOnly := saveTestDocument(t, store, owner, "https://example.net/fox", "# Fox\n\nThe quick brown fox.", trashedCollection.ID())
+	shared := saveTestDocument(t, store, owner, "https://example.net/dog", "# Dog\n\nThe lazy dog.", trashedCollection.ID(), collection.ID())
+	orphaned := saveTestDocument(t, store, owner, "https://example.net/cat", "# Cat\n\nThe sleepy cat.")
+
+	if err := store.TrashCollection(ctx, trashedCollection.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := store.TrashCollection(ctx, otherCollection.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if _, err := store.GetCollectionByID(ctx, trashedCollection.ID(), false); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("store.GetCollectionByID(): expected port.ErrNotFound, got %v", err)
+	}
+
+	// The documents of the trashed collection are hidden, unless they belong
+	// to another collection
+	expected := []model.DocumentID{shared.ID(), orphaned.ID()}
+	slices.Sort(expected)
+
+	if e, g := expected, queryDocumentIDs(t, store); !slices.Equal(e, g) {
+		t.Errorf("documents: expected %v, got %v", e, g)
+	}
+
+	readable, _, err := store.QueryUserReadableDocuments(ctx, owner.ID(), port.QueryDocumentsOptions{HeaderOnly: true})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := expected, documentIDs(readable); !slices.Equal(e, g) {
+		t.Errorf("readable documents: expected %v, got %v", e, g)
+	}
+
+	// Only the collections of the given owner are listed
+	ownerID := owner.ID()
+
+	trashed, _, err := store.QueryTrashedCollections(ctx, port.QueryTrashedCollectionsOptions{OwnerID: &ownerID})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(trashed); e != g {
+		t.Fatalf("len(trashed): expected %d, got %d", e, g)
+	}
+
+	if e, g := trashedCollection.ID(), trashed[0].ID(); e != g {
+		t.Errorf("trashed[0].ID(): expected %s, got %s", e, g)
+	}
+
+	if model.TrashedAt(trashed[0]) == nil {
+		t.Errorf("model.TrashedAt(trashed[0]): expected a time, got nil")
+	}
+
+	// Restored collections show their documents again
+	if err := store.RestoreCollection(ctx, trashedCollection.ID()); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	expected = []model.DocumentID{trashedOnly.ID(), shared.ID(), orphaned.ID()}
+	slices.Sort(expected)
+
+	if e, g := expected, queryDocumentIDs(t, store); !slices.Equal(e, g) {
+		t.Errorf("documents: expected %v, got %v", e, g)
+	}
+
+	if err := store.RestoreCollection(ctx, trashedCollection.ID()); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("store.RestoreCollection(): expected port.ErrNotFound, got %v", err)
+	}
+
+	if err := store.TrashCollection(ctx, "unknown"); !errors.Is(err, port.ErrNotFound) {
+		t.Errorf("store.TrashCollection(unknown): expected port.ErrNotFound, got %v", err)
+	}
+}
+
+// queryDocumentIDs returns the sorted identifiers of the listed documents
+func queryDocumentIDs(t *testing.T, store *Store) []model.DocumentID {
+	limit := 100
+
+	documents, _, err := store.QueryDocuments(context.Background(), port.QueryDocumentsOptions{
+		Limit:      &limit,
+		HeaderOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	return documentIDs(documents)
+}
+
+// documentIDs returns the sorted identifiers of the given documents
+func documentIDs(documents []model.PersistedDocument) []model.DocumentID {
+	ids := make([]model.DocumentID, 0, len(documents))
+	for _, d := range documents {
+		ids = append(ids, d.ID())
+	}
+
+	slices.Sort(ids)
+
+	return ids
+}
@@ -0,0 +1,42 @@
+package memvec
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// SectionEmbeddings implements port.EmbeddingsIndex.
+func (i *Index) SectionEmbeddings(ctx context.Context, ids ...model.SectionID) (map[model.SectionID][]float32, error) {
+	if err := i.load(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	i.rwLock.RLock()
+	defer i.rwLock.RUnlock()
+
+	embeddings := make(map[model.SectionID][]float32, len(ids))
+
+	for _, id := range ids {
+		records := i.records[id]
+		if len(records) == 0 {
+			continue
+		}
+
+		// Mean of the chunks embeddings of the section
+		sum := make([]float64, len(records[0].Vector))
+		for _, r := range records {
+			for idx := range min(len(sum), len(r.Vector)) {
+				sum[idx] += float64(r.Vector[idx])
+			}
+		}
+
+		embeddings[id] = normalize(sum)
+	}
+
+	return embeddings, nil
+}
+
+var _ port.EmbeddingsIndex = &Index{}
@@ -0,0 +1,509 @@
+package memvec
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// Index is a pure Go vector index keeping the embeddings of the indexed
+// sections in memory and searching them exhaustively by cosine similarity.
+type Index struct {
+	maxWords   int
+	llm        llm.Client
+	model      string
+	dimensions int
+	path       string
+
+	// rwLock allows concurrent Search operations while serializing Index/Delete
+	rwLock sync.RWMutex
+
+	// records holds the embedded chunks of each indexed section
+	records map[model.SectionID][]*record
+	// sources holds the document level attributes used by the search filters
+	sources map[string]*sourceAttributes
+
+	loadOnce sync.Once
+	loadErr  error
+}
+
+type record struct {
+	Source     string
+	SectionID  model.SectionID
+	ChunkIndex int
+	// Vector is the normalized embeddings of the chunk
+	Vector      []float32
+	Collections []model.CollectionID
+}
+
+type sourceAttributes struct {
+	UpdatedAt time.Time
+	Metadata  map[string][]string
+}
+
+// DeleteByID implements port.Index.
+func (i *Index) DeleteByID(ctx context.Context, ids ...model.SectionID) error {
+	if err := i.load(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	i.deleteByIDLocked(ids...)
+
+	return errors.WithStack(i.saveLocked())
+}
+
+// deleteByIDLocked removes the given sections and the attributes of the
+// sources without any section left. Callers must hold i.rwLock.
+func (i *Index) deleteByIDLocked(ids ...model.SectionID) {
+	for _, id := range ids {
+		delete(i.records, id)
+	}
+
+	indexed := make(map[string]struct{}, len(i.sources))
+	for _, records := range i.records {
+		for _, r := range records {
+			indexed[r.Source] = struct{}{}
+		}
+	}
+
+	for source := range i.sources {
+		if _, exists := indexed[source]; !exists {
+			delete(i.sources, source)
+		}
+	}
+}
+
+// DeleteBySource implements port.Index.
+func (i *Index) DeleteBySource(ctx context.Context, source *url.URL) error {
+	if err := i.load(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	i.deleteBySourceLocked(source.String())
+
+	return errors.WithStack(i.saveLocked())
+}
+
+// deleteBySourceLocked removes the sections of the given source. Callers must
+// hold i.rwLock.
+func (i *Index) deleteBySourceLocked(source string) {
+	for id, records := range i.records {
+		if len(records) > 0 && records[0].Source == source {
+			delete(i.records, id)
+		}
+	}
+
+	delete(i.sources, source)
+}
+
+// All implements port.Index.
+func (i *Index) All(ctx context.Context, yield func(model.SectionID) bool) error {
+	if err := i.load(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.rwLock.RLock()
+	ids := make([]model.SectionID, 0, len(i.records))
+	for id := range i.records {
+		ids = append(ids, id)
+	}
+	i.rwLock.RUnlock()
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		if !yield(id) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+type indexableChunk struct {
+	Section  model.Section
+	Text     string
+	ChunkIdx int
+}
+
+const (
+	maxBatchItemCount = 100
+
+	overlapChars = 200
+)
+
+// Index implements port.Index.
+func (i *Index) Index(ctx context.Context, document model.Document, funcs ...port.IndexOptionFunc) error {
+	if err := i.load(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	opts := port.NewIndexOptions(funcs...)
+
+	source := document.Source()
+	if source == nil {
+		return errors.New("source missing")
+	}
+
+	chunks, err := i.chunk(document, opts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer func() {
+		if opts.OnProgress != nil {
+			opts.OnProgress(1.0)
+		}
+	}()
+
+	// Embeddings are generated before acquiring the lock, searches are not
+	// blocked by the calls to the embeddings model
+	records := make([]*record, 0, len(chunks))
+
+	collections := make([]model.CollectionID, 0, len(document.Collections()))
+	for _, c := range document.Collections() {
+		collections = append(collections, c.ID())
+	}
+
+	for start := 0; start < len(chunks); start += maxBatchItemCount {
+		batch := chunks[start:min(start+maxBatchItemCount, len(chunks))]
+
+		texts := make([]string, 0, len(batch))
+		for _, c := range batch {
+			texts = append(texts, c.Text)
+		}
+
+		res, err := i.llm.Embeddings(ctx, texts)
+		if err != nil {
+			return errors.Wrap(err, "generation failed")
+		}
+
+		embeddings := res.Embeddings()
+		if len(embeddings) != len(batch) {
+			return errors.New("vector count mismatch")
+		}
+
+		for idx, c := range batch {
+			vector, err := i.fit(embeddings[idx])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			records = append(records, &record{
+				Source:      source.String(),
+				SectionID:   c.Section.ID(),
+				ChunkIndex:  c.ChunkIdx,
+				Vector:      vector,
+				Collections: collections,
+			})
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(float32(start+len(batch)) / float32(len(chunks)))
+		}
+	}
+
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	if opts.Sections != nil {
+		// Only replace the given sections, the other sections of the document
+		// are kept
+		i.deleteByIDLocked(opts.Sections...)
+	} else {
+		i.deleteBySourceLocked(source.String())
+	}
+
+	for _, r := range records {
+		i.records[r.SectionID] = append(i.records[r.SectionID], r)
+	}
+
+	if len(records) > 0 {
+		i.sources[source.String()] = &sourceAttributes{
+			UpdatedAt: model.DocumentUpdatedAt(document),
+			Metadata:  model.DocumentMetadata(document),
+		}
+	}
+
+	return errors.WithStack(i.saveLocked())
+}
+
+// chunk splits the sections of the document to index in chunks fitting the
+// maximum number of words of the index.
+func (i *Index) chunk(document model.Document, opts *port.IndexOptions) ([]*indexableChunk, error) {
+	chunks := make([]*indexableChunk, 0)
+
+	limitChars := i.maxWords * 6
+
+	err := model.WalkSections(document, func(s model.Section) error {
+		if !opts.Includes(s.ID()) {
+			return nil
+		}
+
+		content, err := s.Content()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		runes := []rune(string(content))
+		if len(runes) == 0 {
+			return nil
+		}
+
+		chunkIdx := 0
+		for start := 0; start < len(runes); start += limitChars - overlapChars {
+			end := min(start+limitChars, len(runes))
+
+			chunks = append(chunks, &indexableChunk{
+				Section:  s,
+				Text:     opts.Contextualize(s.ID(), string(runes[start:end])),
+				ChunkIdx: chunkIdx,
+			})
+
+			chunkIdx++
+
+			if end == len(runes) {
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return chunks, nil
+}
+
+// fit checks the dimension of the given embeddings, detecting it on the first
+// call if not configured, and returns the normalized vector.
+func (i *Index) fit(embeddings []float64) ([]float32, error) {
+	i.rwLock.Lock()
+	if i.dimensions == 0 {
+		i.dimensions = len(embeddings)
+	}
+	dimensions := i.dimensions
+	i.rwLock.Unlock()
+
+	if len(embeddings) != dimensions {
+		return nil, errors.Errorf("unexpected embeddings dimension %d, the index expects %d", len(embeddings), dimensions)
+	}
+
+	return normalize(embeddings), nil
+}
+
+// Search implements port.Index.
+func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error) {
+	if err := i.load(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	i.rwLock.RLock()
+	empty := len(i.records) == 0
+	i.rwLock.RUnlock()
+
+	if empty {
+		return []*port.IndexSearchResult{}, nil
+	}
+
+	res, err := i.llm.Embeddings(ctx, []string{query})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	vector, err := i.fit(res.Embeddings()[0])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = 10 // default
+	}
+
+	type match struct {
+		record *record
+		score  float64
+	}
+
+	i.rwLock.RLock()
+
+	matches := make([]match, 0)
+	for _, records := range i.records {
+		for _, r := range records {
+			if !i.matchLocked(r, opts) {
+				continue
+			}
+
+			matches = append(matches, match{record: r, score: similarity(vector, r.Vector)})
+		}
+	}
+
+	i.rwLock.RUnlock()
+
+	// Records are collected from a map: ties are broken on the section and
+	// chunk to keep the results deterministic
+	slices.SortStableFunc(matches, func(m1, m2 match) int {
+		if m1.score > m2.score {
+			return -1
+		}
+		if m1.score < m2.score {
+			return 1
+		}
+		if c := cmp.Compare(m1.record.SectionID, m2.record.SectionID); c != 0 {
+			return c
+		}
+		return cmp.Compare(m1.record.ChunkIndex, m2.record.ChunkIndex)
+	})
+
+	if len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
+
+	mappedScores := map[string]float64{}
+	mappedSections := map[string][]model.SectionID{}
+	sectionScores := map[model.SectionID]float64{}
+	sources := make([]string, 0)
+
+	for _, m := range matches {
+		source := m.record.Source
+		sectionID := m.record.SectionID
+
+		if _, exists := mappedSections[source]; !exists {
+			mappedSections[source] = make([]model.SectionID, 0)
+			sources = append(sources, source)
+		}
+
+		// A section can be split in several chunks, keep its best match
+		if score, seen := sectionScores[sectionID]; !seen || m.score > score {
+			sectionScores[sectionID] = m.score
+		}
+
+		if !slices.Contains(mappedSections[source], sectionID) {
+			mappedSections[source] = append(mappedSections[source], sectionID)
+		}
+
+		mappedScores[source] += m.score
+	}
+
+	searchResults := make([]*port.IndexSearchResult, 0, len(sources))
+
+	for _, rawSource := range sources {
+		source, err := url.Parse(rawSource)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		sectionIDs := mappedSections[rawSource]
+
+		scores := make(map[model.SectionID]float64, len(sectionIDs))
+		for _, id := range sectionIDs {
+			scores[id] = sectionScores[id]
+		}
+
+		searchResults = append(searchResults, &port.IndexSearchResult{
+			Source:   source,
+			Sections: sectionIDs,
+			Scores:   scores,
+		})
+	}
+
+	slices.SortStableFunc(searchResults, func(r1 *port.IndexSearchResult, r2 *port.IndexSearchResult) int {
+		score1 := mappedScores[r1.Source.String()]
+		score2 := mappedScores[r2.Source.String()]
+		if score1 > score2 {
+			return -1
+		}
+		if score1 < score2 {
+			return 1
+		}
+		return 0
+	})
+
+	return searchResults, nil
+}
+
+// matchLocked returns true if the record satisfies the collections and filter
+// of the search options. Callers must hold i.rwLock.
+func (i *Index) matchLocked(r *record, opts port.IndexSearchOptions) bool {
+	if len(opts.Collections) > 0 && !slices.ContainsFunc(r.Collections, func(id model.CollectionID) bool {
+		return slices.Contains(opts.Collections, id)
+	}) {
+		return false
+	}
+
+	if opts.Filter.IsZero() {
+		return true
+	}
+
+	attrs, exists := i.sources[r.Source]
+	if !exists {
+		return false
+	}
+
+	return opts.Filter.Match(r.Source, attrs.UpdatedAt, attrs.Metadata)
+}
+
+func NewIndex(llm llm.Client, embeddingsModel string, maxWords int, funcs ...OptionFunc) *Index {
+	opts := NewOptions(funcs...)
+
+	return &Index{
+		maxWords:   maxWords,
+		llm:        llm,
+		model:      embeddingsModel,
+		dimensions: opts.Dimensions,
+		path:       opts.Path,
+		records:    map[model.SectionID][]*record{},
+		sources:    map[string]*sourceAttributes{},
+	}
+}
+
+var _ port.Index = &Index{}
+
+// normalize returns the given vector scaled to a unit length
+func normalize(vector []float64) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+
+	norm = math.Sqrt(norm)
+
+	normalized := make([]float32, len(vector))
+	if norm == 0 {
+		return normalized
+	}
+
+	for idx, v := range vector {
+		normalized[idx] = float32(v / norm)
+	}
+
+	return normalized
+}
+
+// similarity returns the cosine similarity of two normalized vectors, clamped
+// to [0, 1].
+func similarity(v1, v2 []float32) float64 {
+	var dot float64
+	for idx := range v1 {
+		dot += float64(v1[idx]) * float64(v2[idx])
+	}
+
+	return math.Max(0, math.Min(1, dot))
+}
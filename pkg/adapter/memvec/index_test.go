@@ -0,0 +1,150 @@
+package memvec
+
+import (
+	"context"
+	"hash/fnv"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/corpus/pkg/port/testsuite"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+// wordsEmbeddingsLLM generates bag of words embeddings, enough for lexical
+// queries to match their documents
+type wordsEmbeddingsLLM struct {
+	llm.Client
+	dimensions int
+}
+
+func (m *wordsEmbeddingsLLM) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	embeddings := make([][]float64, len(inputs))
+	for idx, input := range inputs {
+		vector := make([]float64, m.dimensions)
+		words := strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			if len(word) < 2 {
+				continue
+			}
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vector[int(h.Sum32())%m.dimensions] += 1
+		}
+		embeddings[idx] = vector
+	}
+
+	return &embeddingsResponse{embeddings: embeddings}, nil
+}
+
+type embeddingsResponse struct {
+	embeddings [][]float64
+}
+
+func (r *embeddingsResponse) Embeddings() [][]float64 {
+	return r.embeddings
+}
+
+func (r *embeddingsResponse) Usage() llm.EmbeddingsUsage {
+	return llm.NewEmbeddingsUsage(0, 0)
+}
+
+func TestIndex(t *testing.T) {
+	client := &wordsEmbeddingsLLM{dimensions: 1024}
+
+	testsuite.TestIndex(t, func(t *testing.T) (port.Index, error) {
+		return NewIndex(client, "words", 500), nil
+	})
+}
+
+func TestIndexPersistence(t *testing.T) {
+	ctx := context.Background()
+
+	client := &wordsEmbeddingsLLM{dimensions: 256}
+	path := filepath.Join(t.TempDir(), "index.memvec")
+
+	doc, err := markdown.Parse([]byte("# Fox\n\nThe quick brown fox jumps over the lazy dog."))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/fox")
+	doc.SetSource(source)
+
+	if err := NewIndex(client, "words", 500, WithPath(path)).Index(ctx, doc); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	results, err := NewIndex(client, "words", 500, WithPath(path)).Search(ctx, "brown fox", port.IndexSearchOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	if e, g := source.String(), results[0].Source.String(); e != g {
+		t.Errorf("results[0].Source: expected '%s', got '%s'", e, g)
+	}
+
+	// The index file can not be loaded with another embeddings model
+	if _, err := NewIndex(client, "other", 500, WithPath(path)).Search(ctx, "fox", port.IndexSearchOptions{}); err == nil {
+		t.Errorf("expected an error when loading the index with another embeddings model")
+	}
+}
+
+func TestSearchTies(t *testing.T) {
+	ctx := context.Background()
+
+	index := NewIndex(&wordsEmbeddingsLLM{dimensions: 256}, "words", 500)
+
+	// Identical documents get the same score
+	for _, rawSource := range []string{"https://example.net/a", "https://example.net/b", "https://example.net/c", "https://example.net/d"} {
+		doc, err := markdown.Parse([]byte("# Fox\n\nThe quick brown fox jumps over the lazy dog."))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		source, _ := url.Parse(rawSource)
+		doc.SetSource(source)
+
+		if err := index.Index(ctx, doc); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	search := func() []string {
+		results, err := index.Search(ctx, "brown fox", port.IndexSearchOptions{MaxResults: 10})
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		sources := make([]string, 0, len(results))
+		for _, r := range results {
+			sources = append(sources, r.Source.String())
+		}
+
+		return sources
+	}
+
+	expected := search()
+
+	if e, g := 4, len(expected); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	for range 20 {
+		if g := search(); !slices.Equal(expected, g) {
+			t.Fatalf("results order: expected %v, got %v", expected, g)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package memvec
+
+type Options struct {
+	// Dimensions is the dimension of the vectors returned by the embeddings
+	// model. If zero, it is detected from the first embeddings response.
+	Dimensions int
+	// Path is the file the index is persisted to after each change and loaded
+	// from at startup. If empty, the index only lives in memory.
+	Path string
+}
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		Dimensions: 0,
+		Path:       "",
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+func WithDimensions(dimensions int) OptionFunc {
+	return func(opts *Options) {
+		opts.Dimensions = dimensions
+	}
+}
+
+func WithPath(path string) OptionFunc {
+	return func(opts *Options) {
+		opts.Path = path
+	}
+}
@@ -0,0 +1,249 @@
+package memvec
+
+import (
+	"context"
+	"encoding/gob"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bornholm/corpus/internal/backup"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	gob.Register(SnapshottedRecord{})
+	gob.Register(SnapshottedMetadata{})
+}
+
+type SnapshottedMetadata struct {
+	Model      string
+	Dimensions int
+}
+
+type SnapshottedRecord struct {
+	Source      string
+	SectionID   string
+	ChunkIndex  int
+	Embeddings  []float32
+	Collections []string
+	// Document level attributes of the record source
+	UpdatedAt int64
+	Metadata  map[string][]string
+}
+
+// GenerateSnapshot implements backup.Snapshotable.
+func (i *Index) GenerateSnapshot(ctx context.Context) (io.ReadCloser, error) {
+	if err := i.load(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r, w := io.Pipe()
+
+	go func() {
+		defer w.Close()
+
+		i.rwLock.RLock()
+		defer i.rwLock.RUnlock()
+
+		if err := i.encodeLocked(w); err != nil {
+			w.CloseWithError(errors.WithStack(err))
+			return
+		}
+	}()
+
+	return io.NopCloser(r), nil
+}
+
+// RestoreSnapshot implements backup.Snapshotable.
+func (i *Index) RestoreSnapshot(ctx context.Context, r io.Reader) error {
+	if err := i.load(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	if err := i.decodeLocked(r); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(i.saveLocked())
+}
+
+// encodeLocked writes the index content to the given writer. Callers must
+// hold i.rwLock.
+func (i *Index) encodeLocked(w io.Writer) error {
+	encoder := gob.NewEncoder(w)
+
+	metadata := SnapshottedMetadata{
+		Model:      i.model,
+		Dimensions: i.dimensions,
+	}
+
+	if err := encoder.Encode(metadata); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, records := range i.records {
+		for _, r := range records {
+			record := SnapshottedRecord{
+				Source:      r.Source,
+				SectionID:   string(r.SectionID),
+				ChunkIndex:  r.ChunkIndex,
+				Embeddings:  r.Vector,
+				Collections: make([]string, 0, len(r.Collections)),
+			}
+
+			for _, id := range r.Collections {
+				record.Collections = append(record.Collections, string(id))
+			}
+
+			if attrs, exists := i.sources[r.Source]; exists {
+				record.UpdatedAt = attrs.UpdatedAt.UnixMilli()
+				record.Metadata = attrs.Metadata
+			}
+
+			if err := encoder.Encode(record); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeLocked replaces the index content with the one read from the given
+// reader. Callers must hold i.rwLock.
+func (i *Index) decodeLocked(r io.Reader) error {
+	decoder := gob.NewDecoder(r)
+
+	metadata := SnapshottedMetadata{}
+
+	if err := decoder.Decode(&metadata); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if metadata.Model != i.model {
+		return errors.Errorf("could not restore snapshot with a different embedding model '%s'", metadata.Model)
+	}
+
+	if i.dimensions != 0 && metadata.Dimensions != 0 && metadata.Dimensions != i.dimensions {
+		return errors.Errorf("could not restore snapshot with %d dimensions embeddings, the index expects %d", metadata.Dimensions, i.dimensions)
+	}
+
+	records := map[model.SectionID][]*record{}
+	sources := map[string]*sourceAttributes{}
+
+	for {
+		var snapshotted SnapshottedRecord
+		if err := decoder.Decode(&snapshotted); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return errors.WithStack(err)
+		}
+
+		r := &record{
+			Source:      snapshotted.Source,
+			SectionID:   model.SectionID(snapshotted.SectionID),
+			ChunkIndex:  snapshotted.ChunkIndex,
+			Vector:      snapshotted.Embeddings,
+			Collections: make([]model.CollectionID, 0, len(snapshotted.Collections)),
+		}
+
+		for _, id := range snapshotted.Collections {
+			r.Collections = append(r.Collections, model.CollectionID(id))
+		}
+
+		records[r.SectionID] = append(records[r.SectionID], r)
+
+		if _, exists := sources[r.Source]; !exists {
+			sources[r.Source] = &sourceAttributes{
+				UpdatedAt: time.UnixMilli(snapshotted.UpdatedAt),
+				Metadata:  snapshotted.Metadata,
+			}
+		}
+	}
+
+	if i.dimensions == 0 {
+		i.dimensions = metadata.Dimensions
+	}
+
+	i.records = records
+	i.sources = sources
+
+	return nil
+}
+
+// load reads the index content from its file on first use, if any.
+func (i *Index) load(ctx context.Context) error {
+	i.loadOnce.Do(func() {
+		if i.path == "" {
+			return
+		}
+
+		file, err := os.Open(i.path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				i.loadErr = errors.WithStack(err)
+			}
+
+			return
+		}
+
+		defer file.Close()
+
+		i.rwLock.Lock()
+		defer i.rwLock.Unlock()
+
+		if err := i.decodeLocked(file); err != nil {
+			i.loadErr = errors.Wrapf(err, "could not load index file '%s'", i.path)
+			return
+		}
+
+		slog.DebugContext(ctx, "index file loaded", slog.String("path", i.path), slog.Int("sections", len(i.records)))
+	})
+
+	return i.loadErr
+}
+
+// saveLocked writes the index content to its file, if any. The file is
+// replaced atomically. Callers must hold i.rwLock.
+func (i *Index) saveLocked() error {
+	if i.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(i.path), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(i.path), filepath.Base(i.path)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer os.Remove(file.Name())
+
+	if err := i.encodeLocked(file); err != nil {
+		file.Close()
+		return errors.WithStack(err)
+	}
+
+	if err := file.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.Rename(file.Name(), i.path); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+var _ backup.Snapshotable = &Index{}
@@ -0,0 +1,242 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/prompt"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxHeadingLength is the maximum number of bytes read to extract the
+	// heading of a section
+	maxHeadingLength = 512
+	// maxSummarizedWords is the maximum number of words of the document given
+	// to the LLM to generate its summary
+	maxSummarizedWords = 2000
+	// summaryCacheSize is the maximum number of document summaries kept in
+	// memory
+	summaryCacheSize = 1000
+)
+
+// DocumentContextualizer situates each section in its document with the
+// document title and the breadcrumb of the headings leading to the section,
+// optionally followed by a one-line summary of the document generated by an
+// LLM.
+type DocumentContextualizer struct {
+	llm llm.Client
+	// summaries holds the generated summaries, keyed by the checksum of the
+	// summarized content, so that reindexing a document does not summarize
+	// it again
+	summaries *lru.Cache[string, string]
+}
+
+const defaultSummaryPromptTemplate = `
+Summarize the following document in a single sentence, written in the language of the document. Do not output anything else than the sentence.
+
+## Document
+
+{{ .Content }}
+`
+
+// Contextualize implements Contextualizer.
+func (c *DocumentContextualizer) Contextualize(ctx context.Context, document model.Document) (map[model.SectionID]string, error) {
+	breadcrumbs := map[model.SectionID][]string{}
+
+	var title string
+
+	var walk func(s model.Section, headings []string) error
+	walk = func(s model.Section, headings []string) error {
+		heading, err := sectionHeading(document, s)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if heading != "" {
+			if title == "" && s.Level() == 1 {
+				title = heading
+			}
+
+			headings = append(headings[:len(headings):len(headings)], heading)
+		}
+
+		breadcrumbs[s.ID()] = headings
+
+		for _, child := range s.Sections() {
+			if err := walk(child, headings); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		return nil
+	}
+
+	for _, s := range document.Sections() {
+		if err := walk(s, nil); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if metadataTitle := documentTitle(document); metadataTitle != "" {
+		title = metadataTitle
+	}
+
+	if title == "" {
+		title = sourceTitle(document.Source())
+	}
+
+	var summary string
+	if c.llm != nil {
+		generated, err := c.summarize(ctx, document)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		summary = generated
+	}
+
+	contexts := make(map[model.SectionID]string, len(breadcrumbs))
+
+	for sectionID, headings := range breadcrumbs {
+		// The title is not repeated when it is the top level heading
+		if len(headings) > 0 && headings[0] == title {
+			headings = headings[1:]
+		}
+
+		lines := make([]string, 0, 3)
+
+		if title != "" {
+			lines = append(lines, title)
+		}
+
+		if len(headings) > 0 {
+			lines = append(lines, strings.Join(headings, " > "))
+		}
+
+		if summary != "" {
+			lines = append(lines, summary)
+		}
+
+		contexts[sectionID] = strings.Join(lines, "\n")
+	}
+
+	return contexts, nil
+}
+
+func (c *DocumentContextualizer) summarize(ctx context.Context, document model.Document) (string, error) {
+	content, err := document.Content()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return "", nil
+	}
+
+	summarized := text.MiddleOut(string(content), maxSummarizedWords, " [...] ")
+
+	hash := sha256.Sum256([]byte(summarized))
+	key := hex.EncodeToString(hash[:])
+
+	if summary, exists := c.summaries.Get(key); exists {
+		return summary, nil
+	}
+
+	prompt, err := prompt.Template(defaultSummaryPromptTemplate, struct {
+		Content string
+	}{
+		Content: summarized,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	completion, err := c.llm.ChatCompletion(ctx,
+		llm.WithMessages(
+			llm.NewMessage(llm.RoleUser, prompt),
+		),
+		llm.WithTemperature(0),
+	)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// The summary has to fit on a single line
+	summary := strings.Join(strings.Fields(completion.Message().Content()), " ")
+
+	slog.DebugContext(ctx, "generated document summary", slog.String("summary", summary))
+
+	c.summaries.Add(key, summary)
+
+	return summary, nil
+}
+
+// NewDocumentContextualizer creates a contextualizer prefixing the sections
+// with their document title and headings breadcrumb. If client is not nil, it
+// is used to generate a summary of the document added to the context.
+func NewDocumentContextualizer(client llm.Client) *DocumentContextualizer {
+	// The cache size being positive, no error can occur
+	summaries, _ := lru.New[string, string](summaryCacheSize)
+
+	return &DocumentContextualizer{
+		llm:       client,
+		summaries: summaries,
+	}
+}
+
+var _ Contextualizer = &DocumentContextualizer{}
+
+// sectionHeading returns the text of the heading starting the given section,
+// or an empty string if the section does not start with a heading
+func sectionHeading(document model.Document, s model.Section) (string, error) {
+	chunk, err := document.Chunk(s.Start(), min(s.End(), s.Start()+maxHeadingLength))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	line, _, _ := bytes.Cut(chunk, []byte("\n"))
+	line = bytes.TrimSpace(line)
+
+	if !bytes.HasPrefix(line, []byte("#")) {
+		return "", nil
+	}
+
+	heading := strings.TrimSpace(strings.Trim(string(line), "#"))
+
+	return heading, nil
+}
+
+// documentTitle returns the title found in the document metadata, if any
+func documentTitle(document model.Document) string {
+	for _, value := range model.DocumentMetadata(document)["title"] {
+		if title := strings.TrimSpace(value); title != "" {
+			return title
+		}
+	}
+
+	return ""
+}
+
+// sourceTitle returns the name of the document source file, or its host
+func sourceTitle(source *url.URL) string {
+	if source == nil {
+		return ""
+	}
+
+	if name := path.Base(source.Path); name != "." && name != "/" {
+		return name
+	}
+
+	return source.Host
+}
@@ -0,0 +1,145 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/adapter/fakellm"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/genai/llm"
+	"github.com/pkg/errors"
+)
+
+func TestDocumentContextualizer(t *testing.T) {
+	data := "---\nsource: https://example.net/docs/install.md\n---\n\n# Corpus\n\n## Installation\n\n### Windows\n\nRun the installer.\n\n### Linux\n\nExtract the archive.\n"
+
+	document, err := markdown.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	contextualizer := NewDocumentContextualizer(nil)
+
+	contexts, err := contextualizer.Contextualize(context.Background(), document)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	expected := map[string]string{
+		"Run the installer.":   "Corpus\nInstallation > Windows",
+		"Extract the archive.": "Corpus\nInstallation > Linux",
+	}
+
+	found := 0
+
+	err = model.WalkSections(document, func(s model.Section) error {
+		if len(s.Sections()) > 0 {
+			return nil
+		}
+
+		content, err := s.Content()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		for text, e := range expected {
+			if !containsLine(string(content), text) {
+				continue
+			}
+
+			found++
+
+			if g := contexts[s.ID()]; e != g {
+				t.Errorf("contexts[%s]: expected '%s', got '%s'", s.ID(), e, g)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := len(expected), found; e != g {
+		t.Errorf("found: expected '%d', got '%d'", e, g)
+	}
+}
+
+func TestDocumentContextualizerSourceTitle(t *testing.T) {
+	document, err := markdown.Parse([]byte("---\nsource: https://example.net/notes.md\n---\n\nSome notes without heading.\n"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	contexts, err := NewDocumentContextualizer(nil).Contextualize(context.Background(), document)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if len(contexts) == 0 {
+		t.Fatalf("len(contexts): expected at least one context")
+	}
+
+	for id, g := range contexts {
+		if e := "notes.md"; e != g {
+			t.Errorf("contexts[%s]: expected '%s', got '%s'", id, e, g)
+		}
+	}
+}
+
+func TestDocumentContextualizerSummaryCache(t *testing.T) {
+	client := &countingLLM{Client: fakellm.NewClient(fakellm.ModeEcho, nil, 0)}
+
+	contextualizer := NewDocumentContextualizer(client)
+
+	contextualize := func(data string) {
+		document, err := markdown.Parse([]byte(data))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		if _, err := contextualizer.Contextualize(context.Background(), document); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	// The summary of an unchanged document is generated once
+	contextualize("# Fox\n\nThe quick brown fox.\n")
+	contextualize("# Fox\n\nThe quick brown fox.\n")
+
+	if e, g := int64(1), client.completions.Load(); e != g {
+		t.Errorf("client.completions: expected %d, got %d", e, g)
+	}
+
+	// A modified document is summarized again
+	contextualize("# Fox\n\nThe quick brown fox jumps over the lazy dog.\n")
+
+	if e, g := int64(2), client.completions.Load(); e != g {
+		t.Errorf("client.completions: expected %d, got %d", e, g)
+	}
+}
+
+// countingLLM counts the chat completions requested to the underlying client
+type countingLLM struct {
+	llm.Client
+	completions atomic.Int64
+}
+
+// ChatCompletion implements llm.ChatCompletionClient.
+func (c *countingLLM) ChatCompletion(ctx context.Context, funcs ...llm.ChatCompletionOptionFunc) (llm.ChatCompletionResponse, error) {
+	c.completions.Add(1)
+	return c.Client.ChatCompletion(ctx, funcs...)
+}
+
+func containsLine(content string, line string) bool {
+	for l := range strings.Lines(content) {
+		if strings.TrimSpace(l) == line {
+			return true
+		}
+	}
+
+	return false
+}
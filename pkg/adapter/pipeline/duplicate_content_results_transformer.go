@@ -20,8 +20,11 @@ func (t *DuplicateContentResultsTransformer) TransformResults(ctx context.Contex
 
 	for _, r := range results {
 		updated := &port.IndexSearchResult{
-			Source:   r.Source,
-			Sections: make([]model.SectionID, 0),
+			Source:     r.Source,
+			Sections:   make([]model.SectionID, 0),
+			Scores:     r.Scores,
+			Ranks:      r.Ranks,
+			Highlights: r.Highlights,
 		}
 
 		// Batch load all sections for this result
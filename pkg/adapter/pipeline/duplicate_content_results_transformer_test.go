@@ -180,6 +180,11 @@ func (d *dummyStore) CreateCollection(ctx context.Context, ownerID model.UserID,
 	panic("unimplemented")
 }
 
+// MarkDocumentIndexed implements [port.DocumentStore].
+func (d *dummyStore) MarkDocumentIndexed(ctx context.Context, id model.DocumentID, checksum string) error {
+	panic("unimplemented")
+}
+
 // DeleteDocumentByID implements [port.DocumentStore].
 func (d *dummyStore) DeleteDocumentByID(ctx context.Context, ids ...model.DocumentID) error {
 	panic("unimplemented")
@@ -205,6 +210,46 @@ func (d *dummyStore) GetDocumentByID(ctx context.Context, id model.DocumentID) (
 	panic("unimplemented")
 }
 
+// QueryDocumentVersions implements [port.DocumentStore].
+func (d *dummyStore) QueryDocumentVersions(ctx context.Context, documentID model.DocumentID, opts port.QueryDocumentVersionsOptions) ([]model.DocumentVersion, int64, error) {
+	panic("unimplemented")
+}
+
+// GetDocumentVersionByID implements [port.DocumentStore].
+func (d *dummyStore) GetDocumentVersionByID(ctx context.Context, id model.DocumentVersionID) (model.DocumentVersion, error) {
+	panic("unimplemented")
+}
+
+// TrashDocumentByID implements [port.DocumentStore].
+func (d *dummyStore) TrashDocumentByID(ctx context.Context, ids ...model.DocumentID) error {
+	panic("unimplemented")
+}
+
+// RestoreDocumentByID implements [port.DocumentStore].
+func (d *dummyStore) RestoreDocumentByID(ctx context.Context, ids ...model.DocumentID) error {
+	panic("unimplemented")
+}
+
+// QueryTrashedDocuments implements [port.DocumentStore].
+func (d *dummyStore) QueryTrashedDocuments(ctx context.Context, opts port.QueryTrashedDocumentsOptions) ([]model.PersistedDocument, int64, error) {
+	panic("unimplemented")
+}
+
+// TrashCollection implements [port.DocumentStore].
+func (d *dummyStore) TrashCollection(ctx context.Context, id model.CollectionID) error {
+	panic("unimplemented")
+}
+
+// RestoreCollection implements [port.DocumentStore].
+func (d *dummyStore) RestoreCollection(ctx context.Context, id model.CollectionID) error {
+	panic("unimplemented")
+}
+
+// QueryTrashedCollections implements [port.DocumentStore].
+func (d *dummyStore) QueryTrashedCollections(ctx context.Context, opts port.QueryTrashedCollectionsOptions) ([]model.PersistedCollection, int64, error) {
+	panic("unimplemented")
+}
+
 // GetSectionByID implements [port.DocumentStore].
 func (d *dummyStore) GetSectionByID(ctx context.Context, id model.SectionID) (model.Section, error) {
 	return d.sections[id], nil
@@ -276,6 +321,11 @@ func (d *dummyStore) UpdateCollection(ctx context.Context, id model.CollectionID
 	panic("unimplemented")
 }
 
+// CreateCollectionGroupShare implements [port.DocumentStore].
+func (d *dummyStore) CreateCollectionGroupShare(ctx context.Context, collectionID model.CollectionID, groupID model.GroupID, level model.CollectionShareLevel) (model.PersistedCollectionShare, error) {
+	panic("unimplemented")
+}
+
 // CreateCollectionShare implements [port.DocumentStore].
 func (d *dummyStore) CreateCollectionShare(ctx context.Context, collectionID model.CollectionID, userID model.UserID, level model.CollectionShareLevel) (model.PersistedCollectionShare, error) {
 	panic("unimplemented")
@@ -0,0 +1,337 @@
+package pipeline
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+// IndexResults holds the results returned by one of the pipeline's
+// underlying indexes, alongside the index weight. With query expansion, each
+// index returns one results list per searched query.
+type IndexResults struct {
+	Index *IdentifiedIndex
+	// Query is the position of the searched query, 0 being the original one
+	// and the following ones its alternatives
+	Query   int
+	Weight  float64
+	Results []*port.IndexSearchResult
+}
+
+// Fusion merges the results of the underlying indexes into a single ranked
+// results list.
+type Fusion interface {
+	Fuse(ctx context.Context, results []*IndexResults, maxResults int) ([]*port.IndexSearchResult, error)
+}
+
+type FusionFunc func(ctx context.Context, results []*IndexResults, maxResults int) ([]*port.IndexSearchResult, error)
+
+func (fn FusionFunc) Fuse(ctx context.Context, results []*IndexResults, maxResults int) ([]*port.IndexSearchResult, error) {
+	return fn(ctx, results, maxResults)
+}
+
+const DefaultRRFK = 60
+
+// ReciprocalRankFusion scores each source and section with the sum, over
+// every index, of weight / (k + rank). Sections absent from an index do not
+// receive any contribution from it.
+type ReciprocalRankFusion struct {
+	k int
+}
+
+// Fuse implements Fusion.
+func (f *ReciprocalRankFusion) Fuse(ctx context.Context, indexResults []*IndexResults, maxResults int) ([]*port.IndexSearchResult, error) {
+	k := float64(f.k)
+
+	sourceScores := map[string]float64{}
+	sectionScores := map[string]map[model.SectionID]float64{}
+	ranks := map[model.SectionID]map[string]int{}
+
+	// Best score a section could achieve, i.e. being ranked first
+	// by every index
+	maxScore := 0.0
+
+	for _, r := range indexResults {
+		maxScore += r.Weight / (k + 1)
+
+		sectionRanks := rankSections(r.Results)
+		seen := map[model.SectionID]struct{}{}
+
+		for sourceRank, rr := range r.Results {
+			source := rr.Source.String()
+
+			sourceScores[source] += r.Weight / (k + float64(sourceRank+1))
+
+			if _, exists := sectionScores[source]; !exists {
+				sectionScores[source] = map[model.SectionID]float64{}
+			}
+
+			for _, sectionID := range rr.Sections {
+				if _, exists := seen[sectionID]; exists {
+					continue
+				}
+
+				seen[sectionID] = struct{}{}
+
+				rank := sectionRanks[sectionID]
+
+				sectionScores[source][sectionID] += r.Weight / (k + float64(rank))
+
+				setBestRank(ranks, sectionID, r.Index.ID(), rank)
+			}
+		}
+	}
+
+	merged, err := sortResults(sourceScores, sectionScores, ranks, maxScore)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if maxResults > 0 && len(merged) > maxResults {
+		merged = merged[:maxResults]
+	}
+
+	return merged, nil
+}
+
+func NewReciprocalRankFusion(k int) *ReciprocalRankFusion {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	return &ReciprocalRankFusion{
+		k: k,
+	}
+}
+
+var _ Fusion = &ReciprocalRankFusion{}
+
+// WeightedFusion is the legacy fusion strategy: each source gets a fixed
+// bonus per index returning it and each section a fixed bonus per index
+// returning it, both scaled by the index weight. Ranks are ignored.
+type WeightedFusion struct{}
+
+// Fuse implements Fusion.
+func (f *WeightedFusion) Fuse(ctx context.Context, indexResults []*IndexResults, maxResults int) ([]*port.IndexSearchResult, error) {
+	sourceScores := map[string]float64{}
+	sectionScores := map[string]map[model.SectionID]float64{}
+	ranks := map[model.SectionID]map[string]int{}
+
+	maxScore := 0.0
+
+	for _, r := range indexResults {
+		maxScore += r.Weight
+
+		for _, rr := range r.Results {
+			source := rr.Source.String()
+
+			if _, exists := sectionScores[source]; !exists {
+				sectionScores[source] = map[model.SectionID]float64{}
+			}
+
+			sourceScores[source] += 1.5 * r.Weight
+
+			for _, s := range rr.Sections {
+				sectionScores[source][s] += 1 * r.Weight
+			}
+		}
+
+		for sectionID, rank := range rankSections(r.Results) {
+			setBestRank(ranks, sectionID, r.Index.ID(), rank)
+		}
+	}
+
+	// Sources are ordered by their own score plus the sum of their sections
+	for source, sections := range sectionScores {
+		for _, score := range sections {
+			sourceScores[source] += score
+		}
+	}
+
+	merged, err := sortResults(sourceScores, sectionScores, ranks, maxScore)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if maxResults > 0 && len(merged) > maxResults {
+		merged = merged[:maxResults]
+	}
+
+	return merged, nil
+}
+
+func NewWeightedFusion() *WeightedFusion {
+	return &WeightedFusion{}
+}
+
+var _ Fusion = &WeightedFusion{}
+
+// setBestRank records the rank of the section in the given index, keeping the
+// best one when the index returned several results lists
+func setBestRank(ranks map[model.SectionID]map[string]int, sectionID model.SectionID, indexID string, rank int) {
+	if _, exists := ranks[sectionID]; !exists {
+		ranks[sectionID] = map[string]int{}
+	}
+
+	if existing, exists := ranks[sectionID][indexID]; exists && existing <= rank {
+		return
+	}
+
+	ranks[sectionID][indexID] = rank
+}
+
+// rankSections returns the 1-based rank of each section across the given
+// results. Sections are ordered by their score when the index provides one,
+// by order of appearance otherwise.
+func rankSections(results []*port.IndexSearchResult) map[model.SectionID]int {
+	type rankedSection struct {
+		ID       model.SectionID
+		Score    float64
+		Position int
+	}
+
+	sections := make([]rankedSection, 0)
+	seen := map[model.SectionID]struct{}{}
+
+	for _, r := range results {
+		for _, id := range r.Sections {
+			if _, exists := seen[id]; exists {
+				continue
+			}
+
+			seen[id] = struct{}{}
+
+			sections = append(sections, rankedSection{
+				ID:       id,
+				Score:    r.Score(id),
+				Position: len(sections),
+			})
+		}
+	}
+
+	slices.SortStableFunc(sections, func(s1, s2 rankedSection) int {
+		if s1.Score > s2.Score {
+			return -1
+		}
+		if s1.Score < s2.Score {
+			return 1
+		}
+		return s1.Position - s2.Position
+	})
+
+	ranks := make(map[model.SectionID]int, len(sections))
+	for idx, s := range sections {
+		ranks[s.ID] = idx + 1
+	}
+
+	return ranks
+}
+
+// sortResults orders sources and their sections by descending score and
+// normalizes section scores against maxScore.
+func sortResults(sourceScores map[string]float64, sectionScores map[string]map[model.SectionID]float64, ranks map[model.SectionID]map[string]int, maxScore float64) ([]*port.IndexSearchResult, error) {
+	sources := make([]string, 0, len(sourceScores))
+	for s := range sourceScores {
+		sources = append(sources, s)
+	}
+
+	slices.SortFunc(sources, func(s1, s2 string) int {
+		score1 := sourceScores[s1]
+		score2 := sourceScores[s2]
+		if score1 < score2 {
+			return 1
+		}
+		if score1 > score2 {
+			return -1
+		}
+		return strings.Compare(s1, s2)
+	})
+
+	merged := make([]*port.IndexSearchResult, 0, len(sources))
+
+	for _, rawSource := range sources {
+		source, err := url.Parse(rawSource)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		scores := sectionScores[rawSource]
+
+		sectionIDs := make([]model.SectionID, 0, len(scores))
+		for id := range scores {
+			sectionIDs = append(sectionIDs, id)
+		}
+
+		slices.SortFunc(sectionIDs, func(id1, id2 model.SectionID) int {
+			score1 := scores[id1]
+			score2 := scores[id2]
+			if score1 < score2 {
+				return 1
+			}
+			if score1 > score2 {
+				return -1
+			}
+			return strings.Compare(string(id1), string(id2))
+		})
+
+		result := &port.IndexSearchResult{
+			Source:   source,
+			Sections: sectionIDs,
+			Scores:   make(map[model.SectionID]float64, len(sectionIDs)),
+			Ranks:    make(map[model.SectionID]map[string]int, len(sectionIDs)),
+		}
+
+		for _, id := range sectionIDs {
+			if maxScore > 0 {
+				result.Scores[id] = min(scores[id]/maxScore, 1)
+			}
+
+			result.Ranks[id] = ranks[id]
+		}
+
+		merged = append(merged, result)
+	}
+
+	return merged, nil
+}
+
+// attachHighlights copies on the merged results the highlights of their
+// sections returned by the underlying indexes. Indexes are expected to be
+// sorted, the first index highlighting a section wins.
+func attachHighlights(merged []*port.IndexSearchResult, indexResults []*IndexResults) {
+	highlights := map[model.SectionID][]*port.IndexSearchHighlight{}
+
+	for _, r := range indexResults {
+		for _, rr := range r.Results {
+			for id, h := range rr.Highlights {
+				if _, exists := highlights[id]; !exists {
+					highlights[id] = h
+				}
+			}
+		}
+	}
+
+	if len(highlights) == 0 {
+		return
+	}
+
+	for _, r := range merged {
+		for _, id := range r.Sections {
+			h, exists := highlights[id]
+			if !exists {
+				continue
+			}
+
+			if r.Highlights == nil {
+				r.Highlights = map[model.SectionID][]*port.IndexSearchHighlight{}
+			}
+
+			r.Highlights[id] = h
+		}
+	}
+}
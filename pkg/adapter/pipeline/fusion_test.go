@@ -0,0 +1,174 @@
+package pipeline
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+func TestReciprocalRankFusion(t *testing.T) {
+	first, _ := url.Parse("https://example.net/first")
+	second, _ := url.Parse("https://example.net/second")
+
+	fullText := NewIdentifiedIndex("fulltext", &mockIndex{})
+	vector := NewIdentifiedIndex("vector", &mockIndex{})
+
+	results := []*IndexResults{
+		{
+			Index:  fullText,
+			Weight: 1,
+			Results: []*port.IndexSearchResult{
+				{
+					Source:   first,
+					Sections: []model.SectionID{"a", "b"},
+					Scores:   map[model.SectionID]float64{"a": 1, "b": 0.2},
+				},
+				{
+					Source:   second,
+					Sections: []model.SectionID{"c"},
+					Scores:   map[model.SectionID]float64{"c": 0.5},
+				},
+			},
+		},
+		{
+			Index:  vector,
+			Weight: 1,
+			Results: []*port.IndexSearchResult{
+				{
+					Source:   second,
+					Sections: []model.SectionID{"c"},
+					Scores:   map[model.SectionID]float64{"c": 0.9},
+				},
+				{
+					Source:   first,
+					Sections: []model.SectionID{"a"},
+					Scores:   map[model.SectionID]float64{"a": 0.8},
+				},
+			},
+		},
+	}
+
+	fusion := NewReciprocalRankFusion(DefaultRRFK)
+
+	merged, err := fusion.Fuse(context.Background(), results, 5)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 2, len(merged); e != g {
+		t.Fatalf("len(merged): expected %d, got %d", e, g)
+	}
+
+	// "first" is ranked 1st then 2nd, "second" 2nd then 1st: the tie is
+	// broken on the source URL
+	if e, g := first.String(), merged[0].Source.String(); e != g {
+		t.Errorf("merged[0].Source: expected %s, got %s", e, g)
+	}
+
+	if e, g := []model.SectionID{"a", "b"}, merged[0].Sections; !equalSections(e, g) {
+		t.Errorf("merged[0].Sections: expected %v, got %v", e, g)
+	}
+
+	// "a" is ranked 1st by the full-text index and 2nd by the vector index
+	if e, g := 1, merged[0].Ranks["a"]["fulltext"]; e != g {
+		t.Errorf("merged[0].Ranks[a][fulltext]: expected %d, got %d", e, g)
+	}
+
+	if e, g := 2, merged[0].Ranks["a"]["vector"]; e != g {
+		t.Errorf("merged[0].Ranks[a][vector]: expected %d, got %d", e, g)
+	}
+
+	for _, r := range merged {
+		for _, s := range r.Sections {
+			if score := r.Score(s); score <= 0 || score > 1 {
+				t.Errorf("r.Score(%s): expected score in ]0, 1], got %f", s, score)
+			}
+		}
+	}
+
+	// "b" is only returned by one index, at a lower rank
+	if merged[0].Score("b") >= merged[0].Score("a") {
+		t.Errorf("expected section 'b' to score lower than section 'a'")
+	}
+}
+
+func TestWeightedFusion(t *testing.T) {
+	first, _ := url.Parse("https://example.net/first")
+	second, _ := url.Parse("https://example.net/second")
+
+	results := []*IndexResults{
+		{
+			Index:  NewIdentifiedIndex("fulltext", &mockIndex{}),
+			Weight: 0.4,
+			Results: []*port.IndexSearchResult{
+				{Source: first, Sections: []model.SectionID{"a"}},
+			},
+		},
+		{
+			Index:  NewIdentifiedIndex("vector", &mockIndex{}),
+			Weight: 0.6,
+			Results: []*port.IndexSearchResult{
+				{Source: second, Sections: []model.SectionID{"c"}},
+			},
+		},
+	}
+
+	merged, err := NewWeightedFusion().Fuse(context.Background(), results, 5)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 2, len(merged); e != g {
+		t.Fatalf("len(merged): expected %d, got %d", e, g)
+	}
+
+	if e, g := second.String(), merged[0].Source.String(); e != g {
+		t.Errorf("merged[0].Source: expected %s, got %s", e, g)
+	}
+}
+
+func TestWeightedFusionMaxResults(t *testing.T) {
+	searchResults := make([]*port.IndexSearchResult, 0, 5)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		source, _ := url.Parse("https://example.net/" + name)
+		searchResults = append(searchResults, &port.IndexSearchResult{
+			Source:   source,
+			Sections: []model.SectionID{model.SectionID(name)},
+		})
+	}
+
+	results := []*IndexResults{
+		{
+			Index:   NewIdentifiedIndex("fulltext", &mockIndex{}),
+			Weight:  1,
+			Results: searchResults,
+		},
+	}
+
+	merged, err := NewWeightedFusion().Fuse(context.Background(), results, 3)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 3, len(merged); e != g {
+		t.Errorf("len(merged): expected %d, got %d", e, g)
+	}
+}
+
+func equalSections(s1, s2 []model.SectionID) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -20,7 +20,10 @@ type WeightedIndexes map[*IdentifiedIndex]float64
 
 type Index struct {
 	queryTransformers   []QueryTransformer
+	queryExpanders      []QueryExpander
 	resultsTransformers []ResultsTransformer
+	fusion              Fusion
+	contextualizer      Contextualizer
 	indexes             WeightedIndexes
 }
 
@@ -85,11 +88,6 @@ func (i *Index) DeleteByID(ctx context.Context, ids ...model.SectionID) error {
 	return nil
 }
 
-type indexSearchResults struct {
-	Results []*port.IndexSearchResult
-	Index   *IdentifiedIndex
-}
-
 // DeleteBySource implements port.Index.
 func (i *Index) DeleteBySource(ctx context.Context, source *url.URL) error {
 	count := len(i.indexes)
@@ -151,6 +149,17 @@ func (i *Index) DeleteBySource(ctx context.Context, source *url.URL) error {
 func (i *Index) Index(ctx context.Context, document model.Document, funcs ...port.IndexOptionFunc) error {
 	opts := port.NewIndexOptions(funcs...)
 
+	// The sections contexts are computed once and shared by all the
+	// underlying indexes
+	if opts.Contexts == nil {
+		contexts, err := i.Contextualize(ctx, document)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		opts.Contexts = contexts
+	}
+
 	var progress syncx.Map[port.Index, float32]
 
 	count := len(i.indexes)
@@ -189,6 +198,14 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 
 			indexOptions := []port.IndexOptionFunc{}
 
+			if opts.Sections != nil {
+				indexOptions = append(indexOptions, port.WithIndexSections(opts.Sections...))
+			}
+
+			if opts.Contexts != nil {
+				indexOptions = append(indexOptions, port.WithIndexContexts(opts.Contexts))
+			}
+
 			if opts.OnProgress != nil {
 				indexOptions = append(indexOptions, port.WithIndexOnProgress(func(p float32) {
 					progress.Store(index.Index(), p)
@@ -242,17 +259,45 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 	return nil
 }
 
+// Contextualize implements port.ContextualIndex.
+func (i *Index) Contextualize(ctx context.Context, document model.Document) (map[model.SectionID]string, error) {
+	if i.contextualizer == nil {
+		return nil, nil
+	}
+
+	contexts, err := i.contextualizer.Contextualize(ctx, document)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not contextualize document sections")
+	}
+
+	return contexts, nil
+}
+
 // Search implements port.Index.
 func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error) {
-	query, err := i.transformQuery(ctx, query, opts)
+	alternatives, err := i.expandQuery(ctx, query, opts)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	count := len(i.indexes)
+	query, err = i.transformQuery(ctx, query, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	queries := []string{query}
+	for _, q := range alternatives {
+		if slices.Contains(queries, q) {
+			continue
+		}
+
+		queries = append(queries, q)
+	}
+
+	count := len(i.indexes) * len(queries)
 
 	type Message struct {
-		Results *indexSearchResults
+		Results *IndexResults
 		Err     error
 	}
 
@@ -275,48 +320,55 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 
 	aggregatedErr := NewAggregatedError()
 
-	for index := range i.indexes {
-		go func(index *IdentifiedIndex) {
-			defer func() {
-				if r := recover(); r != nil {
-					if err, ok := r.(error); ok {
-						aggregatedErr.Add(errors.WithStack(err))
-					} else {
-						panic(r)
+	for queryIdx, q := range queries {
+		for index := range i.indexes {
+			go func(index *IdentifiedIndex, queryIdx int, q string) {
+				defer func() {
+					if r := recover(); r != nil {
+						if err, ok := r.(error); ok {
+							aggregatedErr.Add(errors.WithStack(err))
+						} else {
+							panic(r)
+						}
 					}
+				}()
+				defer wg.Done()
+
+				indexCtx := slogx.WithAttrs(ctx, slog.String("index_type", fmt.Sprintf("%T", index.Index())), slog.Int("query", queryIdx))
+
+				results, err := index.Index().Search(indexCtx, q, port.IndexSearchOptions{
+					MaxResults:  maxResults * 2,
+					Collections: collections,
+					Filter:      opts.Filter,
+					Highlight:   opts.Highlight,
+					Boost:       opts.Boost,
+				})
+				if err != nil {
+					err = errors.WithStack(err)
+					slog.ErrorContext(indexCtx, "could not search documents", slog.Any("error", err))
+					messages <- &Message{
+						Err: err,
+					}
+					return
 				}
-			}()
-			defer wg.Done()
 
-			indexCtx := slogx.WithAttrs(ctx, slog.String("index_type", fmt.Sprintf("%T", index.Index())))
+				slog.DebugContext(indexCtx, "found documents", slog.Int("total", len(results)))
 
-			results, err := index.Index().Search(indexCtx, query, port.IndexSearchOptions{
-				MaxResults:  maxResults * 2,
-				Collections: collections,
-			})
-			if err != nil {
-				err = errors.WithStack(err)
-				slog.ErrorContext(indexCtx, "could not search documents", slog.Any("error", err))
 				messages <- &Message{
-					Err: err,
+					Results: &IndexResults{
+						Index:   index,
+						Query:   queryIdx,
+						Weight:  i.indexes[index],
+						Results: results,
+					},
 				}
-				return
-			}
-
-			slog.DebugContext(indexCtx, "found documents", slog.Int("total", len(results)))
-
-			messages <- &Message{
-				Results: &indexSearchResults{
-					Results: results,
-					Index:   index,
-				},
-			}
-		}(index)
+			}(index, queryIdx, q)
+		}
 	}
 
 	wg.Wait()
 
-	results := make([]*indexSearchResults, 0)
+	results := make([]*IndexResults, 0)
 
 	idx := 0
 
@@ -340,11 +392,24 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 		return nil, errors.WithStack(aggregatedErr.OrOnlyOne())
 	}
 
-	merged, err := i.mergeResults(results, maxResults)
+	// Sort by query and index identifier to keep the fusion deterministic
+	slices.SortFunc(results, func(r1, r2 *IndexResults) int {
+		if r1.Query != r2.Query {
+			return r1.Query - r2.Query
+		}
+
+		return strings.Compare(r1.Index.ID(), r2.Index.ID())
+	})
+
+	merged, err := i.fusion.Fuse(ctx, results, maxResults)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	if opts.Highlight {
+		attachHighlights(merged, results)
+	}
+
 	transformed, err := i.transformResults(ctx, query, merged, opts)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -365,132 +430,59 @@ func (i *Index) transformQuery(ctx context.Context, query string, opts port.Inde
 	return query, nil
 }
 
-func (i *Index) transformResults(ctx context.Context, query string, results []*port.IndexSearchResult, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error) {
-	var err error
-	for _, t := range i.resultsTransformers {
-		results, err = t.TransformResults(ctx, query, results, opts)
+// expandQuery returns the alternative queries derived from the original query
+// by the expanders
+func (i *Index) expandQuery(ctx context.Context, query string, opts port.IndexSearchOptions) ([]string, error) {
+	alternatives := make([]string, 0)
+
+	for _, e := range i.queryExpanders {
+		expanded, err := e.ExpandQuery(ctx, query, opts)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
 
-		if emptyResults(results) {
-			return []*port.IndexSearchResult{}, nil
-		}
-	}
-
-	return results, nil
-}
-
-func (i *Index) mergeResults(indexResults []*indexSearchResults, maxResults int) ([]*port.IndexSearchResult, error) {
-	type scoreItem struct {
-		Score    float64
-		Sections map[model.SectionID]float64
-	}
-
-	scores := map[string]scoreItem{}
-
-	for _, r := range indexResults {
-		for _, rr := range r.Results {
-			source := rr.Source.String()
-
-			var resultScore scoreItem
-			if _, exists := scores[source]; !exists {
-				scores[source] = scoreItem{
-					Score:    0,
-					Sections: make(map[model.SectionID]float64),
-				}
-			}
-
-			resultScore = scores[source]
-			resultScore.Score += 1.5 * i.indexes[r.Index]
-
-			for _, s := range rr.Sections {
-				if _, exists := resultScore.Sections[s]; !exists {
-					resultScore.Sections[s] = 0
-				}
-
-				resultScore.Sections[s] += 1 * i.indexes[r.Index]
+		for _, q := range expanded {
+			q = strings.TrimSpace(q)
+			if q == "" || slices.Contains(alternatives, q) {
+				continue
 			}
 
-			scores[source] = resultScore
+			alternatives = append(alternatives, q)
 		}
 	}
 
-	sources := []string{}
-	for s := range scores {
-		sources = append(sources, s)
-	}
-
-	slices.SortFunc(sources, func(s1, s2 string) int {
-		score1 := scores[s1].Score
-		for _, v := range scores[s1].Sections {
-			score1 += v
-		}
-
-		score2 := scores[s2].Score
-		for _, v := range scores[s2].Sections {
-			score2 += v
-		}
-
-		if score1 < score2 {
-			return 1
-		}
-		if score1 > score2 {
-			return -1
-		}
-		return strings.Compare(s1, s2)
-	})
-
-	merged := make([]*port.IndexSearchResult, 0)
+	return alternatives, nil
+}
 
-	for _, rawSource := range sources {
-		source, err := url.Parse(rawSource)
+func (i *Index) transformResults(ctx context.Context, query string, results []*port.IndexSearchResult, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error) {
+	var err error
+	for _, t := range i.resultsTransformers {
+		results, err = t.TransformResults(ctx, query, results, opts)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
 
-		sectionScores := scores[rawSource].Sections
-
-		sectionIDs := []model.SectionID{}
-		for id := range sectionScores {
-			sectionIDs = append(sectionIDs, id)
+		if emptyResults(results) {
+			return []*port.IndexSearchResult{}, nil
 		}
-
-		slices.SortFunc(sectionIDs, func(id1, id2 model.SectionID) int {
-			score1 := sectionScores[id1]
-			score2 := sectionScores[id2]
-			if score1 < score2 {
-				return 1
-			}
-			if score1 > score2 {
-				return -1
-			}
-			return strings.Compare(string(id1), string(id2))
-		})
-
-		merged = append(merged, &port.IndexSearchResult{
-			Source:   source,
-			Sections: sectionIDs,
-		})
 	}
 
-	if len(merged) > maxResults && maxResults > 1 {
-		merged = merged[0 : maxResults-1]
-	}
-
-	return merged, nil
+	return results, nil
 }
 
 func NewIndex(indexes WeightedIndexes, funcs ...OptionFunc) *Index {
 	opts := NewOptions(funcs...)
 	return &Index{
 		queryTransformers:   opts.QueryTransformers,
+		queryExpanders:      opts.QueryExpanders,
 		resultsTransformers: opts.ResultsTransformers,
+		fusion:              opts.Fusion,
+		contextualizer:      opts.Contextualizer,
 		indexes:             indexes,
 	}
 }
 
-var _ port.Index = &Index{}
+var _ port.ContextualIndex = &Index{}
 
 func emptyResults(results []*port.IndexSearchResult) bool {
 	if len(results) == 0 {
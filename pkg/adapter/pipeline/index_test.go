@@ -3,6 +3,8 @@ package pipeline
 import (
 	"context"
 	"net/url"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -38,8 +40,93 @@ func TestIndex(t *testing.T) {
 	}
 }
 
+func TestIndexes(t *testing.T) {
+	first := &mockIndex{}
+	second := &mockIndex{}
+
+	index := NewIndex(
+		WeightedIndexes{
+			NewIdentifiedIndex("first", first):   1,
+			NewIdentifiedIndex("second", second): 1,
+		},
+	)
+
+	indexes := index.Indexes()
+
+	if e, g := 2, len(indexes); e != g {
+		t.Fatalf("len(indexes): expected %d, got %d", e, g)
+	}
+
+	if indexes["first"] != first {
+		t.Errorf("indexes[\"first\"]: expected the first index")
+	}
+
+	if indexes["second"] != second {
+		t.Errorf("indexes[\"second\"]: expected the second index")
+	}
+}
+
+func TestSearchQueryExpansion(t *testing.T) {
+	a, _ := url.Parse("https://example.net/a")
+	b, _ := url.Parse("https://example.net/b")
+
+	first := &mockIndex{
+		results: map[string][]*port.IndexSearchResult{
+			"original":    {{Source: a, Sections: []model.SectionID{"a1"}}},
+			"alternative": {{Source: b, Sections: []model.SectionID{"b1"}}, {Source: a, Sections: []model.SectionID{"a1"}}},
+		},
+	}
+
+	second := &mockIndex{
+		results: map[string][]*port.IndexSearchResult{
+			"alternative": {{Source: b, Sections: []model.SectionID{"b1"}}},
+		},
+	}
+
+	index := NewIndex(
+		WeightedIndexes{
+			NewIdentifiedIndex("first", first):   1,
+			NewIdentifiedIndex("second", second): 1,
+		},
+		WithQueryExpanders(QueryExpanderFunc(func(ctx context.Context, query string, opts port.IndexSearchOptions) ([]string, error) {
+			return []string{"alternative", query, " ", "alternative"}, nil
+		})),
+	)
+
+	results, err := index.Search(context.Background(), "original", port.IndexSearchOptions{})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	// Duplicated and empty alternatives are searched only once, or not at all
+	for _, m := range []*mockIndex{first, second} {
+		queries := slices.Sorted(slices.Values(m.queries))
+		if e, g := []string{"alternative", "original"}, queries; !slices.Equal(e, g) {
+			t.Errorf("searched queries: expected %v, got %v", e, g)
+		}
+	}
+
+	// "b" is only returned for the alternative query, by both indexes
+	if e, g := 2, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	if e, g := b.String(), results[0].Source.String(); e != g {
+		t.Errorf("results[0].Source: expected '%s', got '%s'", e, g)
+	}
+
+	// The best rank over the queries is kept
+	if e, g := 1, results[1].Ranks["a1"]["first"]; e != g {
+		t.Errorf("results[1].Ranks[a1][first]: expected %d, got %d", e, g)
+	}
+}
+
 type mockIndex struct {
 	indexErr error
+	results  map[string][]*port.IndexSearchResult
+
+	mutex   sync.Mutex
+	queries []string
 }
 
 // All implements port.Index.
@@ -64,6 +151,15 @@ func (m *mockIndex) Index(ctx context.Context, document model.Document, funcs ..
 
 // Search implements port.Index.
 func (m *mockIndex) Search(ctx context.Context, query string, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.queries = append(m.queries, query)
+
+	if results, exists := m.results[query]; exists {
+		return results, nil
+	}
+
 	return []*port.IndexSearchResult{}, nil
 }
 
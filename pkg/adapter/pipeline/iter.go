@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
 	"github.com/pkg/errors"
 )
 
@@ -16,3 +17,14 @@ func (i *Index) All(ctx context.Context, yield func(model.SectionID) bool) error
 	}
 	return nil
 }
+
+// Indexes implements port.CompositeIndex.
+func (i *Index) Indexes() map[string]port.Index {
+	indexes := make(map[string]port.Index, len(i.indexes))
+	for index := range i.indexes {
+		indexes[index.ID()] = index.Index()
+	}
+	return indexes
+}
+
+var _ port.CompositeIndex = &Index{}
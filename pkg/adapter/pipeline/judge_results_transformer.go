@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/bornholm/corpus/pkg/model"
@@ -27,7 +28,7 @@ You are a document retrieval relevance judge.
 
 ## Input
 - **Query**: The user's search query
-- **Documents**: A list of documents, each with an identifier and content
+- **Documents**: A list of documents, each with an identifier, a retrieval score between 0 and 1 (when available) and content
 
 ## Task
 For each document, assess:
@@ -35,6 +36,8 @@ For each document, assess:
 2. Does it provide necessary context or supporting details?
 3. Would including it improve a final synthesized answer?
 
+The retrieval score only reflects how well the search engine matched the document. Use it as a hint, never as a substitute for reading the content.
+
 Rate each document as RELEVANT or NOT RELEVANT.
 
 ## Output Format (strict JSON, no markdown fencing)
@@ -191,6 +194,12 @@ func (t *JudgeResultsTransformer) getUserPrompt(ctx context.Context, query strin
 			sb.WriteString(string(section.ID()))
 			sb.WriteString("\n\n")
 
+			if score, exists := r.Scores[s]; exists {
+				sb.WriteString("**Retrieval score:** ")
+				sb.WriteString(strconv.FormatFloat(score, 'f', 2, 64))
+				sb.WriteString("\n\n")
+			}
+
 			content, err := section.Content()
 			if err != nil {
 				return "", errors.WithStack(err)
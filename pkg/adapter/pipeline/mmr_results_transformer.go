@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultMMRLambda        = 0.7
+	DefaultMMRMaxSimilarity = 0.95
+)
+
+// MMRResultsTransformer reorders the results sections with Maximal Marginal
+// Relevance: each section is selected in turn for the best trade-off between
+// its relevance and its novelty, i.e. its dissimilarity with the sections
+// already selected. Sections too similar to an already selected one are
+// discarded. Similarities are computed on the sections embeddings.
+type MMRResultsTransformer struct {
+	embeddings port.EmbeddingsIndex
+	// lambda weights the relevance against the novelty, 1 ignoring the
+	// novelty and 0 the relevance
+	lambda float64
+	// maxSimilarity is the similarity above which a section is considered
+	// redundant with an already selected one
+	maxSimilarity float64
+}
+
+type mmrCandidate struct {
+	result    int
+	section   model.SectionID
+	relevance float64
+	embedding []float32
+}
+
+// TransformResults implements ResultsTransformer.
+func (t *MMRResultsTransformer) TransformResults(ctx context.Context, query string, results []*port.IndexSearchResult, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error) {
+	candidates := make([]*mmrCandidate, 0)
+	sectionIDs := make([]model.SectionID, 0)
+
+	for resultIdx, r := range results {
+		for _, sectionID := range r.Sections {
+			candidates = append(candidates, &mmrCandidate{
+				result:  resultIdx,
+				section: sectionID,
+			})
+			sectionIDs = append(sectionIDs, sectionID)
+		}
+	}
+
+	if len(candidates) < 2 {
+		return results, nil
+	}
+
+	embeddings, err := t.embeddings.SectionEmbeddings(ctx, sectionIDs...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for idx, c := range candidates {
+		c.embedding = embeddings[c.section]
+
+		// Unscored sections are ranked by their position in the results
+		if score, exists := results[c.result].Scores[c.section]; exists {
+			c.relevance = score
+		} else {
+			c.relevance = 1 - float64(idx)/float64(len(candidates))
+		}
+	}
+
+	selected := make([]*mmrCandidate, 0, len(candidates))
+	remaining := candidates
+
+	for len(remaining) > 0 {
+		bestIdx := -1
+		bestScore := math.Inf(-1)
+		bestSimilarity := 0.0
+
+		for idx, c := range remaining {
+			similarity := 0.0
+			for _, s := range selected {
+				similarity = math.Max(similarity, cosineSimilarity(c.embedding, s.embedding))
+			}
+
+			score := t.lambda*c.relevance - (1-t.lambda)*similarity
+			if score > bestScore {
+				bestIdx, bestScore, bestSimilarity = idx, score, similarity
+			}
+		}
+
+		best := remaining[bestIdx]
+		remaining = slices.Delete(remaining, bestIdx, bestIdx+1)
+
+		if bestSimilarity >= t.maxSimilarity {
+			continue
+		}
+
+		selected = append(selected, best)
+	}
+
+	// Results are ordered by their first selected section
+	transformed := make([]*port.IndexSearchResult, 0, len(results))
+	byResult := map[int]*port.IndexSearchResult{}
+
+	for _, c := range selected {
+		updated, exists := byResult[c.result]
+		if !exists {
+			r := results[c.result]
+			updated = &port.IndexSearchResult{
+				Source:     r.Source,
+				Sections:   make([]model.SectionID, 0),
+				Scores:     r.Scores,
+				Ranks:      r.Ranks,
+				Highlights: r.Highlights,
+			}
+			byResult[c.result] = updated
+			transformed = append(transformed, updated)
+		}
+
+		updated.Sections = append(updated.Sections, c.section)
+	}
+
+	return transformed, nil
+}
+
+// NewMMRResultsTransformer creates a Maximal Marginal Relevance results
+// transformer. A lambda outside [0, 1] or a non-positive maxSimilarity falls
+// back to the default value.
+func NewMMRResultsTransformer(embeddings port.EmbeddingsIndex, lambda float64, maxSimilarity float64) *MMRResultsTransformer {
+	if lambda < 0 || lambda > 1 {
+		lambda = DefaultMMRLambda
+	}
+
+	if maxSimilarity <= 0 {
+		maxSimilarity = DefaultMMRMaxSimilarity
+	}
+
+	return &MMRResultsTransformer{
+		embeddings:    embeddings,
+		lambda:        lambda,
+		maxSimilarity: maxSimilarity,
+	}
+}
+
+var _ ResultsTransformer = &MMRResultsTransformer{}
+
+// cosineSimilarity returns the cosine similarity of two normalized vectors,
+// 0 if one of them is missing
+func cosineSimilarity(v1, v2 []float32) float64 {
+	if len(v1) == 0 || len(v1) != len(v2) {
+		return 0
+	}
+
+	var dot float64
+	for idx := range v1 {
+		dot += float64(v1[idx]) * float64(v2[idx])
+	}
+
+	return dot
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/pkg/errors"
+)
+
+type mockEmbeddingsIndex map[model.SectionID][]float32
+
+// SectionEmbeddings implements port.EmbeddingsIndex.
+func (m mockEmbeddingsIndex) SectionEmbeddings(ctx context.Context, ids ...model.SectionID) (map[model.SectionID][]float32, error) {
+	embeddings := map[model.SectionID][]float32{}
+	for _, id := range ids {
+		if e, exists := m[id]; exists {
+			embeddings[id] = e
+		}
+	}
+	return embeddings, nil
+}
+
+func TestMMRResultsTransformer(t *testing.T) {
+	v1, _ := url.Parse("https://example.net/docs/v1")
+	v2, _ := url.Parse("https://example.net/docs/v2")
+	mirror, _ := url.Parse("https://mirror.example.net/docs/v1")
+	other, _ := url.Parse("https://example.net/other")
+
+	embeddings := mockEmbeddingsIndex{
+		"v1-install":     {1, 0, 0},
+		"v2-install":     {0.9, 0.43588989, 0},
+		"mirror-install": {1, 0, 0},
+		"other":          {0, 0, 1},
+	}
+
+	results := []*port.IndexSearchResult{
+		{
+			Source:   v1,
+			Sections: []model.SectionID{"v1-install"},
+			Scores:   map[model.SectionID]float64{"v1-install": 1},
+		},
+		{
+			Source:   v2,
+			Sections: []model.SectionID{"v2-install"},
+			Scores:   map[model.SectionID]float64{"v2-install": 0.95},
+		},
+		{
+			Source:   mirror,
+			Sections: []model.SectionID{"mirror-install"},
+			Scores:   map[model.SectionID]float64{"mirror-install": 0.9},
+		},
+		{
+			Source:   other,
+			Sections: []model.SectionID{"other", "unembedded"},
+			Scores:   map[model.SectionID]float64{"other": 0.5, "unembedded": 0.1},
+		},
+	}
+
+	// The mirrored section, identical to an already selected one, is always
+	// discarded
+	expected := map[string][]model.SectionID{
+		v1.String():    {"v1-install"},
+		v2.String():    {"v2-install"},
+		other.String(): {"other", "unembedded"},
+	}
+
+	tests := []struct {
+		name   string
+		lambda float64
+		order  []string
+	}{
+		{
+			name:   "RelevanceOnly",
+			lambda: 1,
+			order:  []string{v1.String(), v2.String(), other.String()},
+		},
+		{
+			name:   "NoveltyPromotesDissimilarSections",
+			lambda: 0.3,
+			order:  []string{v1.String(), other.String(), v2.String()},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transformer := NewMMRResultsTransformer(embeddings, tc.lambda, 0.95)
+
+			transformed, err := transformer.TransformResults(context.Background(), "install", results, port.IndexSearchOptions{})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			order := make([]string, 0, len(transformed))
+			for _, r := range transformed {
+				order = append(order, r.Source.String())
+
+				if e, g := expected[r.Source.String()], r.Sections; !slices.Equal(e, g) {
+					t.Errorf("%s sections: expected %v, got %v", r.Source, e, g)
+				}
+			}
+
+			if e, g := tc.order, order; !slices.Equal(e, g) {
+				t.Errorf("sources: expected %v, got %v", e, g)
+			}
+		})
+	}
+}
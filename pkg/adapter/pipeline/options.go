@@ -3,6 +3,7 @@ package pipeline
 import (
 	"context"
 
+	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
 )
 
@@ -16,6 +17,19 @@ func (fn QueryTransformerFunc) TransformQuery(ctx context.Context, query string,
 	return fn(ctx, query, opts)
 }
 
+// QueryExpander derives alternative queries from the search query. The query
+// and all its alternatives are searched on every underlying index, and all the
+// results lists are fused together.
+type QueryExpander interface {
+	ExpandQuery(ctx context.Context, query string, opts port.IndexSearchOptions) ([]string, error)
+}
+
+type QueryExpanderFunc func(ctx context.Context, query string, opts port.IndexSearchOptions) ([]string, error)
+
+func (fn QueryExpanderFunc) ExpandQuery(ctx context.Context, query string, opts port.IndexSearchOptions) ([]string, error) {
+	return fn(ctx, query, opts)
+}
+
 type ResultsTransformer interface {
 	TransformResults(ctx context.Context, query string, results []*port.IndexSearchResult, opts port.IndexSearchOptions) ([]*port.IndexSearchResult, error)
 }
@@ -26,9 +40,24 @@ func (fn ResultsTransformerFunc) TransformResults(ctx context.Context, query str
 	return fn(ctx, query, results, opts)
 }
 
+// Contextualizer computes, for each section of an indexed document, the text
+// situating it in the document, indexed along with the section content.
+type Contextualizer interface {
+	Contextualize(ctx context.Context, document model.Document) (map[model.SectionID]string, error)
+}
+
+type ContextualizerFunc func(ctx context.Context, document model.Document) (map[model.SectionID]string, error)
+
+func (fn ContextualizerFunc) Contextualize(ctx context.Context, document model.Document) (map[model.SectionID]string, error) {
+	return fn(ctx, document)
+}
+
 type Options struct {
 	QueryTransformers   []QueryTransformer
+	QueryExpanders      []QueryExpander
 	ResultsTransformers []ResultsTransformer
+	Fusion              Fusion
+	Contextualizer      Contextualizer
 }
 
 type OptionFunc func(opts *Options)
@@ -36,7 +65,9 @@ type OptionFunc func(opts *Options)
 func NewOptions(funcs ...OptionFunc) *Options {
 	opts := &Options{
 		QueryTransformers:   make([]QueryTransformer, 0),
+		QueryExpanders:      make([]QueryExpander, 0),
 		ResultsTransformers: make([]ResultsTransformer, 0),
+		Fusion:              NewReciprocalRankFusion(DefaultRRFK),
 	}
 
 	for _, fn := range funcs {
@@ -52,8 +83,32 @@ func WithQueryTransformers(transformers ...QueryTransformer) OptionFunc {
 	}
 }
 
+// WithQueryExpanders sets the expanders deriving alternative queries from the
+// original one, i.e. before any query transformation.
+func WithQueryExpanders(expanders ...QueryExpander) OptionFunc {
+	return func(opts *Options) {
+		opts.QueryExpanders = expanders
+	}
+}
+
 func WithResultsTransformers(transformers ...ResultsTransformer) OptionFunc {
 	return func(opts *Options) {
 		opts.ResultsTransformers = transformers
 	}
 }
+
+// WithFusion sets the strategy used to merge the results of the underlying
+// indexes. Reciprocal Rank Fusion is used by default.
+func WithFusion(fusion Fusion) OptionFunc {
+	return func(opts *Options) {
+		opts.Fusion = fusion
+	}
+}
+
+// WithContextualizer sets the contextualizer of the indexed sections, nil
+// indexing the sections content only.
+func WithContextualizer(contextualizer Contextualizer) OptionFunc {
+	return func(opts *Options) {
+		opts.Contextualizer = contextualizer
+	}
+}
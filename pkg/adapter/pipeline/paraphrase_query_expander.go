@@ -0,0 +1,156 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/bornholm/corpus/internal/text"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
+	"github.com/bornholm/genai/llm/prompt"
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+const DefaultParaphrases = 3
+
+const defaultParaphrasePromptTemplate = `
+You are a search query rewriting assistant for a documents retrieval system.
+
+Rewrite the user's query into at most {{ .Paraphrases }} alternative search queries.
+Each alternative must keep the meaning of the original query while using different
+wording: synonyms, rephrasing, more specific or more generic terms, or the main
+keywords only. Keep the language of the original query. Do not answer the query.
+
+## Output Format (strict JSON, no markdown fencing)
+{"queries": ["alternative query 1", "alternative query 2"]}
+`
+
+// ParaphraseQueryExpander asks the LLM for alternative wordings of the query,
+// improving the recall of documents which do not use the query's terms.
+type ParaphraseQueryExpander struct {
+	llm         llm.Client
+	paraphrases int
+}
+
+// ExpandQuery implements QueryExpander.
+func (e *ParaphraseQueryExpander) ExpandQuery(ctx context.Context, query string, opts port.IndexSearchOptions) ([]string, error) {
+	systemPrompt, err := prompt.Template(defaultParaphrasePromptTemplate, struct {
+		Paraphrases int
+	}{
+		Paraphrases: e.paraphrases,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	seed, err := text.IntHash(systemPrompt + query)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ctx = slogx.WithAttrs(ctx, slog.Int("seed", seed))
+
+	completion, err := e.llm.ChatCompletion(ctx,
+		llm.WithJSONResponse(
+			llm.NewResponseSchema(
+				"Paraphrases",
+				"The alternative search queries",
+				map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"queries": map[string]any{
+							"type":        "array",
+							"description": "Alternative search queries",
+							"items":       map[string]any{"type": "string"},
+						},
+					},
+					"required":             []string{"queries"},
+					"additionalProperties": false,
+				},
+			),
+		),
+		llm.WithMessages(
+			llm.NewMessage(llm.RoleSystem, systemPrompt),
+			llm.NewMessage(llm.RoleUser, query),
+		),
+		llm.WithTemperature(0.2),
+		llm.WithSeed(seed),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	type llmResponse struct {
+		Queries []string `json:"queries"`
+	}
+
+	responses, err := llm.ParseJSON[llmResponse](completion.Message())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	paraphrases := make([]string, 0, e.paraphrases)
+	for _, r := range responses {
+		for _, q := range r.Queries {
+			q = strings.TrimSpace(q)
+			if q == "" || strings.EqualFold(q, query) {
+				continue
+			}
+
+			paraphrases = append(paraphrases, q)
+			if len(paraphrases) >= e.paraphrases {
+				break
+			}
+		}
+
+		if len(paraphrases) >= e.paraphrases {
+			break
+		}
+	}
+
+	slog.DebugContext(ctx, "generated query paraphrases", slog.Any("paraphrases", paraphrases))
+
+	return paraphrases, nil
+}
+
+// NewParaphraseQueryExpander creates a query expander generating at most the
+// given number of paraphrases (DefaultParaphrases when <= 0).
+func NewParaphraseQueryExpander(client llm.Client, paraphrases int) *ParaphraseQueryExpander {
+	if paraphrases <= 0 {
+		paraphrases = DefaultParaphrases
+	}
+
+	return &ParaphraseQueryExpander{
+		llm:         client,
+		paraphrases: paraphrases,
+	}
+}
+
+var _ QueryExpander = &ParaphraseQueryExpander{}
+
+// TransformerQueryExpander uses the query produced by a query transformer as
+// an alternative query, the original one being searched too. For example, the
+// HyDE transformer can then complete the query instead of replacing it.
+type TransformerQueryExpander struct {
+	transformer QueryTransformer
+}
+
+// ExpandQuery implements QueryExpander.
+func (e *TransformerQueryExpander) ExpandQuery(ctx context.Context, query string, opts port.IndexSearchOptions) ([]string, error) {
+	transformed, err := e.transformer.TransformQuery(ctx, query, opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return []string{transformed}, nil
+}
+
+func NewTransformerQueryExpander(transformer QueryTransformer) *TransformerQueryExpander {
+	return &TransformerQueryExpander{
+		transformer: transformer,
+	}
+}
+
+var _ QueryExpander = &TransformerQueryExpander{}
@@ -0,0 +1,196 @@
+package sqlitevec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bornholm/corpus/internal/metrics"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+
+	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/ncruces"
+)
+
+// embeddingsCacheKey returns the cache key of the given text embedded by the
+// given model. The text is normalized so that whitespace only changes do not
+// invalidate its embeddings.
+func embeddingsCacheKey(model string, text string) string {
+	hash := sha256.New()
+	hash.Write([]byte(model))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// getCachedEmbeddings returns the cached embeddings of the given texts, nil
+// for the ones missing from the cache.
+func (i *Index) getCachedEmbeddings(conn *sqlite3.Conn, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+
+	if i.cacheSize <= 0 {
+		return embeddings, nil
+	}
+
+	stmt, _, err := conn.Prepare("SELECT embedding FROM embeddings_cache WHERE key = ?;")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	touchStmt, _, err := conn.Prepare("UPDATE embeddings_cache SET used_at = ? WHERE key = ?;")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer touchStmt.Close()
+
+	now := time.Now().UnixMilli()
+	hits := 0
+
+	for idx, text := range texts {
+		key := embeddingsCacheKey(i.model, text)
+
+		if err := stmt.BindText(1, key); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if stmt.Step() {
+			embeddings[idx] = deserializeFloat32(stmt.ColumnRawBlob(0))
+		}
+
+		if err := stmt.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if err := stmt.Reset(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if embeddings[idx] == nil {
+			continue
+		}
+
+		hits++
+
+		if _, err := bindArgs(touchStmt, 1, now, key); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if err := touchStmt.Exec(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if err := touchStmt.Reset(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	metrics.EmbeddingsCacheHits.With(map[string]string{metrics.LabelModel: i.model}).Add(float64(hits))
+	metrics.EmbeddingsCacheMisses.With(map[string]string{metrics.LabelModel: i.model}).Add(float64(len(texts) - hits))
+
+	return embeddings, nil
+}
+
+// cacheEmbeddings stores the embeddings of the given texts
+func (i *Index) cacheEmbeddings(conn *sqlite3.Conn, texts []string, embeddings [][]float64) error {
+	if i.cacheSize <= 0 {
+		return nil
+	}
+
+	stmt, _, err := conn.Prepare(`
+		INSERT INTO embeddings_cache (key, model, embedding, used_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET embedding = excluded.embedding, used_at = excluded.used_at;
+	`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	now := time.Now().UnixMilli()
+
+	for idx, text := range texts {
+		blob, err := sqlite_vec.SerializeFloat32(toFloat32(embeddings[idx]))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if _, err := bindArgs(stmt, 1, embeddingsCacheKey(i.model, text), i.model, blob, now); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := stmt.Exec(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := stmt.Reset(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// evictEmbeddings removes the least recently used entries exceeding the
+// cache size.
+func (i *Index) evictEmbeddings(conn *sqlite3.Conn) error {
+	if i.cacheSize <= 0 {
+		return nil
+	}
+
+	stmt, _, err := conn.Prepare(`
+		DELETE FROM embeddings_cache WHERE key IN (
+			SELECT key FROM embeddings_cache
+			ORDER BY used_at ASC
+			LIMIT max(0, (SELECT COUNT(*) FROM embeddings_cache) - ?)
+		);
+	`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	if err := stmt.BindInt(1, i.cacheSize); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := stmt.Exec(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	metrics.EmbeddingsCacheEvictions.Add(float64(conn.Changes()))
+
+	countStmt, _, err := conn.Prepare("SELECT COUNT(*) FROM embeddings_cache;")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer countStmt.Close()
+
+	if countStmt.Step() {
+		metrics.EmbeddingsCacheEntries.Set(float64(countStmt.ColumnInt64(0)))
+	}
+
+	if err := countStmt.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// deserializeFloat32 decodes a vector serialized with
+// sqlite_vec.SerializeFloat32
+func deserializeFloat32(blob []byte) []float64 {
+	vector := make([]float64, len(blob)/4)
+	for idx := range vector {
+		vector[idx] = float64(math.Float32frombits(binary.LittleEndian.Uint32(blob[idx*4:])))
+	}
+	return vector
+}
@@ -0,0 +1,102 @@
+package sqlitevec
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/genai/llm"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// countingEmbeddingsLLM counts the texts sent to the embeddings endpoint
+type countingEmbeddingsLLM struct {
+	fakeEmbeddingsLLM
+	inputs int
+}
+
+func (m *countingEmbeddingsLLM) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	m.inputs += len(inputs)
+	return m.fakeEmbeddingsLLM.Embeddings(ctx, inputs, funcs...)
+}
+
+func TestEmbeddingsCache(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite3.Open(filepath.Join(t.TempDir(), "index.sqlite"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	defer db.Close()
+
+	client := &countingEmbeddingsLLM{fakeEmbeddingsLLM: fakeEmbeddingsLLM{dimensions: 256}}
+
+	index := NewIndex(db, client, "fake", 500, WithDimensions(256), WithCacheSize(2))
+
+	parse := func(rawSource string, content string) *markdown.Document {
+		doc, err := markdown.Parse([]byte(content))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		source, _ := url.Parse(rawSource)
+		doc.SetSource(source)
+
+		return doc
+	}
+
+	if err := index.Index(ctx, parse("https://example.net/fox", "# Fox\n\nThe quick brown fox.")); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	embedded := client.inputs
+	if embedded == 0 {
+		t.Fatalf("expected sections to be embedded")
+	}
+
+	// Reindexing the same content only differing by whitespaces does not call
+	// the embeddings endpoint
+	if err := index.Index(ctx, parse("https://example.net/fox", "# Fox\n\nThe  quick   brown fox.")); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := embedded, client.inputs; e != g {
+		t.Errorf("client.inputs: expected %d, got %d", e, g)
+	}
+
+	// Modified content is embedded again, the least recently used entries
+	// being evicted beyond the cache size
+	for _, content := range []string{"# Fox\n\nThe sleepy fox.", "# Fox\n\nThe lazy fox."} {
+		if err := index.Index(ctx, parse("https://example.net/fox", content)); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	if e, g := embedded+2, client.inputs; e != g {
+		t.Errorf("client.inputs: expected %d, got %d", e, g)
+	}
+
+	conn, err := index.getConn(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	stmt, _, err := conn.Prepare("SELECT COUNT(*) FROM embeddings_cache;")
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	defer stmt.Close()
+
+	if !stmt.Step() {
+		t.Fatalf("%+v", errors.WithStack(stmt.Err()))
+	}
+
+	if e, g := 2, stmt.ColumnInt(0); e != g {
+		t.Errorf("cached embeddings: expected %d, got %d", e, g)
+	}
+}
@@ -0,0 +1,114 @@
+package sqlitevec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// SectionEmbeddings implements port.EmbeddingsIndex.
+func (i *Index) SectionEmbeddings(ctx context.Context, ids ...model.SectionID) (map[model.SectionID][]float32, error) {
+	i.rwLock.RLock()
+	defer i.rwLock.RUnlock()
+
+	if _, err := i.getConn(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	embeddings := map[model.SectionID][]float32{}
+
+	table := i.searchTable()
+	if table == nil || len(ids) == 0 {
+		return embeddings, nil
+	}
+
+	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
+		sql := fmt.Sprintf(`
+			SELECT e.section_id, v.embedding
+			FROM embeddings e
+			JOIN %s v ON v.rowid = e.id
+			WHERE e.vec_table = ? AND e.section_id IN ( SELECT value FROM json_each(?) );
+		`, table.Name)
+
+		stmt, _, err := conn.Prepare(sql)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		defer stmt.Close()
+
+		jsonIDs, err := json.Marshal(ids)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := stmt.BindText(1, table.Name); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := stmt.BindBlob(2, jsonIDs); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Sum of the chunks embeddings of each section
+		sums := map[model.SectionID][]float64{}
+
+		for stmt.Step() {
+			sectionID := model.SectionID(stmt.ColumnText(0))
+			vector := deserializeFloat32(stmt.ColumnBlob(1, []byte{}))
+
+			sum, exists := sums[sectionID]
+			if !exists {
+				sum = make([]float64, len(vector))
+				sums[sectionID] = sum
+			}
+
+			for idx := range min(len(sum), len(vector)) {
+				sum[idx] += vector[idx]
+			}
+		}
+
+		if err := stmt.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		for sectionID, sum := range sums {
+			embeddings[sectionID] = normalize(sum)
+		}
+
+		return nil
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return embeddings, nil
+}
+
+// normalize returns the given vector scaled to a unit length
+func normalize(vector []float64) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return toFloat32(vector)
+	}
+
+	normalized := make([]float32, len(vector))
+	for idx, v := range vector {
+		normalized[idx] = float32(v / norm)
+	}
+
+	return normalized
+}
+
+var _ port.EmbeddingsIndex = &Index{}
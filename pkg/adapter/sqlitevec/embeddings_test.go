@@ -0,0 +1,72 @@
+package sqlitevec
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+func TestSectionEmbeddings(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite3.Open(filepath.Join(t.TempDir(), "index.sqlite"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	defer db.Close()
+
+	index := NewIndex(db, &fakeEmbeddingsLLM{dimensions: 256}, "fake", 500, WithDimensions(256))
+
+	doc, err := markdown.Parse([]byte("Animals\n\n# Fox\n\nThe quick brown fox.\n\n# Dog\n\nThe lazy dog.\n"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/animals")
+	doc.SetSource(source)
+
+	if err := index.Index(ctx, doc); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	ids := make([]model.SectionID, 0)
+	err = model.WalkSections(doc, func(s model.Section) error {
+		ids = append(ids, s.ID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	embeddings, err := index.SectionEmbeddings(ctx, append(ids, "unknown")...)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := len(ids), len(embeddings); e != g {
+		t.Fatalf("len(embeddings): expected %d, got %d", e, g)
+	}
+
+	for id, embedding := range embeddings {
+		if e, g := 256, len(embedding); e != g {
+			t.Errorf("len(embeddings[%s]): expected %d, got %d", id, e, g)
+		}
+
+		var norm float64
+		for _, v := range embedding {
+			norm += float64(v) * float64(v)
+		}
+
+		if math.Abs(math.Sqrt(norm)-1) > 1e-3 {
+			t.Errorf("embeddings[%s]: expected a normalized vector, got norm %f", id, math.Sqrt(norm))
+		}
+	}
+}
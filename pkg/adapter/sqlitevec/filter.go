@@ -0,0 +1,111 @@
+package sqlitevec
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// candidatesQuery returns the query selecting the embeddings rows matching
+// the collections and filter of the given search options, and its arguments.
+// It returns an empty query if the search is not restricted.
+func candidatesQuery(table *vectorTable, opts port.IndexSearchOptions) (string, []any, error) {
+	hasCollections := len(opts.Collections) > 0
+	hasFilter := !opts.Filter.IsZero()
+
+	if !hasCollections && !hasFilter {
+		return "", nil, nil
+	}
+
+	var sb strings.Builder
+	args := []any{}
+
+	sb.WriteString(`SELECT e.id FROM embeddings e`)
+
+	if hasCollections {
+		sb.WriteString(` JOIN embeddings_collections ec ON e.id = ec.embeddings_id`)
+	}
+
+	if hasFilter {
+		sb.WriteString(` LEFT JOIN embeddings_sources s ON s.source = e.source`)
+	}
+
+	sb.WriteString(` WHERE e.vec_table = ?`)
+	args = append(args, table.Name)
+
+	if hasCollections {
+		jsonCollections, err := json.Marshal(opts.Collections)
+		if err != nil {
+			return "", nil, errors.WithStack(err)
+		}
+
+		sb.WriteString(` AND ec.collection_id IN ( SELECT value FROM json_each(?) )`)
+		args = append(args, string(jsonCollections))
+	}
+
+	if !hasFilter {
+		return sb.String(), args, nil
+	}
+
+	filter := opts.Filter
+
+	if filter.SourcePrefix != "" {
+		sb.WriteString(` AND substr(e.source, 1, length(?)) = ?`)
+		args = append(args, filter.SourcePrefix, filter.SourcePrefix)
+	}
+
+	if filter.SourceGlob != "" {
+		// Only '*' and '?' are wildcards, character classes are escaped
+		sb.WriteString(` AND e.source GLOB ?`)
+		args = append(args, strings.ReplaceAll(filter.SourceGlob, "[", "[[]"))
+	}
+
+	if !filter.UpdatedAfter.IsZero() {
+		sb.WriteString(` AND s.updated_at >= ?`)
+		args = append(args, filter.UpdatedAfter.UnixMilli())
+	}
+
+	if !filter.UpdatedBefore.IsZero() {
+		sb.WriteString(` AND s.updated_at < ?`)
+		args = append(args, filter.UpdatedBefore.UnixMilli())
+	}
+
+	for key, value := range filter.Metadata {
+		sb.WriteString(` AND EXISTS ( SELECT 1 FROM json_each(s.metadata) m, json_each(m.value) mv WHERE m.key = ? AND mv.value = ? )`)
+		args = append(args, key, value)
+	}
+
+	return sb.String(), args, nil
+}
+
+func bindArgs(stmt *sqlite3.Stmt, start int, args ...any) (int, error) {
+	idx := start
+
+	for _, arg := range args {
+		var err error
+
+		switch v := arg.(type) {
+		case string:
+			err = stmt.BindText(idx, v)
+		case int:
+			err = stmt.BindInt(idx, v)
+		case int64:
+			err = stmt.BindInt64(idx, v)
+		case []byte:
+			err = stmt.BindBlob(idx, v)
+		default:
+			err = errors.Errorf("unexpected argument type '%T'", arg)
+		}
+
+		if err != nil {
+			return idx, errors.WithStack(err)
+		}
+
+		idx++
+	}
+
+	return idx, nil
+}
@@ -0,0 +1,181 @@
+package sqlitevec
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+type metadataDocument struct {
+	*markdown.Document
+	metadata map[string][]string
+}
+
+func (d *metadataDocument) Metadata() map[string][]string {
+	return d.metadata
+}
+
+func TestSearchFilter(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite3.Open(filepath.Join(t.TempDir(), "index.sqlite"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	defer db.Close()
+
+	index := NewIndex(db, &fakeEmbeddingsLLM{dimensions: 256}, "fake", 500)
+
+	documents := []struct {
+		Source string
+		Tag    string
+	}{
+		{Source: "https://example.net/docs/first", Tag: "policy"},
+		{Source: "https://example.net/docs/second", Tag: "guide"},
+		{Source: "https://example.net/blog/third", Tag: "policy"},
+	}
+
+	for _, d := range documents {
+		doc, err := markdown.Parse([]byte(fmt.Sprintf("# %s\n\nThe quick brown fox jumps over the lazy dog.", d.Source)))
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		source, _ := url.Parse(d.Source)
+		doc.SetSource(source)
+
+		document := &metadataDocument{
+			Document: doc,
+			metadata: map[string][]string{"tags": {d.Tag, "all"}},
+		}
+
+		if err := index.Index(ctx, document); err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+	}
+
+	testCases := []struct {
+		Name     string
+		Filter   *port.IndexSearchFilter
+		Expected int
+	}{
+		{Name: "NoFilter", Filter: nil, Expected: 3},
+		{Name: "SourcePrefix", Filter: &port.IndexSearchFilter{SourcePrefix: "https://example.net/docs/"}, Expected: 2},
+		{Name: "SourceGlob", Filter: &port.IndexSearchFilter{SourceGlob: "*/t?ird"}, Expected: 1},
+		{Name: "Metadata", Filter: &port.IndexSearchFilter{Metadata: map[string]string{"tags": "policy"}}, Expected: 2},
+		{Name: "MetadataAndPrefix", Filter: &port.IndexSearchFilter{SourcePrefix: "https://example.net/docs/", Metadata: map[string]string{"tags": "policy"}}, Expected: 1},
+		{Name: "UpdatedAfter", Filter: &port.IndexSearchFilter{UpdatedAfter: time.Now().Add(time.Hour)}, Expected: 0},
+		{Name: "UpdatedBefore", Filter: &port.IndexSearchFilter{UpdatedBefore: time.Now().Add(time.Hour)}, Expected: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			results, err := index.Search(ctx, "quick fox", port.IndexSearchOptions{
+				MaxResults: 10,
+				Filter:     tc.Filter,
+			})
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if e, g := tc.Expected, len(results); e != g {
+				t.Errorf("len(results): expected %d, got %d", e, g)
+			}
+		})
+	}
+}
+
+func TestSourcesBackfill(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite3.Open(filepath.Join(t.TempDir(), "index.sqlite"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	defer db.Close()
+
+	index := NewIndex(db, &fakeEmbeddingsLLM{dimensions: 256}, "fake", 500)
+
+	doc, err := markdown.Parse([]byte("# Corpus\n\nThe quick brown fox jumps over the lazy dog."))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/docs/first")
+	doc.SetSource(source)
+
+	document := &metadataDocument{
+		Document: doc,
+		metadata: map[string][]string{"tags": {"policy"}},
+	}
+
+	if err := index.Index(ctx, document); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	pending, err := index.SourcesPending(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if pending {
+		t.Errorf("expected no sources backfill to be pending")
+	}
+
+	// Simulate a document indexed before its attributes were recorded
+	if err := db.Exec("DELETE FROM embeddings_sources;"); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	pending, err = index.SourcesPending(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if !pending {
+		t.Errorf("expected a sources backfill to be pending")
+	}
+
+	search := func() int {
+		results, err := index.Search(ctx, "quick fox", port.IndexSearchOptions{
+			MaxResults: 10,
+			Filter:     &port.IndexSearchFilter{Metadata: map[string]string{"tags": "policy"}},
+		})
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		return len(results)
+	}
+
+	if e, g := 0, search(); e != g {
+		t.Errorf("len(results): expected %d, got %d", e, g)
+	}
+
+	if err := index.SaveSource(ctx, document); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	pending, err = index.SourcesPending(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if pending {
+		t.Errorf("expected no sources backfill to be pending")
+	}
+
+	if e, g := 1, search(); e != g {
+		t.Errorf("len(results): expected %d, got %d", e, g)
+	}
+}
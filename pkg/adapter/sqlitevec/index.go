@@ -21,12 +21,24 @@ import (
 )
 
 type Index struct {
-	maxWords int
-	getConn  func(ctx context.Context) (*sqlite3.Conn, error)
-	llm      llm.Client
-	model    string
+	maxWords   int
+	getConn    func(ctx context.Context) (*sqlite3.Conn, error)
+	llm        llm.Client
+	model      string
+	dimensions int
+	// cacheSize is the maximum number of cached embeddings, zero disables the
+	// cache
+	cacheSize int
 	// rwLock allows concurrent Search operations while serializing Index/Delete
 	rwLock sync.RWMutex
+
+	// tablesMutex guards the vec0 tables descriptions
+	tablesMutex sync.Mutex
+	// tables lists the vec0 tables recorded in the embeddings_models table
+	tables []*vectorTable
+	// current is the vec0 table of the configured embeddings model, nil until
+	// its dimension is known
+	current *vectorTable
 }
 
 // DeleteByID implements port.Index.
@@ -34,9 +46,15 @@ func (i *Index) DeleteByID(ctx context.Context, ids ...model.SectionID) error {
 	i.rwLock.Lock()
 	defer i.rwLock.Unlock()
 
+	return errors.WithStack(i.deleteByIDLocked(ctx, ids...))
+}
+
+// deleteByIDLocked performs the deletion of the given sections without acquiring the lock.
+// Callers must hold i.rwLock before calling this method.
+func (i *Index) deleteByIDLocked(ctx context.Context, ids ...model.SectionID) error {
 	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
 		// First, get the embeddings IDs to delete
-		getIDsStmt, _, err := conn.Prepare("SELECT id FROM embeddings WHERE section_id IN ( SELECT value FROM json_each(?) );")
+		getIDsStmt, _, err := conn.Prepare("SELECT id, vec_table FROM embeddings WHERE section_id IN ( SELECT value FROM json_each(?) );")
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -51,48 +69,16 @@ func (i *Index) DeleteByID(ctx context.Context, ids ...model.SectionID) error {
 			return errors.WithStack(err)
 		}
 
-		var idsToDelete []int
+		idsToDelete := map[string][]int{}
 		for getIDsStmt.Step() {
-			idsToDelete = append(idsToDelete, getIDsStmt.ColumnInt(0))
+			idsToDelete[getIDsStmt.ColumnText(1)] = append(idsToDelete[getIDsStmt.ColumnText(1)], getIDsStmt.ColumnInt(0))
 		}
 
 		if len(idsToDelete) == 0 {
 			return nil
 		}
 
-		// Delete from embeddings_collections first (has FK)
-		colStmt, _, err := conn.Prepare("DELETE FROM embeddings_collections WHERE embeddings_id IN ( SELECT value FROM json_each(?) );")
-		if err != nil {
-			return errors.WithStack(err)
-		}
-		defer colStmt.Close()
-
-		jsonColIDsBytes, err := json.Marshal(idsToDelete)
-		if err != nil {
-			return errors.WithStack(err)
-		}
-		jsonColIDs := string(jsonColIDsBytes)
-
-		if err := colStmt.BindText(1, jsonColIDs); err != nil {
-			return errors.WithStack(err)
-		}
-
-		if err := colStmt.Exec(); err != nil {
-			return errors.WithStack(err)
-		}
-
-		// Delete from vec0 virtual table
-		vecStmt, _, err := conn.Prepare("DELETE FROM embeddings_vec WHERE rowid IN ( SELECT value FROM json_each(?) );")
-		if err != nil {
-			return errors.WithStack(err)
-		}
-		defer vecStmt.Close()
-
-		if err := vecStmt.BindText(1, jsonColIDs); err != nil {
-			return errors.WithStack(err)
-		}
-
-		if err := vecStmt.Exec(); err != nil {
+		if err := deleteVectors(conn, idsToDelete); err != nil {
 			return errors.WithStack(err)
 		}
 
@@ -111,6 +97,11 @@ func (i *Index) DeleteByID(ctx context.Context, ids ...model.SectionID) error {
 			return errors.WithStack(err)
 		}
 
+		// Delete the attributes of the sources without any section left
+		if err := conn.Exec("DELETE FROM embeddings_sources WHERE source NOT IN ( SELECT source FROM embeddings );"); err != nil {
+			return errors.WithStack(err)
+		}
+
 		return nil
 	}, sqlite3.BUSY, sqlite3.LOCKED)
 	if err != nil {
@@ -125,7 +116,7 @@ func (i *Index) DeleteByID(ctx context.Context, ids ...model.SectionID) error {
 func (i *Index) deleteBySourceLocked(ctx context.Context, source *url.URL) error {
 	return i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
 		// First, get the embeddings IDs to delete
-		getIDsStmt, _, err := conn.Prepare("SELECT id FROM embeddings WHERE source = ?;")
+		getIDsStmt, _, err := conn.Prepare("SELECT id, vec_table FROM embeddings WHERE source = ?;")
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -135,68 +126,93 @@ func (i *Index) deleteBySourceLocked(ctx context.Context, source *url.URL) error
 			return errors.WithStack(err)
 		}
 
-		var idsToDelete []int
+		idsToDelete := map[string][]int{}
 		for getIDsStmt.Step() {
-			idsToDelete = append(idsToDelete, getIDsStmt.ColumnInt(0))
+			idsToDelete[getIDsStmt.ColumnText(1)] = append(idsToDelete[getIDsStmt.ColumnText(1)], getIDsStmt.ColumnInt(0))
 		}
 
 		if len(idsToDelete) == 0 {
 			return nil
 		}
 
-		// Delete from embeddings_collections first (has FK)
-		colStmt, _, err := conn.Prepare("DELETE FROM embeddings_collections WHERE embeddings_id IN ( SELECT value FROM json_each(?) );")
+		if err := deleteVectors(conn, idsToDelete); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Delete from embeddings
+		delStmt, _, err := conn.Prepare("DELETE FROM embeddings WHERE source = ?;")
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		defer colStmt.Close()
+		defer delStmt.Close()
+
+		if err := delStmt.BindText(1, source.String()); err != nil {
+			return errors.WithStack(err)
+		}
 
-		jsonColIDsBytes, err := json.Marshal(idsToDelete)
+		if err := delStmt.Exec(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		srcStmt, _, err := conn.Prepare("DELETE FROM embeddings_sources WHERE source = ?;")
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		jsonColIDs := string(jsonColIDsBytes)
+		defer srcStmt.Close()
 
-		if err := colStmt.BindText(1, jsonColIDs); err != nil {
+		if err := srcStmt.BindText(1, source.String()); err != nil {
 			return errors.WithStack(err)
 		}
 
-		if err := colStmt.Exec(); err != nil {
+		if err := srcStmt.Exec(); err != nil {
 			return errors.WithStack(err)
 		}
 
-		// Delete from vec0 virtual table
-		vecStmt, _, err := conn.Prepare("DELETE FROM embeddings_vec WHERE rowid IN ( SELECT value FROM json_each(?) );")
+		return nil
+	}, sqlite3.BUSY, sqlite3.LOCKED)
+}
+
+// deleteVectors deletes the given embeddings rows from their vec0 table and
+// from the embeddings_collections table.
+func deleteVectors(conn *sqlite3.Conn, idsByTable map[string][]int) error {
+	for table, ids := range idsByTable {
+		jsonIDs, err := json.Marshal(ids)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		defer vecStmt.Close()
 
-		if err := vecStmt.BindText(1, jsonColIDs); err != nil {
+		// Delete from embeddings_collections first (has FK)
+		colStmt, _, err := conn.Prepare("DELETE FROM embeddings_collections WHERE embeddings_id IN ( SELECT value FROM json_each(?) );")
+		if err != nil {
 			return errors.WithStack(err)
 		}
+		defer colStmt.Close()
 
-		if err := vecStmt.Exec(); err != nil {
+		if err := colStmt.BindText(1, string(jsonIDs)); err != nil {
 			return errors.WithStack(err)
 		}
 
-		// Delete from embeddings
-		delStmt, _, err := conn.Prepare("DELETE FROM embeddings WHERE source = ?;")
+		if err := colStmt.Exec(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Delete from vec0 virtual table
+		vecStmt, _, err := conn.Prepare(fmt.Sprintf("DELETE FROM %s WHERE rowid IN ( SELECT value FROM json_each(?) );", table))
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		defer delStmt.Close()
+		defer vecStmt.Close()
 
-		if err := delStmt.BindText(1, source.String()); err != nil {
+		if err := vecStmt.BindText(1, string(jsonIDs)); err != nil {
 			return errors.WithStack(err)
 		}
 
-		if err := delStmt.Exec(); err != nil {
+		if err := vecStmt.Exec(); err != nil {
 			return errors.WithStack(err)
 		}
+	}
 
-		return nil
-	}, sqlite3.BUSY, sqlite3.LOCKED)
+	return nil
 }
 
 // DeleteBySource implements port.Index.
@@ -235,7 +251,13 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 	opts := port.NewIndexOptions(funcs...)
 
 	source := document.Source()
-	if source != nil {
+	if opts.Sections != nil {
+		// Only replace the given sections, the other sections of the document
+		// are kept
+		if err := i.deleteByIDLocked(ctx, opts.Sections...); err != nil {
+			return errors.WithStack(err)
+		}
+	} else if source != nil {
 		if err := i.deleteBySourceLocked(ctx, source); err != nil {
 			return errors.WithStack(err)
 		}
@@ -245,8 +267,7 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 
 	limitChars := i.maxWords * 6
 
-	var collect func(s model.Section) error
-	collect = func(s model.Section) error {
+	chunk := func(s model.Section) error {
 		content, err := s.Content()
 		if err != nil {
 			return err
@@ -258,7 +279,7 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 			if textLen > 0 { // On ignore les sections vides
 				chunksToProcess = append(chunksToProcess, &indexableChunk{
 					Section:  s,
-					Text:     textStr,
+					Text:     opts.Contextualize(s.ID(), textStr),
 					ChunkIdx: 0,
 				})
 			}
@@ -280,7 +301,7 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 
 				chunksToProcess = append(chunksToProcess, &indexableChunk{
 					Section:  s,
-					Text:     chunkText,
+					Text:     opts.Contextualize(s.ID(), chunkText),
 					ChunkIdx: currentChunkIdx,
 				})
 				currentChunkIdx++
@@ -293,6 +314,17 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 			}
 		}
 
+		return nil
+	}
+
+	var collect func(s model.Section) error
+	collect = func(s model.Section) error {
+		if opts.Includes(s.ID()) {
+			if err := chunk(s); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
 		for _, child := range s.Sections() {
 			if err := collect(child); err != nil {
 				return errors.WithStack(err)
@@ -317,11 +349,22 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 		return nil
 	}
 
+	current, err := i.currentTable(ctx, chunksToProcess[0].Text)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tables := i.writeTables(current)
+
 	return i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
+		if err := saveSource(conn, document); err != nil {
+			return errors.WithStack(err)
+		}
+
 		// Insert into main embeddings table (metadata only - vectors go to vec0)
 		stmt, _, err := conn.Prepare(`
-			INSERT INTO embeddings (source, section_id, chunk_index)
-			VALUES (?, ?, ?)
+			INSERT INTO embeddings (source, section_id, chunk_index, vec_table)
+			VALUES (?, ?, ?, ?)
 			RETURNING id;
 		`)
 		if err != nil {
@@ -329,15 +372,20 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 		}
 		defer stmt.Close()
 
-		// Prepare vec0 statement for HNSW index
-		vecStmt, _, err := conn.Prepare(fmt.Sprintf(`
-			INSERT INTO embeddings_vec (rowid, embedding)
-			VALUES (?, vec_normalize(vec_slice(?, 0, %d)));
-		`, VectorSize))
-		if err != nil {
-			return errors.WithStack(err)
+		// Prepare vec0 statements for HNSW index
+		vecStmts := make([]*sqlite3.Stmt, len(tables))
+		for idx, table := range tables {
+			vecStmt, _, err := conn.Prepare(fmt.Sprintf(`
+				INSERT INTO %s (rowid, embedding)
+				VALUES (?, vec_normalize(?));
+			`, table.Name))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			defer vecStmt.Close()
+
+			vecStmts[idx] = vecStmt
 		}
-		defer vecStmt.Close()
 
 		var batchItems []*indexableChunk
 		var batchTexts []string
@@ -348,56 +396,91 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 				return nil
 			}
 
-			res, err := i.llm.Embeddings(ctx, batchTexts)
+			embeddings, err := i.getCachedEmbeddings(conn, batchTexts)
 			if err != nil {
-				return errors.Wrap(err, "generation failed")
+				return errors.WithStack(err)
 			}
 
-			embeddings := res.Embeddings()
-
-			if len(embeddings) != len(batchItems) {
-				return errors.New("vector count mismatch")
+			missingIndexes := make([]int, 0)
+			missingTexts := make([]string, 0)
+			for idx, e := range embeddings {
+				if e == nil {
+					missingIndexes = append(missingIndexes, idx)
+					missingTexts = append(missingTexts, batchTexts[idx])
+				}
 			}
 
-			for idx, item := range batchItems {
-				vecBlob, err := sqlite_vec.SerializeFloat32(toFloat32(embeddings[idx]))
+			if len(missingTexts) > 0 {
+				res, err := i.llm.Embeddings(ctx, missingTexts)
 				if err != nil {
-					return err
+					return errors.Wrap(err, "generation failed")
 				}
 
-				if err := stmt.BindText(1, item.Section.Document().Source().String()); err != nil {
-					return err
-				}
-				if err := stmt.BindText(2, string(item.Section.ID())); err != nil {
-					return err
-				}
-				if err := stmt.BindInt64(3, int64(item.ChunkIdx)); err != nil {
-					return err
-				}
+				generated := res.Embeddings()
 
-				if hasRow := stmt.Step(); !hasRow {
-					return errors.New("no id returned")
+				if len(generated) != len(missingTexts) {
+					return errors.New("vector count mismatch")
 				}
 
-				embeddingsID := stmt.ColumnInt(0)
-				stmt.Reset()
-
-				// Insert into vec0 for HNSW index
-				if err := vecStmt.BindInt(1, embeddingsID); err != nil {
-					return err
-				}
-				if err := vecStmt.BindBlob(2, vecBlob); err != nil {
-					return err
+				for idx, e := range generated {
+					embeddings[missingIndexes[idx]] = e
 				}
-				if err := vecStmt.Exec(); err != nil {
-					return err
+
+				if err := i.cacheEmbeddings(conn, missingTexts, generated); err != nil {
+					return errors.WithStack(err)
 				}
-				vecStmt.Reset()
+			}
 
-				for _, coll := range item.Section.Document().Collections() {
-					if err := i.insertCollection(ctx, conn, embeddingsID, coll.ID()); err != nil {
+			for idx, item := range batchItems {
+				for tableIdx, table := range tables {
+					vector, err := table.Fit(embeddings[idx])
+					if err != nil {
 						return errors.WithStack(err)
 					}
+
+					vecBlob, err := sqlite_vec.SerializeFloat32(toFloat32(vector))
+					if err != nil {
+						return err
+					}
+
+					if err := stmt.BindText(1, item.Section.Document().Source().String()); err != nil {
+						return err
+					}
+					if err := stmt.BindText(2, string(item.Section.ID())); err != nil {
+						return err
+					}
+					if err := stmt.BindInt64(3, int64(item.ChunkIdx)); err != nil {
+						return err
+					}
+					if err := stmt.BindText(4, table.Name); err != nil {
+						return err
+					}
+
+					if hasRow := stmt.Step(); !hasRow {
+						return errors.New("no id returned")
+					}
+
+					embeddingsID := stmt.ColumnInt(0)
+					stmt.Reset()
+
+					// Insert into vec0 for HNSW index
+					vecStmt := vecStmts[tableIdx]
+					if err := vecStmt.BindInt(1, embeddingsID); err != nil {
+						return err
+					}
+					if err := vecStmt.BindBlob(2, vecBlob); err != nil {
+						return err
+					}
+					if err := vecStmt.Exec(); err != nil {
+						return err
+					}
+					vecStmt.Reset()
+
+					for _, coll := range item.Section.Document().Collections() {
+						if err := i.insertCollection(ctx, conn, embeddingsID, coll.ID()); err != nil {
+							return errors.WithStack(err)
+						}
+					}
 				}
 			}
 			return nil
@@ -429,10 +512,56 @@ func (i *Index) Index(ctx context.Context, document model.Document, funcs ...por
 			}
 		}
 
+		if err := i.evictEmbeddings(conn); err != nil {
+			return errors.WithStack(err)
+		}
+
 		return nil
 	}, sqlite3.BUSY, sqlite3.LOCKED)
 }
 
+// saveSource records the document level attributes used by the search filters
+func saveSource(conn *sqlite3.Conn, document model.Document) error {
+	if document.Source() == nil {
+		return errors.New("source missing")
+	}
+
+	metadata := model.DocumentMetadata(document)
+	if metadata == nil {
+		metadata = map[string][]string{}
+	}
+
+	jsonMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return upsertSource(conn, document.Source().String(), model.DocumentUpdatedAt(document).UnixMilli(), string(jsonMetadata))
+}
+
+func upsertSource(conn *sqlite3.Conn, source string, updatedAt int64, jsonMetadata string) error {
+	stmt, _, err := conn.Prepare(`
+		INSERT INTO embeddings_sources (source, updated_at, metadata)
+		VALUES (?, ?, ?)
+		ON CONFLICT (source) DO UPDATE SET updated_at = excluded.updated_at, metadata = excluded.metadata;
+	`)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	if _, err := bindArgs(stmt, 1, source, updatedAt, jsonMetadata); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := stmt.Exec(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
 func (i *Index) insertCollection(ctx context.Context, conn *sqlite3.Conn, embeddingsID int, collectionID model.CollectionID) error {
 	deleteStmt, _, err := conn.Prepare("DELETE FROM embeddings_collections WHERE embeddings_id = ? and collection_id = ?;")
 	if err != nil {
@@ -482,6 +611,28 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 	i.rwLock.RLock()
 	defer i.rwLock.RUnlock()
 
+	if _, err := i.getConn(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// The dimension of the configured model is detected with the query if
+	// existing vectors may have to be searched
+	if !i.tablesResolved() {
+		if _, err := i.currentTable(ctx, query); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	table := i.searchTable()
+	if table == nil {
+		// Nothing was indexed with the configured embeddings model yet
+		return []*port.IndexSearchResult{}, nil
+	}
+
+	if table.Status == vectorTableBuilding {
+		slog.WarnContext(ctx, "embeddings migration in progress, search results are limited to migrated documents", slog.String("model", table.Model))
+	}
+
 	var searchResults []*port.IndexSearchResult
 	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
 		res, err := i.llm.Embeddings(ctx, []string{query})
@@ -489,31 +640,38 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 			return errors.WithStack(err)
 		}
 
+		vector, err := table.Fit(res.Embeddings()[0])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
 		// Use vec0 virtual table with HNSW index for fast KNN search
 		// IMPORTANT: vec0 uses <column> match <vector> syntax, not <column>.match
 		// Also requires k = <number> to specify number of nearest neighbors
-		sql := `
+		sql := fmt.Sprintf(`
 		SELECT
 			e.source,
 			e.section_id,
 			v.distance
-		FROM embeddings_vec v
+		FROM %s v
 		JOIN embeddings e ON v.rowid = e.id
-	`
+	`, table.Name)
 
-		hasCollections := len(opts.Collections) > 0
-		if hasCollections {
-			sql += ` JOIN embeddings_collections ec ON e.id = ec.embeddings_id`
+		// Collections and filter are applied before the KNN search, restricting
+		// the candidate vectors
+		candidates, candidatesArgs, err := candidatesQuery(table, opts)
+		if err != nil {
+			return errors.WithStack(err)
 		}
 
 		// Use <column> match <value> syntax for vec0 KNN query
-		sql += fmt.Sprintf(` WHERE v.embedding match vec_normalize(vec_slice(?, 0, %d))`, VectorSize)
+		sql += ` WHERE v.embedding match vec_normalize(?)`
 
 		// Add k parameter for vec0 (number of nearest neighbors)
 		sql += ` AND k = ?`
 
-		if hasCollections {
-			sql += ` AND ec.collection_id IN ( SELECT value FROM json_each(?) )`
+		if candidates != "" {
+			sql += ` AND v.rowid IN ( ` + candidates + ` )`
 		}
 
 		sql += ` ORDER BY v.distance ASC`
@@ -527,7 +685,7 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 
 		defer stmt.Close()
 
-		embeddings, err := sqlite_vec.SerializeFloat32(toFloat32(res.Embeddings()[0]))
+		embeddings, err := sqlite_vec.SerializeFloat32(toFloat32(vector))
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -552,21 +710,13 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 
 		bindIndex++
 
-		if hasCollections {
-			jsonCollections, err := json.Marshal(opts.Collections)
-			if err != nil {
-				return errors.WithStack(err)
-			}
-
-			if err := stmt.BindBlob(bindIndex, jsonCollections); err != nil {
-				return errors.WithStack(err)
-			}
-
-			bindIndex++
+		if _, err := bindArgs(stmt, bindIndex, candidatesArgs...); err != nil {
+			return errors.WithStack(err)
 		}
 
 		mappedScores := map[string]float64{}
 		mappedSections := map[string][]model.SectionID{}
+		sectionScores := map[model.SectionID]float64{}
 
 		for stmt.Step() {
 			source := stmt.ColumnText(0)
@@ -577,11 +727,19 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 				mappedSections[source] = make([]model.SectionID, 0)
 			}
 
+			// A section can be split in several chunks, keep its best match
+			if score := similarity(distance); score > sectionScores[model.SectionID(sectionID)] {
+				sectionScores[model.SectionID(sectionID)] = score
+			}
+
 			if distance == 0 {
 				distance = math.SmallestNonzeroFloat64
 			}
 
-			mappedSections[source] = append(mappedSections[source], model.SectionID(sectionID))
+			if !slices.Contains(mappedSections[source], model.SectionID(sectionID)) {
+				mappedSections[source] = append(mappedSections[source], model.SectionID(sectionID))
+			}
+
 			mappedScores[source] += 1 / distance
 		}
 
@@ -597,9 +755,15 @@ func (i *Index) Search(ctx context.Context, query string, opts port.IndexSearchO
 				return errors.WithStack(err)
 			}
 
+			scores := make(map[model.SectionID]float64, len(sectionIDs))
+			for _, id := range sectionIDs {
+				scores[id] = sectionScores[id]
+			}
+
 			searchResults = append(searchResults, &port.IndexSearchResult{
 				Source:   source,
 				Sections: sectionIDs,
+				Scores:   scores,
 			})
 		}
 
@@ -676,17 +840,25 @@ func (i *Index) withRetry(ctx context.Context, fn func(ctx context.Context, conn
 	}
 }
 
-func NewIndex(conn *sqlite3.Conn, llm llm.Client, model string, maxWords int) *Index {
-	return &Index{
-		maxWords: maxWords,
-		llm:      llm,
-		getConn:  createGetConn(conn),
+func NewIndex(conn *sqlite3.Conn, llm llm.Client, model string, maxWords int, funcs ...OptionFunc) *Index {
+	opts := NewOptions(funcs...)
+
+	index := &Index{
+		maxWords:   maxWords,
+		llm:        llm,
+		model:      model,
+		dimensions: opts.Dimensions,
+		cacheSize:  opts.CacheSize,
 	}
+
+	index.getConn = index.createGetConn(conn)
+
+	return index
 }
 
 var _ port.Index = &Index{}
 
-func createGetConn(conn *sqlite3.Conn) func(ctx context.Context) (*sqlite3.Conn, error) {
+func (i *Index) createGetConn(conn *sqlite3.Conn) func(ctx context.Context) (*sqlite3.Conn, error) {
 	var (
 		migrateOnce sync.Once
 		migrateErr  error
@@ -705,6 +877,16 @@ func createGetConn(conn *sqlite3.Conn) func(ctx context.Context) (*sqlite3.Conn,
 					return
 				}
 			}
+
+			if err := migrateVecTableColumn(conn); err != nil {
+				migrateErr = errors.Wrap(err, "could not migrate embeddings table")
+				return
+			}
+
+			if err := i.setupVectorTables(ctx, conn); err != nil {
+				migrateErr = errors.Wrap(err, "could not setup embeddings vector tables")
+				return
+			}
 		})
 		if migrateErr != nil {
 			return nil, errors.WithStack(migrateErr)
@@ -714,6 +896,13 @@ func createGetConn(conn *sqlite3.Conn) func(ctx context.Context) (*sqlite3.Conn,
 	}
 }
 
+// similarity converts the euclidean distance between two normalized vectors
+// into their cosine similarity, clamped to [0, 1].
+func similarity(distance float64) float64 {
+	cosine := 1 - (distance*distance)/2
+	return math.Max(0, math.Min(1, cosine))
+}
+
 func toFloat32(f64 []float64) []float32 {
 	f32 := make([]float32, len(f64))
 	for i, v := range f64 {
@@ -1,8 +1,21 @@
 package sqlitevec
 
-import "fmt"
+import (
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
 
-const VectorSize int = 768
+// LegacyVectorSize is the dimension of the vec0 table created before the
+// embeddings dimension became configurable.
+const LegacyVectorSize int = 768
+
+// legacyVectorTable is the name of the vec0 table created before vectors
+// were stored in per-model tables.
+const legacyVectorTable = "embeddings_vec"
+
+// legacyVectorModel is the model recorded for the legacy vec0 table, as the
+// model used to build its vectors is not known.
+const legacyVectorModel = "legacy"
 
 var migrations = []string{
 	// Main embeddings table (metadata only)
@@ -18,6 +31,64 @@ var migrations = []string{
 	"CREATE INDEX IF NOT EXISTS embeddings_source_idx ON embeddings ( source );",
 	"CREATE TABLE IF NOT EXISTS embeddings_collections ( embeddings_id INTEGER, collection_id TEXT NOT NULL, FOREIGN KEY (embeddings_id) REFERENCES embeddings (id) ON DELETE CASCADE );",
 	"CREATE INDEX IF NOT EXISTS embeddings_collections_idx ON embeddings_collections ( embeddings_id, collection_id );",
-	// vec0 virtual table - vector only, metadata in embeddings table
-	fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS embeddings_vec USING vec0(embedding float[%d]);", VectorSize),
+	// Embeddings models metadata: one vec0 table per embeddings model
+	`
+		CREATE TABLE IF NOT EXISTS embeddings_models (
+			vec_table TEXT NOT NULL PRIMARY KEY,
+			model TEXT NOT NULL,
+			dimensions INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`,
+	// Document level attributes, used by the search filters
+	`
+		CREATE TABLE IF NOT EXISTS embeddings_sources (
+			source TEXT NOT NULL PRIMARY KEY,
+			updated_at INTEGER NOT NULL,
+			metadata TEXT NOT NULL DEFAULT '{}'
+		);
+	`,
+	// Embeddings cache, keyed by a hash of the model name and the normalized text
+	`
+		CREATE TABLE IF NOT EXISTS embeddings_cache (
+			key TEXT NOT NULL PRIMARY KEY,
+			model TEXT NOT NULL,
+			embedding BLOB NOT NULL,
+			used_at INTEGER NOT NULL
+		);
+	`,
+	"CREATE INDEX IF NOT EXISTS embeddings_cache_used_at_idx ON embeddings_cache ( used_at );",
+}
+
+// migrateVecTableColumn adds the vec_table column to the embeddings table,
+// linking each embeddings row to the vec0 table holding its vector. Existing
+// rows belong to the legacy vec0 table.
+func migrateVecTableColumn(conn *sqlite3.Conn) error {
+	stmt, _, err := conn.Prepare("SELECT COUNT(*) FROM pragma_table_info('embeddings') WHERE name = 'vec_table';")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	exists := stmt.Step() && stmt.ColumnInt(0) > 0
+
+	if err := stmt.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	if err := conn.Exec("ALTER TABLE embeddings ADD COLUMN vec_table TEXT NOT NULL DEFAULT '" + legacyVectorTable + "';"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := conn.Exec("CREATE INDEX IF NOT EXISTS embeddings_vec_table_idx ON embeddings ( vec_table, source );"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
 }
@@ -0,0 +1,37 @@
+package sqlitevec
+
+type Options struct {
+	// Dimensions is the dimension of the vectors returned by the embeddings
+	// model. If zero, it is detected from the first embeddings response.
+	Dimensions int
+	// CacheSize is the maximum number of embeddings kept in the cache, the
+	// least recently used ones being evicted first. Zero disables the cache.
+	CacheSize int
+}
+
+const DefaultCacheSize = 100_000
+
+type OptionFunc func(opts *Options)
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		Dimensions: 0,
+		CacheSize:  DefaultCacheSize,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+func WithDimensions(dimensions int) OptionFunc {
+	return func(opts *Options) {
+		opts.Dimensions = dimensions
+	}
+}
+
+func WithCacheSize(size int) OptionFunc {
+	return func(opts *Options) {
+		opts.CacheSize = size
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/gob"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"time"
@@ -20,14 +21,19 @@ func init() {
 }
 
 type SnapshottedMetadata struct {
-	Model string
+	Model      string
+	Dimensions int
 }
 
 type SnapshottedRecord struct {
 	Source      string
 	SectionID   string
+	ChunkIndex  int
 	Embeddings  []byte
 	Collections []string
+	// Document level attributes of the record source
+	UpdatedAt int64
+	Metadata  string
 }
 
 // GenerateSnapshot implements snapshot.Snapshotable.
@@ -39,28 +45,49 @@ func (i *Index) GenerateSnapshot(ctx context.Context) (io.ReadCloser, error) {
 
 		encoder := gob.NewEncoder(w)
 
+		if _, err := i.getConn(ctx); err != nil {
+			w.CloseWithError(errors.WithStack(err))
+			return
+		}
+
+		table := i.searchTable()
+
 		metadata := SnapshottedMetadata{
 			Model: i.model,
 		}
 
+		if table != nil {
+			metadata.Dimensions = table.Dimensions
+		}
+
 		if err := encoder.Encode(metadata); err != nil {
 			w.CloseWithError(errors.WithStack(err))
 			return
 		}
 
+		if table == nil {
+			return
+		}
+
 		err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
-			sql := `
+			sql := fmt.Sprintf(`
 				SELECT
 					e.id,
 					e.source,
 					e.section_id,
-					e.embeddings,
-					COALESCE(json_group_array(ec.collection_id), '[]') AS collections
+					e.chunk_index,
+					v.embedding,
+					COALESCE(json_group_array(ec.collection_id) FILTER (WHERE ec.collection_id IS NOT NULL), '[]') AS collections,
+					COALESCE(s.updated_at, 0),
+					COALESCE(s.metadata, '{}')
 				FROM embeddings e
+				JOIN %s v ON v.rowid = e.id
 				LEFT JOIN embeddings_collections ec ON e.id = ec.embeddings_id
+				LEFT JOIN embeddings_sources s ON s.source = e.source
+				WHERE e.vec_table = ?
 				GROUP BY e.id, e.source, e.section_id
 				;
-			`
+			`, table.Name)
 
 			stmt, _, err := conn.Prepare(sql)
 			if err != nil {
@@ -69,12 +96,19 @@ func (i *Index) GenerateSnapshot(ctx context.Context) (io.ReadCloser, error) {
 
 			defer stmt.Close()
 
+			if err := stmt.BindText(1, table.Name); err != nil {
+				return errors.WithStack(err)
+			}
+
 			for stmt.Step() {
 				record := SnapshottedRecord{}
 				record.Source = stmt.ColumnText(1)
 				record.SectionID = stmt.ColumnText(2)
-				record.Embeddings = stmt.ColumnBlob(3, []byte{})
-				rawCollections := stmt.ColumnBlob(4, []byte{})
+				record.ChunkIndex = stmt.ColumnInt(3)
+				record.Embeddings = stmt.ColumnBlob(4, []byte{})
+				rawCollections := stmt.ColumnBlob(5, []byte{})
+				record.UpdatedAt = stmt.ColumnInt64(6)
+				record.Metadata = stmt.ColumnText(7)
 				if err := json.Unmarshal(rawCollections, &record.Collections); err != nil {
 					return errors.WithStack(err)
 				}
@@ -113,11 +147,37 @@ func (i *Index) RestoreSnapshot(ctx context.Context, r io.Reader) error {
 		return errors.Errorf("could not restore snapshot with a different embedding model '%s'", metadata.Model)
 	}
 
-	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
-		if err := conn.Exec("DELETE FROM embeddings;"); err != nil {
+	if _, err := i.getConn(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	table, err := i.restoreTable(ctx, metadata.Dimensions)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if table == nil {
+		// Empty snapshot
+		return nil
+	}
+
+	err = i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
+		if err := conn.Exec("DELETE FROM embeddings; DELETE FROM embeddings_sources;"); err != nil {
 			return errors.WithStack(err)
 		}
 
+		i.tablesMutex.Lock()
+		defer i.tablesMutex.Unlock()
+
+		for _, t := range i.tables {
+			if err := conn.Exec(fmt.Sprintf("DELETE FROM %s;", t.Name)); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
 		return nil
 	}, sqlite3.LOCKED, sqlite3.BUSY)
 	if err != nil {
@@ -132,7 +192,7 @@ func (i *Index) RestoreSnapshot(ctx context.Context, r io.Reader) error {
 		if err := decoder.Decode(&record); err != nil {
 			if errors.Is(err, io.EOF) {
 				if len(batch) > 0 {
-					if err := i.restoreRecords(ctx, batch...); err != nil {
+					if err := i.restoreRecords(ctx, table, batch...); err != nil {
 						return errors.WithStack(err)
 					}
 
@@ -148,7 +208,7 @@ func (i *Index) RestoreSnapshot(ctx context.Context, r io.Reader) error {
 		batch = append(batch, &record)
 
 		if len(batch) >= batchSize {
-			if err := i.restoreRecords(ctx, batch...); err != nil {
+			if err := i.restoreRecords(ctx, table, batch...); err != nil {
 				return errors.WithStack(err)
 			}
 
@@ -158,7 +218,40 @@ func (i *Index) RestoreSnapshot(ctx context.Context, r io.Reader) error {
 	}
 }
 
-func (i *Index) restoreRecords(ctx context.Context, records ...*SnapshottedRecord) error {
+// restoreTable returns the vec0 table receiving the restored vectors, creating
+// it from the snapshot dimension if needed.
+func (i *Index) restoreTable(ctx context.Context, dimensions int) (*vectorTable, error) {
+	i.tablesMutex.Lock()
+	defer i.tablesMutex.Unlock()
+
+	if dimensions == 0 {
+		return nil, nil
+	}
+
+	if i.current != nil {
+		if i.current.Dimensions != dimensions {
+			return nil, errors.Errorf("could not restore snapshot with %d dimensions embeddings, the index expects %d", dimensions, i.current.Dimensions)
+		}
+
+		return i.current, nil
+	}
+
+	table := i.newVectorTable(dimensions)
+
+	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
+		return createVectorTable(ctx, conn, table)
+	}, sqlite3.LOCKED, sqlite3.BUSY)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	i.tables = append(i.tables, table)
+	i.current = table
+
+	return table, nil
+}
+
+func (i *Index) restoreRecords(ctx context.Context, table *vectorTable, records ...*SnapshottedRecord) error {
 	start := time.Now()
 	defer func() {
 		slog.DebugContext(ctx, "restored record batch", slog.Any("batchSize", len(records)), slog.Duration("duration", time.Now().Sub(start)))
@@ -167,55 +260,68 @@ func (i *Index) restoreRecords(ctx context.Context, records ...*SnapshottedRecor
 	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
 
 		restoreRecord := func(record *SnapshottedRecord) error {
-			deleteStmt, _, err := conn.Prepare("DELETE FROM embeddings WHERE source = ? and section_id = ?;")
+			insertStmt, _, err := conn.Prepare("INSERT INTO embeddings ( source, section_id, chunk_index, vec_table ) VALUES (?, ?, ?, ?) RETURNING id;")
 			if err != nil {
 				return errors.WithStack(err)
 			}
 
-			defer deleteStmt.Close()
+			defer insertStmt.Close()
 
-			if err := deleteStmt.BindText(1, record.Source); err != nil {
+			if err := insertStmt.BindText(1, record.Source); err != nil {
 				return errors.WithStack(err)
 			}
 
-			if err := deleteStmt.BindText(2, record.SectionID); err != nil {
+			if err := insertStmt.BindText(2, record.SectionID); err != nil {
 				return errors.WithStack(err)
 			}
 
-			if err := deleteStmt.Exec(); err != nil {
+			if err := insertStmt.BindInt(3, record.ChunkIndex); err != nil {
 				return errors.WithStack(err)
 			}
 
-			if err := deleteStmt.Close(); err != nil {
+			if err := insertStmt.BindText(4, table.Name); err != nil {
 				return errors.WithStack(err)
 			}
 
-			insertStmt, _, err := conn.Prepare("INSERT INTO embeddings ( source, section_id, embeddings ) VALUES (?, ?, ?) RETURNING id;")
-			if err != nil {
-				return errors.WithStack(err)
+			if hasRow := insertStmt.Step(); !hasRow {
+				if err := insertStmt.Err(); err != nil {
+					return errors.WithStack(err)
+				}
+
+				return errors.New("no id returned")
 			}
 
-			defer insertStmt.Close()
+			embeddingsID := insertStmt.ColumnInt(0)
 
-			if err := insertStmt.BindText(1, record.Source); err != nil {
-				return errors.WithStack(err)
+			if record.UpdatedAt > 0 {
+				metadata := record.Metadata
+				if metadata == "" {
+					metadata = "{}"
+				}
+
+				if err := upsertSource(conn, record.Source, record.UpdatedAt, metadata); err != nil {
+					return errors.WithStack(err)
+				}
 			}
 
-			if err := insertStmt.BindText(2, record.SectionID); err != nil {
+			vecStmt, _, err := conn.Prepare(fmt.Sprintf("INSERT INTO %s ( rowid, embedding ) VALUES (?, ?);", table.Name))
+			if err != nil {
 				return errors.WithStack(err)
 			}
 
-			if err := insertStmt.BindBlob(3, record.Embeddings); err != nil {
+			defer vecStmt.Close()
+
+			if err := vecStmt.BindInt(1, embeddingsID); err != nil {
 				return errors.WithStack(err)
 			}
 
-			insertStmt.Step()
-
-			if err := insertStmt.Err(); err != nil {
+			if err := vecStmt.BindBlob(2, record.Embeddings); err != nil {
 				return errors.WithStack(err)
 			}
 
-			embeddingsID := insertStmt.ColumnInt(0)
+			if err := vecStmt.Exec(); err != nil {
+				return errors.WithStack(err)
+			}
 
 			for _, collectionID := range record.Collections {
 				if err := i.insertCollection(ctx, conn, embeddingsID, model.CollectionID(collectionID)); err != nil {
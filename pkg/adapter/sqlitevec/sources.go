@@ -0,0 +1,85 @@
+package sqlitevec
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// SourcesPending returns true if some indexed sources have no recorded
+// document level attributes, i.e. they were indexed before the search filters
+// were introduced and must be backfilled with SaveSource.
+func (i *Index) SourcesPending(ctx context.Context) (bool, error) {
+	i.rwLock.RLock()
+	defer i.rwLock.RUnlock()
+
+	conn, err := i.getConn(ctx)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	stmt, _, err := conn.Prepare(`
+		SELECT EXISTS (
+			SELECT 1 FROM embeddings e
+			WHERE e.source IS NOT NULL
+			AND NOT EXISTS ( SELECT 1 FROM embeddings_sources s WHERE s.source = e.source )
+		);
+	`)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	pending := stmt.Step() && stmt.ColumnBool(0)
+
+	if err := stmt.Err(); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return pending, nil
+}
+
+// SaveSource records the document level attributes used by the search
+// filters, without re-embedding the document. Documents which are not indexed
+// are ignored.
+func (i *Index) SaveSource(ctx context.Context, document model.Document) error {
+	if document.Source() == nil {
+		return errors.New("source missing")
+	}
+
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
+		stmt, _, err := conn.Prepare("SELECT EXISTS ( SELECT 1 FROM embeddings WHERE source = ? );")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		defer stmt.Close()
+
+		if err := stmt.BindText(1, document.Source().String()); err != nil {
+			return errors.WithStack(err)
+		}
+
+		indexed := stmt.Step() && stmt.ColumnBool(0)
+
+		if err := stmt.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if !indexed {
+			return nil
+		}
+
+		return saveSource(conn, document)
+	}, sqlite3.BUSY, sqlite3.LOCKED)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,501 @@
+package sqlitevec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+type vectorTableStatus string
+
+const (
+	// vectorTableActive marks the vec0 table used to answer searches
+	vectorTableActive vectorTableStatus = "active"
+	// vectorTableBuilding marks a vec0 table still being filled by a
+	// re-embedding of the documents, after an embeddings model change
+	vectorTableBuilding vectorTableStatus = "building"
+)
+
+// vectorTable describes a vec0 table holding the vectors of a given
+// embeddings model, as recorded in the embeddings_models table.
+type vectorTable struct {
+	Name       string
+	Model      string
+	Dimensions int
+	Status     vectorTableStatus
+}
+
+// Legacy returns true if the table was created before the embeddings
+// dimension became configurable. Vectors stored in the legacy table were
+// truncated to LegacyVectorSize.
+func (t *vectorTable) Legacy() bool {
+	return t.Name == legacyVectorTable
+}
+
+// Fit checks that the given embeddings can be stored in the table and
+// returns them. For the legacy table, embeddings are truncated the same way
+// the stored vectors were: this only happens while the table of the configured
+// model is being built, as the legacy table is only used as the current one
+// when the model dimension matches.
+func (t *vectorTable) Fit(embeddings []float64) ([]float64, error) {
+	if t.Legacy() && len(embeddings) >= t.Dimensions {
+		return embeddings[:t.Dimensions], nil
+	}
+
+	if len(embeddings) != t.Dimensions {
+		return nil, errors.Errorf(
+			"embeddings dimension mismatch for model '%s': the index expects %d dimensions, the embeddings model returned %d",
+			t.Model, t.Dimensions, len(embeddings),
+		)
+	}
+
+	return embeddings, nil
+}
+
+func vectorTableName(model string, dimensions int) string {
+	hash := sha256.Sum256([]byte(model + ":" + strconv.Itoa(dimensions)))
+	return "embeddings_vec_" + hex.EncodeToString(hash[:])[:12]
+}
+
+// setupVectorTables registers the legacy vec0 table if needed, then resolves
+// the vec0 table of the configured embeddings model. The table is created
+// only if its dimension is known, either from the configuration or from a
+// previous run. Otherwise it is resolved by currentTable on the first
+// indexation or search.
+func (i *Index) setupVectorTables(ctx context.Context, conn *sqlite3.Conn) error {
+	tables, err := i.listVectorTables(conn)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(tables) == 0 {
+		legacy, err := hasTable(conn, legacyVectorTable)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if legacy {
+			// The model used to build the vectors indexed before the dimension
+			// became configurable is not known
+			table := &vectorTable{
+				Name:       legacyVectorTable,
+				Model:      legacyVectorModel,
+				Dimensions: LegacyVectorSize,
+				Status:     vectorTableActive,
+			}
+
+			if err := registerVectorTable(conn, table); err != nil {
+				return errors.WithStack(err)
+			}
+
+			tables = append(tables, table)
+		}
+	}
+
+	var current *vectorTable
+	for _, t := range tables {
+		if t.Model != i.model || t.Legacy() {
+			continue
+		}
+
+		if i.dimensions > 0 && t.Dimensions != i.dimensions {
+			return errors.Errorf(
+				"embeddings dimension mismatch for model '%s': the index was built with %d dimensions but %d are configured",
+				i.model, t.Dimensions, i.dimensions,
+			)
+		}
+
+		// Tables are listed by creation order: the most recent one wins
+		current = t
+	}
+
+	i.tables = tables
+	i.current = current
+
+	if i.current != nil || i.dimensions == 0 {
+		return nil
+	}
+
+	if legacy := i.legacyTable(i.dimensions); legacy != nil {
+		i.current = legacy
+		return nil
+	}
+
+	table := i.newVectorTable(i.dimensions)
+
+	if err := createVectorTable(ctx, conn, table); err != nil {
+		return errors.WithStack(err)
+	}
+
+	i.tables = append(i.tables, table)
+	i.current = table
+
+	return nil
+}
+
+// legacyTable returns the active legacy vec0 table if it can hold the vectors
+// of the given dimension, nil otherwise. Callers must hold i.tablesMutex or
+// be setting up the tables.
+func (i *Index) legacyTable(dimensions int) *vectorTable {
+	for _, t := range i.tables {
+		if t.Legacy() && t.Status == vectorTableActive && t.Dimensions == dimensions {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// newVectorTable describes the vec0 table of the configured embeddings model.
+// The table is marked as building if other tables are still in use.
+func (i *Index) newVectorTable(dimensions int) *vectorTable {
+	table := &vectorTable{
+		Name:       vectorTableName(i.model, dimensions),
+		Model:      i.model,
+		Dimensions: dimensions,
+		Status:     vectorTableActive,
+	}
+
+	if len(i.tables) > 0 {
+		table.Status = vectorTableBuilding
+	}
+
+	return table
+}
+
+// currentTable returns the vec0 table of the configured embeddings model. If
+// its dimension is not known yet, it is detected by embedding the given sample
+// text and the table is created. A failed detection is retried on the next
+// call.
+func (i *Index) currentTable(ctx context.Context, sample string) (*vectorTable, error) {
+	i.tablesMutex.Lock()
+	defer i.tablesMutex.Unlock()
+
+	if i.current != nil {
+		return i.current, nil
+	}
+
+	dimensions, err := i.probeDimensions(ctx, sample)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// The vectors of the legacy table are assumed to come from the configured
+	// model if their dimensions match, otherwise they must be re-embedded
+	if legacy := i.legacyTable(dimensions); legacy != nil {
+		i.current = legacy
+		return legacy, nil
+	}
+
+	table := i.newVectorTable(dimensions)
+
+	err = i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
+		return createVectorTable(ctx, conn, table)
+	}, sqlite3.BUSY, sqlite3.LOCKED)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	i.tables = append(i.tables, table)
+	i.current = table
+
+	return table, nil
+}
+
+// probeDimensions returns the dimension of the embeddings returned by the
+// configured model for the given sample text
+func (i *Index) probeDimensions(ctx context.Context, sample string) (int, error) {
+	res, err := i.llm.Embeddings(ctx, []string{sample})
+	if err != nil {
+		return 0, errors.Wrap(err, "could not detect embeddings dimension")
+	}
+
+	embeddings := res.Embeddings()
+	if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+		return 0, errors.New("could not detect embeddings dimension: empty embeddings")
+	}
+
+	return len(embeddings[0]), nil
+}
+
+// searchTable returns the vec0 table to query. While the table of the
+// configured model is being built, the active table is still used if it holds
+// vectors of the same model, or the legacy vectors.
+func (i *Index) searchTable() *vectorTable {
+	i.tablesMutex.Lock()
+	defer i.tablesMutex.Unlock()
+
+	if i.current == nil || i.current.Status == vectorTableActive {
+		return i.current
+	}
+
+	for _, t := range i.tables {
+		if t.Status == vectorTableActive && (t.Model == i.model || t.Legacy()) {
+			return t
+		}
+	}
+
+	return i.current
+}
+
+// tablesResolved returns true if the vec0 table of the configured model is
+// known, or if there is no table to search at all
+func (i *Index) tablesResolved() bool {
+	i.tablesMutex.Lock()
+	defer i.tablesMutex.Unlock()
+
+	return i.current != nil || len(i.tables) == 0
+}
+
+// writeTables returns the vec0 tables to fill when indexing a document: the
+// table of the configured model and, during a migration, the active table if
+// it holds vectors of the same model, or the legacy vectors.
+func (i *Index) writeTables(current *vectorTable) []*vectorTable {
+	i.tablesMutex.Lock()
+	defer i.tablesMutex.Unlock()
+
+	tables := []*vectorTable{current}
+
+	for _, t := range i.tables {
+		if t != current && t.Status == vectorTableActive && (t.Model == i.model || t.Legacy()) {
+			tables = append(tables, t)
+		}
+	}
+
+	return tables
+}
+
+// MigrationPending returns true if the vec0 table of the configured
+// embeddings model is still being built, i.e. documents must be re-embedded
+// before CompleteMigration is called.
+func (i *Index) MigrationPending(ctx context.Context) (bool, error) {
+	if _, err := i.getConn(ctx); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	if !i.tablesResolved() {
+		// Detect the dimension of the configured model to know if the existing
+		// vectors can be kept
+		if _, err := i.currentTable(ctx, "dimension probe"); err != nil {
+			slog.WarnContext(ctx, "could not detect embeddings dimension, assuming a migration is pending", slog.Any("error", errors.WithStack(err)))
+			return true, nil
+		}
+	}
+
+	i.tablesMutex.Lock()
+	defer i.tablesMutex.Unlock()
+
+	if i.current != nil {
+		return i.current.Status == vectorTableBuilding, nil
+	}
+
+	return false, nil
+}
+
+// CompleteMigration marks the vec0 table of the configured embeddings model as
+// active, then drops the tables of the previous models.
+func (i *Index) CompleteMigration(ctx context.Context) error {
+	i.rwLock.Lock()
+	defer i.rwLock.Unlock()
+
+	i.tablesMutex.Lock()
+	defer i.tablesMutex.Unlock()
+
+	err := i.withRetry(ctx, func(ctx context.Context, conn *sqlite3.Conn) error {
+		for _, t := range i.tables {
+			if t == i.current {
+				continue
+			}
+
+			if err := dropVectorTable(conn, t); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		// No document was indexed with the configured model yet: there is
+		// nothing left to activate
+		if i.current == nil {
+			return nil
+		}
+
+		stmt, _, err := conn.Prepare("UPDATE embeddings_models SET status = ? WHERE vec_table = ?;")
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		defer stmt.Close()
+
+		if err := stmt.BindText(1, string(vectorTableActive)); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := stmt.BindText(2, i.current.Name); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := stmt.Exec(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return nil
+	}, sqlite3.BUSY, sqlite3.LOCKED)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, t := range i.tables {
+		if t != i.current {
+			slog.InfoContext(ctx, "dropped embeddings vector table", slog.String("table", t.Name), slog.String("model", t.Model))
+		}
+	}
+
+	i.tables = make([]*vectorTable, 0, 1)
+
+	if i.current != nil {
+		i.current.Status = vectorTableActive
+		i.tables = append(i.tables, i.current)
+	}
+
+	return nil
+}
+
+func createVectorTable(ctx context.Context, conn *sqlite3.Conn, table *vectorTable) error {
+	if err := conn.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING vec0(embedding float[%d]);", table.Name, table.Dimensions)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := registerVectorTable(conn, table); err != nil {
+		return errors.WithStack(err)
+	}
+
+	slog.InfoContext(ctx, "created embeddings vector table",
+		slog.String("table", table.Name),
+		slog.String("model", table.Model),
+		slog.Int("dimensions", table.Dimensions),
+		slog.String("status", string(table.Status)),
+	)
+
+	return nil
+}
+
+func (i *Index) listVectorTables(conn *sqlite3.Conn) ([]*vectorTable, error) {
+	stmt, _, err := conn.Prepare("SELECT vec_table, model, dimensions, status FROM embeddings_models ORDER BY rowid ASC;")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	tables := make([]*vectorTable, 0)
+
+	for stmt.Step() {
+		tables = append(tables, &vectorTable{
+			Name:       stmt.ColumnText(0),
+			Model:      stmt.ColumnText(1),
+			Dimensions: stmt.ColumnInt(2),
+			Status:     vectorTableStatus(stmt.ColumnText(3)),
+		})
+	}
+
+	if err := stmt.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return tables, nil
+}
+
+func registerVectorTable(conn *sqlite3.Conn, table *vectorTable) error {
+	stmt, _, err := conn.Prepare("INSERT INTO embeddings_models ( vec_table, model, dimensions, status ) VALUES (?, ?, ?, ?);")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	if err := stmt.BindText(1, table.Name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := stmt.BindText(2, table.Model); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := stmt.BindInt(3, table.Dimensions); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := stmt.BindText(4, string(table.Status)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := stmt.Exec(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func dropVectorTable(conn *sqlite3.Conn, table *vectorTable) error {
+	stmt, _, err := conn.Prepare("DELETE FROM embeddings WHERE vec_table = ?;")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	if err := stmt.BindText(1, table.Name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := stmt.Exec(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := conn.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s;", table.Name)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	modelStmt, _, err := conn.Prepare("DELETE FROM embeddings_models WHERE vec_table = ?;")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer modelStmt.Close()
+
+	if err := modelStmt.BindText(1, table.Name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := modelStmt.Exec(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func hasTable(conn *sqlite3.Conn, name string) (bool, error) {
+	stmt, _, err := conn.Prepare("SELECT COUNT(*) FROM sqlite_master WHERE name = ?;")
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	defer stmt.Close()
+
+	if err := stmt.BindText(1, name); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	exists := stmt.Step() && stmt.ColumnInt(0) > 0
+
+	if err := stmt.Err(); err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	return exists, nil
+}
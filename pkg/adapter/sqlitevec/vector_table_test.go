@@ -0,0 +1,279 @@
+package sqlitevec
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
+	"github.com/ncruces/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// fakeEmbeddingsLLM implements llm.Client by returning deterministic
+// embeddings of a fixed dimension
+type fakeEmbeddingsLLM struct {
+	llm.Client
+	dimensions int
+}
+
+func (m *fakeEmbeddingsLLM) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	embeddings := make([][]float64, len(inputs))
+	for idx, input := range inputs {
+		vector := make([]float64, m.dimensions)
+		for _, word := range []byte(input) {
+			h := fnv.New32a()
+			h.Write([]byte{word})
+			vector[int(h.Sum32())%m.dimensions] += 1
+		}
+		embeddings[idx] = vector
+	}
+
+	return &fakeEmbeddingsResponse{embeddings: embeddings}, nil
+}
+
+type fakeEmbeddingsResponse struct {
+	embeddings [][]float64
+}
+
+func (r *fakeEmbeddingsResponse) Embeddings() [][]float64 {
+	return r.embeddings
+}
+
+func (r *fakeEmbeddingsResponse) Usage() llm.EmbeddingsUsage {
+	return llm.NewEmbeddingsUsage(0, 0)
+}
+
+func TestVectorTables(t *testing.T) {
+	ctx := context.Background()
+
+	dbFile := filepath.Join(t.TempDir(), "index.sqlite")
+
+	openIndex := func(model string, dimensions int, funcs ...OptionFunc) *Index {
+		db, err := sqlite3.Open(dbFile)
+		if err != nil {
+			t.Fatalf("%+v", errors.WithStack(err))
+		}
+
+		t.Cleanup(func() {
+			db.Close()
+		})
+
+		return NewIndex(db, &fakeEmbeddingsLLM{dimensions: dimensions}, model, 500, funcs...)
+	}
+
+	doc, err := markdown.Parse([]byte("# Corpus\n\nThe quick brown fox jumps over the lazy dog."))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	source, _ := url.Parse("https://example.net/fox")
+	doc.SetSource(source)
+
+	// The dimension is detected from the first embeddings response
+	index := openIndex("first-model", 1024)
+
+	if err := index.Index(ctx, doc); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1024, index.current.Dimensions; e != g {
+		t.Errorf("index.current.Dimensions: expected %d, got %d", e, g)
+	}
+
+	results, err := index.Search(ctx, "quick fox", port.IndexSearchOptions{MaxResults: 5})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+
+	// A configured dimension different from the recorded one is rejected at
+	// startup
+	index = openIndex("first-model", 1024, WithDimensions(768))
+
+	if _, err := index.MigrationPending(ctx); err == nil {
+		t.Errorf("expected a dimension mismatch error")
+	}
+
+	// A new embeddings model gets its own table, built alongside the old one
+	index = openIndex("second-model", 1536, WithDimensions(1536))
+
+	pending, err := index.MigrationPending(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if !pending {
+		t.Errorf("expected a migration to be pending")
+	}
+
+	if err := index.Index(ctx, doc); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if err := index.CompleteMigration(ctx); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	pending, err = index.MigrationPending(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if pending {
+		t.Errorf("expected no migration to be pending")
+	}
+
+	if e, g := 1, len(index.tables); e != g {
+		t.Errorf("len(index.tables): expected %d, got %d", e, g)
+	}
+
+	results, err = index.Search(ctx, "quick fox", port.IndexSearchOptions{MaxResults: 5})
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, len(results); e != g {
+		t.Fatalf("len(results): expected %d, got %d", e, g)
+	}
+}
+
+func TestLegacyVectorTable(t *testing.T) {
+	ctx := context.Background()
+
+	type testCase struct {
+		Name            string
+		ModelDimensions int
+		ExpectedPending bool
+	}
+
+	testCases := []testCase{
+		{
+			Name:            "SameDimension",
+			ModelDimensions: LegacyVectorSize,
+			ExpectedPending: false,
+		},
+		{
+			Name:            "DifferentDimension",
+			ModelDimensions: 1024,
+			ExpectedPending: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, err := sqlite3.Open(filepath.Join(t.TempDir(), "index.sqlite"))
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			defer db.Close()
+
+			// Simulate an index created before the dimension became configurable
+			if err := db.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE %s USING vec0(embedding float[%d]);", legacyVectorTable, LegacyVectorSize)); err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			index := NewIndex(db, &fakeEmbeddingsLLM{dimensions: tc.ModelDimensions}, "legacy-model", 500)
+
+			pending, err := index.MigrationPending(ctx)
+			if err != nil {
+				t.Fatalf("%+v", errors.WithStack(err))
+			}
+
+			if e, g := tc.ExpectedPending, pending; e != g {
+				t.Errorf("pending: expected %v, got %v", e, g)
+			}
+
+			if e, g := tc.ModelDimensions, index.current.Dimensions; e != g {
+				t.Errorf("index.current.Dimensions: expected %d, got %d", e, g)
+			}
+
+			if e, g := !tc.ExpectedPending, index.current.Legacy(); e != g {
+				t.Errorf("index.current.Legacy(): expected %v, got %v", e, g)
+			}
+
+			// The model used to build the legacy vectors is not known
+			for _, table := range index.tables {
+				if table.Legacy() && table.Model != legacyVectorModel {
+					t.Errorf("legacy table model: expected '%s', got '%s'", legacyVectorModel, table.Model)
+				}
+			}
+		})
+	}
+}
+
+// flakyEmbeddingsLLM fails the given number of embeddings calls before
+// answering them
+type flakyEmbeddingsLLM struct {
+	fakeEmbeddingsLLM
+	failures int
+}
+
+func (m *flakyEmbeddingsLLM) Embeddings(ctx context.Context, inputs []string, funcs ...llm.EmbeddingsOptionFunc) (llm.EmbeddingsResponse, error) {
+	if m.failures > 0 {
+		m.failures--
+		return nil, errors.New("provider unavailable")
+	}
+
+	return m.fakeEmbeddingsLLM.Embeddings(ctx, inputs, funcs...)
+}
+
+func TestLegacyVectorTableProbeFailure(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite3.Open(filepath.Join(t.TempDir(), "index.sqlite"))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	defer db.Close()
+
+	if err := db.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE %s USING vec0(embedding float[%d]);", legacyVectorTable, LegacyVectorSize)); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	client := &flakyEmbeddingsLLM{fakeEmbeddingsLLM: fakeEmbeddingsLLM{dimensions: LegacyVectorSize}, failures: 2}
+
+	index := NewIndex(db, client, "legacy-model", 500)
+
+	// A failed detection is reported as a pending migration
+	pending, err := index.MigrationPending(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if !pending {
+		t.Errorf("expected a migration to be pending")
+	}
+
+	if _, err := index.Search(ctx, "quick fox", port.IndexSearchOptions{MaxResults: 5}); err == nil {
+		t.Errorf("expected a search error while the provider is unavailable")
+	}
+
+	// The detection is retried once the provider is back
+	if _, err := index.Search(ctx, "quick fox", port.IndexSearchOptions{MaxResults: 5}); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	pending, err = index.MigrationPending(ctx)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if pending {
+		t.Errorf("expected no migration to be pending")
+	}
+
+	if !index.current.Legacy() {
+		t.Errorf("expected the legacy table to be used")
+	}
+}
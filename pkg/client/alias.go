@@ -8,3 +8,6 @@ type Task = api.Task
 type Document = api.Document
 type DocumentHeader = api.DocumentHeader
 type Section = api.Section
+type SearchResult = api.SearchResult
+type SearchResultSection = api.SearchResultSection
+type AskResponse = api.AskResponse
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/bornholm/corpus/internal/http/handler/api"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNoResults = errors.New("no results")
+)
+
+type AskOptions struct {
+	Collections []string
+}
+
+type AskOptionFunc func(opts *AskOptions)
+
+func WithAskCollections(collections ...string) AskOptionFunc {
+	return func(opts *AskOptions) {
+		opts.Collections = collections
+	}
+}
+
+func NewAskOptions(funcs ...AskOptionFunc) *AskOptions {
+	opts := &AskOptions{
+		Collections: make([]string, 0),
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+// Ask returns the answer of the server to the query, or ErrNoResults if no
+// document matches it.
+func (c *Client) Ask(ctx context.Context, query string, funcs ...AskOptionFunc) (*AskResponse, error) {
+	opts := NewAskOptions(funcs...)
+
+	endpoint := &url.URL{
+		Path: "/ask",
+	}
+
+	values := endpoint.Query()
+	values.Set("query", query)
+
+	for _, c := range opts.Collections {
+		values.Add("collection", c)
+	}
+
+	endpoint.RawQuery = values.Encode()
+
+	var buff bytes.Buffer
+
+	if err := c.request(ctx, "GET", endpoint.String(), nil, nil, &buff); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// The server answers with an empty response when no document matches
+	if buff.Len() == 0 {
+		return nil, errors.WithStack(ErrNoResults)
+	}
+
+	var res api.AskResponse
+
+	if err := json.Unmarshal(buff.Bytes(), &res); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &res, nil
+}
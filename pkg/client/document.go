@@ -140,3 +140,17 @@ func (c *Client) DeleteDocument(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (c *Client) RestoreDocument(ctx context.Context, id string) error {
+	endpoint := &url.URL{
+		Path: "/trash/documents",
+	}
+
+	endpoint = endpoint.JoinPath(id, "restore")
+
+	if err := c.request(ctx, "POST", endpoint.String(), nil, nil, nil); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/bornholm/corpus/internal/http/handler/api"
+	"github.com/pkg/errors"
+)
+
+type SearchOptions struct {
+	Collections []string
+	Size        *int
+}
+
+type SearchOptionFunc func(opts *SearchOptions)
+
+func WithSearchCollections(collections ...string) SearchOptionFunc {
+	return func(opts *SearchOptions) {
+		opts.Collections = collections
+	}
+}
+
+func WithSearchSize(size int) SearchOptionFunc {
+	return func(opts *SearchOptions) {
+		opts.Size = &size
+	}
+}
+
+func NewSearchOptions(funcs ...SearchOptionFunc) *SearchOptions {
+	opts := &SearchOptions{
+		Collections: make([]string, 0),
+	}
+
+	for _, fn := range funcs {
+		fn(opts)
+	}
+
+	return opts
+}
+
+func (c *Client) Search(ctx context.Context, query string, funcs ...SearchOptionFunc) ([]*SearchResult, error) {
+	opts := NewSearchOptions(funcs...)
+
+	endpoint := &url.URL{
+		Path: "/search",
+	}
+
+	values := endpoint.Query()
+	values.Set("query", query)
+
+	for _, c := range opts.Collections {
+		values.Add("collection", c)
+	}
+
+	if opts.Size != nil {
+		values.Set("size", strconv.FormatInt(int64(*opts.Size), 10))
+	}
+
+	endpoint.RawQuery = values.Encode()
+
+	var res api.SearchResponse
+
+	if err := c.jsonRequest(ctx, "GET", endpoint.String(), nil, nil, &res); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return res.Results, nil
+}
@@ -16,14 +16,17 @@ import (
 	bleve "github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/bornholm/corpus/internal/core/service"
+	"github.com/bornholm/corpus/internal/markdown"
 	documentTask "github.com/bornholm/corpus/internal/task/document"
 	bleveAdapter "github.com/bornholm/corpus/pkg/adapter/bleve"
 	gormAdapter "github.com/bornholm/corpus/pkg/adapter/gorm"
 	memoryAdapter "github.com/bornholm/corpus/pkg/adapter/memory"
+	memvecAdapter "github.com/bornholm/corpus/pkg/adapter/memvec"
 	"github.com/bornholm/corpus/pkg/adapter/pipeline"
 	sqlitevecAdapter "github.com/bornholm/corpus/pkg/adapter/sqlitevec"
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
+	"github.com/bornholm/genai/llm"
 	"github.com/ncruces/go-sqlite3"
 	gormlite "github.com/ncruces/go-sqlite3/gormlite"
 	"github.com/pkg/errors"
@@ -82,9 +85,11 @@ func New(ctx context.Context, funcs ...OptionFunc) (*Corpus, error) {
 		}
 	}
 
+	var sqliteVecIdx *sqlitevecAdapter.Index
+
 	if idx == nil {
-		if opts.storagePath == "" && (opts.bleveDSN == "" || opts.sqliteVecDSN == "") {
-			return nil, errors.New("corpus: WithStoragePath or both WithBleveDSN and WithSQLiteVecDSN are required when no Index is provided")
+		if opts.storagePath == "" && (opts.bleveDSN == "" || (opts.sqliteVecDSN == "" && !opts.memVecIndex)) {
+			return nil, errors.New("corpus: WithStoragePath or both WithBleveDSN and WithSQLiteVecDSN (or WithMemVecIndex) are required when no Index is provided")
 		}
 
 		bleveDSN := opts.bleveDSN
@@ -97,35 +102,80 @@ func New(ctx context.Context, funcs ...OptionFunc) (*Corpus, error) {
 			return nil, errors.Wrap(err, "could not open bleve index")
 		}
 
-		sqliteVecDSN := opts.sqliteVecDSN
-		if sqliteVecDSN == "" {
-			sqliteVecDSN = filepath.Join(opts.storagePath, "index.sqlite")
-		}
+		var vectorIdx *pipeline.IdentifiedIndex
 
-		sqliteConn, err := sqlite3.Open(sqliteVecDSN)
-		if err != nil {
-			return nil, errors.Wrap(err, "could not open sqlitevec database")
-		}
+		if opts.memVecIndex {
+			vectorIdx = pipeline.NewIdentifiedIndex("memvec", memvecAdapter.NewIndex(
+				opts.llmClient, opts.embeddingsModel, opts.maxIndexWords,
+				memvecAdapter.WithDimensions(opts.embeddingsDimensions),
+				memvecAdapter.WithPath(opts.memVecPath),
+			))
+		} else {
+			sqliteVecDSN := opts.sqliteVecDSN
+			if sqliteVecDSN == "" {
+				sqliteVecDSN = filepath.Join(opts.storagePath, "index.sqlite")
+			}
 
-		sqliteVecIdx := sqlitevecAdapter.NewIndex(sqliteConn, opts.llmClient, opts.embeddingsModel, opts.maxIndexWords)
+			sqliteConn, err := sqlite3.Open(sqliteVecDSN)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not open sqlitevec database")
+			}
+
+			sqliteVecIdx = sqlitevecAdapter.NewIndex(
+				sqliteConn, opts.llmClient, opts.embeddingsModel, opts.maxIndexWords,
+				sqlitevecAdapter.WithDimensions(opts.embeddingsDimensions),
+				sqlitevecAdapter.WithCacheSize(opts.embeddingsCacheSize),
+			)
+
+			vectorIdx = pipeline.NewIdentifiedIndex("sqlitevec", sqliteVecIdx)
+		}
 
 		weightedIndexes := pipeline.WeightedIndexes{
-			pipeline.NewIdentifiedIndex("bleve", bleveIdx):         opts.bleveWeight,
-			pipeline.NewIdentifiedIndex("sqlitevec", sqliteVecIdx): opts.sqliteVecWeight,
+			pipeline.NewIdentifiedIndex("bleve", bleveIdx): opts.bleveWeight,
+			vectorIdx: opts.sqliteVecWeight,
 		}
 
 		pipelineOpts := []pipeline.OptionFunc{}
+		if opts.fusion != nil {
+			pipelineOpts = append(pipelineOpts, pipeline.WithFusion(opts.fusion))
+		}
+		queryExpanders := []pipeline.QueryExpander{}
 		if !opts.disableHyDE && opts.llmClient != nil {
-			pipelineOpts = append(pipelineOpts,
-				pipeline.WithQueryTransformers(
-					pipeline.NewHyDEQueryTransformer(opts.llmClient, docStore),
-				),
+			hyde := pipeline.NewHyDEQueryTransformer(opts.llmClient, docStore)
+			if opts.hydeExpansion {
+				queryExpanders = append(queryExpanders, pipeline.NewTransformerQueryExpander(hyde))
+			} else {
+				pipelineOpts = append(pipelineOpts, pipeline.WithQueryTransformers(hyde))
+			}
+		}
+		if opts.multiQuery > 0 && opts.llmClient != nil {
+			queryExpanders = append(queryExpanders,
+				pipeline.NewParaphraseQueryExpander(opts.llmClient, opts.multiQuery),
 			)
 		}
+		pipelineOpts = append(pipelineOpts, pipeline.WithQueryExpanders(queryExpanders...))
+
+		if opts.contextualHeaders {
+			var summarizer llm.Client
+			if opts.contextualSummary {
+				summarizer = opts.llmClient
+			}
+			pipelineOpts = append(pipelineOpts, pipeline.WithContextualizer(pipeline.NewDocumentContextualizer(summarizer)))
+		}
 
 		resultsTransformers := []pipeline.ResultsTransformer{
 			pipeline.NewDuplicateContentResultsTransformer(docStore),
 		}
+		if opts.mmr {
+			embeddingsIdx, ok := vectorIdx.Index().(port.EmbeddingsIndex)
+			if !ok {
+				return nil, errors.Errorf("vector index '%s' does not provide sections embeddings, required by mmr", vectorIdx.ID())
+			}
+
+			resultsTransformers = append(resultsTransformers,
+				pipeline.NewMMRResultsTransformer(embeddingsIdx, opts.mmrLambda, opts.mmrMaxSimilarity),
+			)
+		}
 		if !opts.disableJudge && opts.llmClient != nil {
 			resultsTransformers = append(resultsTransformers,
 				pipeline.NewJudgeResultsTransformer(opts.llmClient, docStore, opts.maxTotalWords),
@@ -146,7 +196,12 @@ func New(ctx context.Context, funcs ...OptionFunc) (*Corpus, error) {
 		)
 	}
 
-	dmOpts := []service.DocumentManagerOptionFunc{}
+	dmOpts := []service.DocumentManagerOptionFunc{
+		service.WithMaxTotalWords(opts.maxTotalWords),
+	}
+	if opts.contextExpansion != "" {
+		dmOpts = append(dmOpts, service.WithContextExpansion(service.ContextExpansion(opts.contextExpansion), opts.contextExpansionSiblings))
+	}
 	if opts.fileConverter != nil {
 		dmOpts = append(dmOpts, service.WithDocumentManagerFileConverter(opts.fileConverter))
 	}
@@ -175,17 +230,30 @@ func New(ctx context.Context, funcs ...OptionFunc) (*Corpus, error) {
 	documentManager := service.NewDocumentManager(docStore, idx, taskRunner, opts.llmClient, dmOpts...)
 
 	// Register task handlers
+	chunkers, err := newChunkers(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create chunkers")
+	}
+
 	indexFileHandler := documentTask.NewIndexFileHandler(
-		userStore, docStore, opts.fileConverter, idx, opts.maxWordsPerSection,
+		userStore, docStore, opts.fileConverter, idx, chunkers,
 	)
 	taskRunner.RegisterTask(documentTask.TaskTypeIndexFile, indexFileHandler)
 
 	cleanupHandler := documentTask.NewCleanupHandler(idx, docStore)
 	taskRunner.RegisterTask(documentTask.TaskTypeCleanup, cleanupHandler)
 
+	checkConsistencyHandler := documentTask.NewCheckConsistencyHandler(idx, docStore)
+	taskRunner.RegisterTask(documentTask.TaskTypeCheckConsistency, checkConsistencyHandler)
+
 	reindexCollectionHandler := documentTask.NewReindexHandler(docStore, idx, opts.maxWordsPerSection)
 	taskRunner.RegisterTask(documentTask.TaskTypeReindexCollection, reindexCollectionHandler)
 
+	if sqliteVecIdx != nil {
+		migrateEmbeddingsHandler := documentTask.NewMigrateEmbeddingsHandler(docStore, sqliteVecIdx)
+		taskRunner.RegisterTask(documentTask.TaskTypeMigrateEmbeddings, migrateEmbeddingsHandler)
+	}
+
 	// Start task runner
 	go func() {
 		if err := taskRunner.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
@@ -199,6 +267,26 @@ func New(ctx context.Context, funcs ...OptionFunc) (*Corpus, error) {
 		return nil, errors.Wrap(err, "could not find or create system user")
 	}
 
+	// Re-embed the documents if the embeddings model has changed, or backfill
+	// the attributes of the documents indexed before they were recorded
+	if sqliteVecIdx != nil {
+		pending, err := sqliteVecIdx.MigrationPending(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not check embeddings migration")
+		}
+
+		sourcesPending, err := sqliteVecIdx.SourcesPending(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not check documents attributes backfill")
+		}
+
+		if pending || sourcesPending {
+			if err := taskRunner.ScheduleTask(ctx, documentTask.NewMigrateEmbeddingsTask(systemUser)); err != nil {
+				return nil, errors.Wrap(err, "could not schedule embeddings migration")
+			}
+		}
+	}
+
 	return &Corpus{
 		documentManager: documentManager,
 		taskRunner:      taskRunner,
@@ -226,6 +314,9 @@ func (c *Corpus) IndexFile(ctx context.Context, collectionID model.CollectionID,
 	if opts.ETag != "" {
 		dmOpts = append(dmOpts, service.WithDocumentManagerIndexFileETag(opts.ETag))
 	}
+	if len(opts.Metadata) > 0 {
+		dmOpts = append(dmOpts, service.WithDocumentManagerIndexFileMetadata(opts.Metadata))
+	}
 
 	taskID, err := c.documentManager.IndexFile(ctx, c.systemUser, filename, r, dmOpts...)
 	if err != nil {
@@ -246,10 +337,17 @@ func (c *Corpus) Search(ctx context.Context, query string, funcs ...SearchOption
 
 	dmOpts := []service.DocumentManagerSearchOptionFunc{
 		service.WithDocumentManagerSearchMaxResults(opts.MaxResults),
+		service.WithDocumentManagerSearchHighlight(opts.Highlight),
 	}
 	if len(opts.Collections) > 0 {
 		dmOpts = append(dmOpts, service.WithDocumentManagerSearchCollections(opts.Collections...))
 	}
+	if !opts.Filter.IsZero() {
+		dmOpts = append(dmOpts, service.WithDocumentManagerSearchFilter(opts.Filter))
+	}
+	if len(opts.Boost) > 0 {
+		dmOpts = append(dmOpts, service.WithDocumentManagerSearchBoost(opts.Boost))
+	}
 
 	results, err := c.documentManager.Search(ctx, query, dmOpts...)
 	if err != nil {
@@ -271,6 +369,9 @@ func (c *Corpus) Ask(ctx context.Context, query string, results []*port.IndexSea
 	if opts.SystemPromptTemplate != "" {
 		dmOpts = append(dmOpts, service.WithAskSystemPromptTemplate(opts.SystemPromptTemplate))
 	}
+	if opts.ContextExpansion != "" {
+		dmOpts = append(dmOpts, service.WithAskContextExpansion(service.ContextExpansion(opts.ContextExpansion), opts.ContextExpansionSiblings))
+	}
 
 	answer, sections, err := c.documentManager.Ask(ctx, query, results, dmOpts...)
 	if err != nil {
@@ -293,7 +394,7 @@ func (c *Corpus) AskWithRetrieval(ctx context.Context, query string, funcs ...Se
 		fn(opts)
 	}
 
-	result, err := c.documentManager.AskWithRetrieval(ctx, query, opts.Collections)
+	result, err := c.documentManager.AskWithRetrieval(ctx, query, opts.Collections, service.WithAskFilter(opts.Filter))
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -451,3 +552,44 @@ func newBleveIndex(ctx context.Context, indexPath string) (port.Index, error) {
 
 	return bleveAdapter.NewIndex(bleveIdx), nil
 }
+
+// newChunkers creates the chunkers of the indexed files from the chunking
+// options
+func newChunkers(opts *options) (*documentTask.Chunkers, error) {
+	chunkerOptions := []markdown.ChunkerOptionFunc{
+		markdown.WithChunkerMaxWords(opts.maxWordsPerSection),
+		markdown.WithChunkerOverlap(opts.chunkOverlap),
+		markdown.WithChunkerSentences(opts.chunkSentences, opts.chunkSentenceOverlap),
+		markdown.WithChunkerPercentile(opts.chunkSemanticPercentile),
+	}
+	if opts.llmClient != nil {
+		chunkerOptions = append(chunkerOptions, markdown.WithChunkerEmbeddings(opts.llmClient))
+	}
+
+	defaultChunker, err := markdown.NewChunker(string(opts.chunking), chunkerOptions...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	chunkers := documentTask.NewChunkers(defaultChunker)
+
+	for ext, strategy := range opts.extensionsChunking {
+		chunker, err := markdown.NewChunker(string(strategy), chunkerOptions...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create chunker of extension '%s'", ext)
+		}
+
+		chunkers.SetExtensionChunker(ext, chunker)
+	}
+
+	for collectionID, strategy := range opts.collectionsChunking {
+		chunker, err := markdown.NewChunker(string(strategy), chunkerOptions...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create chunker of collection '%s'", collectionID)
+		}
+
+		chunkers.SetCollectionChunker(collectionID, chunker)
+	}
+
+	return chunkers, nil
+}
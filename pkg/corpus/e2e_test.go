@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bornholm/corpus/pkg/adapter/fakellm"
 	"github.com/bornholm/corpus/pkg/corpus"
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
@@ -170,6 +171,89 @@ func TestEndToEndRAG(t *testing.T) {
 	})
 }
 
+// TestEndToEndRAGOffline runs the same pipeline against the deterministic fake
+// LLM provider, which requires neither docker nor network. The answers being
+// extracted from the retrieved passages, they can be asserted strictly.
+func TestEndToEndRAGOffline(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := provider.Create(ctx,
+		provider.WithChatCompletion(fakellm.Name, fakellm.Options{}),
+		provider.WithEmbeddings(fakellm.Name, fakellm.Options{}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create llm client: %+v", errors.WithStack(err))
+	}
+
+	c, err := corpus.New(ctx,
+		corpus.WithStoragePath(t.TempDir()),
+		corpus.WithLLMClient(client),
+		corpus.WithGroundingCheck(),
+		corpus.WithGroundingMinScore(0.4),
+		corpus.WithIterativeRetrieval(1),
+		corpus.WithQueryDecomposition(3),
+	)
+	if err != nil {
+		t.Fatalf("could not create corpus: %+v", errors.WithStack(err))
+	}
+
+	collID, err := c.CreateCollection(ctx, "e2e")
+	if err != nil {
+		t.Fatalf("could not create collection: %+v", errors.WithStack(err))
+	}
+
+	indexAndWait(ctx, t, c, collID, "eiffel.md", "example://e2e/eiffel.md",
+		"# The Eiffel Tower\n\nThe Eiffel Tower is a wrought-iron lattice tower located in Paris. "+
+			"It was completed in 1889 and is one of the most recognisable structures in Paris.")
+	indexAndWait(ctx, t, c, collID, "paris.md", "example://e2e/paris.md",
+		"# Paris\n\nParis is the capital and most populous city of France. "+
+			"It sits on the river Seine in the north of France.")
+
+	t.Run("grounded question", func(t *testing.T) {
+		result, err := c.AskWithRetrieval(ctx, "When was the Eiffel Tower completed?",
+			corpus.WithSearchCollections(collID),
+		)
+		if err != nil {
+			t.Fatalf("AskWithRetrieval error: %+v", errors.WithStack(err))
+		}
+
+		t.Logf("answer=%q rounds=%d grounding=%+v results=%d",
+			result.Answer, result.Rounds, result.Grounding, len(result.Results))
+
+		if len(result.Results) == 0 {
+			t.Fatalf("expected retrieved evidence for a supported question, got none")
+		}
+		if result.Grounding == nil {
+			t.Fatalf("expected a grounding verdict (checker is enabled)")
+		}
+		if result.Abstained {
+			t.Fatalf("expected an answer, got an abstention: %q", result.Answer)
+		}
+		if !strings.Contains(result.Answer, "1889") {
+			t.Errorf("expected the answer to mention '1889', got %q", result.Answer)
+		}
+		if len(result.Citations) == 0 {
+			t.Errorf("expected the answer to cite its sources")
+		}
+	})
+
+	t.Run("abstains on unsupported question", func(t *testing.T) {
+		result, err := c.AskWithRetrieval(ctx, "Who won the 2018 FIFA World Cup final?",
+			corpus.WithSearchCollections(collID),
+		)
+		if err != nil {
+			t.Fatalf("AskWithRetrieval error: %+v", errors.WithStack(err))
+		}
+
+		t.Logf("answer=%q rounds=%d grounding=%+v results=%d",
+			result.Answer, result.Rounds, result.Grounding, len(result.Results))
+
+		if len(result.Results) > 0 && !result.Abstained {
+			t.Errorf("expected an abstention, got %q", result.Answer)
+		}
+	})
+}
+
 // isAbstention reports whether an answer is the grounding-gate abstention message
 // (its stable prefix, mirroring service's defaultAbstentionMessage).
 func isAbstention(answer string) bool {
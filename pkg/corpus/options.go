@@ -3,6 +3,10 @@ package corpus
 import (
 	"net/url"
 
+	"github.com/bornholm/corpus/internal/core/service"
+	"github.com/bornholm/corpus/internal/markdown"
+	"github.com/bornholm/corpus/pkg/adapter/pipeline"
+	sqlitevecAdapter "github.com/bornholm/corpus/pkg/adapter/sqlitevec"
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
 	"github.com/bornholm/genai/llm"
@@ -14,16 +18,37 @@ type options struct {
 	databaseDSN                string
 	bleveDSN                   string
 	sqliteVecDSN               string
+	memVecIndex                bool
+	memVecPath                 string
 	llmClient                  llm.Client
 	embeddingsModel            string
+	embeddingsDimensions       int
+	embeddingsCacheSize        int
 	fileConverter              port.FileConverter
 	bleveWeight                float64
 	sqliteVecWeight            float64
+	fusion                     pipeline.Fusion
+	mmr                        bool
+	mmrLambda                  float64
+	mmrMaxSimilarity           float64
 	maxWordsPerSection         int
+	chunking                   ChunkingStrategy
+	extensionsChunking         map[string]ChunkingStrategy
+	collectionsChunking        map[model.CollectionID]ChunkingStrategy
+	chunkOverlap               int
+	chunkSentences             int
+	chunkSentenceOverlap       int
+	chunkSemanticPercentile    float64
 	maxIndexWords              int
 	maxTotalWords              int
+	contextExpansion           ContextExpansion
+	contextExpansionSiblings   int
 	taskParallelism            int
 	disableHyDE                bool
+	hydeExpansion              bool
+	multiQuery                 int
+	contextualHeaders          bool
+	contextualSummary          bool
 	disableJudge               bool
 	groundingCheck             bool
 	groundingMinScore          float64
@@ -43,12 +68,22 @@ func defaultOptions() *options {
 		bleveWeight:                0.4,
 		sqliteVecWeight:            0.6,
 		maxWordsPerSection:         250,
+		chunking:                   ChunkingHeading,
+		extensionsChunking:         map[string]ChunkingStrategy{},
+		collectionsChunking:        map[model.CollectionID]ChunkingStrategy{},
+		chunkOverlap:               markdown.DefaultChunkOverlap,
+		chunkSentences:             markdown.DefaultWindowSentences,
+		chunkSentenceOverlap:       markdown.DefaultWindowOverlap,
+		chunkSemanticPercentile:    markdown.DefaultSemanticPercentile,
 		maxIndexWords:              2000,
 		maxTotalWords:              50000,
 		taskParallelism:            5,
 		groundingMinScore:          0.4,
 		iterativeMaxRounds:         1,
 		decompositionMaxSubQueries: 3,
+		embeddingsCacheSize:        sqlitevecAdapter.DefaultCacheSize,
+		mmrLambda:                  pipeline.DefaultMMRLambda,
+		mmrMaxSimilarity:           pipeline.DefaultMMRMaxSimilarity,
 	}
 }
 
@@ -85,6 +120,15 @@ func WithSQLiteVecDSN(path string) OptionFunc {
 	}
 }
 
+// WithMemVecIndex replaces the SQLiteVec vector index with the pure Go
+// in-memory one, persisted to the given file if not empty.
+func WithMemVecIndex(path string) OptionFunc {
+	return func(o *options) {
+		o.memVecIndex = true
+		o.memVecPath = path
+	}
+}
+
 // WithLLMClient sets the LLM client used for embeddings, HyDE and Judge transformers.
 func WithLLMClient(client llm.Client) OptionFunc {
 	return func(o *options) {
@@ -99,6 +143,22 @@ func WithEmbeddingsModel(model string) OptionFunc {
 	}
 }
 
+// WithEmbeddingsDimensions sets the dimension of the vectors returned by the
+// embeddings model. By default, it is detected from the first embeddings response.
+func WithEmbeddingsDimensions(n int) OptionFunc {
+	return func(o *options) {
+		o.embeddingsDimensions = n
+	}
+}
+
+// WithEmbeddingsCacheSize sets the maximum number of section embeddings kept
+// in cache to avoid re-embedding unchanged sections. Zero disables the cache.
+func WithEmbeddingsCacheSize(n int) OptionFunc {
+	return func(o *options) {
+		o.embeddingsCacheSize = n
+	}
+}
+
 // WithFileConverter sets a file converter for converting files before indexing.
 func WithFileConverter(fc port.FileConverter) OptionFunc {
 	return func(o *options) {
@@ -106,7 +166,7 @@ func WithFileConverter(fc port.FileConverter) OptionFunc {
 	}
 }
 
-// WithIndexWeights sets the relative weights for bleve (full-text) and sqlitevec (vector) indexes.
+// WithIndexWeights sets the relative weights for bleve (full-text) and vector (sqlitevec or memvec) indexes.
 func WithIndexWeights(bleveWeight, sqliteVecWeight float64) OptionFunc {
 	return func(o *options) {
 		o.bleveWeight = bleveWeight
@@ -114,6 +174,37 @@ func WithIndexWeights(bleveWeight, sqliteVecWeight float64) OptionFunc {
 	}
 }
 
+// WithFusion sets the strategy used to merge the bleve and sqlitevec results.
+// Defaults to Reciprocal Rank Fusion; use pipeline.NewWeightedFusion() to
+// restore the legacy fixed weighting.
+func WithFusion(fusion pipeline.Fusion) OptionFunc {
+	return func(o *options) {
+		o.fusion = fusion
+	}
+}
+
+// WithMMR enables the Maximal Marginal Relevance reranking of the search
+// results, discarding near-duplicate sections and trading relevance against
+// novelty with the given lambda (1 ignores novelty, default 0.7 when outside
+// [0, 1]). Sections embeddings are read from the vector index.
+func WithMMR(lambda float64) OptionFunc {
+	return func(o *options) {
+		o.mmr = true
+		if lambda >= 0 && lambda <= 1 {
+			o.mmrLambda = lambda
+		}
+	}
+}
+
+// WithMMRMaxSimilarity sets the similarity from which a section is discarded as
+// redundant with an already selected one (default 0.95). Only meaningful
+// together with WithMMR.
+func WithMMRMaxSimilarity(maxSimilarity float64) OptionFunc {
+	return func(o *options) {
+		o.mmrMaxSimilarity = maxSimilarity
+	}
+}
+
 // WithMaxWordsPerSection sets the maximum number of words per document section.
 func WithMaxWordsPerSection(n int) OptionFunc {
 	return func(o *options) {
@@ -121,6 +212,75 @@ func WithMaxWordsPerSection(n int) OptionFunc {
 	}
 }
 
+// ChunkingStrategy identifies how the indexed files are split into sections.
+type ChunkingStrategy string
+
+const (
+	// ChunkingHeading splits the documents on their markdown headings, sections
+	// exceeding the maximum number of words per section being split in turn.
+	ChunkingHeading ChunkingStrategy = markdown.ChunkerHeading
+	// ChunkingSentenceWindow groups consecutive sentences, each section sharing
+	// some sentences with the previous one.
+	ChunkingSentenceWindow ChunkingStrategy = markdown.ChunkerSentenceWindow
+	// ChunkingFixed splits the documents into sections of the maximum number of
+	// words per section, each section sharing some words with the previous one.
+	ChunkingFixed ChunkingStrategy = markdown.ChunkerFixed
+	// ChunkingSemantic starts a new section where the topic shifts, based on the
+	// sentences embeddings. Requires an LLM client.
+	ChunkingSemantic ChunkingStrategy = markdown.ChunkerSemantic
+)
+
+// WithChunking sets the default strategy splitting the indexed files into
+// sections (default: ChunkingHeading).
+func WithChunking(strategy ChunkingStrategy) OptionFunc {
+	return func(o *options) {
+		o.chunking = strategy
+	}
+}
+
+// WithExtensionChunking sets the chunking strategy of the files with the given
+// extension, e.g. ".pdf".
+func WithExtensionChunking(ext string, strategy ChunkingStrategy) OptionFunc {
+	return func(o *options) {
+		o.extensionsChunking[ext] = strategy
+	}
+}
+
+// WithCollectionChunking sets the chunking strategy of the files indexed in the
+// given collection. It takes precedence over the extension strategy.
+func WithCollectionChunking(collectionID model.CollectionID, strategy ChunkingStrategy) OptionFunc {
+	return func(o *options) {
+		o.collectionsChunking[collectionID] = strategy
+	}
+}
+
+// WithChunkOverlap sets the number of words shared by consecutive sections with
+// the ChunkingFixed strategy (default 50).
+func WithChunkOverlap(n int) OptionFunc {
+	return func(o *options) {
+		o.chunkOverlap = n
+	}
+}
+
+// WithChunkSentences sets the number of sentences per section and the number of
+// sentences shared by consecutive sections with the ChunkingSentenceWindow
+// strategy (default 5 and 1).
+func WithChunkSentences(n int, overlap int) OptionFunc {
+	return func(o *options) {
+		o.chunkSentences = n
+		o.chunkSentenceOverlap = overlap
+	}
+}
+
+// WithChunkSemanticPercentile sets the percentile of the distances between
+// consecutive sentences above which the ChunkingSemantic strategy starts a new
+// section (default 0.9).
+func WithChunkSemanticPercentile(percentile float64) OptionFunc {
+	return func(o *options) {
+		o.chunkSemanticPercentile = percentile
+	}
+}
+
 // WithMaxIndexWords sets the maximum number of words indexed per document.
 func WithMaxIndexWords(n int) OptionFunc {
 	return func(o *options) {
@@ -128,7 +288,8 @@ func WithMaxIndexWords(n int) OptionFunc {
 	}
 }
 
-// WithMaxTotalWords sets the maximum total words used by the Judge transformer.
+// WithMaxTotalWords sets the maximum total words used by the Judge transformer
+// and by the expansion of the sections given to the LLM when answering.
 func WithMaxTotalWords(n int) OptionFunc {
 	return func(o *options) {
 		o.maxTotalWords = n
@@ -149,6 +310,41 @@ func WithDisableHyDE() OptionFunc {
 	}
 }
 
+// WithHyDEExpansion searches the HyDE hypothetical answer alongside the
+// original query, fusing their results, instead of replacing the query.
+func WithHyDEExpansion() OptionFunc {
+	return func(o *options) {
+		o.hydeExpansion = true
+	}
+}
+
+// WithMultiQuery searches the given number of LLM paraphrases of each query
+// alongside it, fusing all the results (RAG-fusion). Requires an LLM client.
+// Disabled by default.
+func WithMultiQuery(paraphrases int) OptionFunc {
+	return func(o *options) {
+		o.multiQuery = paraphrases
+	}
+}
+
+// WithContextualHeaders prefixes the indexed text of each section with the
+// document title and the breadcrumb of its headings. The sections content
+// returned by searches is left untouched. Disabled by default.
+func WithContextualHeaders() OptionFunc {
+	return func(o *options) {
+		o.contextualHeaders = true
+	}
+}
+
+// WithContextualSummary adds an LLM generated one-line summary of the document
+// to the contextual headers of its sections. Requires an LLM client.
+func WithContextualSummary() OptionFunc {
+	return func(o *options) {
+		o.contextualHeaders = true
+		o.contextualSummary = true
+	}
+}
+
 // WithDisableJudge disables the Judge results transformer.
 func WithDisableJudge() OptionFunc {
 	return func(o *options) {
@@ -234,6 +430,7 @@ type IndexFileOptions struct {
 	Source      *url.URL
 	ETag        string
 	Collections []model.CollectionID
+	Metadata    map[string][]string
 }
 
 // IndexFileOptionFunc configures an IndexFile call.
@@ -260,10 +457,21 @@ func WithIndexFileCollections(ids ...model.CollectionID) IndexFileOptionFunc {
 	}
 }
 
+// WithIndexFileMetadata sets metadata on the indexed file, each key replacing
+// the values of its front matter.
+func WithIndexFileMetadata(metadata map[string][]string) IndexFileOptionFunc {
+	return func(o *IndexFileOptions) {
+		o.Metadata = metadata
+	}
+}
+
 // SearchOptions holds options for Search calls.
 type SearchOptions struct {
 	MaxResults  int
 	Collections []model.CollectionID
+	Filter      *port.IndexSearchFilter
+	Highlight   bool
+	Boost       map[string]string
 }
 
 // SearchOptionFunc configures a Search call.
@@ -276,6 +484,27 @@ func WithSearchMaxResults(n int) SearchOptionFunc {
 	}
 }
 
+// WithSearchFilter restricts the search to the documents matching the given filter.
+func WithSearchFilter(filter *port.IndexSearchFilter) SearchOptionFunc {
+	return func(o *SearchOptions) {
+		o.Filter = filter
+	}
+}
+
+// WithSearchHighlight requests the fragments of each section matching the query.
+func WithSearchHighlight(highlight bool) SearchOptionFunc {
+	return func(o *SearchOptions) {
+		o.Highlight = highlight
+	}
+}
+
+// WithSearchBoost ranks higher the documents having the given metadata key/values.
+func WithSearchBoost(boost map[string]string) SearchOptionFunc {
+	return func(o *SearchOptions) {
+		o.Boost = boost
+	}
+}
+
 // WithSearchCollections restricts the search to the given collection IDs.
 func WithSearchCollections(ids ...model.CollectionID) SearchOptionFunc {
 	return func(o *SearchOptions) {
@@ -283,9 +512,40 @@ func WithSearchCollections(ids ...model.CollectionID) SearchOptionFunc {
 	}
 }
 
+// ContextExpansion selects the surroundings of the retrieved sections given to
+// the LLM when answering.
+type ContextExpansion string
+
+const (
+	// ContextExpansionNone gives the retrieved sections only.
+	ContextExpansionNone ContextExpansion = ContextExpansion(service.ContextExpansionNone)
+	// ContextExpansionParent gives the parent section of each retrieved
+	// section.
+	ContextExpansionParent ContextExpansion = ContextExpansion(service.ContextExpansionParent)
+	// ContextExpansionSiblings adds the given number of sibling sections
+	// before and after each retrieved section.
+	ContextExpansionSiblings ContextExpansion = ContextExpansion(service.ContextExpansionSiblings)
+	// ContextExpansionDocument gives the whole document of each retrieved
+	// section.
+	ContextExpansionDocument ContextExpansion = ContextExpansion(service.ContextExpansionDocument)
+)
+
+// WithContextExpansion sets the default expansion of the retrieved sections
+// when answering (default: ContextExpansionNone), siblings being used with
+// ContextExpansionSiblings. Expanded sections are only added while the context
+// does not exceed the maximum total words.
+func WithContextExpansion(expansion ContextExpansion, siblings int) OptionFunc {
+	return func(o *options) {
+		o.contextExpansion = expansion
+		o.contextExpansionSiblings = siblings
+	}
+}
+
 // AskOptions holds options for Ask calls.
 type AskOptions struct {
-	SystemPromptTemplate string
+	SystemPromptTemplate     string
+	ContextExpansion         ContextExpansion
+	ContextExpansionSiblings int
 }
 
 // AskOptionFunc configures an Ask call.
@@ -297,3 +557,12 @@ func WithAskSystemPromptTemplate(tmpl string) AskOptionFunc {
 		o.SystemPromptTemplate = tmpl
 	}
 }
+
+// WithAskContextExpansion overrides the expansion of the retrieved sections for
+// this call, siblings being used with ContextExpansionSiblings.
+func WithAskContextExpansion(expansion ContextExpansion, siblings int) AskOptionFunc {
+	return func(o *AskOptions) {
+		o.ContextExpansion = expansion
+		o.ContextExpansionSiblings = siblings
+	}
+}
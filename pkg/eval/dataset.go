@@ -0,0 +1,148 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Dataset is a set of questions asked to the corpus, with the sources expected
+// to be retrieved to answer them.
+type Dataset struct {
+	// Collections restricts the retrieval of all the cases, unless a case
+	// defines its own collections
+	Collections []string `json:"collections,omitempty" yaml:"collections,omitempty"`
+	Cases       []Case   `json:"cases" yaml:"cases"`
+}
+
+// Case is a question of the dataset
+type Case struct {
+	ID       string `json:"id,omitempty" yaml:"id,omitempty"`
+	Question string `json:"question" yaml:"question"`
+	// ExpectedSources are the sources of the documents which should be
+	// retrieved to answer the question
+	ExpectedSources []string `json:"expected_sources,omitempty" yaml:"expected_sources,omitempty"`
+	// ReferenceAnswer is compared to the generated answer, when given
+	ReferenceAnswer string `json:"reference_answer,omitempty" yaml:"reference_answer,omitempty"`
+	// Answerable tells whether the corpus holds the answer to the question,
+	// true by default. The questions without answer are expected to be
+	// abstained from.
+	Answerable  *bool    `json:"answerable,omitempty" yaml:"answerable,omitempty"`
+	Collections []string `json:"collections,omitempty" yaml:"collections,omitempty"`
+}
+
+// IsAnswerable returns whether the corpus is expected to answer the question
+func (c Case) IsAnswerable() bool {
+	return c.Answerable == nil || *c.Answerable
+}
+
+// LoadDataset reads the dataset file, either a YAML document (".yaml", ".yml")
+// or a case per line (".jsonl").
+func LoadDataset(path string) (*Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer file.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dataset, err := ReadYAMLDataset(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read dataset '%s'", path)
+		}
+
+		return dataset, nil
+
+	case ".jsonl":
+		dataset, err := ReadJSONLDataset(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read dataset '%s'", path)
+		}
+
+		return dataset, nil
+
+	default:
+		return nil, errors.Errorf("no dataset format associated with '%s' file extension", ext)
+	}
+}
+
+// ReadYAMLDataset reads a dataset from a YAML document
+func ReadYAMLDataset(r io.Reader) (*Dataset, error) {
+	var dataset Dataset
+
+	if err := yaml.NewDecoder(r).Decode(&dataset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := dataset.validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &dataset, nil
+}
+
+// ReadJSONLDataset reads a dataset from a JSON encoded case per line, the empty
+// lines being ignored
+func ReadJSONLDataset(r io.Reader) (*Dataset, error) {
+	dataset := &Dataset{
+		Cases: make([]Case, 0),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var c Case
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, errors.Wrapf(err, "could not parse line %d", line)
+		}
+
+		dataset.Cases = append(dataset.Cases, c)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := dataset.validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return dataset, nil
+}
+
+// validate ensures each case has a question, numbering the cases without
+// identifier
+func (d *Dataset) validate() error {
+	for i := range d.Cases {
+		c := &d.Cases[i]
+
+		if strings.TrimSpace(c.Question) == "" {
+			return errors.Errorf("case #%d has no question", i+1)
+		}
+
+		if c.ID == "" {
+			c.ID = strconv.Itoa(i + 1)
+		}
+	}
+
+	return nil
+}
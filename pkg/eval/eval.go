@@ -0,0 +1,251 @@
+// Package eval measures the quality of the retrieval and of the answers of a
+// corpus against a dataset of questions, so that configuration changes can be
+// compared by diffing the reports.
+package eval
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/bornholm/go-x/slogx"
+	"github.com/pkg/errors"
+)
+
+type Options struct {
+	// MaxResults is the number of retrieved sources considered for the
+	// retrieval metrics, the k of recall@k
+	MaxResults int
+	// SearchOnly disables the answering of the questions, only the retrieval
+	// metrics being computed
+	SearchOnly bool
+}
+
+type OptionFunc func(opts *Options)
+
+func WithMaxResults(maxResults int) OptionFunc {
+	return func(opts *Options) {
+		opts.MaxResults = maxResults
+	}
+}
+
+func WithSearchOnly(searchOnly bool) OptionFunc {
+	return func(opts *Options) {
+		opts.SearchOnly = searchOnly
+	}
+}
+
+func NewOptions(funcs ...OptionFunc) *Options {
+	opts := &Options{
+		MaxResults: 5,
+	}
+	for _, fn := range funcs {
+		fn(opts)
+	}
+	return opts
+}
+
+// Report is the outcome of the evaluation of a dataset. It holds no timing so
+// that the reports of two runs on the same corpus can be diffed.
+type Report struct {
+	MaxResults int           `json:"max_results"`
+	Summary    Summary       `json:"summary"`
+	Cases      []*CaseReport `json:"cases"`
+}
+
+// Summary aggregates the metrics of the cases. The metrics are nil when no
+// case allows to compute them.
+type Summary struct {
+	Cases  int `json:"cases"`
+	Failed int `json:"failed"`
+	// RecallAtK is the mean share of the expected sources in the first
+	// MaxResults retrieved ones, over the cases having expected sources
+	RecallAtK *float64 `json:"recall_at_k,omitempty"`
+	// MRR is the mean reciprocal rank of the first expected source, over the
+	// cases having expected sources
+	MRR *float64 `json:"mrr,omitempty"`
+	// AbstentionRate is the share of the questions asked which were abstained
+	// from or had no matching document
+	AbstentionRate *float64 `json:"abstention_rate,omitempty"`
+	// GroundingAgreement is the share of the questions asked where the
+	// decision to answer or to abstain matches their answerability
+	GroundingAgreement *float64 `json:"grounding_agreement,omitempty"`
+	// AnswerF1 is the mean word overlap F1 score of the answers against the
+	// reference answers, over the answered cases having one
+	AnswerF1 *float64 `json:"answer_f1,omitempty"`
+}
+
+// CaseReport is the outcome of the evaluation of a case
+type CaseReport struct {
+	ID               string   `json:"id"`
+	Question         string   `json:"question"`
+	RetrievedSources []string `json:"retrieved_sources"`
+	Recall           *float64 `json:"recall,omitempty"`
+	ReciprocalRank   *float64 `json:"reciprocal_rank,omitempty"`
+
+	Answer             string   `json:"answer,omitempty"`
+	Abstained          *bool    `json:"abstained,omitempty"`
+	GroundingStatus    string   `json:"grounding_status,omitempty"`
+	GroundingScore     *float64 `json:"grounding_score,omitempty"`
+	GroundingAgreement *bool    `json:"grounding_agreement,omitempty"`
+	AnswerF1           *float64 `json:"answer_f1,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Run evaluates the cases of the dataset against the target. The failure of a
+// case is reported without interrupting the evaluation.
+func Run(ctx context.Context, target Target, dataset *Dataset, funcs ...OptionFunc) (*Report, error) {
+	opts := NewOptions(funcs...)
+
+	report := &Report{
+		MaxResults: opts.MaxResults,
+		Cases:      make([]*CaseReport, 0, len(dataset.Cases)),
+	}
+
+	for _, c := range dataset.Cases {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		collections := c.Collections
+		if len(collections) == 0 {
+			collections = dataset.Collections
+		}
+
+		caseReport, err := runCase(ctx, target, c, collections, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			slog.ErrorContext(ctx, "could not evaluate case", slog.String("case", c.ID), slogx.Error(err))
+
+			caseReport.Error = err.Error()
+		}
+
+		slog.DebugContext(ctx, "case evaluated", slog.String("case", c.ID))
+
+		report.Cases = append(report.Cases, caseReport)
+	}
+
+	report.Summary = summarize(report.Cases)
+
+	return report, nil
+}
+
+func runCase(ctx context.Context, target Target, c Case, collections []string, opts *Options) (*CaseReport, error) {
+	report := &CaseReport{
+		ID:               c.ID,
+		Question:         c.Question,
+		RetrievedSources: []string{},
+	}
+
+	sources, err := target.Search(ctx, c.Question, collections, opts.MaxResults)
+	if err != nil {
+		return report, errors.Wrap(err, "could not search")
+	}
+
+	if len(sources) > opts.MaxResults {
+		sources = sources[:opts.MaxResults]
+	}
+
+	report.RetrievedSources = sources
+
+	if len(c.ExpectedSources) > 0 {
+		r := recall(sources, c.ExpectedSources)
+		rr := reciprocalRank(sources, c.ExpectedSources)
+		report.Recall = &r
+		report.ReciprocalRank = &rr
+	}
+
+	if opts.SearchOnly {
+		return report, nil
+	}
+
+	answer, err := target.Ask(ctx, c.Question, collections)
+	if err != nil {
+		return report, errors.Wrap(err, "could not ask")
+	}
+
+	abstained := answer.Abstained || answer.NoResults
+	agreement := abstained != c.IsAnswerable()
+
+	report.Answer = answer.Text
+	report.Abstained = &abstained
+	report.GroundingStatus = answer.GroundingStatus
+	report.GroundingScore = answer.GroundingScore
+	report.GroundingAgreement = &agreement
+
+	if c.ReferenceAnswer != "" && !abstained {
+		f1 := tokenF1(answer.Text, c.ReferenceAnswer)
+		report.AnswerF1 = &f1
+	}
+
+	return report, nil
+}
+
+func summarize(cases []*CaseReport) Summary {
+	summary := Summary{
+		Cases: len(cases),
+	}
+
+	var recalls, reciprocalRanks, abstentions, agreements, f1s []float64
+
+	for _, c := range cases {
+		if c.Error != "" {
+			summary.Failed++
+			continue
+		}
+
+		if c.Recall != nil {
+			recalls = append(recalls, *c.Recall)
+		}
+
+		if c.ReciprocalRank != nil {
+			reciprocalRanks = append(reciprocalRanks, *c.ReciprocalRank)
+		}
+
+		if c.Abstained != nil {
+			abstentions = append(abstentions, boolToFloat(*c.Abstained))
+		}
+
+		if c.GroundingAgreement != nil {
+			agreements = append(agreements, boolToFloat(*c.GroundingAgreement))
+		}
+
+		if c.AnswerF1 != nil {
+			f1s = append(f1s, *c.AnswerF1)
+		}
+	}
+
+	summary.RecallAtK = mean(recalls)
+	summary.MRR = mean(reciprocalRanks)
+	summary.AbstentionRate = mean(abstentions)
+	summary.GroundingAgreement = mean(agreements)
+	summary.AnswerF1 = mean(f1s)
+
+	return summary
+}
+
+func mean(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	m := sum / float64(len(values))
+
+	return &m
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fakeTarget answers with canned sources and answers
+type fakeTarget struct {
+	sources map[string][]string
+	answers map[string]*Answer
+}
+
+func (t *fakeTarget) Search(ctx context.Context, question string, collections []string, maxResults int) ([]string, error) {
+	return t.sources[question], nil
+}
+
+func (t *fakeTarget) Ask(ctx context.Context, question string, collections []string) (*Answer, error) {
+	answer, exists := t.answers[question]
+	if !exists {
+		return nil, errors.New("unexpected question")
+	}
+
+	return answer, nil
+}
+
+const testDataset = `
+{"id": "capital", "question": "capital", "expected_sources": ["https://example.net/france"], "reference_answer": "Paris is the capital of France"}
+{"id": "river", "question": "river", "expected_sources": ["https://example.net/seine", "https://example.net/loire"]}
+
+{"id": "atlantis", "question": "atlantis", "answerable": false}
+`
+
+func TestRun(t *testing.T) {
+	dataset, err := ReadJSONLDataset(strings.NewReader(testDataset))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	target := &fakeTarget{
+		sources: map[string][]string{
+			"capital": {"https://example.net/paris", "https://example.net/france/"},
+			"river":   {"https://example.net/seine", "https://example.net/rhone"},
+		},
+		answers: map[string]*Answer{
+			"capital":  {Text: "Paris."},
+			"river":    {Text: "The Seine."},
+			"atlantis": {NoResults: true},
+		},
+	}
+
+	report, err := Run(context.Background(), target, dataset, WithMaxResults(2))
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 3, len(report.Cases); e != g {
+		t.Fatalf("len(report.Cases): expected %d, got %d", e, g)
+	}
+
+	summary := report.Summary
+
+	assertMetric(t, "recall_at_k", 0.75, summary.RecallAtK)
+	assertMetric(t, "mrr", 0.75, summary.MRR)
+	assertMetric(t, "abstention_rate", 1.0/3, summary.AbstentionRate)
+	assertMetric(t, "grounding_agreement", 1, summary.GroundingAgreement)
+	assertMetric(t, "answer_f1", 2.0/7, summary.AnswerF1)
+
+	// The report must be serializable to be diffed
+	if _, err := json.Marshal(report); err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+}
+
+func TestRunFailedCase(t *testing.T) {
+	dataset := &Dataset{Cases: []Case{{ID: "unknown", Question: "unknown"}}}
+
+	report, err := Run(context.Background(), &fakeTarget{}, dataset)
+	if err != nil {
+		t.Fatalf("%+v", errors.WithStack(err))
+	}
+
+	if e, g := 1, report.Summary.Failed; e != g {
+		t.Fatalf("report.Summary.Failed: expected %d, got %d", e, g)
+	}
+
+	if report.Cases[0].Error == "" {
+		t.Errorf("expected the case to report its error")
+	}
+}
+
+func assertMetric(t *testing.T, name string, expected float64, value *float64) {
+	t.Helper()
+
+	if value == nil {
+		t.Errorf("%s: expected %v, got nil", name, expected)
+		return
+	}
+
+	if math.Abs(expected-*value) > 1e-9 {
+		t.Errorf("%s: expected %v, got %v", name, expected, *value)
+	}
+}
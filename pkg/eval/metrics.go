@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// recall returns the share of the expected sources found in the retrieved
+// ones
+func recall(retrieved []string, expected []string) float64 {
+	if len(expected) == 0 {
+		return 0
+	}
+
+	found := 0
+	for _, e := range expected {
+		if slices.ContainsFunc(retrieved, func(r string) bool { return sameSource(r, e) }) {
+			found++
+		}
+	}
+
+	return float64(found) / float64(len(expected))
+}
+
+// reciprocalRank returns the inverse of the rank of the first expected source
+// in the retrieved ones, 0 if none was retrieved
+func reciprocalRank(retrieved []string, expected []string) float64 {
+	for i, r := range retrieved {
+		if slices.ContainsFunc(expected, func(e string) bool { return sameSource(r, e) }) {
+			return 1 / float64(i+1)
+		}
+	}
+
+	return 0
+}
+
+func sameSource(s1, s2 string) bool {
+	return strings.TrimSuffix(s1, "/") == strings.TrimSuffix(s2, "/")
+}
+
+// tokenF1 returns the harmonic mean of the precision and recall of the words
+// of the answer against the words of the reference
+func tokenF1(answer string, reference string) float64 {
+	answerTokens := tokenize(answer)
+	referenceTokens := tokenize(reference)
+
+	if len(answerTokens) == 0 || len(referenceTokens) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(referenceTokens))
+	for _, t := range referenceTokens {
+		counts[t]++
+	}
+
+	common := 0
+	for _, t := range answerTokens {
+		if counts[t] > 0 {
+			common++
+			counts[t]--
+		}
+	}
+
+	if common == 0 {
+		return 0
+	}
+
+	precision := float64(common) / float64(len(answerTokens))
+	recall := float64(common) / float64(len(referenceTokens))
+
+	return 2 * precision * recall / (precision + recall)
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
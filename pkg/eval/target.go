@@ -0,0 +1,143 @@
+package eval
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/client"
+	"github.com/bornholm/corpus/pkg/corpus"
+	"github.com/bornholm/corpus/pkg/model"
+	"github.com/pkg/errors"
+)
+
+// Target is the corpus evaluated, embedded or remote
+type Target interface {
+	// Search returns the sources of the documents matching the question, by
+	// decreasing relevance
+	Search(ctx context.Context, question string, collections []string, maxResults int) ([]string, error)
+	// Ask answers the question with the retrieval pipeline of the corpus
+	Ask(ctx context.Context, question string, collections []string) (*Answer, error)
+}
+
+// Answer is the outcome of a question asked to the target
+type Answer struct {
+	Text      string
+	Abstained bool
+	// NoResults is true when no document matched the question
+	NoResults bool
+	// GroundingStatus and GroundingScore are empty when the grounding check
+	// is disabled
+	GroundingStatus string
+	GroundingScore  *float64
+}
+
+// CorpusTarget evaluates an embedded corpus
+type CorpusTarget struct {
+	corpus *corpus.Corpus
+}
+
+func NewCorpusTarget(c *corpus.Corpus) *CorpusTarget {
+	return &CorpusTarget{corpus: c}
+}
+
+// Search implements Target.
+func (t *CorpusTarget) Search(ctx context.Context, question string, collections []string, maxResults int) ([]string, error) {
+	results, err := t.corpus.Search(ctx, question,
+		corpus.WithSearchMaxResults(maxResults),
+		corpus.WithSearchCollections(collectionIDs(collections)...),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sources := make([]string, 0, len(results))
+	for _, r := range results {
+		sources = append(sources, r.Source.String())
+	}
+
+	return sources, nil
+}
+
+// Ask implements Target.
+func (t *CorpusTarget) Ask(ctx context.Context, question string, collections []string) (*Answer, error) {
+	result, err := t.corpus.AskWithRetrieval(ctx, question, corpus.WithSearchCollections(collectionIDs(collections)...))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	answer := &Answer{
+		Text:      result.Answer,
+		Abstained: result.Abstained,
+		NoResults: len(result.Results) == 0,
+	}
+
+	if result.Grounding != nil {
+		answer.GroundingStatus = string(result.Grounding.Status)
+		answer.GroundingScore = &result.Grounding.Score
+	}
+
+	return answer, nil
+}
+
+var _ Target = &CorpusTarget{}
+
+// ClientTarget evaluates a remote corpus server
+type ClientTarget struct {
+	client *client.Client
+}
+
+func NewClientTarget(c *client.Client) *ClientTarget {
+	return &ClientTarget{client: c}
+}
+
+// Search implements Target.
+func (t *ClientTarget) Search(ctx context.Context, question string, collections []string, maxResults int) ([]string, error) {
+	results, err := t.client.Search(ctx, question,
+		client.WithSearchSize(maxResults),
+		client.WithSearchCollections(collections...),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sources := make([]string, 0, len(results))
+	for _, r := range results {
+		sources = append(sources, r.Source)
+	}
+
+	return sources, nil
+}
+
+// Ask implements Target.
+func (t *ClientTarget) Ask(ctx context.Context, question string, collections []string) (*Answer, error) {
+	res, err := t.client.Ask(ctx, question, client.WithAskCollections(collections...))
+	if err != nil {
+		if errors.Is(err, client.ErrNoResults) {
+			return &Answer{NoResults: true}, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	answer := &Answer{
+		Text:      res.Response,
+		Abstained: res.Abstained,
+	}
+
+	if res.Grounding != nil {
+		answer.GroundingStatus = string(res.Grounding.Status)
+		answer.GroundingScore = &res.Grounding.Score
+	}
+
+	return answer, nil
+}
+
+var _ Target = &ClientTarget{}
+
+func collectionIDs(collections []string) []model.CollectionID {
+	ids := make([]model.CollectionID, 0, len(collections))
+	for _, c := range collections {
+		ids = append(ids, model.CollectionID(c))
+	}
+
+	return ids
+}
@@ -29,6 +29,26 @@ type PersistedCollection interface {
 	WithLifecycle
 }
 
+// DefaultVersionRetention is the number of previous versions kept for each
+// document of a collection not specifying it
+const DefaultVersionRetention = 10
+
+// WithVersionRetention is implemented by the collections limiting the number
+// of previous versions kept for each of their documents.
+type WithVersionRetention interface {
+	VersionRetention() int
+}
+
+// CollectionVersionRetention returns the number of previous versions kept for
+// each document of the given collection.
+func CollectionVersionRetention(c Collection) int {
+	if r, ok := c.(WithVersionRetention); ok {
+		return r.VersionRetention()
+	}
+
+	return DefaultVersionRetention
+}
+
 type CollectionStats struct {
 	TotalDocuments int64
 }
@@ -17,12 +17,18 @@ const (
 	CollectionShareLevelWrite CollectionShareLevel = "write"
 )
 
-// CollectionShare represents a share of a collection with a specific user at a given access level.
+// CollectionShare represents a share of a collection with a specific user or
+// group at a given access level.
 type CollectionShare interface {
 	WithID[CollectionShareID]
 
 	CollectionID() CollectionID
+	// SharedWith returns the user the collection is shared with, or nil if the
+	// collection is shared with a group
 	SharedWith() User
+	// SharedWithGroup returns the group the collection is shared with, or nil
+	// if the collection is shared with a user
+	SharedWithGroup() Group
 	Level() CollectionShareLevel
 }
 
@@ -36,6 +42,7 @@ type BaseCollectionShare struct {
 	id           CollectionShareID
 	collectionID CollectionID
 	sharedWith   User
+	group        Group
 	level        CollectionShareLevel
 }
 
@@ -54,6 +61,11 @@ func (s *BaseCollectionShare) SharedWith() User {
 	return s.sharedWith
 }
 
+// SharedWithGroup implements CollectionShare.
+func (s *BaseCollectionShare) SharedWithGroup() Group {
+	return s.group
+}
+
 // Level implements CollectionShare.
 func (s *BaseCollectionShare) Level() CollectionShareLevel {
 	return s.level
@@ -68,4 +80,13 @@ func NewCollectionShare(collectionID CollectionID, sharedWith User, level Collec
 	}
 }
 
+func NewCollectionGroupShare(collectionID CollectionID, group Group, level CollectionShareLevel) *BaseCollectionShare {
+	return &BaseCollectionShare{
+		id:           NewCollectionShareID(),
+		collectionID: collectionID,
+		group:        group,
+		level:        level,
+	}
+}
+
 var _ CollectionShare = &BaseCollectionShare{}
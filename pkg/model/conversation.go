@@ -0,0 +1,142 @@
+package model
+
+import (
+	"github.com/rs/xid"
+)
+
+type ConversationID string
+
+func NewConversationID() ConversationID {
+	return ConversationID(xid.New().String())
+}
+
+type ConversationTurnID string
+
+func NewConversationTurnID() ConversationTurnID {
+	return ConversationTurnID(xid.New().String())
+}
+
+// Conversation is a sequence of questions asked by a user
+// on a set of collections, each question being answered
+// in the light of the previous turns
+type Conversation interface {
+	WithID[ConversationID]
+
+	Title() string
+
+	// Collections returns the collections the conversation is scoped to,
+	// all the collections readable by its owner if empty
+	Collections() []CollectionID
+}
+
+type OwnedConversation interface {
+	Conversation
+	WithOwner
+}
+
+type PersistedConversation interface {
+	OwnedConversation
+	WithLifecycle
+}
+
+// ConversationTurn is a question of a conversation and its answer
+type ConversationTurn interface {
+	WithID[ConversationTurnID]
+
+	Question() string
+	// StandaloneQuestion returns the question rewritten to be understood
+	// without the previous turns, as used for the retrieval
+	StandaloneQuestion() string
+	Answer() string
+
+	// Sections returns the sections cited by the answer
+	Sections() []SectionID
+}
+
+type PersistedConversationTurn interface {
+	ConversationTurn
+	WithLifecycle
+}
+
+type BaseConversation struct {
+	id          ConversationID
+	owner       User
+	title       string
+	collections []CollectionID
+}
+
+// ID implements Conversation.
+func (c *BaseConversation) ID() ConversationID {
+	return c.id
+}
+
+// Owner implements OwnedConversation.
+func (c *BaseConversation) Owner() User {
+	return c.owner
+}
+
+// Title implements Conversation.
+func (c *BaseConversation) Title() string {
+	return c.title
+}
+
+// Collections implements Conversation.
+func (c *BaseConversation) Collections() []CollectionID {
+	return c.collections
+}
+
+var _ OwnedConversation = &BaseConversation{}
+
+func NewConversation(owner User, title string, collections ...CollectionID) *BaseConversation {
+	return &BaseConversation{
+		id:          NewConversationID(),
+		owner:       owner,
+		title:       title,
+		collections: collections,
+	}
+}
+
+type BaseConversationTurn struct {
+	id                 ConversationTurnID
+	question           string
+	standaloneQuestion string
+	answer             string
+	sections           []SectionID
+}
+
+// ID implements ConversationTurn.
+func (t *BaseConversationTurn) ID() ConversationTurnID {
+	return t.id
+}
+
+// Question implements ConversationTurn.
+func (t *BaseConversationTurn) Question() string {
+	return t.question
+}
+
+// StandaloneQuestion implements ConversationTurn.
+func (t *BaseConversationTurn) StandaloneQuestion() string {
+	return t.standaloneQuestion
+}
+
+// Answer implements ConversationTurn.
+func (t *BaseConversationTurn) Answer() string {
+	return t.answer
+}
+
+// Sections implements ConversationTurn.
+func (t *BaseConversationTurn) Sections() []SectionID {
+	return t.sections
+}
+
+var _ ConversationTurn = &BaseConversationTurn{}
+
+func NewConversationTurn(question string, standaloneQuestion string, answer string, sections ...SectionID) *BaseConversationTurn {
+	return &BaseConversationTurn{
+		id:                 NewConversationTurnID(),
+		question:           question,
+		standaloneQuestion: standaloneQuestion,
+		answer:             answer,
+		sections:           sections,
+	}
+}
@@ -37,6 +37,16 @@ func (d *ownedDocument) Owner() User {
 	return d.owner
 }
 
+// Metadata implements WithMetadata.
+func (d *ownedDocument) Metadata() map[string][]string {
+	return DocumentMetadata(d.Document)
+}
+
+// TaskID implements WithTaskID.
+func (d *ownedDocument) TaskID() TaskID {
+	return DocumentTaskID(d.Document)
+}
+
 func AsOwnedDocument(doc Document, owner User) OwnedDocument {
 	return &ownedDocument{
 		Document: doc,
@@ -44,6 +54,29 @@ func AsOwnedDocument(doc Document, owner User) OwnedDocument {
 	}
 }
 
+type taskDocument struct {
+	OwnedDocument
+	taskID TaskID
+}
+
+// TaskID implements WithTaskID.
+func (d *taskDocument) TaskID() TaskID {
+	return d.taskID
+}
+
+// Metadata implements WithMetadata.
+func (d *taskDocument) Metadata() map[string][]string {
+	return DocumentMetadata(d.OwnedDocument)
+}
+
+// AsTaskDocument marks the document as produced by the given task.
+func AsTaskDocument(doc OwnedDocument, taskID TaskID) OwnedDocument {
+	return &taskDocument{
+		OwnedDocument: doc,
+		taskID:        taskID,
+	}
+}
+
 type PersistedDocument interface {
 	OwnedDocument
 	WithLifecycle
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/rs/xid"
+)
+
+type DocumentVersionID string
+
+func NewDocumentVersionID() DocumentVersionID {
+	return DocumentVersionID(xid.New().String())
+}
+
+// DocumentVersion is a previous version of a document, archived when the
+// document was replaced by a new one
+type DocumentVersion interface {
+	WithID[DocumentVersionID]
+
+	DocumentID() DocumentID
+	ETag() string
+	Metadata() map[string][]string
+	Content() ([]byte, error)
+
+	// TaskID returns the identifier of the task which produced the version,
+	// empty if unknown
+	TaskID() TaskID
+
+	// CreatedAt returns the time the version was produced
+	CreatedAt() time.Time
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"github.com/rs/xid"
+)
+
+type GroupID string
+
+func NewGroupID() GroupID {
+	return GroupID(xid.New().String())
+}
+
+// Group is a named set of users collections can be shared with.
+type Group interface {
+	WithID[GroupID]
+
+	Name() string
+	Description() string
+
+	// Provider returns the identity provider the group is synchronized from,
+	// or an empty string if the group is managed in Corpus
+	Provider() string
+
+	// Owner returns the user managing the group, or nil if the group is
+	// synchronized from an identity provider
+	Owner() User
+
+	Members() []User
+}
+
+// PersistedGroup is a Group that has been persisted to the store.
+type PersistedGroup interface {
+	Group
+	WithLifecycle
+}
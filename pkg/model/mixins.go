@@ -1,6 +1,8 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 )
 
@@ -16,3 +18,87 @@ type WithLifecycle interface {
 	CreatedAt() time.Time
 	UpdatedAt() time.Time
 }
+
+// WithMetadata is implemented by the documents carrying metadata key/values.
+type WithMetadata interface {
+	Metadata() map[string][]string
+}
+
+// DocumentMetadata returns the metadata of the given document, or nil if the
+// document does not carry any.
+func DocumentMetadata(d Document) map[string][]string {
+	if m, ok := d.(WithMetadata); ok {
+		return m.Metadata()
+	}
+
+	return nil
+}
+
+// WithTaskID is implemented by the documents produced by a task.
+type WithTaskID interface {
+	TaskID() TaskID
+}
+
+// DocumentTaskID returns the identifier of the task which produced the given
+// document, or an empty identifier if unknown.
+func DocumentTaskID(d Document) TaskID {
+	if t, ok := d.(WithTaskID); ok {
+		return t.TaskID()
+	}
+
+	return ""
+}
+
+// WithIndexedChecksum is implemented by the persisted documents recording the
+// checksum of the content they were last successfully indexed with.
+type WithIndexedChecksum interface {
+	IndexedChecksum() string
+}
+
+// DocumentIndexedChecksum returns the checksum of the content the given
+// document was last successfully indexed with, or an empty string if unknown.
+func DocumentIndexedChecksum(d Document) string {
+	if c, ok := d.(WithIndexedChecksum); ok {
+		return c.IndexedChecksum()
+	}
+
+	return ""
+}
+
+// DocumentChecksum returns the checksum of the content of the given document
+func DocumentChecksum(d Document) (string, error) {
+	content, err := d.Content()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(content)
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// DocumentUpdatedAt returns the last update time of the given document, or
+// the current time if the document is not persisted yet.
+func DocumentUpdatedAt(d Document) time.Time {
+	if l, ok := d.(WithLifecycle); ok && !l.UpdatedAt().IsZero() {
+		return l.UpdatedAt()
+	}
+
+	return time.Now()
+}
+
+// WithTrashedAt is implemented by the documents and collections which can be
+// moved to the trash.
+type WithTrashedAt interface {
+	TrashedAt() *time.Time
+}
+
+// TrashedAt returns the time the given document or collection was moved to
+// the trash, or nil if it is not in the trash.
+func TrashedAt(v any) *time.Time {
+	if t, ok := v.(WithTrashedAt); ok {
+		return t.TrashedAt()
+	}
+
+	return nil
+}
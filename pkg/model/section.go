@@ -1,6 +1,8 @@
 package model
 
 import (
+	"bytes"
+
 	"github.com/pkg/errors"
 	"github.com/rs/xid"
 )
@@ -23,6 +25,75 @@ type Section interface {
 	Content() ([]byte, error)
 }
 
+// Range is a byte range of a document content
+type Range struct {
+	Start int
+	End   int
+}
+
+// Empty returns true if the range does not cover any byte
+func (r Range) Empty() bool {
+	return r.End <= r.Start
+}
+
+// FramedSection is implemented by the sections whose content is framed by
+// ranges of the document outside of their own range, e.g. a part of a table
+// split by rows repeating the table header or a part of a code block
+// repeating its fences.
+type FramedSection interface {
+	Section
+	// Frame returns the ranges of the document preceding and following the
+	// section range in its content
+	Frame() (prefix Range, suffix Range)
+}
+
+// SectionFrame returns the frame of the given section, empty ranges if the
+// section is not framed
+func SectionFrame(s Section) (prefix Range, suffix Range) {
+	if framed, ok := s.(FramedSection); ok {
+		return framed.Frame()
+	}
+
+	return Range{}, Range{}
+}
+
+// FramedChunk returns the chunk of the document between start and end,
+// preceded by the prefix range and followed by the suffix range
+func FramedChunk(d Document, start int, end int, prefix Range, suffix Range) ([]byte, error) {
+	chunk, err := d.Chunk(start, end)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if prefix.Empty() && suffix.Empty() {
+		return chunk, nil
+	}
+
+	var buff bytes.Buffer
+
+	if !prefix.Empty() {
+		data, err := d.Chunk(prefix.Start, prefix.End)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		buff.Write(data)
+	}
+
+	buff.Write(chunk)
+
+	if !suffix.Empty() {
+		data, err := d.Chunk(suffix.Start, suffix.End)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		buff.Write(data)
+	}
+
+	return buff.Bytes(), nil
+}
+
 func WalkSections(d interface{ Sections() []Section }, fn func(s Section) error) error {
 	sections := d.Sections()
 	for _, s := range sections {
@@ -44,3 +115,59 @@ func CountSections(d interface{ Sections() []Section }) int {
 	}
 	return total
 }
+
+// SectionsDiff describes the changes between two versions of a document
+// sections tree. Section identities being derived from their content, a
+// modified section is reported as removed and added.
+type SectionsDiff struct {
+	// Added lists the sections absent from the previous version
+	Added []SectionID
+	// Removed lists the sections absent from the new version
+	Removed []SectionID
+	// Unchanged lists the sections present in both versions
+	Unchanged []SectionID
+}
+
+// Empty returns true if no section was added nor removed
+func (d SectionsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffSections compares the sections of the new version of a document with
+// the sections of its previous version.
+func DiffSections(previous []SectionID, next interface{ Sections() []Section }) SectionsDiff {
+	diff := SectionsDiff{
+		Added:     make([]SectionID, 0),
+		Removed:   make([]SectionID, 0),
+		Unchanged: make([]SectionID, 0),
+	}
+
+	existing := make(map[SectionID]struct{}, len(previous))
+	for _, id := range previous {
+		existing[id] = struct{}{}
+	}
+
+	seen := make(map[SectionID]struct{})
+
+	// The walk function never fails
+	_ = WalkSections(next, func(s Section) error {
+		id := s.ID()
+		seen[id] = struct{}{}
+
+		if _, exists := existing[id]; exists {
+			diff.Unchanged = append(diff.Unchanged, id)
+		} else {
+			diff.Added = append(diff.Added, id)
+		}
+
+		return nil
+	})
+
+	for _, id := range previous {
+		if _, exists := seen[id]; !exists {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,30 @@
+package port
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+type ConversationStore interface {
+	// SaveConversation creates or updates a conversation, its turns excepted
+	SaveConversation(ctx context.Context, conversation model.OwnedConversation) (model.PersistedConversation, error)
+	// GetConversationByID returns a conversation by its id, or port.ErrNotFound
+	GetConversationByID(ctx context.Context, id model.ConversationID) (model.PersistedConversation, error)
+	// QueryUserConversations returns the conversations owned by the given user,
+	// the most recently updated first, and their total number
+	QueryUserConversations(ctx context.Context, userID model.UserID, opts QueryConversationsOptions) ([]model.PersistedConversation, int64, error)
+	// DeleteConversation deletes a conversation and its turns
+	DeleteConversation(ctx context.Context, id model.ConversationID) error
+
+	// AppendConversationTurn adds a turn at the end of the conversation
+	AppendConversationTurn(ctx context.Context, id model.ConversationID, turn model.ConversationTurn) (model.PersistedConversationTurn, error)
+	// GetConversationTurns returns the turns of the conversation, the oldest
+	// first
+	GetConversationTurns(ctx context.Context, id model.ConversationID) ([]model.PersistedConversationTurn, error)
+}
+
+type QueryConversationsOptions struct {
+	Page  *int
+	Limit *int
+}
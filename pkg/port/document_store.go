@@ -3,6 +3,7 @@ package port
 import (
 	"context"
 	"net/url"
+	"time"
 
 	"github.com/bornholm/corpus/pkg/model"
 )
@@ -23,6 +24,19 @@ type DocumentStore interface {
 	SaveDocuments(ctx context.Context, documents ...model.OwnedDocument) error
 	DeleteDocumentBySource(ctx context.Context, ownerID model.UserID, source *url.URL) error
 	DeleteDocumentByID(ctx context.Context, ids ...model.DocumentID) error
+
+	// MarkDocumentIndexed records that the document was successfully indexed
+	// with the content of the given checksum
+	MarkDocumentIndexed(ctx context.Context, id model.DocumentID, checksum string) error
+
+	// TrashDocumentByID moves the documents to the trash, hiding them from the
+	// queries until they are restored or permanently deleted
+	TrashDocumentByID(ctx context.Context, ids ...model.DocumentID) error
+	// RestoreDocumentByID moves the documents out of the trash
+	RestoreDocumentByID(ctx context.Context, ids ...model.DocumentID) error
+	// QueryTrashedDocuments returns the documents in the trash, the most
+	// recently trashed first, and their total number
+	QueryTrashedDocuments(ctx context.Context, opts QueryTrashedDocumentsOptions) ([]model.PersistedDocument, int64, error)
 	QueryDocuments(ctx context.Context, opts QueryDocumentsOptions) ([]model.PersistedDocument, int64, error)
 
 	// QueryDocumentsByCollectionID retrieves all documents belonging to a specific collection.
@@ -36,6 +50,13 @@ type DocumentStore interface {
 
 	CountReadableDocuments(ctx context.Context, userID model.UserID) (int64, error)
 
+	// QueryDocumentVersions returns the previous versions of the document, the
+	// most recent first, and their total number
+	QueryDocumentVersions(ctx context.Context, documentID model.DocumentID, opts QueryDocumentVersionsOptions) ([]model.DocumentVersion, int64, error)
+	// GetDocumentVersionByID returns a previous version of a document by its
+	// id, or port.ErrNotFound
+	GetDocumentVersionByID(ctx context.Context, id model.DocumentVersionID) (model.DocumentVersion, error)
+
 	GetSectionByID(ctx context.Context, id model.SectionID) (model.Section, error)
 	GetSectionsByIDs(ctx context.Context, ids []model.SectionID) (map[model.SectionID]model.Section, error)
 	SectionExists(ctx context.Context, id model.SectionID) (bool, error)
@@ -50,6 +71,15 @@ type DocumentStore interface {
 
 	DeleteCollection(ctx context.Context, id model.CollectionID) error
 
+	// TrashCollection moves the collection to the trash, hiding it from the
+	// queries until it is restored or permanently deleted
+	TrashCollection(ctx context.Context, id model.CollectionID) error
+	// RestoreCollection moves the collection out of the trash
+	RestoreCollection(ctx context.Context, id model.CollectionID) error
+	// QueryTrashedCollections returns the collections in the trash, the most
+	// recently trashed first, and their total number
+	QueryTrashedCollections(ctx context.Context, opts QueryTrashedCollectionsOptions) ([]model.PersistedCollection, int64, error)
+
 	QueryUserReadableCollections(ctx context.Context, userID model.UserID, opts QueryCollectionsOptions) ([]model.PersistedCollection, int64, error)
 	QueryUserWritableCollections(ctx context.Context, userID model.UserID, opts QueryCollectionsOptions) ([]model.PersistedCollection, int64, error)
 
@@ -60,6 +90,10 @@ type DocumentStore interface {
 	// Only one share per (collection, user) pair is allowed; an existing share will be updated.
 	CreateCollectionShare(ctx context.Context, collectionID model.CollectionID, userID model.UserID, level model.CollectionShareLevel) (model.PersistedCollectionShare, error)
 
+	// CreateCollectionGroupShare shares a collection with the members of a group at the given access level.
+	// Only one share per (collection, group) pair is allowed; an existing share will be updated.
+	CreateCollectionGroupShare(ctx context.Context, collectionID model.CollectionID, groupID model.GroupID, level model.CollectionShareLevel) (model.PersistedCollectionShare, error)
+
 	// DeleteCollectionShare removes a share by its ID.
 	DeleteCollectionShare(ctx context.Context, shareID model.CollectionShareID) error
 
@@ -76,6 +110,9 @@ type QueryDocumentsOptions struct {
 
 	// Filters
 
+	// Documents with these ids
+	IDs []model.DocumentID
+
 	// Documents matching the given source
 	MatchingSource *url.URL
 
@@ -85,6 +122,9 @@ type QueryDocumentsOptions struct {
 	// Documents matching the given source pattern (LIKE %pattern%)
 	SourcePattern *string
 
+	// Documents having all the given metadata key/values
+	Metadata map[string]string
+
 	// Column to sort by: "source" or "created_at" (default)
 	SortBy *string
 
@@ -92,6 +132,43 @@ type QueryDocumentsOptions struct {
 	SortOrder *string
 }
 
+type QueryDocumentVersionsOptions struct {
+	Page  *int
+	Limit *int
+}
+
+type QueryTrashedDocumentsOptions struct {
+	Page  *int
+	Limit *int
+
+	// Filters
+
+	// Documents with these ids
+	IDs []model.DocumentID
+
+	// Documents owned by the given user
+	OwnerID *model.UserID
+
+	// Documents moved to the trash before the given time
+	TrashedBefore *time.Time
+}
+
+type QueryTrashedCollectionsOptions struct {
+	Page  *int
+	Limit *int
+
+	// Filters
+
+	// Collections with these ids
+	IDs []model.CollectionID
+
+	// Collections owned by the given user
+	OwnerID *model.UserID
+
+	// Collections moved to the trash before the given time
+	TrashedBefore *time.Time
+}
+
 type QueryCollectionsOptions struct {
 	Page  *int
 	Limit *int
@@ -108,4 +185,7 @@ type QueryCollectionsOptions struct {
 type CollectionUpdates struct {
 	Label       *string
 	Description *string
+
+	// Number of previous versions kept for each document of the collection
+	VersionRetention *int
 }
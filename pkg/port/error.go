@@ -3,7 +3,8 @@ package port
 import "errors"
 
 var (
-	ErrNotFound  = errors.New("not found")
-	ErrCanceled  = errors.New("canceled")
-	ErrQueueFull = errors.New("queue full")
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrCanceled      = errors.New("canceled")
+	ErrQueueFull     = errors.New("queue full")
 )
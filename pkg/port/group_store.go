@@ -0,0 +1,58 @@
+package port
+
+import (
+	"context"
+
+	"github.com/bornholm/corpus/pkg/model"
+)
+
+type GroupStore interface {
+	// CreateGroup creates a new group managed by the given user
+	CreateGroup(ctx context.Context, ownerID model.UserID, name string, description string) (model.PersistedGroup, error)
+
+	// GetGroupByID returns a group with its members, or ErrNotFound if not found
+	GetGroupByID(ctx context.Context, id model.GroupID) (model.PersistedGroup, error)
+
+	// QueryGroups returns a paginated list of groups and the total number of
+	// groups matching the query options
+	QueryGroups(ctx context.Context, opts QueryGroupsOptions) ([]model.PersistedGroup, int64, error)
+
+	// UpdateGroup updates the name and/or the description of a group
+	UpdateGroup(ctx context.Context, id model.GroupID, updates GroupUpdates) (model.PersistedGroup, error)
+
+	// DeleteGroup deletes a group, its memberships and the collection shares
+	// targeting it
+	DeleteGroup(ctx context.Context, id model.GroupID) error
+
+	// AddGroupMembers adds users to a group
+	AddGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error
+
+	// RemoveGroupMembers removes users from a group
+	RemoveGroupMembers(ctx context.Context, id model.GroupID, userIDs ...model.UserID) error
+
+	// SyncUserGroups makes the user a member of the groups of the identity
+	// provider with the given names, creating the missing ones, and removes the
+	// user from the other groups of this provider
+	SyncUserGroups(ctx context.Context, userID model.UserID, provider string, names []string) error
+}
+
+type GroupUpdates struct {
+	Name        *string
+	Description *string
+}
+
+type QueryGroupsOptions struct {
+	Page  *int
+	Limit *int
+
+	// Filters
+
+	// Groups managed by a specific user
+	OwnerID *model.UserID
+
+	// Groups with a specific member
+	MemberID *model.UserID
+
+	// Do not load the members of the groups
+	HeaderOnly bool
+}
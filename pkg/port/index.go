@@ -3,6 +3,10 @@ package port
 import (
 	"context"
 	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/bornholm/corpus/pkg/model"
 )
@@ -15,8 +19,44 @@ type Index interface {
 	Search(ctx context.Context, query string, opts IndexSearchOptions) ([]*IndexSearchResult, error)
 }
 
+// CompositeIndex is implemented by the indexes dispatching their operations to
+// other indexes, allowing callers to inspect each of them separately.
+type CompositeIndex interface {
+	Index
+	// Indexes returns the underlying indexes, keyed by identifier
+	Indexes() map[string]Index
+}
+
+// ContextualIndex is implemented by the indexes situating the indexed sections
+// in their document, allowing callers indexing a document on the underlying
+// indexes to provide the same contexts, see IndexOptions.Contexts.
+type ContextualIndex interface {
+	Index
+	// Contextualize returns the context of each section of the document, nil
+	// if the sections are not contextualized
+	Contextualize(ctx context.Context, document model.Document) (map[model.SectionID]string, error)
+}
+
+// EmbeddingsIndex is implemented by the indexes storing the embeddings of the
+// indexed sections.
+type EmbeddingsIndex interface {
+	// SectionEmbeddings returns the normalized embeddings of the given
+	// sections. Sections split in several chunks are represented by the mean of
+	// their chunks embeddings. Sections without embeddings are omitted.
+	SectionEmbeddings(ctx context.Context, ids ...model.SectionID) (map[model.SectionID][]float32, error)
+}
+
 type IndexOptions struct {
 	OnProgress func(progress float32)
+	// Sections restricts the indexing to the given sections of the document,
+	// the other previously indexed sections of the document being left
+	// untouched. Nil indexes the whole document, replacing its previously
+	// indexed sections.
+	Sections []model.SectionID
+	// Contexts holds, for each section, a text situating it in its document
+	// (title, headings...). It is indexed along with the section content but
+	// is not part of it. Nil indexes the sections content only.
+	Contexts map[model.SectionID]string
 }
 
 type IndexOptionFunc func(opts *IndexOptions)
@@ -35,12 +75,167 @@ func WithIndexOnProgress(onProgress func(progress float32)) IndexOptionFunc {
 	}
 }
 
+func WithIndexSections(sections ...model.SectionID) IndexOptionFunc {
+	return func(opts *IndexOptions) {
+		if sections == nil {
+			sections = []model.SectionID{}
+		}
+		opts.Sections = sections
+	}
+}
+
+// WithIndexContexts sets the texts situating the sections in their document,
+// indexed along with their content
+func WithIndexContexts(contexts map[model.SectionID]string) IndexOptionFunc {
+	return func(opts *IndexOptions) {
+		opts.Contexts = contexts
+	}
+}
+
+// Contextualize returns the given section content preceded by the section
+// context, if any
+func (o *IndexOptions) Contextualize(id model.SectionID, content string) string {
+	prefix := o.Contexts[id]
+	if prefix == "" {
+		return content
+	}
+
+	return prefix + "\n\n" + content
+}
+
+// Includes returns true if the given section has to be indexed
+func (o *IndexOptions) Includes(id model.SectionID) bool {
+	return o.Sections == nil || slices.Contains(o.Sections, id)
+}
+
 type IndexSearchOptions struct {
 	MaxResults  int
 	Collections []model.CollectionID
+	// Filter restricts the search to the matching documents, nil means no
+	// restriction
+	Filter *IndexSearchFilter
+	// Highlight requests the fragments of each section matching the query.
+	// Indexes unable to highlight their results ignore it.
+	Highlight bool
+	// Boost ranks higher the sections of the documents having the given
+	// metadata key/values, without restricting the search to them. Indexes
+	// unable to boost their results ignore it.
+	Boost map[string]string
 }
 
 type IndexSearchResult struct {
 	Source   *url.URL
 	Sections []model.SectionID
+	// Scores holds the relevance score of each section, normalized in [0, 1].
+	// Indexes that do not score their results leave it nil.
+	Scores map[model.SectionID]float64
+	// Ranks holds, for each section, its 1-based rank in the results of each
+	// underlying index, keyed by index identifier.
+	Ranks map[model.SectionID]map[string]int
+	// Highlights holds, for each section, the fragments of its content
+	// matching the query. Only filled when requested with
+	// IndexSearchOptions.Highlight and supported by the index.
+	Highlights map[model.SectionID][]*IndexSearchHighlight
+}
+
+// IndexSearchHighlight is an excerpt of a section content matching the query
+type IndexSearchHighlight struct {
+	// Fragment is the excerpt of the section content
+	Fragment string `json:"fragment"`
+	// Terms locates the matched terms in the fragment
+	Terms []IndexSearchHighlightTerm `json:"terms"`
+}
+
+// IndexSearchHighlightTerm locates a matched term in a highlight fragment
+type IndexSearchHighlightTerm struct {
+	// Term is the analyzed term which matched the query
+	Term string `json:"term"`
+	// Start is the byte offset of the term in the fragment
+	Start int `json:"start"`
+	// End is the byte offset of the end of the term in the fragment
+	End int `json:"end"`
+}
+
+// Score returns the normalized relevance score of the given section, or 0
+// when the section has not been scored.
+func (r *IndexSearchResult) Score(id model.SectionID) float64 {
+	return r.Scores[id]
+}
+
+// IndexSearchFilter restricts a search to the documents matching all of its
+// non-zero criteria.
+type IndexSearchFilter struct {
+	// SourcePrefix keeps the documents whose source starts with the given prefix
+	SourcePrefix string `json:"sourcePrefix,omitempty"`
+	// SourceGlob keeps the documents whose source matches the given pattern,
+	// where '*' matches any sequence of characters and '?' a single character
+	SourceGlob string `json:"sourceGlob,omitempty"`
+	// UpdatedAfter keeps the documents updated at or after the given time
+	UpdatedAfter time.Time `json:"updatedAfter,omitzero"`
+	// UpdatedBefore keeps the documents updated before the given time
+	UpdatedBefore time.Time `json:"updatedBefore,omitzero"`
+	// Metadata keeps the documents having, for each key, the given value
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// IsZero returns true if the filter has no criteria.
+func (f *IndexSearchFilter) IsZero() bool {
+	return f == nil || (f.SourcePrefix == "" && f.SourceGlob == "" &&
+		f.UpdatedAfter.IsZero() && f.UpdatedBefore.IsZero() && len(f.Metadata) == 0)
+}
+
+// Match returns true if a document with the given source, update time and
+// metadata satisfies the filter.
+func (f *IndexSearchFilter) Match(source string, updatedAt time.Time, metadata map[string][]string) bool {
+	if f.IsZero() {
+		return true
+	}
+
+	if f.SourcePrefix != "" && !strings.HasPrefix(source, f.SourcePrefix) {
+		return false
+	}
+
+	if f.SourceGlob != "" && !GlobRegexp(f.SourceGlob).MatchString(source) {
+		return false
+	}
+
+	if !f.UpdatedAfter.IsZero() && updatedAt.Before(f.UpdatedAfter) {
+		return false
+	}
+
+	if !f.UpdatedBefore.IsZero() && !updatedAt.Before(f.UpdatedBefore) {
+		return false
+	}
+
+	for key, value := range f.Metadata {
+		values, exists := metadata[key]
+		if !exists || !slices.Contains(values, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GlobRegexp compiles a source glob pattern, where '*' matches any sequence of
+// characters and '?' a single character, into an anchored regular expression.
+func GlobRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
 }
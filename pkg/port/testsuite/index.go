@@ -8,6 +8,7 @@ import (
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bornholm/corpus/pkg/model"
 	"github.com/bornholm/corpus/pkg/port"
@@ -93,6 +94,68 @@ func TestIndex(t *testing.T, factory func(t *testing.T) (port.Index, error)) {
 					t.Errorf("results[0].Source.String(): expected %s, got %s", e, g)
 				}
 
+				return nil
+			},
+		},
+		{
+			Name: "FilterBySourceAndMetadata",
+			Run: func(t *testing.T, ctx context.Context, index port.Index) error {
+				if _, err := loadTestDocuments(t, index); err != nil {
+					return errors.WithStack(err)
+				}
+
+				query := "Par qui a été créé le langage ?"
+
+				filters := []struct {
+					Filter   *port.IndexSearchFilter
+					Expected string
+				}{
+					{
+						Filter:   &port.IndexSearchFilter{SourcePrefix: "https://fr.wikipedia.org/wiki/Rust"},
+						Expected: "https://fr.wikipedia.org/wiki/Rust_(langage)",
+					},
+					{
+						Filter:   &port.IndexSearchFilter{SourceGlob: "*/Go_(*)"},
+						Expected: "https://fr.wikipedia.org/wiki/Go_(langage)",
+					},
+					{
+						Filter:   &port.IndexSearchFilter{Metadata: map[string]string{"category": "cooking"}},
+						Expected: "https://fr.wikipedia.org/wiki/B%C5%93uf_bourguignon",
+					},
+				}
+
+				for _, f := range filters {
+					t.Logf("executing query '%s' with filter %s", query, spew.Sdump(f.Filter))
+
+					results, err := index.Search(ctx, query, port.IndexSearchOptions{
+						Filter: f.Filter,
+					})
+					if err != nil {
+						return errors.WithStack(err)
+					}
+
+					t.Logf("results: %s", spew.Sdump(results))
+
+					if e, g := 1, len(results); e != g {
+						t.Fatalf("len(results): expected %d, got %d", e, g)
+					}
+
+					if e, g := f.Expected, results[0].Source.String(); e != g {
+						t.Errorf("results[0].Source.String(): expected %s, got %s", e, g)
+					}
+				}
+
+				results, err := index.Search(ctx, query, port.IndexSearchOptions{
+					Filter: &port.IndexSearchFilter{UpdatedAfter: time.Now().Add(time.Hour)},
+				})
+				if err != nil {
+					return errors.WithStack(err)
+				}
+
+				if e, g := 0, len(results); e != g {
+					t.Errorf("len(results): expected %d, got %d", e, g)
+				}
+
 				return nil
 			},
 		},
@@ -155,6 +218,11 @@ func loadTestDocuments(t *testing.T, index port.Index) (map[string]model.Collect
 
 		doc.AddCollection(coll)
 
+		document := &metadataDocument{
+			Document: doc,
+			metadata: map[string][]string{"category": {collectionName}},
+		}
+
 		t.Logf("indexing document %s within collections %v", doc.Source(), slices.Collect(func(yield func(string) bool) {
 			for _, c := range doc.Collections() {
 				if !yield(c.Label()) {
@@ -163,10 +231,23 @@ func loadTestDocuments(t *testing.T, index port.Index) (map[string]model.Collect
 			}
 		}))
 
-		if err := index.Index(ctx, doc); err != nil {
+		if err := index.Index(ctx, document); err != nil {
 			return nil, errors.WithStack(err)
 		}
 	}
 
 	return collections, nil
 }
+
+// metadataDocument attaches metadata to a test document
+type metadataDocument struct {
+	*markdown.Document
+	metadata map[string][]string
+}
+
+// Metadata implements model.WithMetadata.
+func (d *metadataDocument) Metadata() map[string][]string {
+	return d.metadata
+}
+
+var _ model.WithMetadata = &metadataDocument{}